@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
+	"github.com/go-go-golems/go-go-agent/goagent/eval"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// EvalCommand runs an evaluation suite against its agent command
+type EvalCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.WriterCommand = (*EvalCommand)(nil)
+
+// EvalSettings holds the settings of the eval command
+type EvalSettings struct {
+	Suite          string `glazed.parameter:"suite"`
+	Mode           string `glazed.parameter:"mode"`
+	Baseline       string `glazed.parameter:"baseline"`
+	UpdateBaseline bool   `glazed.parameter:"update-baseline"`
+	Report         string `glazed.parameter:"report"`
+}
+
+// NewEvalCommand creates the eval command
+func NewEvalCommand() (*EvalCommand, error) {
+	cmdDesc := cmds.NewCommandDescription(
+		"eval",
+		cmds.WithShort("Run an evaluation suite against an agent command"),
+		cmds.WithLong(`Runs each case of a suite file through its agent command, checks the output
+against the assertions of the case and compares the results with the baseline report.
+By default the LLM and tool calls are replayed from the cassettes recorded with
+--mode record, so that suites run offline. Exits with an error if a case fails.`),
+		cmds.WithArguments(
+			parameters.NewParameterDefinition(
+				"suite",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("YAML suite file"),
+				parameters.WithRequired(true),
+			),
+		),
+		cmds.WithFlags(
+			parameters.NewParameterDefinition(
+				"mode",
+				parameters.ParameterTypeChoice,
+				parameters.WithHelp("Replay the recorded calls, record them, or run live without cassettes"),
+				parameters.WithDefault(string(eval.ModeReplay)),
+				parameters.WithChoices(eval.Modes...),
+			),
+			parameters.NewParameterDefinition(
+				"baseline",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("JSON report to compare the results with (defaults to <suite>.baseline.json)"),
+				parameters.WithDefault(""),
+			),
+			parameters.NewParameterDefinition(
+				"update-baseline",
+				parameters.ParameterTypeBool,
+				parameters.WithHelp("Save the results as the new baseline"),
+				parameters.WithDefault(false),
+			),
+			parameters.NewParameterDefinition(
+				"report",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("Write the JSON report of the run to this file"),
+				parameters.WithDefault(""),
+			),
+		),
+	)
+
+	return &EvalCommand{
+		CommandDescription: cmdDesc,
+	}, nil
+}
+
+func (c *EvalCommand) RunIntoWriter(
+	ctx context.Context,
+	parsedLayers *layers.ParsedLayers,
+	w io.Writer,
+) error {
+	s := &EvalSettings{}
+	if err := parsedLayers.InitializeStruct(layers.DefaultSlug, s); err != nil {
+		return err
+	}
+
+	suite, err := eval.LoadSuite(s.Suite)
+	if err != nil {
+		return err
+	}
+	runner, err := eval.NewRunner(suite, eval.Mode(s.Mode))
+	if err != nil {
+		return err
+	}
+	report, err := runner.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	baselinePath := s.Baseline
+	if baselinePath == "" {
+		baselinePath = strings.TrimSuffix(s.Suite, filepath.Ext(s.Suite)) + ".baseline.json"
+	}
+	baseline, err := eval.LoadReport(baselinePath)
+	if err != nil {
+		return err
+	}
+	if baseline != nil {
+		report.Compare(baseline)
+	}
+
+	if err := report.WriteText(w); err != nil {
+		return err
+	}
+	if s.Report != "" {
+		if err := report.Save(s.Report); err != nil {
+			return err
+		}
+	}
+	if s.UpdateBaseline {
+		if err := report.Save(baselinePath); err != nil {
+			return err
+		}
+		log.Info().Str("baseline", baselinePath).Msg("Updated evaluation baseline")
+	}
+
+	if report.Failed > 0 {
+		return errors.Errorf("%d of %d cases failed", report.Failed, len(report.Cases))
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	glazed_settings "github.com/go-go-golems/glazed/pkg/settings"
+	glazed_types "github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/go-go-agent/internal/redis"
+	"github.com/go-go-golems/go-go-agent/internal/server"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	"github.com/go-go-golems/go-go-agent/pkg/eventlog"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// Targets of the replay command
+const (
+	ReplayTargetRedisStream = "redis-stream"
+	ReplayTargetRedisPubSub = "redis-pubsub"
+	ReplayTargetServer      = "server"
+)
+
+// NewEventsCommand creates the events command, grouping the commands reading the JSONL
+// event logs written by the jsonl event sink
+func NewEventsCommand() (*cobra.Command, error) {
+	eventsCmd := &cobra.Command{
+		Use:   "events",
+		Short: "Tail and replay the JSONL event logs of agent runs",
+	}
+
+	tailCmd, err := NewEventsTailCommand()
+	if err != nil {
+		return nil, err
+	}
+	tailCobraCmd, err := cli.BuildCobraCommandFromCommand(tailCmd)
+	if err != nil {
+		return nil, err
+	}
+	eventsCmd.AddCommand(tailCobraCmd)
+
+	replayCmd, err := NewEventsReplayCommand()
+	if err != nil {
+		return nil, err
+	}
+	replayCobraCmd, err := cli.BuildCobraCommandFromCommand(replayCmd)
+	if err != nil {
+		return nil, err
+	}
+	eventsCmd.AddCommand(replayCobraCmd)
+
+	return eventsCmd, nil
+}
+
+// eventFilterParameters returns the log argument and the flags selecting its events,
+// shared by tail and replay
+func eventFilterParameters() ([]*parameters.ParameterDefinition, []*parameters.ParameterDefinition) {
+	arguments := []*parameters.ParameterDefinition{
+		parameters.NewParameterDefinition(
+			"log",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("JSONL event log of a run, such as ./goagent-events/<run ID>.jsonl; its rotated files are read first"),
+			parameters.WithRequired(true),
+		),
+	}
+	flags := []*parameters.ParameterDefinition{
+		parameters.NewParameterDefinition(
+			"type",
+			parameters.ParameterTypeStringList,
+			parameters.WithHelp("Only select events of these types, such as llm_call_completed"),
+			parameters.WithDefault([]string{}),
+		),
+		parameters.NewParameterDefinition(
+			"run-id",
+			parameters.ParameterTypeStringList,
+			parameters.WithHelp("Only select events of these runs"),
+			parameters.WithDefault([]string{}),
+		),
+	}
+	return arguments, flags
+}
+
+// EventsTailCommand prints the events of a log as rows
+type EventsTailCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.GlazeCommand = (*EventsTailCommand)(nil)
+
+// EventsTailSettings holds the settings of the tail command
+type EventsTailSettings struct {
+	Log    string   `glazed.parameter:"log"`
+	Types  []string `glazed.parameter:"type"`
+	RunIDs []string `glazed.parameter:"run-id"`
+	Lines  int      `glazed.parameter:"lines"`
+	Follow bool     `glazed.parameter:"follow"`
+}
+
+// NewEventsTailCommand creates the events tail command
+func NewEventsTailCommand() (*EventsTailCommand, error) {
+	glazedLayers, err := glazed_settings.NewGlazedParameterLayers()
+	if err != nil {
+		return nil, err
+	}
+
+	arguments, flags := eventFilterParameters()
+	flags = append(flags,
+		parameters.NewParameterDefinition(
+			"lines",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Only print the last N selected events of the log (0 = all)"),
+			parameters.WithDefault(0),
+		),
+		parameters.NewParameterDefinition(
+			"follow",
+			parameters.ParameterTypeBool,
+			parameters.WithHelp("Keep printing the events appended to the log until interrupted"),
+			parameters.WithDefault(false),
+		),
+	)
+
+	cmdDesc := cmds.NewCommandDescription(
+		"tail",
+		cmds.WithShort("Print the events of a JSONL event log"),
+		cmds.WithLong(`Prints the events of the JSONL event log of a run, written with --event-sinks jsonl,
+one row per event with its timestamp, run, type, ID and payload. Use --follow to
+keep printing the events of a run in progress, and the glazed flags to pick the
+output format, for example --output json or --fields timestamp,event_type.`),
+		cmds.WithArguments(arguments...),
+		cmds.WithFlags(flags...),
+		cmds.WithLayersList(glazedLayers),
+	)
+
+	return &EventsTailCommand{
+		CommandDescription: cmdDesc,
+	}, nil
+}
+
+func (c *EventsTailCommand) RunIntoGlazeProcessor(
+	ctx context.Context,
+	parsedLayers *layers.ParsedLayers,
+	gp middlewares.Processor,
+) error {
+	s := &EventsTailSettings{}
+	if err := parsedLayers.InitializeStruct(layers.DefaultSlug, s); err != nil {
+		return err
+	}
+
+	options := eventlog.TailOptions{
+		Filter: eventlog.Filter{EventTypes: s.Types, RunIDs: s.RunIDs},
+		Last:   s.Lines,
+		Follow: s.Follow,
+	}
+	return eventlog.Tail(ctx, s.Log, options, func(event *events.Event) error {
+		row, err := eventRow(event)
+		if err != nil {
+			return err
+		}
+		return gp.AddRow(ctx, row)
+	})
+}
+
+// eventRow converts event to a row, with its payload as a map
+func eventRow(event *events.Event) (glazed_types.Row, error) {
+	m, err := codec.ToModel(event)
+	if err != nil {
+		return nil, err
+	}
+	var payload map[string]interface{}
+	if len(m.Payload) > 0 {
+		if err := json.Unmarshal(m.Payload, &payload); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode payload of event %s", m.EventID)
+		}
+	}
+	return glazed_types.NewRow(
+		glazed_types.MRP("timestamp", m.Timestamp),
+		glazed_types.MRP("run_id", m.RunID),
+		glazed_types.MRP("event_type", m.EventType),
+		glazed_types.MRP("event_id", m.EventID),
+		glazed_types.MRP("payload", payload),
+	), nil
+}
+
+// EventsReplayCommand publishes the events of a log again
+type EventsReplayCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.BareCommand = (*EventsReplayCommand)(nil)
+
+// EventsReplaySettings holds the settings of the replay command
+type EventsReplaySettings struct {
+	Log       string   `glazed.parameter:"log"`
+	Types     []string `glazed.parameter:"type"`
+	RunIDs    []string `glazed.parameter:"run-id"`
+	Target    string   `glazed.parameter:"target"`
+	Speed     float64  `glazed.parameter:"speed"`
+	ServerURL string   `glazed.parameter:"server-url"`
+	APIToken  string   `glazed.parameter:"api-token"`
+	NewIDs    bool     `glazed.parameter:"new-ids"`
+}
+
+// NewEventsReplayCommand creates the events replay command
+func NewEventsReplayCommand() (*EventsReplayCommand, error) {
+	redisLayer, err := redis.NewRedisLayer()
+	if err != nil {
+		return nil, err
+	}
+	streamLayer, err := redis.NewStreamLayer()
+	if err != nil {
+		return nil, err
+	}
+
+	arguments, flags := eventFilterParameters()
+	flags = append(flags,
+		parameters.NewParameterDefinition(
+			"target",
+			parameters.ParameterTypeChoice,
+			parameters.WithHelp("Publish the events to the Redis stream or Pub/Sub channels the server consumes (configured by the redis and stream flags), or post them to a server started with --event-ingestion"),
+			parameters.WithDefault(ReplayTargetRedisStream),
+			parameters.WithChoices(ReplayTargetRedisStream, ReplayTargetRedisPubSub, ReplayTargetServer),
+		),
+		parameters.NewParameterDefinition(
+			"speed",
+			parameters.ParameterTypeFloat,
+			parameters.WithHelp("Replay speed relative to the original timing, 2 replays twice as fast (0 = no waiting)"),
+			parameters.WithDefault(1.0),
+		),
+		parameters.NewParameterDefinition(
+			"server-url",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("URL of the server of the server target"),
+			parameters.WithDefault("http://localhost:9999"),
+		),
+		parameters.NewParameterDefinition(
+			"api-token",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("API token of the server of the server target, if it was started with --api-token"),
+			parameters.WithDefault(""),
+		),
+		parameters.NewParameterDefinition(
+			"new-ids",
+			parameters.ParameterTypeBool,
+			parameters.WithHelp("Give the replayed events new event and run IDs, so that they do not collide with the events already stored"),
+			parameters.WithDefault(false),
+		),
+	)
+
+	cmdDesc := cmds.NewCommandDescription(
+		"replay",
+		cmds.WithShort("Publish the events of a JSONL event log again"),
+		cmds.WithLong(`Publishes the events of the JSONL event log of a run, written with --event-sinks jsonl,
+to Redis or straight to the server, at their original pace or faster with --speed.
+This reproduces a run in the server and its UI without calling the LLM again.`),
+		cmds.WithArguments(arguments...),
+		cmds.WithFlags(flags...),
+		cmds.WithLayersList(redisLayer, streamLayer),
+	)
+
+	return &EventsReplayCommand{
+		CommandDescription: cmdDesc,
+	}, nil
+}
+
+func (c *EventsReplayCommand) Run(ctx context.Context, parsedLayers *layers.ParsedLayers) error {
+	s := &EventsReplaySettings{}
+	if err := parsedLayers.InitializeStruct(layers.DefaultSlug, s); err != nil {
+		return err
+	}
+	if s.Speed < 0 {
+		return errors.Errorf("invalid speed %v", s.Speed)
+	}
+
+	var evs []*events.Event
+	if err := eventlog.Read(s.Log, eventlog.Filter{EventTypes: s.Types, RunIDs: s.RunIDs}, func(event *events.Event) error {
+		evs = append(evs, event)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if s.NewIDs {
+		for runID, newRunID := range eventlog.RenewIDs(evs) {
+			log.Info().Str("runID", runID).Str("newRunID", newRunID).Msg("Replaying run with a new ID")
+		}
+	}
+
+	publish, closePublisher, err := newReplayPublisher(ctx, parsedLayers, s)
+	if err != nil {
+		return err
+	}
+	defer closePublisher()
+
+	log.Info().Int("events", len(evs)).Str("target", s.Target).Float64("speed", s.Speed).Msg("Replaying events")
+	if err := eventlog.Replay(ctx, evs, s.Speed, publish); err != nil {
+		return errors.Wrap(err, "failed to replay events")
+	}
+	log.Info().Int("events", len(evs)).Msg("Replayed events")
+	return nil
+}
+
+// newReplayPublisher returns the function publishing the replayed events to the target
+// of s, as model.Event JSON, and the function closing its connection
+func newReplayPublisher(
+	ctx context.Context,
+	parsedLayers *layers.ParsedLayers,
+	s *EventsReplaySettings,
+) (func(*events.Event) error, func(), error) {
+	if s.Target == ReplayTargetServer {
+		url := strings.TrimSuffix(s.ServerURL, "/") + "/api/events"
+		return func(event *events.Event) error {
+			return postEvent(ctx, url, s.APIToken, event)
+		}, func() {}, nil
+	}
+
+	redisSettings, err := redis.GetRedisSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get redis settings")
+	}
+	streamSettings, err := redis.GetStreamSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get stream settings")
+	}
+
+	config := redis.DefaultRouterConfig()
+	config.RedisURL = redisSettings.URL
+	config.RedisPassword = redisSettings.Password
+	config.RedisDB = redisSettings.DB
+	config.RedisMaxRetries = redisSettings.MaxRetries
+	config.RedisDialTimeout = redisSettings.DialTimeout
+	config.TransportType = redis.TransportStream
+	if s.Target == ReplayTargetRedisPubSub {
+		config.TransportType = redis.TransportPubSub
+	}
+
+	publisher, err := redis.NewPublisher(ctx, config, log.Logger)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to connect to redis %s", redisSettings.URL)
+	}
+	publish := func(event *events.Event) error {
+		msg, err := codec.NewMessage(watermill.NewUUID(), event, codec.FormatModelJSON)
+		if err != nil {
+			return err
+		}
+		topic := streamSettings.StreamName
+		if config.TransportType == redis.TransportPubSub {
+			topic = redis.PubSubChannel(streamSettings.TopicPattern, event.GetRunId())
+		}
+		if err := publisher.Publish(topic, msg); err != nil {
+			return errors.Wrapf(err, "failed to publish event %s to %s", event.EventId, topic)
+		}
+		return nil
+	}
+	closePublisher := func() {
+		if err := publisher.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close redis publisher")
+		}
+	}
+	return publish, closePublisher, nil
+}
+
+// postEvent posts event to the ingestion endpoint of the server at url, with apiToken
+// as bearer token if it is set
+func postEvent(ctx context.Context, url, apiToken string, event *events.Event) error {
+	payload, err := codec.Encode(event, codec.FormatModelJSON)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request to %s", url)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(server.EventFormatHeader, string(codec.FormatModelJSON))
+	if apiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post event %s to %s", event.EventId, url)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusAccepted {
+		var body bytes.Buffer
+		_, _ = body.ReadFrom(resp.Body)
+		return errors.Errorf("server rejected event %s: %s %s", event.EventId, resp.Status, strings.TrimSpace(body.String()))
+	}
+	return nil
+}
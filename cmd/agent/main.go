@@ -6,6 +6,7 @@ import (
 
 	clay "github.com/go-go-golems/clay/pkg"
 	"github.com/go-go-golems/clay/pkg/doc"
+	"github.com/go-go-golems/glazed/pkg/cli"
 	"github.com/go-go-golems/glazed/pkg/cmds/logging"
 	"github.com/go-go-golems/glazed/pkg/help"
 	"github.com/rs/zerolog/log"
@@ -43,6 +44,16 @@ It supports various agent types like ReAct, Plan-and-Execute, and File Collectio
 	repoPath := "/home/manuel/code/wesen/corporate-headquarters/go-go-agent/goagent/examples/commands"
 	LoadRepositoryCommands(repoPath, rootCmd, helpSystem)
 
+	evalCmd, err := NewEvalCommand()
+	cobra.CheckErr(err)
+	evalCobraCmd, err := cli.BuildCobraCommandFromCommand(evalCmd)
+	cobra.CheckErr(err)
+	rootCmd.AddCommand(evalCobraCmd)
+
+	eventsCmd, err := NewEventsCommand()
+	cobra.CheckErr(err)
+	rootCmd.AddCommand(eventsCmd)
+
 	log.Info().Msg("Starting GoAgent CLI")
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -37,6 +37,8 @@ func LoadRepositoryCommands(repoPath string, rootCmd *cobra.Command, helpSystem
 		// Collect commands from the repository
 		loadedCommands := repo.CollectCommands([]string{}, true)
 		log.Info().Int("count", len(loadedCommands)).Str("path", repoPath).Msg("Loaded commands from repository")
+		// Agents can delegate to the loaded commands by listing them as tools
+		goagentcmds.RegisterAgentTools(loadedCommands)
 
 		// Add commands from repository to Cobra
 		for _, cmd := range loadedCommands {
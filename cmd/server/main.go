@@ -24,7 +24,9 @@ import (
 	"github.com/go-go-golems/go-go-agent/internal/redis"
 	"github.com/go-go-golems/go-go-agent/internal/server"
 	"github.com/go-go-golems/go-go-agent/internal/state"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
 	"github.com/go-go-golems/go-go-agent/pkg/model"
+	"github.com/go-go-golems/go-go-agent/pkg/pricing"
 )
 
 // ServerCommand implements the main server command using Glazed
@@ -43,6 +45,10 @@ type ServerSettings struct {
 	ReloadSession   bool   `glazed.parameter:"reload-session"`
 	MaxEventHistory int    `glazed.parameter:"max-event-history"`
 	LogLevel        string `glazed.parameter:"log-level"`
+	PriceFile       string `glazed.parameter:"price-file"`
+	CommandsDir     string `glazed.parameter:"commands-dir"`
+	APIToken        string `glazed.parameter:"api-token"`
+	EventIngestion  bool   `glazed.parameter:"event-ingestion"`
 }
 
 func (c *ServerCommand) Run(
@@ -108,7 +114,11 @@ func (c *ServerCommand) Run(
 	}()
 
 	// Initialize database manager
-	dbManager, err := db.NewDatabaseManager(serverSettings.DBPath)
+	prices, err := pricing.LoadPriceTableOrDefault(serverSettings.PriceFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load price table")
+	}
+	dbManager, err := db.NewDatabaseManager(serverSettings.DBPath, db.WithPriceTable(prices))
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize database manager")
 	}
@@ -143,34 +153,84 @@ func (c *ServerCommand) Run(
 	httpConfig.StaticFilesDir = serverSettings.StaticFilesDir
 	httpConfig.ReloadSession = serverSettings.ReloadSession
 
-	// Initialize HTTP server
-	httpServer := server.NewHTTPServer(httpConfig, logger, eventManager, graphManager)
+	// The message handler broadcasts through the HTTP server, which is created once the
+	// run manager using the handler exists
+	var httpServer *server.HTTPServer
 
 	// Create a custom message handler that updates state managers and broadcasts to WebSocket clients
 	messageHandler := func(msg *message.Message) error {
-		// Pass to the DB manager for storage
-		if err := dbManager.HandleMessage(msg); err != nil {
-			return err // NACK if DB storage fails
+		// Events are published as model.Event JSON, protojson or binary protobuf. They are
+		// converted to model.Event, whose JSON is what gets stored and broadcast.
+		event, err := codec.DecodeModelMessage(msg)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode event of message %s", msg.UUID)
+		}
+		eventJSON, err := json.Marshal(event)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal event %s", event.EventID)
 		}
 
-		// Parse the event from the message payload
-		var event model.Event
-		if err := json.Unmarshal(msg.Payload, &event); err != nil {
-			// Log error but ACK, as DB storage succeeded
-			logger.Error().Err(err).Str("message_uuid", msg.UUID).Msg("Failed to unmarshal event for state update, but DB storage succeeded. ACKing message.")
+		// Streamed LLM output is only relayed to WebSocket clients. The llm_call_completed
+		// event stores the full response, so deltas are neither stored nor kept in history.
+		if event.EventType == model.EventTypeLLMCallDelta {
+			httpServer.BroadcastEvent(eventJSON)
 			return nil
 		}
 
+		// Store the event in the database
+		dbEvent := db.Event(*event)
+		if err := dbManager.StoreEvent(&dbEvent); err != nil {
+			return errors.Wrap(err, "failed to store event") // NACK if DB storage fails
+		}
+
 		// Update in-memory state with the parsed event
-		eventManager.AddEvent(event)
-		graphManager.ProcessEvent(event)
+		eventManager.AddEvent(*event)
+		graphManager.ProcessEvent(*event)
 
-		// Broadcast the raw event to WebSocket clients
-		httpServer.BroadcastEvent(msg.Payload)
+		// Broadcast the event to WebSocket clients
+		httpServer.BroadcastEvent(eventJSON)
 
 		return nil // ACK
 	}
 
+	// The run control API starts agent runs and event ingestion writes events to the
+	// database, without token they are only served locally
+	if serverSettings.CommandsDir != "" || serverSettings.EventIngestion {
+		if serverSettings.APIToken == "" {
+			listenAddr, err := server.LocalListenAddr(httpConfig.ListenAddr)
+			if err != nil {
+				return errors.Wrap(err, "set --api-token to serve the run control API or event ingestion on a non-loopback address")
+			}
+			if listenAddr != httpConfig.ListenAddr {
+				logger.Warn().Str("addr", listenAddr).Msg("No --api-token set, listening on the loopback interface only")
+			}
+			httpConfig.ListenAddr = listenAddr
+		}
+		httpConfig.APIToken = serverSettings.APIToken
+	}
+
+	// Runs started through the API feed their events directly into the message handler
+	var serverOptions []server.HTTPServerOption
+	var runManager *server.RunManager
+	if serverSettings.CommandsDir != "" {
+		commands, err := server.LoadAgentCommands(serverSettings.CommandsDir)
+		if err != nil {
+			return err
+		}
+		logger.Info().Int("count", len(commands)).Str("dir", serverSettings.CommandsDir).Msg("Loaded agent commands for the run control API")
+		runManager = server.NewRunManager(ctx, commands, dbManager, messageHandler, logger)
+		serverOptions = append(serverOptions, server.WithRunControl(runManager, dbManager))
+	}
+
+	// Events posted to the HTTP server are handled like the events consumed from Redis
+	if serverSettings.EventIngestion {
+		logger.Info().Msg("Accepting events on POST /api/events")
+		serverOptions = append(serverOptions, server.WithEventIngestion(messageHandler))
+	}
+
+	// Initialize HTTP server
+	httpServer = server.NewHTTPServer(httpConfig, logger, eventManager, graphManager, serverOptions...)
+
 	// Setup Redis router configuration
 	routerConfig := redis.DefaultRouterConfig()
 	routerConfig.RedisURL = redisSettings.URL
@@ -233,6 +293,12 @@ func (c *ServerCommand) Run(
 	// Context was cancelled (either by signal or error in a goroutine)
 	logger.Info().Msg("Server shutting down...")
 
+	// Runs started through the API are cancelled with ctx, wait for them to record their end
+	if runManager != nil {
+		cancel()
+		runManager.Wait()
+	}
+
 	// Close the router explicitly (gives Watermill time to finish processing)
 	// HTTP server shutdown is handled by its own goroutine within httpServer.Start
 	if err := router.Close(); err != nil {
@@ -303,6 +369,30 @@ The server supports both Redis Streams and Pub/Sub for message transport.`),
 				parameters.WithDefault("info"),
 				parameters.WithChoices("trace", "debug", "info", "warn", "error", "fatal", "panic"),
 			),
+			parameters.NewParameterDefinition(
+				"commands-dir",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("Directory of agent command YAML files that can be started through the /api/runs endpoints (disabled if empty)"),
+				parameters.WithDefault(""),
+			),
+			parameters.NewParameterDefinition(
+				"api-token",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("Bearer token required by the /api/runs endpoints and by POST /api/events. Without token, the server listens on the loopback interface only when --commands-dir or --event-ingestion is set"),
+				parameters.WithDefault(""),
+			),
+			parameters.NewParameterDefinition(
+				"event-ingestion",
+				parameters.ParameterTypeBool,
+				parameters.WithHelp("Accept events posted to /api/events, such as the events replayed by 'agent events replay --target server'"),
+				parameters.WithDefault(false),
+			),
+			parameters.NewParameterDefinition(
+				"price-file",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("YAML file with model prices in USD per million tokens, used for run costs (defaults to the built-in table)"),
+				parameters.WithDefault(""),
+			),
 		),
 		cmds.WithLayersList(redisLayer, streamLayer),
 	)
@@ -2,14 +2,23 @@ package agent
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-go-golems/glazed/pkg/cmds"
 	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
 	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/go-go-agent/goagent/checkpoint"
 	"github.com/go-go-golems/go-go-agent/goagent/llm"
 	"github.com/go-go-golems/go-go-agent/goagent/memory"
 	"github.com/go-go-golems/go-go-agent/goagent/tools"
 	"github.com/go-go-golems/go-go-agent/goagent/tracing"
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 // Command is the interface that agent command implementations must satisfy
@@ -36,6 +45,25 @@ type Agent interface {
 	SetMemory(mem memory.Memory) error
 }
 
+// EventEmitter is implemented by agents that publish structured events (steps, tool
+// calls) about their run. Commands attach their event bus before running the agent.
+// Events are associated with the run of the execution context passed to Run.
+type EventEmitter interface {
+	SetEventBus(eb *eventbus.EventBus)
+}
+
+// ToolApprovalRequester is implemented by agents whose tool calls can require approval.
+// Commands attach the approver of the run before running the agent.
+type ToolApprovalRequester interface {
+	SetToolApprover(approver tools.Approver)
+}
+
+// ToolWrapper is implemented by agents whose tools can be wrapped, e.g. to record or
+// replay their calls. Commands wrap the tools before running the agent.
+type ToolWrapper interface {
+	WrapTools(wrap func(tools.Tool) tools.Tool)
+}
+
 // WriterAgent is a marker interface for agents using the standard Run method for output.
 type WriterAgent interface {
 	Agent
@@ -63,6 +91,12 @@ type BaseAgent struct {
 	memory  memory.Memory
 	tracer  tracing.Tracer
 	maxIter int
+	// Checkpointing, see SetCheckpointStore
+	checkpoints     checkpoint.Store
+	checkpointRunID string
+	resume          bool
+	// memoryRunID prefixes the IDs of the memory entries of the current run, see startMemoryRun
+	memoryRunID string
 }
 
 // NewBaseAgent creates a new BaseAgent
@@ -87,7 +121,240 @@ func (a *BaseAgent) SetMemory(mem memory.Memory) error {
 	return nil
 }
 
+// startMemoryRun prefixes the IDs of the memory entries added from now on with the ID
+// of the run of ctx, or with a new ID outside of runs. Runs sharing a persistent memory
+// then add their entries instead of overwriting the entries of the previous runs.
+func (a *BaseAgent) startMemoryRun(ctx context.Context) {
+	a.memoryRunID = execctx.FromContext(ctx).RunID
+	if a.memoryRunID == "" {
+		a.memoryRunID = uuid.New().String()
+	}
+}
+
+// addMemory adds an entry to the agent's memory, tagged with the given role.
+// Failures are logged, and agents without memory skip the entry.
+func (a *BaseAgent) addMemory(ctx context.Context, id, content, role string) {
+	if a.memory == nil {
+		return
+	}
+	if a.memoryRunID != "" {
+		id = a.memoryRunID + "/" + id
+	}
+	if err := a.memory.Add(ctx, types.MemoryEntry{
+		ID:       id,
+		Content:  content,
+		Metadata: map[string]string{"role": role},
+	}); err != nil {
+		log.Warn().Err(err).Str("id", id).Msg("Failed to add memory entry")
+	}
+}
+
+// SetToolApprover sets the Approver deciding the tool calls that require approval
+func (a *BaseAgent) SetToolApprover(approver tools.Approver) {
+	if a.tools != nil {
+		a.tools.SetApprover(approver)
+	}
+}
+
+// WrapTools replaces the tools of the agent with wrap(tool)
+func (a *BaseAgent) WrapTools(wrap func(tools.Tool) tools.Tool) {
+	if a.tools != nil {
+		a.tools.WrapTools(wrap)
+	}
+}
+
 // GetTracer returns the tracer
 func (a *BaseAgent) GetTracer() tracing.Tracer {
 	return a.tracer
 }
+
+// ToolConfigOption is the agent-options key holding per-tool configuration,
+// keyed by the tool name used in the command's `tools:` list.
+const ToolConfigOption = "tool-config"
+
+// ToolApprovalOption is the agent-options key holding the tool approval policy,
+// see tools.ParseApprovalPolicy.
+const ToolApprovalOption = "tool-approval"
+
+// NewToolExecutorFromCommand resolves the tool names listed by the command through
+// the global tool registry and returns an executor containing the instantiated tools,
+// applying the approval policy of the agent options. An unknown tool name is an error.
+func NewToolExecutorFromCommand(
+	cmd Command,
+	agentOptions map[string]interface{},
+	options ...tools.ToolExecutorOption,
+) (*tools.ToolExecutor, error) {
+	configs := map[string]map[string]interface{}{}
+	if raw, ok := agentOptions[ToolConfigOption]; ok {
+		rawConfigs, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("agent option '%s' must be a map, got %T", ToolConfigOption, raw)
+		}
+		for name, v := range rawConfigs {
+			config, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("configuration for tool '%s' must be a map, got %T", name, v)
+			}
+			configs[name] = config
+		}
+	}
+
+	if raw, ok := agentOptions[ToolApprovalOption]; ok {
+		rawPolicy, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("agent option '%s' must be a map, got %T", ToolApprovalOption, raw)
+		}
+		policy, err := tools.ParseApprovalPolicy(rawPolicy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid agent option '%s'", ToolApprovalOption)
+		}
+		options = append(options, tools.WithApprovalPolicy(policy))
+	}
+
+	toolList, err := tools.NewToolsFromNames(cmd.GetTools(), configs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create tools for command '%s'", cmd.GetCommandDescription().Name)
+	}
+
+	executor := tools.NewToolExecutor(options...)
+	for _, tool := range toolList {
+		executor.AddTool(tool)
+	}
+	return executor, nil
+}
+
+// toolExecutionParameterDefinitions returns the parameters controlling tool execution,
+// shared by the layers of all agents that call tools.
+func toolExecutionParameterDefinitions() []*parameters.ParameterDefinition {
+	return []*parameters.ParameterDefinition{
+		parameters.NewParameterDefinition(
+			"max-parallel-tools",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Maximum number of tool calls executed concurrently (0 = unlimited)"),
+			parameters.WithDefault(4),
+		),
+		parameters.NewParameterDefinition(
+			"tool-timeout",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Timeout in seconds for a single tool call (0 = no timeout)"),
+			parameters.WithDefault(60),
+		),
+	}
+}
+
+// memoryParameterDefinitions returns the parameters selecting the agent memory,
+// shared by the layers of all agents that keep a memory.
+func memoryParameterDefinitions() []*parameters.ParameterDefinition {
+	return []*parameters.ParameterDefinition{
+		parameters.NewParameterDefinition(
+			"memory-type",
+			parameters.ParameterTypeChoice,
+			parameters.WithHelp("Type of memory system to use"),
+			parameters.WithDefault(memory.MemoryTypeNone),
+			parameters.WithChoices(memory.MemoryTypes...),
+		),
+		parameters.NewParameterDefinition(
+			"memory-db",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("SQLite database file used by the sqlite memory"),
+			parameters.WithDefault("./goagent-memory.db"),
+		),
+		parameters.NewParameterDefinition(
+			"memory-namespace",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("Namespace of the sqlite memory (defaults to the command name)"),
+			parameters.WithDefault(""),
+		),
+		parameters.NewParameterDefinition(
+			"memory-hnsw-m",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Links per node of the hnsw memory index (higher = better recall, more memory)"),
+			parameters.WithDefault(16),
+		),
+		parameters.NewParameterDefinition(
+			"memory-hnsw-ef-construction",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Candidate list size used when inserting into the hnsw memory index"),
+			parameters.WithDefault(200),
+		),
+		parameters.NewParameterDefinition(
+			"memory-hnsw-ef-search",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Candidate list size used when searching the hnsw memory index (higher = better recall, slower)"),
+			parameters.WithDefault(64),
+		),
+	}
+}
+
+// MemorySettings holds the memory parameters shared by the agent layers.
+type MemorySettings struct {
+	MemoryType      string `glazed.parameter:"memory-type"`
+	MemoryDB        string `glazed.parameter:"memory-db"`
+	MemoryNamespace string `glazed.parameter:"memory-namespace"`
+	HNSWM           int    `glazed.parameter:"memory-hnsw-m"`
+	HNSWEfConstruct int    `glazed.parameter:"memory-hnsw-ef-construction"`
+	HNSWEfSearch    int    `glazed.parameter:"memory-hnsw-ef-search"`
+}
+
+// NewMemoryFromCommand creates the memory configured in the agent layer layerSlug,
+// applying the memory-* agent options on top.
+// The namespace defaults to the command name, so that persistent memories of
+// different commands stay separate.
+func NewMemoryFromCommand(
+	cmd Command,
+	parsedLayers *layers.ParsedLayers,
+	layerSlug string,
+	agentOptions map[string]interface{},
+	embedder memory.Embedder,
+) (memory.Memory, error) {
+	var settings MemorySettings
+	if err := parsedLayers.InitializeStruct(layerSlug, &settings); err != nil {
+		return nil, err
+	}
+
+	if memoryType, ok := agentOptions["memory-type"].(string); ok {
+		settings.MemoryType = memoryType
+	}
+	if memoryDB, ok := agentOptions["memory-db"].(string); ok {
+		settings.MemoryDB = memoryDB
+	}
+	if namespace, ok := agentOptions["memory-namespace"].(string); ok {
+		settings.MemoryNamespace = namespace
+	}
+	if m, ok := agentOptions["memory-hnsw-m"].(int); ok {
+		settings.HNSWM = m
+	}
+	if efConstruction, ok := agentOptions["memory-hnsw-ef-construction"].(int); ok {
+		settings.HNSWEfConstruct = efConstruction
+	}
+	if efSearch, ok := agentOptions["memory-hnsw-ef-search"].(int); ok {
+		settings.HNSWEfSearch = efSearch
+	}
+	if settings.MemoryNamespace == "" {
+		settings.MemoryNamespace = cmd.GetCommandDescription().Name
+	}
+
+	mem, err := memory.NewMemoryFromType(
+		settings.MemoryType,
+		embedder,
+		memory.WithDBPath(settings.MemoryDB),
+		memory.WithNamespace(settings.MemoryNamespace),
+		memory.WithHNSWConfig(memory.HNSWConfig{
+			M:              settings.HNSWM,
+			EfConstruction: settings.HNSWEfConstruct,
+			EfSearch:       settings.HNSWEfSearch,
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create agent memory")
+	}
+	return mem, nil
+}
+
+// toolExecutorOptions converts the tool execution parameters into ToolExecutor options.
+func toolExecutorOptions(maxParallelTools int, toolTimeoutSeconds int) []tools.ToolExecutorOption {
+	return []tools.ToolExecutorOption{
+		tools.WithMaxConcurrency(maxParallelTools),
+		tools.WithToolTimeout(time.Duration(toolTimeoutSeconds) * time.Second),
+	}
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-go-golems/go-go-agent/goagent/checkpoint"
+	"github.com/go-go-golems/go-go-agent/goagent/memory"
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Checkpointer is implemented by agents that save their state after each completed
+// step and can resume a run from it. Commands attach the checkpoint store of the run
+// before running the agent.
+type Checkpointer interface {
+	SetCheckpointStore(store checkpoint.Store, runID string, resume bool)
+}
+
+// SetCheckpointStore saves the state of the agent to store after each step of the run
+// runID. If resume is true, the next Run continues from the checkpoint of runID.
+func (a *BaseAgent) SetCheckpointStore(store checkpoint.Store, runID string, resume bool) {
+	a.checkpoints = store
+	a.checkpointRunID = runID
+	a.resume = resume
+}
+
+// loadCheckpoint unmarshals the checkpoint of the resumed run into state and returns
+// the number of completed steps, and false if the run is not resumed.
+func (a *BaseAgent) loadCheckpoint(ctx context.Context, agentType string, state interface{}) (int, bool, error) {
+	if a.checkpoints == nil || !a.resume {
+		return 0, false, nil
+	}
+	// Only the first Run resumes, later runs start over
+	a.resume = false
+
+	cp, err := a.checkpoints.Load(ctx, a.checkpointRunID)
+	if err != nil {
+		return 0, false, err
+	}
+	if cp == nil {
+		return 0, false, errors.Errorf("no checkpoint found for run %s", a.checkpointRunID)
+	}
+	if cp.AgentType != agentType {
+		return 0, false, errors.Errorf("checkpoint of run %s was saved by a %s agent, not %s", a.checkpointRunID, cp.AgentType, agentType)
+	}
+	if err := json.Unmarshal(cp.State, state); err != nil {
+		return 0, false, errors.Wrapf(err, "failed to parse checkpoint of run %s", a.checkpointRunID)
+	}
+	log.Info().Ctx(ctx).Str("runID", a.checkpointRunID).Int("step", cp.Step).Msg("Resuming run from checkpoint")
+	return cp.Step, true, nil
+}
+
+// saveCheckpoint saves state as the checkpoint of the run after step completed steps.
+// Failures are logged, the run goes on without a checkpoint.
+func (a *BaseAgent) saveCheckpoint(ctx context.Context, agentType string, step int, state interface{}) {
+	if a.checkpoints == nil {
+		return
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Warn().Err(err).Int("step", step).Msg("Failed to serialize checkpoint")
+		return
+	}
+	err = a.checkpoints.Save(ctx, &checkpoint.Checkpoint{
+		RunID:     a.checkpointRunID,
+		AgentType: agentType,
+		Step:      step,
+		State:     data,
+	})
+	if err != nil {
+		log.Warn().Err(err).Int("step", step).Msg("Failed to save checkpoint")
+	}
+}
+
+// deleteCheckpoint removes the checkpoint of a run that finished
+func (a *BaseAgent) deleteCheckpoint(ctx context.Context) {
+	if a.checkpoints == nil {
+		return
+	}
+	if err := a.checkpoints.Delete(ctx, a.checkpointRunID); err != nil {
+		log.Warn().Err(err).Msg("Failed to delete checkpoint")
+	}
+}
+
+// memorySnapshot returns the entries of the agent's memory for a checkpoint. Memories
+// that can't list their entries are not checkpointed.
+func (a *BaseAgent) memorySnapshot(ctx context.Context) []types.MemoryEntry {
+	historyMemory, ok := a.memory.(memory.History)
+	if !ok {
+		return nil
+	}
+	entries, err := historyMemory.GetHistory(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not retrieve memory entries for checkpoint")
+		return nil
+	}
+	return entries
+}
+
+// restoreMemory adds the checkpointed entries to the agent's memory. Persistent
+// memories that already contain entries are left as they are.
+func (a *BaseAgent) restoreMemory(ctx context.Context, entries []types.MemoryEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	if historyMemory, ok := a.memory.(memory.History); ok {
+		existing, err := historyMemory.GetHistory(ctx)
+		if err == nil && len(existing) > 0 {
+			return
+		}
+	}
+	for _, entry := range entries {
+		if err := a.memory.Add(ctx, entry); err != nil {
+			log.Warn().Err(err).Str("id", entry.ID).Msg("Failed to restore memory entry")
+		}
+	}
+}
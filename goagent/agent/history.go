@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/rs/zerolog/log"
+)
+
+// History compaction strategies selectable with the history-strategy parameter
+const (
+	HistoryStrategyDrop      = "drop"
+	HistoryStrategySummarize = "summarize"
+)
+
+// HistoryStrategies lists the supported history compaction strategies
+var HistoryStrategies = []string{HistoryStrategySummarize, HistoryStrategyDrop}
+
+// historySummaryPrefix starts the message that replaces summarized turns
+const historySummaryPrefix = "Summary of earlier steps:\n"
+
+const historySummaryPrompt = `You compress the working history of an AI agent so that it fits in its context window.
+Summarize the steps below. Keep the facts that were learned, the tool results that matter for the task,
+failed attempts that should not be repeated and what remains to be done. Be concise and do not add
+anything that is not in the steps.`
+
+// HistoryManager keeps an agent conversation within a token budget.
+// The leading system prompt and user request are always kept. When the budget is
+// exceeded, the oldest turns after them are dropped or, with the summarize strategy,
+// replaced by a single summary message written by an LLM.
+type HistoryManager struct {
+	tokenBudget int
+	strategy    string
+	summarizer  llm.LLM
+	countTokens func(*conversation.Message) int
+}
+
+// HistoryManagerOption configures a HistoryManager
+type HistoryManagerOption func(*HistoryManager)
+
+// WithHistorySummarizer sets the LLM used by the summarize strategy
+func WithHistorySummarizer(summarizer llm.LLM) HistoryManagerOption {
+	return func(h *HistoryManager) {
+		h.summarizer = summarizer
+	}
+}
+
+// WithTokenCounter overrides the per-message token estimator
+func WithTokenCounter(countTokens func(*conversation.Message) int) HistoryManagerOption {
+	return func(h *HistoryManager) {
+		h.countTokens = countTokens
+	}
+}
+
+// NewHistoryManager creates a HistoryManager. A tokenBudget of 0 disables compaction.
+func NewHistoryManager(tokenBudget int, strategy string, options ...HistoryManagerOption) *HistoryManager {
+	h := &HistoryManager{
+		tokenBudget: tokenBudget,
+		strategy:    strategy,
+		countTokens: llm.EstimateMessageTokens,
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// historyPinnedMessages is the number of leading messages (system prompt and user
+// request) that are never compacted
+const historyPinnedMessages = 2
+
+// Compact returns messages trimmed to the token budget. The most recent messages are
+// kept verbatim, by whole turns: a tool result is never kept without the tool call it
+// answers, nor a tool call without the other calls and text of the same response.
+// The last turn is kept even if it exceeds the budget.
+func (h *HistoryManager) Compact(ctx context.Context, messages []*conversation.Message) []*conversation.Message {
+	if h == nil || h.tokenBudget <= 0 || len(messages) <= historyPinnedMessages+1 {
+		return messages
+	}
+
+	counts := make([]int, len(messages))
+	total := 0
+	for i, msg := range messages {
+		counts[i] = h.countTokens(msg)
+		total += counts[i]
+	}
+	if total <= h.tokenBudget {
+		return messages
+	}
+
+	available := h.tokenBudget
+	for i := 0; i < historyPinnedMessages; i++ {
+		available -= counts[i]
+	}
+	summarize := h.strategy == HistoryStrategySummarize && h.summarizer != nil
+	if summarize {
+		// Leave room for the summary itself
+		available -= h.tokenBudget / 4
+	}
+
+	// Keep as many of the most recent messages as fit, but at least the last one
+	cut := len(messages) - 1
+	used := counts[cut]
+	for cut > historyPinnedMessages && used+counts[cut-1] <= available {
+		cut--
+		used += counts[cut]
+	}
+	// Providers reject tool results whose tool call is evicted, so the kept messages
+	// start with a whole turn. Drop the partial turn, or keep the whole last turn if
+	// it is the only one left.
+	start := cut
+	for start < len(messages) && !startsTurn(messages, start) {
+		start++
+	}
+	if start == len(messages) {
+		start = cut
+		for start > historyPinnedMessages && !startsTurn(messages, start) {
+			start--
+		}
+	}
+	cut = start
+
+	evicted := messages[historyPinnedMessages:cut]
+	if len(evicted) == 0 {
+		return messages
+	}
+
+	compacted := make([]*conversation.Message, 0, historyPinnedMessages+1+len(messages)-cut)
+	compacted = append(compacted, messages[:historyPinnedMessages]...)
+	if summarize {
+		summary, err := h.summarize(ctx, evicted)
+		if err != nil {
+			log.Warn().Err(err).Int("messages", len(evicted)).Msg("Failed to summarize history, dropping old messages instead")
+		} else {
+			compacted = append(compacted, conversation.NewChatMessage(conversation.RoleUser, historySummaryPrefix+summary))
+		}
+	}
+	compacted = append(compacted, messages[cut:]...)
+
+	log.Debug().
+		Int("tokensBefore", total).
+		Int("tokenBudget", h.tokenBudget).
+		Int("evictedMessages", len(evicted)).
+		Bool("summarized", summarize).
+		Msg("Compacted conversation history")
+
+	return compacted
+}
+
+// summarize asks the summarizer LLM to condense messages. A previous summary is part
+// of messages, so repeated compactions fold older summaries into the new one.
+func (h *HistoryManager) summarize(ctx context.Context, messages []*conversation.Message) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&transcript, "%s: %s\n", llm.MessageRole(msg), llm.MessageText(msg))
+	}
+
+	response, err := h.summarizer.Generate(ctx, []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleSystem, historySummaryPrompt),
+		conversation.NewChatMessage(conversation.RoleUser, transcript.String()),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	summary := strings.TrimSpace(llm.MessageText(response))
+	if summary == "" {
+		return "", fmt.Errorf("summarizer returned an empty summary")
+	}
+	return summary, nil
+}
+
+// startsTurn returns whether messages[i] starts a turn: it is not a tool result, and
+// not a tool call following the text or other tool calls of the same response
+func startsTurn(messages []*conversation.Message, i int) bool {
+	switch messages[i].Content.(type) {
+	case *conversation.ToolResultContent:
+		return false
+	case *conversation.ToolUseContent:
+		switch previous := messages[i-1].Content.(type) {
+		case *conversation.ToolUseContent:
+			return false
+		case *conversation.ChatMessageContent:
+			return previous.Role != conversation.RoleAssistant
+		}
+	}
+	return true
+}
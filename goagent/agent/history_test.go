@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+)
+
+func TestHistoryCompact(t *testing.T) {
+	// Messages are labelled to compare the compacted conversations
+	labels := map[*conversation.Message]string{}
+	msg := func(label string, m *conversation.Message) *conversation.Message {
+		labels[m] = label
+		return m
+	}
+	system := msg("system", conversation.NewChatMessage(conversation.RoleSystem, "system"))
+	user := msg("user", conversation.NewChatMessage(conversation.RoleUser, "goal"))
+	text := func(label string) *conversation.Message {
+		return msg(label, conversation.NewChatMessage(conversation.RoleAssistant, label))
+	}
+	use := func(label string) *conversation.Message {
+		return msg(label, toolUseMessage(label, "echo", "{}"))
+	}
+	result := func(label string) *conversation.Message {
+		return msg(label, conversation.NewMessage(&conversation.ToolResultContent{ToolID: label, Result: label}))
+	}
+
+	testCases := []struct {
+		name     string
+		budget   int
+		messages []*conversation.Message
+		expected []string
+	}{
+		{
+			name:     "within budget",
+			budget:   100,
+			messages: []*conversation.Message{system, user, text("a1"), use("u1"), result("r1")},
+			expected: []string{"system", "user", "a1", "u1", "r1"},
+		},
+		{
+			// The budget fits the second tool call and the results, not the whole group
+			name:     "parallel tool calls",
+			budget:   60,
+			messages: []*conversation.Message{system, user, text("a1"), use("u1"), use("u2"), result("r1"), result("r2"), text("a2")},
+			expected: []string{"system", "user", "a2"},
+		},
+		{
+			name:     "tool calls without text",
+			budget:   60,
+			messages: []*conversation.Message{system, user, use("u1"), result("r1"), use("u2"), use("u3"), result("r2"), result("r3")},
+			expected: []string{"system", "user", "u2", "u3", "r2", "r3"},
+		},
+		{
+			name:     "budget smaller than the pinned messages",
+			budget:   10,
+			messages: []*conversation.Message{system, user, text("a1"), use("u1"), result("r1"), text("a2")},
+			expected: []string{"system", "user", "a2"},
+		},
+		{
+			// The last turn is kept whole even if it exceeds the budget
+			name:     "budget smaller than the last turn",
+			budget:   10,
+			messages: []*conversation.Message{system, user, text("a1"), text("a2"), use("u1"), use("u2"), result("r1"), result("r2")},
+			expected: []string{"system", "user", "a2", "u1", "u2", "r1", "r2"},
+		},
+	}
+
+	h := NewHistoryManager(0, HistoryStrategyDrop, WithTokenCounter(func(*conversation.Message) int { return 10 }))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h.tokenBudget = tc.budget
+			var got []string
+			for _, m := range h.Compact(context.Background(), tc.messages) {
+				got = append(got, labels[m])
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Compact() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHistoryCompactSummarize(t *testing.T) {
+	summarizer := &scriptedLLM{responses: [][]*conversation.Message{textMessage("a1 was tried")}}
+	h := NewHistoryManager(50, HistoryStrategySummarize,
+		WithHistorySummarizer(summarizer),
+		WithTokenCounter(func(*conversation.Message) int { return 10 }),
+	)
+	messages := []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleSystem, "system"),
+		conversation.NewChatMessage(conversation.RoleUser, "goal"),
+		conversation.NewChatMessage(conversation.RoleAssistant, "a1"),
+		toolUseMessage("u1", "echo", "{}"),
+		conversation.NewMessage(&conversation.ToolResultContent{ToolID: "u1", Result: "r1"}),
+		conversation.NewChatMessage(conversation.RoleAssistant, "a2"),
+	}
+
+	compacted := h.Compact(context.Background(), messages)
+	var got []string
+	for _, m := range compacted {
+		got = append(got, m.Content.String())
+	}
+	expected := []string{"system", "goal", historySummaryPrefix + "a1 was tried", "a2"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Compact() = %q, want %q", got, expected)
+	}
+}
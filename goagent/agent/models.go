@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/middlewares"
+	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/pkg/errors"
+)
+
+// defaultProfileFile returns the geppetto profiles file shared with pinocchio,
+// ~/.config/pinocchio/profiles.yaml on Linux.
+func defaultProfileFile() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to find the user config directory")
+	}
+	return filepath.Join(configDir, "pinocchio", "profiles.yaml"), nil
+}
+
+// cloneParsedLayers copies parsedLayers and their parameters. ParsedLayers.Clone shares
+// the parameters, which profiles would update in place.
+func cloneParsedLayers(parsedLayers *layers.ParsedLayers) *layers.ParsedLayers {
+	ret := layers.NewParsedLayers()
+	parsedLayers.ForEach(func(slug string, layer *layers.ParsedLayer) {
+		ret.Set(slug, &layers.ParsedLayer{Layer: layer.Layer, Parameters: layer.Parameters.Clone()})
+	})
+	return ret
+}
+
+// NewModelFromProfile returns the model an agent uses for one of its roles, such as
+// planning. The settings of the named geppetto profile are applied on top of the
+// parsed layers of the command, and engine overrides the chat engine. Without a
+// profile and an engine, baseModel is returned unchanged.
+//
+// profileFile defaults to the pinocchio profiles file. The derived model shares the
+// event bus, budget and pause gate of baseModel, which must implement
+// llm.ConfigurableLLM.
+func NewModelFromProfile(
+	cmd Command,
+	parsedLayers *layers.ParsedLayers,
+	baseModel llm.LLM,
+	profileFile string,
+	profile string,
+	engine string,
+) (llm.LLM, error) {
+	if profile == "" && engine == "" {
+		return baseModel, nil
+	}
+	configurable, ok := baseModel.(llm.ConfigurableLLM)
+	if !ok {
+		return nil, errors.Errorf("LLM %T can't be configured with another profile or engine", baseModel)
+	}
+
+	if profile != "" {
+		if profileFile == "" {
+			var err error
+			profileFile, err = defaultProfileFile()
+			if err != nil {
+				return nil, err
+			}
+		}
+		// The profile is applied to a copy, the other models keep the command settings.
+		// The profile file is not passed as default file, so that a missing file is an error
+		parsedLayers = cloneParsedLayers(parsedLayers)
+		err := middlewares.ExecuteMiddlewares(
+			cmd.GetCommandDescription().Layers,
+			parsedLayers,
+			middlewares.GatherFlagsFromProfiles(
+				"",
+				profileFile,
+				profile,
+				parameters.WithParseStepSource("profiles"),
+				parameters.WithParseStepMetadata(map[string]interface{}{
+					"profileFile": profileFile,
+					"profile":     profile,
+				}),
+			),
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load profile %s from %s", profile, profileFile)
+		}
+	}
+
+	stepSettings, err := settings.NewStepSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create step settings")
+	}
+	if engine != "" {
+		stepSettings.Chat.Engine = &engine
+	}
+
+	return configurable.WithStepSettings(stepSettings)
+}
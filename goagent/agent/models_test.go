@@ -0,0 +1,235 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/middlewares"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	pinocchio_cmds "github.com/go-go-golems/pinocchio/pkg/cmds"
+)
+
+// testCommand is a command with the geppetto layers and the layers of an agent factory
+type testCommand struct {
+	description  *cmds.CommandDescription
+	agentOptions map[string]interface{}
+}
+
+func (c *testCommand) GetCommandDescription() *cmds.CommandDescription { return c.description }
+func (c *testCommand) GetAgentType() string                            { return PlanAndExecuteAgentType }
+func (c *testCommand) GetSystemPrompt() string                         { return "" }
+func (c *testCommand) GetPrompt() string                               { return "" }
+func (c *testCommand) GetTools() []string                              { return nil }
+func (c *testCommand) RenderAgentOptions(parameters map[string]interface{}, tags map[string]interface{}) (map[string]interface{}, error) {
+	return c.agentOptions, nil
+}
+
+// newTestCommand returns a command using gpt-4o with at most 1000 response tokens, and
+// its parsed layers
+func newTestCommand(t *testing.T, agentOptions map[string]interface{}) (*testCommand, *layers.ParsedLayers) {
+	t.Helper()
+	stepSettings, err := settings.NewStepSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	geppettoLayers, err := pinocchio_cmds.CreateGeppettoLayers(stepSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agentLayers, err := (&PlanAndExecuteAgentFactory{}).CreateLayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	description := cmds.NewCommandDescription("test", cmds.WithLayersList(append(geppettoLayers, agentLayers...)...))
+
+	parsedLayers := layers.NewParsedLayers()
+	err = middlewares.ExecuteMiddlewares(description.Layers, parsedLayers,
+		middlewares.UpdateFromMap(map[string]map[string]interface{}{
+			settings.AiChatSlug: {"ai-engine": "gpt-4o", "ai-max-response-tokens": 1000},
+		}),
+		middlewares.SetFromDefaults(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCommand{description: description, agentOptions: agentOptions}, parsedLayers
+}
+
+// configurableLLM records the step settings it was configured with
+type configurableLLM struct {
+	*scriptedLLM
+	stepSettings *settings.StepSettings
+}
+
+func (c *configurableLLM) WithStepSettings(stepSettings *settings.StepSettings) (llm.LLM, error) {
+	return &configurableLLM{scriptedLLM: &scriptedLLM{}, stepSettings: stepSettings}, nil
+}
+
+const testProfiles = `
+fast:
+  ai-chat:
+    ai-engine: gpt-4o-mini
+`
+
+func TestNewModelFromProfile(t *testing.T) {
+	profileFile := filepath.Join(t.TempDir(), "profiles.yaml")
+	if err := os.WriteFile(profileFile, []byte(testProfiles), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		profileFile string
+		profile     string
+		engine      string
+		// expected engine of the derived model, empty for the base model
+		expected string
+		err      string
+	}{
+		{name: "command settings"},
+		{name: "engine", engine: "o3", expected: "o3"},
+		{name: "profile", profileFile: profileFile, profile: "fast", expected: "gpt-4o-mini"},
+		// The engine overrides the one of the profile
+		{name: "profile and engine", profileFile: profileFile, profile: "fast", engine: "o3", expected: "o3"},
+		{name: "unknown profile", profileFile: profileFile, profile: "slow", err: "profile slow not found"},
+		{name: "missing profile file", profileFile: profileFile + ".missing", profile: "fast", err: "does not exist"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, parsedLayers := newTestCommand(t, nil)
+			base := &configurableLLM{scriptedLLM: &scriptedLLM{}}
+			model, err := NewModelFromProfile(cmd, parsedLayers, base, tc.profileFile, tc.profile, tc.engine)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("NewModelFromProfile() = %v, want an error containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expected == "" {
+				if model != base {
+					t.Errorf("NewModelFromProfile() = %v, want the base model", model)
+				}
+				return
+			}
+
+			derived, ok := model.(*configurableLLM)
+			if !ok || derived == base {
+				t.Fatalf("NewModelFromProfile() = %v, want a model derived from the base model", model)
+			}
+			chat := derived.stepSettings.Chat
+			if chat.Engine == nil || *chat.Engine != tc.expected {
+				t.Errorf("engine = %v, want %s", chat.Engine, tc.expected)
+			}
+			// Settings not set by the profile are the ones of the command
+			if chat.MaxResponseTokens == nil || *chat.MaxResponseTokens != 1000 {
+				t.Errorf("max response tokens = %v, want the 1000 of the command", chat.MaxResponseTokens)
+			}
+
+			// The settings of the command are not changed by the profile
+			commandSettings, err := settings.NewStepSettingsFromParsedLayers(parsedLayers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *commandSettings.Chat.Engine != "gpt-4o" {
+				t.Errorf("command engine = %s after applying the profile, want gpt-4o", *commandSettings.Chat.Engine)
+			}
+		})
+	}
+
+	// Models that can't be configured are only used without profile and engine
+	cmd, parsedLayers := newTestCommand(t, nil)
+	if _, err := NewModelFromProfile(cmd, parsedLayers, &scriptedLLM{}, "", "", "o3"); err == nil {
+		t.Error("NewModelFromProfile() of a model that can't be configured succeeded")
+	}
+}
+
+func TestPlanAndExecuteFactoryModels(t *testing.T) {
+	cmd, parsedLayers := newTestCommand(t, map[string]interface{}{"planner-engine": "o3"})
+	base := &configurableLLM{scriptedLLM: &scriptedLLM{}}
+	a, err := NewPlanAndExecuteAgentFactory(nil, nil).NewAgent(context.Background(), cmd, parsedLayers, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := a.(*PlanAndExecuteAgent)
+	planner, ok := agent.LLM.(*configurableLLM)
+	if !ok || planner == base || *planner.stepSettings.Chat.Engine != "o3" {
+		t.Errorf("planner model = %+v, want the o3 engine", agent.LLM)
+	}
+	// Without executor profile and engine, the final answer is written by the command's model
+	if agent.ExecutorLLM != base {
+		t.Errorf("executor model = %+v, want the command's model", agent.ExecutorLLM)
+	}
+}
+
+func TestFinishPlan(t *testing.T) {
+	plan := `[
+		{"id": "1", "action": "echo", "actionInput": "Paris", "thought": "weather in Paris"},
+		{"id": "2", "action": "echo", "actionInput": "London", "thought": "weather in London", "depends_on": []},
+		{"id": "3", "action": "FinalAnswer", "actionInput": "Paris: {{steps.1.result}}, London: {{steps.2.result}}"}
+	]`
+	testCases := []struct {
+		name string
+		// executor is the executor model, nil to use the planner model
+		executor *scriptedLLM
+	}{
+		{name: "executor model", executor: &scriptedLLM{}},
+		{name: "planner model"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			planner := &scriptedLLM{responses: [][]*conversation.Message{textMessage(plan)}}
+			writer := planner
+			var options []PlanAndExecuteAgentOption
+			if tc.executor != nil {
+				writer = tc.executor
+				options = append(options, WithExecutorExecutionLLM(tc.executor))
+			}
+			writer.responses = append(writer.responses, textMessage("Sunny in Paris, rainy in London"))
+			a := newTestPlanAndExecuteAgent(t, planner, &echoTool{}, options...)
+
+			answer, err := a.Run(context.Background(), "weather in Paris and London")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if answer != "Sunny in Paris, rainy in London" {
+				t.Errorf("answer = %q, want the answer of the final answer call", answer)
+			}
+			if tc.executor != nil && len(planner.calls) != 1 {
+				t.Errorf("planner made %d calls, want only the plan", len(planner.calls))
+			}
+
+			// The final answer is the last call of its model, with the goal, the step
+			// results and the draft of the planner
+			request := writer.calls[len(writer.calls)-1]
+			if system := request[0].Content.String(); !strings.Contains(system, "Goal: weather in Paris and London") {
+				t.Errorf("final answer system prompt = %q, want the goal", system)
+			}
+			expected := `Goal: weather in Paris and London
+
+Executed steps:
+- Step 1: echo(Paris)
+  Thought: weather in Paris
+  Result: echo: Paris
+- Step 2: echo(London)
+  Thought: weather in London
+  Result: echo: London
+
+Draft answer:
+Paris: echo: Paris, London: echo: London
+`
+			if user := request[1].Content.String(); user != expected {
+				t.Errorf("final answer request = %q, want %q", user, expected)
+			}
+		})
+	}
+}
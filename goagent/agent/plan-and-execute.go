@@ -11,8 +11,11 @@ import (
 	"github.com/go-go-golems/glazed/pkg/cmds/layers"
 	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
 	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/go-go-golems/go-go-agent/goagent/memory"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
 	"github.com/go-go-golems/go-go-agent/goagent/types"
 	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
 	events "github.com/go-go-golems/go-go-agent/proto"
 
 	"github.com/google/uuid"
@@ -38,10 +41,25 @@ func NewPlanAndExecuteAgentFactory(
 
 const PlanAndExecuteAgentType = "plan-execute" // Define the type constant
 
+// PlanAndExecuteAgentClass is the agent class of the events of plan-and-execute agents
+const PlanAndExecuteAgentClass = "PlanAndExecuteAgent"
+
+// plannerNodeID is the node of the planning steps
+const plannerNodeID = "plan-execute-planner"
+
 // PlanAndExecuteAgentSettings holds configuration for the PlanAndExecuteAgent.
 type PlanAndExecuteAgentSettings struct {
-	MaxIterations int `glazed.parameter:"max-iterations"`
-	// TODO(manuel): Add separate parameters for planner and executor LLMs if needed
+	MaxIterations    int    `glazed.parameter:"max-iterations"`
+	MaxParallelTools int    `glazed.parameter:"max-parallel-tools"`
+	ToolTimeout      int    `glazed.parameter:"tool-timeout"`
+	MaxParallelSteps int    `glazed.parameter:"max-parallel-steps"`
+	Replan           string `glazed.parameter:"replan"`
+	MaxReplans       int    `glazed.parameter:"max-replans"`
+	PlannerProfile   string `glazed.parameter:"planner-profile"`
+	PlannerEngine    string `glazed.parameter:"planner-engine"`
+	ExecutorProfile  string `glazed.parameter:"executor-profile"`
+	ExecutorEngine   string `glazed.parameter:"executor-engine"`
+	ProfileFile      string `glazed.parameter:"profile-file"`
 }
 
 // NewAgent creates a new PlanAndExecuteAgent.
@@ -68,40 +86,148 @@ func (f *PlanAndExecuteAgentFactory) NewAgent(
 			settings.MaxIterations = maxIter
 		}
 	}
+	if maxParallelTools, ok := agentOptions["max-parallel-tools"].(int); ok {
+		settings.MaxParallelTools = maxParallelTools
+	}
+	if toolTimeout, ok := agentOptions["tool-timeout"].(int); ok {
+		settings.ToolTimeout = toolTimeout
+	}
+	if maxParallelSteps, ok := agentOptions["max-parallel-steps"].(int); ok {
+		settings.MaxParallelSteps = maxParallelSteps
+	}
+	if replan, ok := agentOptions["replan"].(string); ok {
+		settings.Replan = replan
+	}
+	if maxReplans, ok := agentOptions["max-replans"].(int); ok {
+		settings.MaxReplans = maxReplans
+	}
+	if plannerProfile, ok := agentOptions["planner-profile"].(string); ok {
+		settings.PlannerProfile = plannerProfile
+	}
+	if plannerEngine, ok := agentOptions["planner-engine"].(string); ok {
+		settings.PlannerEngine = plannerEngine
+	}
+	if executorProfile, ok := agentOptions["executor-profile"].(string); ok {
+		settings.ExecutorProfile = executorProfile
+	}
+	if executorEngine, ok := agentOptions["executor-engine"].(string); ok {
+		settings.ExecutorEngine = executorEngine
+	}
+	if profileFile, ok := agentOptions["profile-file"].(string); ok {
+		settings.ProfileFile = profileFile
+	}
 
 	// Use the provided models from factory
 	planningModel := f.planningModel
 	executionModel := f.executionModel
 
-	// If no dedicated models are set in the factory, use the base model for both
+	// If no dedicated models are set in the factory, derive them from the base model,
+	// using the profile or engine configured for their role
 	if planningModel == nil {
-		planningModel = baseModel
+		planningModel, err = NewModelFromProfile(cmd, parsedLayers, baseModel, settings.ProfileFile, settings.PlannerProfile, settings.PlannerEngine)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create planner model")
+		}
 	}
 	if executionModel == nil {
-		executionModel = baseModel
+		executionModel, err = NewModelFromProfile(cmd, parsedLayers, baseModel, settings.ProfileFile, settings.ExecutorProfile, settings.ExecutorEngine)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create executor model")
+		}
+	}
+
+	mem, err := NewMemoryFromCommand(cmd, parsedLayers, PlanAndExecuteAgentType, agentOptions, baseModel)
+	if err != nil {
+		return nil, err
+	}
+
+	toolExecutor, err := NewToolExecutorFromCommand(
+		cmd,
+		agentOptions,
+		toolExecutorOptions(settings.MaxParallelTools, settings.ToolTimeout)...,
+	)
+	if err != nil {
+		return nil, err
 	}
 
 	return NewPlanAndExecuteAgent(
 		WithExecutorLLM(planningModel),
-		WithExecutorLLM(executionModel),
+		WithExecutorExecutionLLM(executionModel),
+		WithExecutorTools(toolExecutor),
+		WithExecutorMemory(mem),
 		WithExecutorMaxPlanningLoops(settings.MaxIterations),
+		WithExecutorMaxParallelSteps(settings.MaxParallelSteps),
+		WithExecutorReplanMode(ReplanMode(settings.Replan)),
+		WithExecutorMaxReplans(settings.MaxReplans),
 	)
 }
 
 // CreateLayers defines the Glazed parameter layers for the PlanAndExecuteAgent.
 func (f *PlanAndExecuteAgentFactory) CreateLayers() ([]layers.ParameterLayer, error) {
+	definitions := []*parameters.ParameterDefinition{
+		parameters.NewParameterDefinition(
+			"max-iterations",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Maximum number of planning/execution iterations"),
+			parameters.WithDefault(5), // Lower default for plan-execute?
+		),
+		parameters.NewParameterDefinition(
+			"max-parallel-steps",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Maximum number of plan steps executed in parallel once their dependencies are done"),
+			parameters.WithDefault(4),
+		),
+		parameters.NewParameterDefinition(
+			"replan",
+			parameters.ParameterTypeChoice,
+			parameters.WithHelp("When to revise the remaining plan: after a failed step, after every step, or never"),
+			parameters.WithDefault(string(ReplanOnFailure)),
+			parameters.WithChoices(string(ReplanOnFailure), string(ReplanAlways), string(ReplanNever)),
+		),
+		parameters.NewParameterDefinition(
+			"max-replans",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Maximum number of times the remaining plan is revised during a run"),
+			parameters.WithDefault(3),
+		),
+		parameters.NewParameterDefinition(
+			"planner-profile",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("Geppetto profile of the model creating and revising the plan (defaults to the command's model)"),
+			parameters.WithDefault(""),
+		),
+		parameters.NewParameterDefinition(
+			"planner-engine",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("Engine of the model creating and revising the plan, applied after planner-profile"),
+			parameters.WithDefault(""),
+		),
+		parameters.NewParameterDefinition(
+			"executor-profile",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("Geppetto profile of the model writing the final answer from the step results (defaults to the command's model)"),
+			parameters.WithDefault(""),
+		),
+		parameters.NewParameterDefinition(
+			"executor-engine",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("Engine of the model writing the final answer, applied after executor-profile"),
+			parameters.WithDefault(""),
+		),
+		parameters.NewParameterDefinition(
+			"profile-file",
+			parameters.ParameterTypeString,
+			parameters.WithHelp("Profiles file of planner-profile and executor-profile (defaults to the pinocchio profiles.yaml)"),
+			parameters.WithDefault(""),
+		),
+	}
+	definitions = append(definitions, toolExecutionParameterDefinitions()...)
+	definitions = append(definitions, memoryParameterDefinitions()...)
+
 	agentLayer, err := layers.NewParameterLayer(
 		PlanAndExecuteAgentType,
 		"Plan-and-Execute agent configuration",
-		layers.WithParameterDefinitions(
-			parameters.NewParameterDefinition(
-				"max-iterations",
-				parameters.ParameterTypeInteger,
-				parameters.WithHelp("Maximum number of planning/execution iterations"),
-				parameters.WithDefault(5), // Lower default for plan-execute?
-			),
-			// Add parameters for planner/executor models here if needed
-		),
+		layers.WithParameterDefinitions(definitions...),
 	)
 	if err != nil {
 		return nil, err
@@ -109,40 +235,61 @@ func (f *PlanAndExecuteAgentFactory) CreateLayers() ([]layers.ParameterLayer, er
 	return []layers.ParameterLayer{agentLayer}, nil
 }
 
-// Plan represents a single step in the execution plan.
+// Plan represents a single step in the execution plan. Steps form a DAG: a step is
+// executed once the steps it depends on are done, and its ActionInput can reference
+// their results as {{steps.<id>.result}}.
 type Plan struct {
 	ID          string `json:"id"`          // Unique identifier for the step
 	Thought     string `json:"thought"`     // Reasoning behind the step
 	Action      string `json:"action"`      // Tool or action to perform (e.g., Search, FinalAnswer)
 	ActionInput string `json:"actionInput"` // Input for the action/tool
-	Result      string `json:"result"`      // Result of executing the action (populated later)
-	IsFinal     bool   `json:"isFinal"`     // Indicates if this is the final step
+	// IDs of the steps this step depends on, in addition to the referenced ones. A nil
+	// list (no depends_on field) makes the step depend on the previous step.
+	DependsOn []string `json:"depends_on"`
+	Result    string   `json:"result"`           // Result of executing the action (populated later)
+	IsFinal   bool     `json:"isFinal"`          // Indicates if this is the final step
+	Done      bool     `json:"done,omitempty"`   // The step has been executed
+	NodeID    string   `json:"nodeId,omitempty"` // Graph node of the step, assigned by the agent
 }
 
 // PlanAndExecuteAgent implements a plan-and-execute agent.
-// It first creates a plan using an LLM and then executes each step.
+// It first creates a plan using an LLM, then executes each step and finally lets the
+// executor LLM write the answer from the step results.
 type PlanAndExecuteAgent struct {
 	*BaseAgent
-	LLM              llm.LLM
-	PlannerPrompt    string // Template for the planning prompt
-	ExecutorPrompt   string // Template for the executor prompt (if needed, maybe just use tool results)
-	MaxPlanningLoops int    // Max attempts to create a valid plan
+	LLM              llm.LLM // Creates and revises the plan
+	ExecutorLLM      llm.LLM // Writes the final answer, defaults to LLM
+	PlannerPrompt    string  // Template for the planning prompt
+	ExecutorPrompt   string  // Template for the system prompt of the final answer
+	MaxPlanningLoops int     // Max attempts to create a valid plan
+	MaxParallelSteps int     // Max steps executed at the same time
+	ReplanMode       ReplanMode
+	MaxReplans       int // Max plan revisions per run
 	currentStep      int
+	planRevision     int    // Plan revisions of the current run
+	rootNodeID       string // Graph node of the goal, the parent of the step nodes
 	// Optional event bus
 	eventBus *eventbus.EventBus
-	runID    *string
 }
 
 // PlanAndExecuteAgentOption defines functional options.
 type PlanAndExecuteAgentOption func(*PlanAndExecuteAgent)
 
-// WithExecutorLLM sets the LLM for the agent (used for both planning and potentially execution).
+// WithExecutorLLM sets the LLM for the agent (used for planning, and for the final
+// answer unless WithExecutorExecutionLLM is set).
 func WithExecutorLLM(llm llm.LLM) PlanAndExecuteAgentOption {
 	return func(a *PlanAndExecuteAgent) {
 		a.LLM = llm
 	}
 }
 
+// WithExecutorExecutionLLM sets the LLM writing the final answer from the step results.
+func WithExecutorExecutionLLM(llm llm.LLM) PlanAndExecuteAgentOption {
+	return func(a *PlanAndExecuteAgent) {
+		a.ExecutorLLM = llm
+	}
+}
+
 // WithExecutorPlannerPrompt sets the planning prompt template.
 func WithExecutorPlannerPrompt(prompt string) PlanAndExecuteAgentOption {
 	return func(a *PlanAndExecuteAgent) {
@@ -150,10 +297,25 @@ func WithExecutorPlannerPrompt(prompt string) PlanAndExecuteAgentOption {
 	}
 }
 
-// WithExecutorExecutorPrompt sets the executor prompt template (currently unused).
+// WithExecutorExecutorPrompt sets the system prompt template used to write the final
+// answer from the step results. {{.goal}} is replaced with the goal.
 func WithExecutorExecutorPrompt(prompt string) PlanAndExecuteAgentOption {
 	return func(a *PlanAndExecuteAgent) {
-		a.ExecutorPrompt = prompt // Currently unused
+		a.ExecutorPrompt = prompt
+	}
+}
+
+// WithExecutorTools sets the tools available to the plan steps.
+func WithExecutorTools(toolExecutor *tools.ToolExecutor) PlanAndExecuteAgentOption {
+	return func(a *PlanAndExecuteAgent) {
+		a.tools = toolExecutor
+	}
+}
+
+// WithExecutorMemory sets the memory system for the agent.
+func WithExecutorMemory(mem memory.Memory) PlanAndExecuteAgentOption {
+	return func(a *PlanAndExecuteAgent) {
+		a.memory = mem
 	}
 }
 
@@ -164,19 +326,50 @@ func WithExecutorMaxPlanningLoops(maxLoops int) PlanAndExecuteAgentOption {
 	}
 }
 
+// WithExecutorMaxParallelSteps sets the maximum number of steps executed at the same time.
+func WithExecutorMaxParallelSteps(maxParallelSteps int) PlanAndExecuteAgentOption {
+	return func(a *PlanAndExecuteAgent) {
+		a.MaxParallelSteps = maxParallelSteps
+	}
+}
+
+// WithExecutorReplanMode sets when the remaining plan is revised.
+func WithExecutorReplanMode(mode ReplanMode) PlanAndExecuteAgentOption {
+	return func(a *PlanAndExecuteAgent) {
+		a.ReplanMode = mode
+	}
+}
+
+// WithExecutorMaxReplans sets the maximum number of plan revisions per run.
+func WithExecutorMaxReplans(maxReplans int) PlanAndExecuteAgentOption {
+	return func(a *PlanAndExecuteAgent) {
+		a.MaxReplans = maxReplans
+	}
+}
+
 // WithExecutorEventBus configures the agent with an event bus.
-func WithExecutorEventBus(eb *eventbus.EventBus, runID string) PlanAndExecuteAgentOption {
+func WithExecutorEventBus(eb *eventbus.EventBus) PlanAndExecuteAgentOption {
 	return func(a *PlanAndExecuteAgent) {
 		a.eventBus = eb
-		rid := runID
-		a.runID = &rid
 	}
 }
 
+// SetEventBus attaches an event bus after the agent has been created.
+func (a *PlanAndExecuteAgent) SetEventBus(eb *eventbus.EventBus) {
+	WithExecutorEventBus(eb)(a)
+}
+
 // NewPlanAndExecuteAgent creates a new PlanAndExecuteAgent.
 func NewPlanAndExecuteAgent(options ...PlanAndExecuteAgentOption) (*PlanAndExecuteAgent, error) {
 	a := &PlanAndExecuteAgent{
 		MaxPlanningLoops: 3, // Default planning attempts
+		MaxParallelSteps: 4,
+		ReplanMode:       ReplanOnFailure,
+		MaxReplans:       3,
+		BaseAgent: &BaseAgent{
+			tools:  tools.NewToolExecutor(),
+			memory: memory.NewNoopMemory(),
+		},
 	}
 	for _, option := range options {
 		option(a)
@@ -185,11 +378,30 @@ func NewPlanAndExecuteAgent(options ...PlanAndExecuteAgentOption) (*PlanAndExecu
 	if a.LLM == nil {
 		return nil, errors.New("LLM must be provided")
 	}
+	if a.ExecutorLLM == nil {
+		a.ExecutorLLM = a.LLM
+	}
+	if a.tools == nil {
+		a.tools = tools.NewToolExecutor()
+	}
+	if a.MaxParallelSteps < 1 {
+		return nil, errors.New("max-parallel-steps must be at least 1")
+	}
+	switch a.ReplanMode {
+	case ReplanOnFailure, ReplanAlways, ReplanNever:
+	case "":
+		a.ReplanMode = ReplanOnFailure
+	default:
+		return nil, errors.Errorf("unknown replan mode %s", a.ReplanMode)
+	}
 	if a.PlannerPrompt == "" {
 		// Provide a default planner prompt if none is set
 		a.PlannerPrompt = defaultPlannerPromptTemplate()
 		log.Info().Msg("Using default planner prompt template")
 	}
+	if a.ExecutorPrompt == "" {
+		a.ExecutorPrompt = defaultExecutorPromptTemplate()
+	}
 
 	return a, nil
 }
@@ -199,21 +411,30 @@ func defaultPlannerPromptTemplate() string {
 	return `Create a step-by-step plan to achieve the following goal:
 Goal: {{.goal}}
 
-Output the plan as a JSON list of objects, each with "id", "thought", "action", and "actionInput" fields. The final step's action should be "FinalAnswer".
+Output the plan as a JSON list of objects, each with "id", "thought", "action", "actionInput" and "depends_on" fields. "depends_on" lists the ids of the steps that must be done before the step; steps that don't depend on each other are executed in parallel. An actionInput can use the result of an earlier step as {{steps.<id>.result}}. The final step's action should be "FinalAnswer", with a draft of the answer as actionInput; the answer is written from the results of the steps once they are done.
 
 Example:
 [
   {
     "id": "1",
-    "thought": "I need to search for the weather.",
+    "thought": "I need to search for the weather in Paris.",
     "action": "Search",
-    "actionInput": "weather in Paris"
+    "actionInput": "weather in Paris",
+    "depends_on": []
   },
   {
     "id": "2",
-    "thought": "I have the weather information.",
+    "thought": "I need to search for the weather in London.",
+    "action": "Search",
+    "actionInput": "weather in London",
+    "depends_on": []
+  },
+  {
+    "id": "3",
+    "thought": "I have the weather information of both cities.",
     "action": "FinalAnswer",
-    "actionInput": "The weather in Paris is sunny."
+    "actionInput": "Paris: {{steps.1.result}}\nLondon: {{steps.2.result}}",
+    "depends_on": ["1", "2"]
   }
 ]
 
@@ -221,24 +442,319 @@ Plan:
 `
 }
 
+func defaultExecutorPromptTemplate() string {
+	return `You write the final answer to the following goal:
+Goal: {{.goal}}
+
+A plan was executed to achieve the goal. You are given the steps of the plan with the results of their tools, and the draft answer written when the plan was created. Answer the goal based on the step results. Use the draft as a guide for the form of the answer, but rely on the results: they are what actually happened. If a step failed or a result is missing, say what could not be found instead of making it up.
+`
+}
+
 // Run executes the plan-and-execute loop.
 func (a *PlanAndExecuteAgent) Run(ctx context.Context, goal string) (string, error) {
+	a.planRevision = 0
+	ctx = execctx.WithAgentClass(ctx, PlanAndExecuteAgentClass)
+	a.startMemoryRun(ctx)
+
+	var saved planAndExecuteCheckpoint
+	completedSteps, resumed, err := a.loadCheckpoint(ctx, PlanAndExecuteAgentType, &saved)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resume run")
+	}
+
+	// 1. Planning Phase, skipped when resuming a run
+	var plan []*Plan
+	if resumed {
+		if saved.Goal != goal {
+			log.Warn().Ctx(ctx).Str("goal", saved.Goal).Msg("Resumed run was started with a different goal, continuing the checkpointed plan")
+		}
+		plan = saved.Plan
+		a.rootNodeID = saved.RootNodeID
+		if a.rootNodeID == "" {
+			a.rootNodeID = uuid.New().String()
+		}
+		for _, step := range plan {
+			if step.NodeID == "" {
+				step.NodeID = uuid.New().String()
+			}
+		}
+		a.restoreMemory(ctx, saved.Memory)
+	} else {
+		a.rootNodeID = uuid.New().String()
+		plan, err = a.runPlanningPhase(ctx, goal)
+		if err != nil {
+			return "", err
+		}
+		a.emitPlanReceived(ctx, goal, plan, 0, nil, "")
+		a.emitRootNode(ctx, goal)
+		a.emitPlanGraph(ctx, plan)
+		a.savePlanCheckpoint(ctx, goal, plan)
+	}
+
+	// 2. Execution Phase
+	log.Info().Ctx(ctx).Int("completedSteps", completedSteps).Msg("Starting execution phase")
+	a.currentStep = completedSteps // Reset currentStep for execution phase
+	a.setNodeStatus(ctx, a.rootNodeID, goal, NodeStatusNotReady, NodeStatusDoing)
+
+	result, err := a.executePlan(ctx, goal, plan)
+	if err != nil {
+		a.setNodeStatus(ctx, a.rootNodeID, goal, NodeStatusDoing, NodeStatusFailed)
+		return result, err
+	}
+	a.emitNodeResult(ctx, a.rootNodeID, result)
+	a.setNodeStatus(ctx, a.rootNodeID, goal, NodeStatusDoing, NodeStatusFinish)
+	a.deleteCheckpoint(ctx)
+	return result, nil
+}
+
+// stepOutcome is the result of a step executed by a worker
+type stepOutcome struct {
+	step      *Plan
+	number    int
+	startTime time.Time
+	result    string
+	err       error
+}
+
+// executePlan runs the steps of plan whose dependencies are done, at most
+// MaxParallelSteps at a time, until a final step is reached. The plan is revised
+// when a step fails or completes, depending on ReplanMode; revisions wait for the
+// running steps to finish.
+func (a *PlanAndExecuteAgent) executePlan(ctx context.Context, goal string, plan []*Plan) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make(chan stepOutcome, a.MaxParallelSteps)
+	running := map[string]bool{}
+	// The plan is revised after replanStep completed or after failed steps, once the
+	// running steps are done
+	var replanStep *Plan
+	var failures []stepFailure
+
+	for {
+		if replanStep == nil && len(failures) == 0 {
+			for _, step := range readySteps(plan, running) {
+				if len(running) >= a.MaxParallelSteps {
+					break
+				}
+				if step.IsFinal || step.Action == "FinalAnswer" {
+					// Final steps wait for all other steps, so nothing is running
+					return a.finishPlan(ctx, goal, plan, step)
+				}
+				a.currentStep++
+				running[step.ID] = true
+				go a.runStep(ctx, step, resolveStepReferences(step.ActionInput, plan), a.currentStep, outcomes)
+			}
+		}
+
+		if len(running) == 0 {
+			if replanStep == nil && len(failures) == 0 {
+				break
+			}
+			revised, err := a.replan(ctx, goal, plan, replanStep, failures)
+			switch {
+			case err != nil && len(failures) > 0:
+				log.Warn().Ctx(ctx).Err(err).Msg("Failed to revise plan after failed steps")
+				return "", failuresError(failures)
+			case err != nil:
+				log.Warn().Ctx(ctx).Err(err).Msg("Failed to revise plan, continuing with the current plan")
+			default:
+				plan = revised
+				a.savePlanCheckpoint(ctx, goal, plan)
+			}
+			replanStep, failures = nil, nil
+			continue
+		}
+
+		outcome := <-outcomes
+		step := outcome.step
+		delete(running, step.ID)
+		step.Done = true
+		stepStatus := "TOOL_EXECUTED"
+		if outcome.err != nil {
+			log.Error().Ctx(ctx).Int("step", outcome.number).Err(outcome.err).Str("action", step.Action).Msg("Tool execution failed")
+			a.addMemory(ctx, fmt.Sprintf("plan-execute-step-%d", outcome.number), fmt.Sprintf("Execution Step %d: Tool Error: %v", outcome.number, outcome.err), "executor")
+			step.Result = fmt.Sprintf("Error: %v", outcome.err)
+			stepStatus = "TOOL_ERROR"
+		} else {
+			log.Info().Ctx(ctx).Int("step", outcome.number).Str("result", outcome.result).Msg("Tool executed successfully")
+			a.addMemory(ctx, fmt.Sprintf("plan-execute-step-%d", outcome.number), fmt.Sprintf("Execution Step %d: Observation: %s", outcome.number, outcome.result), "executor")
+			step.Result = outcome.result
+		}
+
+		// --- Emit StepFinished Event (Execution Step) ---
+		if a.eventBus != nil {
+			stepFinishedPayload := &events.StepFinishedPayload{
+				Step:            int32(outcome.number),
+				NodeId:          step.NodeID,
+				ActionName:      step.Action,
+				StatusAfter:     stepStatus,
+				DurationSeconds: time.Since(outcome.startTime).Seconds(),
+			}
+			errEmit := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, nil)
+			if errEmit != nil {
+				log.Warn().Err(errEmit).Msg("Failed to emit StepFinished event for execution step")
+			}
+		}
+		a.emitNodeResult(ctx, step.NodeID, step.Result)
+
+		if outcome.err != nil {
+			a.setNodeStatus(ctx, step.NodeID, step.ActionInput, NodeStatusDoing, NodeStatusFailed)
+			// The failed step is kept in the plan with its error, the replanner revises the
+			// remaining steps. Without replanning, we stop and return the error
+			if !a.canReplan(true) {
+				return "", errors.Wrapf(outcome.err, "tool execution failed at step %d (%s)", outcome.number, step.ID)
+			}
+			failures = append(failures, stepFailure{step: step, err: outcome.err})
+		} else {
+			a.setNodeStatus(ctx, step.NodeID, step.ActionInput, NodeStatusDoing, NodeStatusFinish)
+			if replanStep == nil && a.canReplan(false) {
+				replanStep = step
+			}
+		}
+
+		// The step is complete, a resumed run continues with the remaining ones
+		a.savePlanCheckpoint(ctx, goal, plan)
+	}
+
+	// If the plan has no final step left
+	log.Warn().Ctx(ctx).Msg("Agent finished execution loop without a FinalAnswer step")
+	// Return the result of the last step?
+	if len(plan) > 0 {
+		return plan[len(plan)-1].Result, errors.New("agent finished without explicit FinalAnswer")
+	}
+	return "", errors.New("agent finished without completing any steps or providing a FinalAnswer")
+}
+
+// runStep executes the tool of step with input, its action input with the references
+// to other steps resolved, and sends its outcome. It runs in its own goroutine.
+func (a *PlanAndExecuteAgent) runStep(ctx context.Context, step *Plan, input string, number int, outcomes chan<- stepOutcome) {
+	ctx = execctx.WithAction(execctx.WithStep(ctx, number, step.NodeID), step.Action)
+	log.Info().Ctx(ctx).Int("step", number).Str("action", step.Action).Str("id", step.ID).Msg("Executing plan step")
+
+	// --- Emit StepStarted Event (Execution Step) ---
+	if a.eventBus != nil {
+		stepPayload := &events.StepStartedPayload{
+			Step:     int32(number),
+			NodeId:   step.NodeID,
+			NodeGoal: fmt.Sprintf("%s: %s", step.Action, input),
+			RootId:   execctx.FromContext(ctx).RunID,
+		}
+		errEmit := a.eventBus.EmitStepStarted(ctx, stepPayload, nil)
+		if errEmit != nil {
+			log.Warn().Err(errEmit).Msg("Failed to emit StepStarted event for execution")
+		}
+	}
+	a.setNodeStatus(ctx, step.NodeID, step.ActionInput, NodeStatusNotReady, NodeStatusDoing)
+	startTime := time.Now()
+
+	result, err := a.executeTool(ctx, step.Action, input)
+	outcomes <- stepOutcome{step: step, number: number, startTime: startTime, result: result, err: err}
+}
+
+// finishPlan asks the executor LLM for the answer to goal, given the results of the
+// executed steps. The action input of the final step, with the references to the
+// results of other steps resolved, is passed as a draft of the answer.
+func (a *PlanAndExecuteAgent) finishPlan(ctx context.Context, goal string, plan []*Plan, step *Plan) (string, error) {
+	a.currentStep++
+	ctx = execctx.WithAction(execctx.WithStep(ctx, a.currentStep, step.NodeID), "FinalAnswer")
+	draft := resolveStepReferences(step.ActionInput, plan)
+	log.Info().Ctx(ctx).Int("step", a.currentStep).Str("draft", draft).Msg("Writing final answer")
+
+	// --- Emit StepStarted Event (Final Execution Step) ---
+	if a.eventBus != nil {
+		stepPayload := &events.StepStartedPayload{
+			Step:     int32(a.currentStep),
+			NodeId:   step.NodeID,
+			NodeGoal: fmt.Sprintf("%s: %s", step.Action, goal),
+			RootId:   execctx.FromContext(ctx).RunID,
+		}
+		errEmit := a.eventBus.EmitStepStarted(ctx, stepPayload, nil)
+		if errEmit != nil {
+			log.Warn().Err(errEmit).Msg("Failed to emit StepStarted event for execution")
+		}
+	}
+	a.setNodeStatus(ctx, step.NodeID, step.ActionInput, NodeStatusNotReady, NodeStatusDoing)
+	startTime := time.Now()
+
+	messages := []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleSystem, strings.ReplaceAll(a.ExecutorPrompt, "{{.goal}}", goal)),
+		conversation.NewChatMessage(conversation.RoleUser, finalAnswerRequest(goal, plan, draft)),
+	}
+	response, err := a.ExecutorLLM.Generate(ctx, messages)
+	stepStatus := "FINISH"
+	var answer string
+	if err != nil {
+		stepStatus = "FAILED"
+	} else {
+		answer = response.Content.String()
+	}
+
+	// --- Emit StepFinished Event (Final Execution Step) ---
+	if a.eventBus != nil {
+		stepFinishedPayload := &events.StepFinishedPayload{
+			Step:            int32(a.currentStep),
+			NodeId:          step.NodeID,
+			ActionName:      "FinalAnswer",
+			StatusAfter:     stepStatus,
+			DurationSeconds: time.Since(startTime).Seconds(),
+		}
+		errEmit := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, nil)
+		if errEmit != nil {
+			log.Warn().Err(errEmit).Msg("Failed to emit StepFinished event for final execution step")
+		}
+	}
+	if err != nil {
+		a.setNodeStatus(ctx, step.NodeID, step.ActionInput, NodeStatusDoing, NodeStatusFailed)
+		return "", errors.Wrap(err, "LLM call failed while writing the final answer")
+	}
+
+	log.Info().Ctx(ctx).Int("step", a.currentStep).Str("finalAnswer", answer).Msg("Reached final answer")
+	a.addMemory(ctx, fmt.Sprintf("plan-execute-step-%d", a.currentStep), fmt.Sprintf("Execution Step %d: Final Answer: %s", a.currentStep, answer), "executor")
+	step.Result = answer // Store final answer as result
+	step.Done = true
+
+	a.emitNodeResult(ctx, step.NodeID, answer)
+	a.setNodeStatus(ctx, step.NodeID, step.ActionInput, NodeStatusDoing, NodeStatusFinish)
+	return answer, nil
+}
+
+// finalAnswerRequest lists the executed steps of plan with their results, followed by
+// the draft answer of the planner
+func finalAnswerRequest(goal string, plan []*Plan, draft string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Goal: %s\n\nExecuted steps:\n", goal)
+	for _, step := range plan {
+		if !step.Done {
+			continue
+		}
+		fmt.Fprintf(&sb, "- Step %s: %s(%s)\n  Thought: %s\n  Result: %s\n", step.ID, step.Action, step.ActionInput, step.Thought, step.Result)
+	}
+	if draft != "" {
+		fmt.Fprintf(&sb, "\nDraft answer:\n%s\n", draft)
+	}
+	return sb.String()
+}
+
+// runPlanningPhase asks the LLM for a plan until a valid one is returned, at most
+// MaxPlanningLoops times.
+func (a *PlanAndExecuteAgent) runPlanningPhase(ctx context.Context, goal string) ([]*Plan, error) {
 	var plan []*Plan
 	var err error
 
-	// 1. Planning Phase
 	for i := 0; i < a.MaxPlanningLoops; i++ {
 		a.currentStep = i + 1 // Use currentStep for planning phase steps/attempts
+		ctx := execctx.WithAction(execctx.WithStep(ctx, a.currentStep, plannerNodeID), "CreatePlan")
 		log.Info().Ctx(ctx).Int("attempt", a.currentStep).Str("goal", goal).Msg("Planning phase attempt")
 		// --- Emit StepStarted Event (Planning Attempt) ---
 		if a.eventBus != nil {
 			stepPayload := &events.StepStartedPayload{
 				Step:     int32(a.currentStep),
-				NodeId:   "plan-execute-planner",
+				NodeId:   plannerNodeID,
 				NodeGoal: fmt.Sprintf("Plan for: %s", goal),
-				RootId:   *a.runID,
+				RootId:   execctx.FromContext(ctx).RunID,
 			}
-			errEmit := a.eventBus.EmitStepStarted(ctx, stepPayload, a.runID)
+			errEmit := a.eventBus.EmitStepStarted(ctx, stepPayload, nil)
 			if errEmit != nil {
 				log.Warn().Err(errEmit).Msg("Failed to emit StepStarted event for planning")
 			}
@@ -249,15 +765,7 @@ func (a *PlanAndExecuteAgent) Run(ctx context.Context, goal string) (string, err
 		stepStatus := "PLAN_CREATED"
 		if err != nil {
 			log.Warn().Ctx(ctx).Int("attempt", a.currentStep).Err(err).Msg("Failed to create plan, retrying")
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:      fmt.Sprintf("plan-execute-planner-error-%d", a.currentStep),
-				Content: fmt.Sprintf("Planning Error (%d): %v", a.currentStep, err),
-				Metadata: map[string]string{
-					"role": "planner",
-				},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for planning error")
-			}
+			a.addMemory(ctx, fmt.Sprintf("plan-execute-planner-error-%d", a.currentStep), fmt.Sprintf("Planning Error (%d): %v", a.currentStep, err), "planner")
 			stepStatus = "PLAN_ERROR"
 		}
 
@@ -265,12 +773,12 @@ func (a *PlanAndExecuteAgent) Run(ctx context.Context, goal string) (string, err
 		if a.eventBus != nil {
 			stepFinishedPayload := &events.StepFinishedPayload{
 				Step:            int32(a.currentStep),
-				NodeId:          "plan-execute-planner",
+				NodeId:          plannerNodeID,
 				ActionName:      "CreatePlan",
 				StatusAfter:     stepStatus,
 				DurationSeconds: time.Since(startTime).Seconds(),
 			}
-			errEmit := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, a.runID)
+			errEmit := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, nil)
 			if errEmit != nil {
 				log.Warn().Err(errEmit).Msg("Failed to emit StepFinished event for planning")
 			}
@@ -282,151 +790,44 @@ func (a *PlanAndExecuteAgent) Run(ctx context.Context, goal string) (string, err
 	}
 
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to create plan after %d attempts", a.MaxPlanningLoops)
+		return nil, errors.Wrapf(err, "failed to create plan after %d attempts", a.MaxPlanningLoops)
 	}
 
 	log.Info().Ctx(ctx).Int("steps", len(plan)).Msg("Plan created successfully")
-	if err := a.memory.Add(ctx, types.MemoryEntry{
-		ID:      fmt.Sprintf("plan-execute-planner-plan-%d", a.currentStep),
-		Content: fmt.Sprintf("Plan Created: %v", plan),
-		Metadata: map[string]string{
-			"role": "planner",
-		},
-	}); err != nil {
-		log.Warn().Err(err).Msg("Failed to add memory entry for plan creation")
-	}
+	a.addMemory(ctx, fmt.Sprintf("plan-execute-planner-plan-%d", a.currentStep), fmt.Sprintf("Plan Created: %v", plan), "planner")
 	for _, p := range plan {
-		if err := a.memory.Add(ctx, types.MemoryEntry{
-			ID:      fmt.Sprintf("plan-execute-planner-plan-%d", a.currentStep),
-			Content: fmt.Sprintf(" - Step %s: %s(%s) - Thought: %s", p.ID, p.Action, p.ActionInput, p.Thought),
-			Metadata: map[string]string{
-				"role": "planner",
-			},
-		}); err != nil {
-			log.Warn().Err(err).Msg("Failed to add memory entry for plan step")
-		}
+		a.addMemory(ctx, fmt.Sprintf("plan-execute-planner-plan-%d-step-%s", a.currentStep, p.ID), fmt.Sprintf(" - Step %s: %s(%s) - Thought: %s", p.ID, p.Action, p.ActionInput, p.Thought), "planner")
 	}
 
-	// 2. Execution Phase
-	log.Info().Ctx(ctx).Msg("Starting execution phase")
-	for idx, step := range plan {
-		a.currentStep = idx + 1 // Reset currentStep for execution phase
-		log.Info().Ctx(ctx).Int("step", a.currentStep).Str("action", step.Action).Str("id", step.ID).Msg("Executing plan step")
-
-		// --- Emit StepStarted Event (Execution Step) ---
-		if a.eventBus != nil {
-			stepPayload := &events.StepStartedPayload{
-				Step:     int32(a.currentStep),
-				NodeId:   fmt.Sprintf("plan-execute-step-%s", step.ID),
-				NodeGoal: fmt.Sprintf("%s: %s", step.Action, step.ActionInput),
-				RootId:   *a.runID,
-			}
-			errEmit := a.eventBus.EmitStepStarted(ctx, stepPayload, a.runID)
-			if errEmit != nil {
-				log.Warn().Err(errEmit).Msg("Failed to emit StepStarted event for execution")
-			}
-		}
-		startTime := time.Now()
-
-		if step.IsFinal || step.Action == "FinalAnswer" {
-			log.Info().Ctx(ctx).Int("step", a.currentStep).Str("finalAnswer", step.ActionInput).Msg("Reached final answer")
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:      fmt.Sprintf("plan-execute-step-%d", a.currentStep),
-				Content: fmt.Sprintf("Execution Step %d: Final Answer: %s", a.currentStep, step.ActionInput),
-				Metadata: map[string]string{
-					"role": "executor",
-				},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for final answer")
-			}
-			step.Result = step.ActionInput // Store final answer as result
-			// --- Emit StepFinished Event (Final Execution Step) ---
-			if a.eventBus != nil {
-				stepFinishedPayload := &events.StepFinishedPayload{
-					Step:            int32(a.currentStep),
-					NodeId:          fmt.Sprintf("plan-execute-step-%s", step.ID),
-					ActionName:      "FinalAnswer",
-					StatusAfter:     "FINISH",
-					DurationSeconds: time.Since(startTime).Seconds(),
-				}
-				errEmit := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, a.runID)
-				if errEmit != nil {
-					log.Warn().Err(errEmit).Msg("Failed to emit StepFinished event for final execution step")
-				}
-			}
-			return step.Result, nil
-		}
+	return plan, nil
+}
 
-		// Execute tool
-		toolResult, toolErr := a.executeTool(ctx, step.Action, step.ActionInput, step.ID) // Pass step ID
-		stepStatus := "TOOL_EXECUTED"
-		if toolErr != nil {
-			log.Error().Ctx(ctx).Int("step", a.currentStep).Err(toolErr).Str("action", step.Action).Msg("Tool execution failed")
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:      fmt.Sprintf("plan-execute-step-%d", a.currentStep),
-				Content: fmt.Sprintf("Execution Step %d: Tool Error: %v", a.currentStep, toolErr),
-				Metadata: map[string]string{
-					"role": "executor",
-				},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for tool error")
-			}
-			step.Result = fmt.Sprintf("Error: %v", toolErr)
-			stepStatus = "TOOL_ERROR"
-			// Decide whether to stop execution on tool error
-			// For now, we stop and return the error
-			// --- Emit StepFinished Event (Error Execution Step) ---
-			if a.eventBus != nil {
-				stepFinishedPayload := &events.StepFinishedPayload{
-					Step:            int32(a.currentStep),
-					NodeId:          fmt.Sprintf("plan-execute-step-%s", step.ID),
-					ActionName:      step.Action,
-					StatusAfter:     stepStatus,
-					DurationSeconds: time.Since(startTime).Seconds(),
-				}
-				errEmit := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, a.runID)
-				if errEmit != nil {
-					log.Warn().Err(errEmit).Msg("Failed to emit StepFinished event for error execution step")
-				}
-			}
-			return "", errors.Wrapf(toolErr, "tool execution failed at step %d (%s)", a.currentStep, step.ID)
-		} else {
-			log.Info().Ctx(ctx).Int("step", a.currentStep).Str("result", toolResult).Msg("Tool executed successfully")
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:      fmt.Sprintf("plan-execute-step-%d", a.currentStep),
-				Content: fmt.Sprintf("Execution Step %d: Observation: %s", a.currentStep, toolResult),
-				Metadata: map[string]string{
-					"role": "executor",
-				},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for tool observation")
-			}
-			step.Result = toolResult
-		}
+// planAndExecuteCheckpoint is the state of a plan-and-execute run saved after the plan
+// is created and after each completed step. Step results are stored in the plan.
+type planAndExecuteCheckpoint struct {
+	Goal       string              `json:"goal"`
+	Plan       []*Plan             `json:"plan"`
+	RootNodeID string              `json:"root_node_id,omitempty"`
+	Memory     []types.MemoryEntry `json:"memory,omitempty"`
+}
 
-		// --- Emit StepFinished Event (Successful Execution Step) ---
-		if a.eventBus != nil {
-			stepFinishedPayload := &events.StepFinishedPayload{
-				Step:            int32(a.currentStep),
-				NodeId:          fmt.Sprintf("plan-execute-step-%s", step.ID),
-				ActionName:      step.Action,
-				StatusAfter:     stepStatus,
-				DurationSeconds: time.Since(startTime).Seconds(),
-			}
-			errEmit := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, a.runID)
-			if errEmit != nil {
-				log.Warn().Err(errEmit).Msg("Failed to emit StepFinished event for execution step")
-			}
-		}
+// savePlanCheckpoint saves the plan and the results of its done steps
+func (a *PlanAndExecuteAgent) savePlanCheckpoint(ctx context.Context, goal string, plan []*Plan) {
+	if a.checkpoints == nil {
+		return
 	}
-
-	// If loop finishes without a FinalAnswer step
-	log.Warn().Ctx(ctx).Msg("Agent finished execution loop without a FinalAnswer step")
-	// Return the result of the last step?
-	if len(plan) > 0 {
-		return plan[len(plan)-1].Result, errors.New("agent finished without explicit FinalAnswer")
+	completedSteps := 0
+	for _, step := range plan {
+		if step.Done {
+			completedSteps++
+		}
 	}
-	return "", errors.New("agent finished without completing any steps or providing a FinalAnswer")
+	a.saveCheckpoint(ctx, PlanAndExecuteAgentType, completedSteps, &planAndExecuteCheckpoint{
+		Goal:       goal,
+		Plan:       plan,
+		RootNodeID: a.rootNodeID,
+		Memory:     a.memorySnapshot(ctx),
+	})
 }
 
 // createPlan uses the LLM to generate a plan based on the goal.
@@ -439,23 +840,22 @@ func (a *PlanAndExecuteAgent) createPlan(ctx context.Context, goal string) ([]*P
 		conversation.NewChatMessage(conversation.RoleUser, goal),
 	}
 
-	if err := a.memory.Add(ctx, types.MemoryEntry{
-		ID:      fmt.Sprintf("plan-execute-planner-prompt-%d", a.currentStep),
-		Content: fmt.Sprintf("Planning Prompt: %s", prompt),
-		Metadata: map[string]string{
-			"role": "planner",
-		},
-	}); err != nil {
-		log.Warn().Err(err).Msg("Failed to add memory entry for planning prompt")
-	}
+	a.addMemory(ctx, fmt.Sprintf("plan-execute-planner-prompt-%d", a.currentStep), fmt.Sprintf("Planning Prompt: %s", prompt), "planner")
 
-	response, err := a.llm.Generate(ctx, messages)
+	response, err := a.LLM.Generate(ctx, messages)
 	if err != nil {
 		return nil, errors.Wrap(err, "LLM call failed during planning")
 	}
 
-	planContent := response.Content.String()
+	plan, err := parsePlan(response.Content.String())
+	if err != nil {
+		return nil, err
+	}
+	return a.validatePlan(plan, 0)
+}
 
+// parsePlan extracts the JSON plan of an LLM response
+func parsePlan(planContent string) ([]*Plan, error) {
 	// Parse the plan from the response (assuming JSON list)
 	var plan []*Plan
 	// Try to extract JSON block if LLM adds surrounding text
@@ -466,107 +866,165 @@ func (a *PlanAndExecuteAgent) createPlan(ctx context.Context, goal string) ([]*P
 	}
 	jsonPlan := planContent[startIndex : endIndex+1]
 
-	err = json.Unmarshal([]byte(jsonPlan), &plan)
+	err := json.Unmarshal([]byte(jsonPlan), &plan)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse JSON plan: %s", jsonPlan)
 	}
+	return plan, nil
+}
 
+// validatePlan checks the steps of plan from index from on, the earlier steps being
+// done. The first FinalAnswer step, or the last step if there is none, is marked as
+// final and the steps listed after it are dropped. The steps are assigned graph nodes.
+func (a *PlanAndExecuteAgent) validatePlan(plan []*Plan, from int) ([]*Plan, error) {
 	// Validate the plan (basic checks)
-	if len(plan) == 0 {
+	if len(plan) == from {
 		return nil, errors.New("LLM generated an empty plan")
 	}
-	for _, step := range plan {
+	ids := map[string]bool{}
+	for _, step := range plan[:from] {
+		ids[step.ID] = true
+	}
+	for _, step := range plan[from:] {
 		if step.ID == "" || step.Action == "" {
 			return nil, errors.Errorf("invalid plan step: missing ID or Action in %+v", step)
 		}
-		// Check if action is valid? Requires tool knowledge here.
-		if step.Action != "FinalAnswer" {
-			_, err := a.tools.ExecuteTool(ctx, step.Action, step.ActionInput)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to execute tool %s with input %s", step.Action, step.ActionInput)
-			}
+		if ids[step.ID] {
+			return nil, errors.Errorf("plan step ID %s is used by several steps", step.ID)
+		}
+		ids[step.ID] = true
+		if step.Action != "FinalAnswer" && a.tools.GetTool(step.Action) == nil {
+			return nil, errors.Errorf("plan step %s uses unknown tool %s (available tools: %v)", step.ID, step.Action, a.tools.GetToolNames())
 		}
 	}
 
 	// Mark the last step as final if not already identified
-	foundFinal := false
-	for _, step := range plan {
-		if step.Action == "FinalAnswer" {
-			step.IsFinal = true
-			foundFinal = true
+	final := len(plan) - 1
+	for i := from; i < len(plan); i++ {
+		if plan[i].Action == "FinalAnswer" {
+			final = i
 			break
 		}
 	}
-	if !foundFinal && len(plan) > 0 {
+	if plan[final].Action != "FinalAnswer" {
 		log.Warn().Msg("Plan does not explicitly contain 'FinalAnswer' action. Marking last step as final.")
-		plan[len(plan)-1].IsFinal = true
 	}
+	if final < len(plan)-1 {
+		log.Warn().Int("steps", len(plan)-1-final).Msg("Dropping the plan steps listed after the FinalAnswer step")
+		plan = plan[:final+1]
+	}
+	for _, step := range plan[from:] {
+		step.IsFinal = false
+	}
+	plan[final].IsFinal = true
 
-	return plan, nil
-}
-
-// executeTool finds and runs the specified tool.
-func (a *PlanAndExecuteAgent) executeTool(ctx context.Context, toolName, toolInput, stepID string) (string, error) {
-	toolCallID := uuid.New().String()
-	nodeID := fmt.Sprintf("plan-execute-step-%s", stepID)
-	// --- Emit ToolInvoked Event ---
-	if a.eventBus != nil {
-		invokePayload := &events.ToolInvokedPayload{
-			ToolName:    toolName,
-			ApiName:     "Run",
-			ArgsSummary: toolInput,
-			NodeId:      ptr(nodeID), // Associate with execution step node ID
-			Step:        ptr(int32(a.currentStep)),
-			AgentClass:  ptr("PlanAndExecuteAgent"),
-			ToolCallId:  toolCallID,
-		}
-		err := a.eventBus.EmitToolInvoked(ctx, invokePayload, a.runID)
-		if err != nil {
-			log.Warn().Err(err).Msg("Failed to emit ToolInvoked event")
+	// The final step waits for all other steps, which can't depend on it
+	for i := from; i < len(plan); i++ {
+		for _, dep := range stepDependencies(plan, i) {
+			if !ids[dep] {
+				return nil, errors.Errorf("plan step %s depends on unknown step %s", plan[i].ID, dep)
+			}
+			if dep == plan[final].ID {
+				return nil, errors.Errorf("plan step %s depends on the final step %s", plan[i].ID, dep)
+			}
 		}
 	}
-	startTime := time.Now()
+	if err := checkPlanCycles(plan); err != nil {
+		return nil, err
+	}
 
-	// Execute the tool
-	result, err := a.tools.ExecuteTool(ctx, toolName, toolInput)
-	if err != nil {
-		return "", errors.Wrapf(err, "failed to execute tool %s with input %s", toolName, toolInput)
+	for _, step := range plan[from:] {
+		if step.NodeID == "" {
+			step.NodeID = uuid.New().String()
+		}
 	}
+	return plan, nil
+}
 
-	duration := time.Since(startTime)
+// executeTool finds and runs the specified tool.
+func (a *PlanAndExecuteAgent) executeTool(ctx context.Context, toolName, toolInput string) (string, error) {
+	responses := a.executeTools(ctx, []tools.ToolRequest{{
+		ID:       uuid.New().String(),
+		ToolName: toolName,
+		Input:    toolInput,
+	}})
+	if responses[0].Error != nil {
+		return "", errors.Wrapf(responses[0].Error, "failed to execute tool %s with input %s", toolName, toolInput)
+	}
+	return responses[0].Result, nil
+}
 
-	// --- Emit ToolReturned Event ---
-	if a.eventBus != nil {
-		state := "SUCCESS"
-		var errorStr *string
-		resultSummary := result
-		if err != nil {
-			state = "ERROR"
-			es := err.Error()
-			errorStr = &es
-			resultSummary = "Error: " + es
-		}
+// executeTools runs the requested tools concurrently through the tool executor,
+// emitting a ToolInvoked/ToolReturned event pair for every call.
+// Responses are returned in request order.
+func (a *PlanAndExecuteAgent) executeTools(ctx context.Context, requests []tools.ToolRequest) []tools.ToolResponse {
+	hooks := &tools.ToolCallHooks{
+		OnApprovalRequested: func(ctx context.Context, req tools.ToolRequest) {
+			// --- Emit ToolApprovalRequested Event ---
+			if a.eventBus == nil {
+				return
+			}
+			approvalPayload := &events.ToolApprovalRequestedPayload{
+				ToolName:    req.ToolName,
+				ToolCallId:  req.ID,
+				ArgsSummary: req.Input,
+			}
+			err := a.eventBus.EmitToolApprovalRequested(ctx, approvalPayload, nil)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to emit ToolApprovalRequested event")
+			}
+		},
+		OnInvoked: func(ctx context.Context, req tools.ToolRequest) {
+			// --- Emit ToolInvoked Event ---
+			if a.eventBus == nil {
+				return
+			}
+			invokePayload := &events.ToolInvokedPayload{
+				ToolName:    req.ToolName,
+				ApiName:     "Run",
+				ArgsSummary: req.Input,
+				ToolCallId:  req.ID,
+			}
+			err := a.eventBus.EmitToolInvoked(ctx, invokePayload, nil)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to emit ToolInvoked event")
+			}
+		},
+		OnReturned: func(ctx context.Context, req tools.ToolRequest, response tools.ToolResponse) {
+			// --- Emit ToolReturned Event ---
+			if a.eventBus == nil {
+				return
+			}
+			state := "SUCCESS"
+			var errorStr *string
+			resultSummary := response.Result
+			if response.Error != nil {
+				state = "ERROR"
+				es := response.Error.Error()
+				errorStr = &es
+				resultSummary = "Error: " + es
+			}
 
-		returnPayload := &events.ToolReturnedPayload{
-			ToolName:        toolName,
-			ApiName:         "Run",
-			State:           state,
-			DurationSeconds: duration.Seconds(),
-			ResultSummary:   resultSummary,
-			Error:           errorStr,
-			NodeId:          ptr(nodeID),
-			Step:            ptr(int32(a.currentStep)),
-			AgentClass:      ptr("PlanAndExecuteAgent"),
-			ToolCallId:      toolCallID,
-		}
-		errEmit := a.eventBus.EmitToolReturned(ctx, returnPayload, a.runID)
-		if errEmit != nil {
-			log.Warn().Err(errEmit).Msg("Failed to emit ToolReturned event")
-		}
+			returnPayload := &events.ToolReturnedPayload{
+				ToolName:        req.ToolName,
+				ApiName:         "Run",
+				State:           state,
+				DurationSeconds: response.Duration.Seconds(),
+				ResultSummary:   resultSummary,
+				Error:           errorStr,
+				ToolCallId:      req.ID,
+			}
+			errEmit := a.eventBus.EmitToolReturned(ctx, returnPayload, nil)
+			if errEmit != nil {
+				log.Warn().Err(errEmit).Msg("Failed to emit ToolReturned event")
+			}
+		},
 	}
 
-	return result, err
+	return a.tools.ExecuteParallel(ctx, requests, hooks)
 }
 
 // Ensure PlanAndExecuteAgent implements the Agent interface
 var _ Agent = (*PlanAndExecuteAgent)(nil)
+var _ ToolApprovalRequester = (*PlanAndExecuteAgent)(nil)
+var _ Checkpointer = (*PlanAndExecuteAgent)(nil)
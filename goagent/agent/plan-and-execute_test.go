@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/go-go-agent/goagent/checkpoint"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/go-go-golems/go-go-agent/goagent/memory"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+const twoStepPlan = `[
+	{"id": "1", "action": "echo", "actionInput": "a", "thought": "first"},
+	{"id": "2", "action": "echo", "actionInput": "b", "thought": "second", "depends_on": []},
+	{"id": "3", "action": "FinalAnswer", "actionInput": "{{1}} and {{2}}", "thought": "done"}
+]`
+
+func newTestPlanAndExecuteAgent(t *testing.T, model llm.LLM, tool *echoTool, options ...PlanAndExecuteAgentOption) *PlanAndExecuteAgent {
+	t.Helper()
+	executor := tools.NewToolExecutor()
+	executor.AddTool(tool)
+	a, err := NewPlanAndExecuteAgent(append([]PlanAndExecuteAgentOption{
+		WithExecutorLLM(model),
+		WithExecutorTools(executor),
+		WithExecutorReplanMode(ReplanNever),
+	}, options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestPlanAndExecuteMemoryIDs(t *testing.T) {
+	mem := memory.NewHistoryMemory()
+	model := &scriptedLLM{}
+	tool := &echoTool{}
+	a := newTestPlanAndExecuteAgent(t, model, tool, WithExecutorMemory(mem))
+
+	// Two runs sharing the memory, the second one outside of a command run
+	for _, ctx := range []context.Context{
+		execctx.WithRunID(context.Background(), "run-1"),
+		context.Background(),
+	} {
+		model.responses = append(model.responses, textMessage(twoStepPlan), textMessage("a and b"))
+		answer, err := a.Run(ctx, "echo a and b")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if answer != "a and b" {
+			t.Errorf("answer = %q, want %q", answer, "a and b")
+		}
+	}
+
+	entries, err := mem.GetHistory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]bool{}
+	planSteps := 0
+	for _, entry := range entries {
+		if ids[entry.ID] {
+			t.Errorf("memory entry ID %s is used by several entries", entry.ID)
+		}
+		ids[entry.ID] = true
+		if strings.HasPrefix(entry.Content, " - Step ") {
+			planSteps++
+		}
+	}
+	if planSteps != 6 {
+		t.Errorf("memory holds %d plan steps, want the 3 steps of each run", planSteps)
+	}
+	if !ids["run-1/plan-execute-step-2"] {
+		t.Errorf("the entries of run-1 are not prefixed with the run ID: %v", ids)
+	}
+}
+
+// lookupTool returns its input, or err if it is set
+type lookupTool struct {
+	err error
+}
+
+func (t *lookupTool) Name() string        { return "lookup" }
+func (t *lookupTool) Description() string { return "Looks up its input" }
+func (t *lookupTool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	return orderedmap.New[string, types.ParameterSchema]()
+}
+func (t *lookupTool) Execute(ctx context.Context, input string) (string, error) {
+	if t.err != nil {
+		return "", t.err
+	}
+	return "found: " + input, nil
+}
+
+func TestPlanAndExecuteResumeFromCheckpoint(t *testing.T) {
+	store, err := checkpoint.NewSQLiteStore(filepath.Join(t.TempDir(), "checkpoints.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	newAgent := func(model llm.LLM, echo *echoTool, lookup *lookupTool, resume bool) *PlanAndExecuteAgent {
+		t.Helper()
+		a := newTestPlanAndExecuteAgent(t, model, echo)
+		a.tools.AddTool(lookup)
+		a.SetCheckpointStore(store, "run-1", resume)
+		return a
+	}
+	plan := `[
+		{"id": "1", "action": "echo", "actionInput": "a"},
+		{"id": "2", "action": "echo", "actionInput": "b"},
+		{"id": "3", "action": "lookup", "actionInput": "{{steps.2.result}}"},
+		{"id": "4", "action": "FinalAnswer", "actionInput": "{{steps.3.result}}"}
+	]`
+
+	// The first run completes two steps, then fails on the lookup
+	echo := &echoTool{}
+	a := newAgent(&scriptedLLM{responses: [][]*conversation.Message{textMessage(plan)}}, echo, &lookupTool{err: errors.New("lookup service unavailable")}, false)
+	if _, err := a.Run(context.Background(), "look up b"); err == nil {
+		t.Fatal("first run succeeded, want the lookup error")
+	}
+	if !reflect.DeepEqual(echo.inputs, []string{"a", "b"}) {
+		t.Fatalf("first run echoed %v, want a and b", echo.inputs)
+	}
+	cp, err := store.Load(context.Background(), "run-1")
+	if err != nil || cp == nil || cp.Step != 2 {
+		t.Fatalf("checkpoint of the first run = %+v, %v, want 2 completed steps", cp, err)
+	}
+
+	// The resumed run is not planned again and only runs the lookup
+	resumedModel := &scriptedLLM{responses: [][]*conversation.Message{textMessage("found: echo: b")}}
+	resumedEcho := &echoTool{}
+	resumed := newAgent(resumedModel, resumedEcho, &lookupTool{}, true)
+	answer, err := resumed.Run(context.Background(), "look up b")
+	if err != nil {
+		t.Fatalf("resumed run failed: %v", err)
+	}
+	if answer != "found: echo: b" {
+		t.Errorf("answer = %q, want %q", answer, "found: echo: b")
+	}
+	if len(resumedEcho.inputs) != 0 {
+		t.Errorf("resumed run repeated the done steps with inputs %v", resumedEcho.inputs)
+	}
+	if len(resumedModel.calls) != 1 {
+		t.Fatalf("resumed run made %d LLM calls, want only the final answer", len(resumedModel.calls))
+	}
+	// The final answer is written from the checkpointed and the new results
+	request := resumedModel.calls[0][len(resumedModel.calls[0])-1].Content.String()
+	for _, result := range []string{"echo: a", "found: echo: b"} {
+		if !strings.Contains(request, result) {
+			t.Errorf("final answer request does not contain %q: %s", result, request)
+		}
+	}
+
+	// A completed run deletes its checkpoint
+	if cp, err := store.Load(context.Background(), "run-1"); err != nil || cp != nil {
+		t.Errorf("checkpoint after completion = %+v, %v, want none", cp, err)
+	}
+}
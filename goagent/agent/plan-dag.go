@@ -0,0 +1,255 @@
+package agent
+
+import (
+	"context"
+	"regexp"
+	"slices"
+
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// stepReferencePattern matches the references to step results in action inputs,
+// such as {{steps.2.result}}
+var stepReferencePattern = regexp.MustCompile(`\{\{\s*steps\.([^.\s{}]+)\.result\s*\}\}`)
+
+// stepReferences returns the IDs of the steps whose results are referenced in input
+func stepReferences(input string) []string {
+	var ids []string
+	for _, match := range stepReferencePattern.FindAllStringSubmatch(input, -1) {
+		if !slices.Contains(ids, match[1]) {
+			ids = append(ids, match[1])
+		}
+	}
+	return ids
+}
+
+// resolveStepReferences replaces the references to step results in input with the
+// results of the steps of plan
+func resolveStepReferences(input string, plan []*Plan) string {
+	return stepReferencePattern.ReplaceAllStringFunc(input, func(reference string) string {
+		id := stepReferencePattern.FindStringSubmatch(reference)[1]
+		for _, step := range plan {
+			if step.ID == id {
+				return step.Result
+			}
+		}
+		return reference
+	})
+}
+
+// stepDependencies returns the IDs of the steps that step i of plan waits for: its
+// depends_on steps, or the previous step if depends_on is not set, and the steps
+// whose results it references.
+func stepDependencies(plan []*Plan, i int) []string {
+	step := plan[i]
+	var deps []string
+	if step.DependsOn != nil {
+		deps = append(deps, step.DependsOn...)
+	} else if i > 0 {
+		deps = append(deps, plan[i-1].ID)
+	}
+	for _, id := range stepReferences(step.ActionInput) {
+		if !slices.Contains(deps, id) {
+			deps = append(deps, id)
+		}
+	}
+	return deps
+}
+
+// readySteps returns the steps that are not done or running and whose dependencies are
+// done. Final steps are only ready once all other steps are done.
+func readySteps(plan []*Plan, running map[string]bool) []*Plan {
+	done := map[string]bool{}
+	allDone := true
+	for _, step := range plan {
+		done[step.ID] = step.Done
+		if !step.Done && !step.IsFinal && step.Action != "FinalAnswer" {
+			allDone = false
+		}
+	}
+
+	var ready []*Plan
+	for i, step := range plan {
+		if step.Done || running[step.ID] {
+			continue
+		}
+		if (step.IsFinal || step.Action == "FinalAnswer") && !allDone {
+			continue
+		}
+		depsDone := true
+		for _, dep := range stepDependencies(plan, i) {
+			depsDone = depsDone && done[dep]
+		}
+		if depsDone {
+			ready = append(ready, step)
+		}
+	}
+	return ready
+}
+
+// checkPlanCycles returns an error if the dependencies of the steps of plan form a cycle
+func checkPlanCycles(plan []*Plan) error {
+	index := map[string]int{}
+	for i, step := range plan {
+		index[step.ID] = i
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make([]int, len(plan))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return errors.Errorf("plan step %s is part of a dependency cycle", plan[i].ID)
+		}
+		state[i] = visiting
+		for _, dep := range stepDependencies(plan, i) {
+			if j, ok := index[dep]; ok {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range plan {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitRootNode publishes the graph node of the goal, the parent of the step nodes
+func (a *PlanAndExecuteAgent) emitRootNode(ctx context.Context, goal string) {
+	if a.eventBus == nil {
+		return
+	}
+	payload := &events.NodeCreatedPayload{
+		NodeId:     a.rootNodeID,
+		NodeNid:    "",
+		NodeType:   PlanNodeType,
+		TaskGoal:   goal,
+		Layer:      0,
+		RootNodeId: a.rootNodeID,
+		Step:       ptr(int32(a.currentStep)),
+	}
+	if err := a.eventBus.EmitNodeCreated(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit NodeCreated event")
+	}
+}
+
+// emitPlanGraph publishes the nodes of the steps of plan that are not done and the
+// edges of their dependencies. Nodes of revised steps are updated.
+func (a *PlanAndExecuteAgent) emitPlanGraph(ctx context.Context, plan []*Plan) {
+	if a.eventBus == nil {
+		return
+	}
+	step := ptr(int32(a.currentStep))
+	byID := map[string]*Plan{}
+	for _, s := range plan {
+		byID[s.ID] = s
+	}
+
+	var nodeIDs []string
+	edgeCount := 0
+	for i, s := range plan {
+		if s.Done {
+			continue
+		}
+		deps := stepDependencies(plan, i)
+		nodePayload := &events.NodeCreatedPayload{
+			NodeId:            s.NodeID,
+			NodeNid:           s.ID,
+			NodeType:          ExecuteNodeType,
+			TaskType:          s.Action,
+			TaskGoal:          s.ActionInput,
+			Layer:             1,
+			OuterNodeId:       ptr(a.rootNodeID),
+			RootNodeId:        a.rootNodeID,
+			InitialParentNids: deps,
+			Step:              step,
+		}
+		if err := a.eventBus.EmitNodeCreated(ctx, nodePayload, nil); err != nil {
+			log.Warn().Err(err).Msg("Failed to emit NodeCreated event")
+		}
+		addedPayload := &events.NodeAddedPayload{
+			GraphOwnerNodeId: a.rootNodeID,
+			AddedNodeId:      s.NodeID,
+			AddedNodeNid:     s.ID,
+			Step:             step,
+			TaskType:         ptr(s.Action),
+			TaskGoal:         ptr(s.ActionInput),
+		}
+		if err := a.eventBus.EmitNodeAdded(ctx, addedPayload, nil); err != nil {
+			log.Warn().Err(err).Msg("Failed to emit NodeAdded event")
+		}
+		nodeIDs = append(nodeIDs, s.NodeID)
+
+		for _, dep := range deps {
+			parent, ok := byID[dep]
+			if !ok {
+				continue
+			}
+			edgePayload := &events.EdgeAddedPayload{
+				GraphOwnerNodeId: a.rootNodeID,
+				ParentNodeId:     parent.NodeID,
+				ChildNodeId:      s.NodeID,
+				ParentNodeNid:    parent.ID,
+				ChildNodeNid:     s.ID,
+				Step:             step,
+			}
+			if err := a.eventBus.EmitEdgeAdded(ctx, edgePayload, nil); err != nil {
+				log.Warn().Err(err).Msg("Failed to emit EdgeAdded event")
+			}
+			edgeCount++
+		}
+	}
+
+	payload := &events.InnerGraphBuiltPayload{
+		NodeId:    a.rootNodeID,
+		NodeCount: int32(len(nodeIDs)),
+		EdgeCount: int32(edgeCount),
+		NodeIds:   nodeIDs,
+		Step:      step,
+	}
+	if err := a.eventBus.EmitInnerGraphBuilt(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit InnerGraphBuilt event")
+	}
+}
+
+func (a *PlanAndExecuteAgent) setNodeStatus(ctx context.Context, nodeID, goal, oldStatus, newStatus string) {
+	if a.eventBus == nil {
+		return
+	}
+	payload := &events.NodeStatusChangePayload{
+		NodeId:    nodeID,
+		NodeGoal:  goal,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+	}
+	if err := a.eventBus.EmitNodeStatusChanged(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit NodeStatusChanged event")
+	}
+}
+
+func (a *PlanAndExecuteAgent) emitNodeResult(ctx context.Context, nodeID, result string) {
+	if a.eventBus == nil {
+		return
+	}
+	payload := &events.NodeResultAvailablePayload{
+		NodeId:        nodeID,
+		ActionName:    "execute",
+		ResultSummary: result,
+	}
+	if err := a.eventBus.EmitNodeResultAvailable(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit NodeResultAvailable event")
+	}
+}
@@ -0,0 +1,292 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+func TestStepDependencies(t *testing.T) {
+	plan := []*Plan{
+		{ID: "1", Action: "echo", ActionInput: "a"},
+		// Without depends_on, a step depends on the previous step
+		{ID: "2", Action: "echo", ActionInput: "b"},
+		// An empty depends_on runs the step independently, except for its references
+		{ID: "3", Action: "echo", ActionInput: "{{steps.1.result}} and {{ steps.2.result }}", DependsOn: []string{}},
+		{ID: "4", Action: "echo", ActionInput: "{{steps.1.result}} and {{steps.3.result}}", DependsOn: []string{"1"}},
+		{ID: "5", Action: "echo", ActionInput: "c", DependsOn: []string{}},
+	}
+	expected := [][]string{nil, {"1"}, {"1", "2"}, {"1", "3"}, nil}
+	for i := range plan {
+		if deps := stepDependencies(plan, i); !reflect.DeepEqual(deps, expected[i]) {
+			t.Errorf("stepDependencies(%s) = %v, want %v", plan[i].ID, deps, expected[i])
+		}
+	}
+}
+
+func TestResolveStepReferences(t *testing.T) {
+	plan := []*Plan{
+		{ID: "1", Result: "sunny"},
+		{ID: "2", Result: "rainy"},
+	}
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"Paris is {{steps.1.result}}, London is {{ steps.2.result }}", "Paris is sunny, London is rainy"},
+		{"{{steps.1.result}} then {{steps.1.result}}", "sunny then sunny"},
+		// Unknown references are kept as they are
+		{"Berlin is {{steps.7.result}}", "Berlin is {{steps.7.result}}"},
+		{"no references", "no references"},
+	}
+	for _, tc := range testCases {
+		if got := resolveStepReferences(tc.input, plan); got != tc.expected {
+			t.Errorf("resolveStepReferences(%q) = %q, want %q", tc.input, got, tc.expected)
+		}
+	}
+}
+
+func TestReadySteps(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     []*Plan
+		running  map[string]bool
+		expected []string
+	}{
+		{
+			name: "sequential",
+			plan: []*Plan{
+				{ID: "1", Action: "echo"},
+				{ID: "2", Action: "echo"},
+				{ID: "3", Action: "FinalAnswer"},
+			},
+			expected: []string{"1"},
+		},
+		{
+			name: "independent",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", DependsOn: []string{}},
+				{ID: "2", Action: "echo", DependsOn: []string{}},
+				{ID: "3", Action: "echo", ActionInput: "{{steps.1.result}}", DependsOn: []string{}},
+				{ID: "4", Action: "FinalAnswer", DependsOn: []string{}},
+			},
+			expected: []string{"1", "2"},
+		},
+		{
+			name: "running steps are not ready",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", DependsOn: []string{}, Done: true},
+				{ID: "2", Action: "echo", DependsOn: []string{}},
+				{ID: "3", Action: "echo", ActionInput: "{{steps.1.result}}", DependsOn: []string{}},
+				{ID: "4", Action: "FinalAnswer", DependsOn: []string{}},
+			},
+			running:  map[string]bool{"2": true},
+			expected: []string{"3"},
+		},
+		{
+			// The final step waits for all other steps, even if it doesn't depend on them
+			name: "final step",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", DependsOn: []string{}, Done: true},
+				{ID: "2", Action: "echo", DependsOn: []string{}},
+				{ID: "3", Action: "echo", DependsOn: []string{}, IsFinal: true},
+			},
+			running: map[string]bool{"2": true},
+		},
+		{
+			name: "all done",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", DependsOn: []string{}, Done: true},
+				{ID: "2", Action: "echo", DependsOn: []string{}, Done: true},
+				{ID: "3", Action: "FinalAnswer", DependsOn: []string{"1"}},
+			},
+			expected: []string{"3"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ready []string
+			for _, step := range readySteps(tc.plan, tc.running) {
+				ready = append(ready, step.ID)
+			}
+			if !reflect.DeepEqual(ready, tc.expected) {
+				t.Errorf("readySteps() = %v, want %v", ready, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCheckPlanCycles(t *testing.T) {
+	testCases := []struct {
+		name  string
+		plan  []*Plan
+		cycle bool
+	}{
+		{
+			name: "sequential",
+			plan: []*Plan{
+				{ID: "1", Action: "echo"},
+				{ID: "2", Action: "echo"},
+				{ID: "3", Action: "FinalAnswer"},
+			},
+		},
+		{
+			name: "diamond",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", DependsOn: []string{}},
+				{ID: "2", Action: "echo", DependsOn: []string{"1"}},
+				{ID: "3", Action: "echo", ActionInput: "{{steps.1.result}}", DependsOn: []string{}},
+				{ID: "4", Action: "FinalAnswer", ActionInput: "{{steps.2.result}} {{steps.3.result}}", DependsOn: []string{}},
+			},
+		},
+		{
+			name: "depends_on cycle",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", DependsOn: []string{"2"}},
+				{ID: "2", Action: "echo", DependsOn: []string{"1"}},
+			},
+			cycle: true,
+		},
+		{
+			// Step 2 depends on step 1 implicitly, step 1 references the result of step 2
+			name: "implicit dependency and reference",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", ActionInput: "{{steps.2.result}}", DependsOn: []string{}},
+				{ID: "2", Action: "echo"},
+			},
+			cycle: true,
+		},
+		{
+			name: "self reference",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", ActionInput: "{{steps.1.result}}"},
+			},
+			cycle: true,
+		},
+		{
+			// Unknown steps are reported by validatePlan, not as cycles
+			name: "unknown reference",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", ActionInput: "{{steps.7.result}}", DependsOn: []string{"8"}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkPlanCycles(tc.plan); (err != nil) != tc.cycle {
+				t.Errorf("checkPlanCycles() = %v, want a cycle: %v", err, tc.cycle)
+			}
+		})
+	}
+}
+
+func TestValidatePlanReferences(t *testing.T) {
+	a := newTestPlanAndExecuteAgent(t, &scriptedLLM{}, &echoTool{})
+	testCases := []struct {
+		name    string
+		plan    string
+		message string
+	}{
+		{
+			name: "unknown reference",
+			plan: `[
+				{"id": "1", "action": "echo", "actionInput": "{{steps.7.result}}"},
+				{"id": "2", "action": "FinalAnswer", "actionInput": "done"}
+			]`,
+			message: "plan step 1 depends on unknown step 7",
+		},
+		{
+			name: "cycle",
+			plan: `[
+				{"id": "1", "action": "echo", "actionInput": "{{steps.2.result}}", "depends_on": []},
+				{"id": "2", "action": "echo", "actionInput": "b"},
+				{"id": "3", "action": "FinalAnswer", "actionInput": "done"}
+			]`,
+			message: "plan step 1 is part of a dependency cycle",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := parsePlan(tc.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := a.validatePlan(plan, 0); err == nil || err.Error() != tc.message {
+				t.Errorf("validatePlan() = %v, want %q", err, tc.message)
+			}
+		})
+	}
+}
+
+// slowTool counts the calls executing at the same time
+type slowTool struct {
+	mu        sync.Mutex
+	running   int
+	maxActive int
+}
+
+func (t *slowTool) Name() string        { return "slow" }
+func (t *slowTool) Description() string { return "Waits before echoing its input" }
+func (t *slowTool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	return orderedmap.New[string, types.ParameterSchema]()
+}
+func (t *slowTool) Execute(ctx context.Context, input string) (string, error) {
+	t.mu.Lock()
+	t.running++
+	t.maxActive = max(t.maxActive, t.running)
+	t.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	t.mu.Lock()
+	t.running--
+	t.mu.Unlock()
+	return input, nil
+}
+
+func TestParallelSteps(t *testing.T) {
+	model := &scriptedLLM{responses: [][]*conversation.Message{
+		textMessage(`[
+			{"id": "1", "action": "slow", "actionInput": "a", "depends_on": []},
+			{"id": "2", "action": "slow", "actionInput": "b", "depends_on": []},
+			{"id": "3", "action": "slow", "actionInput": "c", "depends_on": []},
+			{"id": "4", "action": "slow", "actionInput": "{{steps.1.result}}{{steps.2.result}}{{steps.3.result}}", "depends_on": []},
+			{"id": "5", "action": "FinalAnswer", "actionInput": "{{steps.4.result}}"}
+		]`),
+		textMessage("abc"),
+	}}
+	tool := &slowTool{}
+	executor := tools.NewToolExecutor()
+	executor.AddTool(tool)
+	a, err := NewPlanAndExecuteAgent(
+		WithExecutorLLM(model),
+		WithExecutorTools(executor),
+		WithExecutorReplanMode(ReplanNever),
+		WithExecutorMaxParallelSteps(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := a.Run(context.Background(), "concatenate a, b and c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "abc" {
+		t.Errorf("answer = %q, want %q", answer, "abc")
+	}
+	// Steps 1 to 3 are independent, but only two of them run at the same time
+	if tool.maxActive != 2 {
+		t.Errorf("%d steps ran at the same time, want 2", tool.maxActive)
+	}
+	// The final answer is written from the result of step 4, which waited for the others
+	if request := model.calls[1][1].Content.String(); !strings.Contains(request, "abc") {
+		t.Errorf("final answer request does not contain the result of step 4: %s", request)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/go-go-golems/go-go-agent/goagent/tools"
 	"github.com/go-go-golems/go-go-agent/goagent/types"
 	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
 	events "github.com/go-go-golems/go-go-agent/proto"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -25,28 +26,127 @@ type ReactAgentFactory struct {
 	LLM llm.LLM
 }
 
+// ReactAgentSettings holds configuration for the ReActAgent.
+type ReactAgentSettings struct {
+	MaxIterations      int    `glazed.parameter:"max-iterations"`
+	MaxToolCalls       int    `glazed.parameter:"max-tool-calls"`
+	ToolCalling        bool   `glazed.parameter:"tool-calling"`
+	MaxParallelTools   int    `glazed.parameter:"max-parallel-tools"`
+	ToolTimeout        int    `glazed.parameter:"tool-timeout"`
+	HistoryTokenBudget int    `glazed.parameter:"history-token-budget"`
+	HistoryStrategy    string `glazed.parameter:"history-strategy"`
+}
+
 func (f *ReactAgentFactory) NewAgent(ctx context.Context, cmd Command, parsedLayers *layers.ParsedLayers, baseModel llm.LLM) (Agent, error) {
-	return NewReActAgent(WithLLM(f.LLM))
+	var settings ReactAgentSettings
+	err := parsedLayers.InitializeStruct(ReactAgentType, &settings)
+	if err != nil {
+		return nil, err
+	}
+
+	agentOptions, err := cmd.RenderAgentOptions(parsedLayers.GetDataMap(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxIter, ok := agentOptions["max-iterations"].(int); ok {
+		settings.MaxIterations = maxIter
+	}
+	if maxToolCalls, ok := agentOptions["max-tool-calls"].(int); ok {
+		settings.MaxToolCalls = maxToolCalls
+	}
+	if toolCalling, ok := agentOptions["tool-calling"].(bool); ok {
+		settings.ToolCalling = toolCalling
+	}
+	if maxParallelTools, ok := agentOptions["max-parallel-tools"].(int); ok {
+		settings.MaxParallelTools = maxParallelTools
+	}
+	if toolTimeout, ok := agentOptions["tool-timeout"].(int); ok {
+		settings.ToolTimeout = toolTimeout
+	}
+	if historyTokenBudget, ok := agentOptions["history-token-budget"].(int); ok {
+		settings.HistoryTokenBudget = historyTokenBudget
+	}
+	if historyStrategy, ok := agentOptions["history-strategy"].(string); ok {
+		settings.HistoryStrategy = historyStrategy
+	}
+
+	mem, err := NewMemoryFromCommand(cmd, parsedLayers, ReactAgentType, agentOptions, baseModel)
+	if err != nil {
+		return nil, err
+	}
+
+	toolExecutor, err := NewToolExecutorFromCommand(
+		cmd,
+		agentOptions,
+		toolExecutorOptions(settings.MaxParallelTools, settings.ToolTimeout)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	model := f.LLM
+	if model == nil {
+		model = baseModel
+	}
+
+	return NewReActAgent(
+		WithLLM(model),
+		WithSystemPrompt(cmd.GetSystemPrompt()),
+		WithTools(toolExecutor),
+		WithMemory(mem),
+		WithMaxIterations(settings.MaxIterations),
+		WithMaxToolCalls(settings.MaxToolCalls),
+		WithToolCalling(settings.ToolCalling),
+		WithHistoryManager(NewHistoryManager(
+			settings.HistoryTokenBudget,
+			settings.HistoryStrategy,
+			WithHistorySummarizer(model),
+		)),
+	)
 }
 
 func (f *ReactAgentFactory) CreateLayers() ([]layers.ParameterLayer, error) {
+	definitions := []*parameters.ParameterDefinition{
+		parameters.NewParameterDefinition(
+			"max-iterations",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Maximum number of ReAct iterations"),
+			parameters.WithDefault(10),
+		),
+		parameters.NewParameterDefinition(
+			"max-tool-calls",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Maximum number of tool calls per iteration"),
+			parameters.WithDefault(5),
+		),
+		parameters.NewParameterDefinition(
+			"tool-calling",
+			parameters.ParameterTypeBool,
+			parameters.WithHelp("Use the provider's native tool calling instead of parsing Action: lines (falls back to text parsing if unsupported)"),
+			parameters.WithDefault(true),
+		),
+		parameters.NewParameterDefinition(
+			"history-token-budget",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Approximate token budget for the conversation sent to the LLM (0 = unlimited)"),
+			parameters.WithDefault(16000),
+		),
+		parameters.NewParameterDefinition(
+			"history-strategy",
+			parameters.ParameterTypeChoice,
+			parameters.WithHelp("How to shrink the conversation when it exceeds the token budget"),
+			parameters.WithDefault(HistoryStrategySummarize),
+			parameters.WithChoices(HistoryStrategies...),
+		),
+	}
+	definitions = append(definitions, toolExecutionParameterDefinitions()...)
+	definitions = append(definitions, memoryParameterDefinitions()...)
+
 	agentLayer, err := layers.NewParameterLayer(
 		ReactAgentType,
 		"React agent configuration",
-		layers.WithParameterDefinitions(
-			parameters.NewParameterDefinition(
-				"max-iterations",
-				parameters.ParameterTypeInteger,
-				parameters.WithHelp("Maximum number of ReAct iterations"),
-				parameters.WithDefault(10),
-			),
-			parameters.NewParameterDefinition(
-				"max-tool-calls",
-				parameters.ParameterTypeInteger,
-				parameters.WithHelp("Maximum number of tool calls per iteration"),
-				parameters.WithDefault(5),
-			),
-		),
+		layers.WithParameterDefinitions(definitions...),
 	)
 	if err != nil {
 		return nil, err
@@ -62,16 +162,32 @@ type ReActAgent struct {
 	LLM              llm.LLM
 	SystemPrompt     string
 	MaxIterations    int
-	MaxToolCalls     int // Maximum tool calls per iteration
+	MaxToolCalls     int  // Maximum tool calls per iteration
+	ToolCalling      bool // Use native tool calling if the LLM supports it
 	currentIteration int
+	// nativeToolCalling is true while the current run uses native tool calling
+	nativeToolCalling bool
+	// history keeps the conversation within the token budget
+	history *HistoryManager
 	// Optional event bus
 	eventBus *eventbus.EventBus
-	runID    *string
+	// nodeID identifies the agent in step and tool events
+	nodeID string
+	// stepOffset is added to the iteration numbers to get the step numbers of events
+	stepOffset int
 	// Removed scratchpad *helpers.RingBuffer[string]
 }
 
 const ReactAgentType = "react"
 
+// step returns the step number of the current iteration
+func (a *ReActAgent) step() int {
+	return a.stepOffset + a.currentIteration
+}
+
+// ReActAgentClass is the agent class of the events of ReAct agents
+const ReActAgentClass = "ReActAgent"
+
 // ReActAgentOption defines functional options for configuring ReActAgent.
 type ReActAgentOption func(*ReActAgent)
 
@@ -90,12 +206,12 @@ func WithSystemPrompt(prompt string) ReActAgentOption {
 }
 
 // WithTools sets the available tools for the agent.
-func WithTools(toolExecutor tools.ToolExecutor) ReActAgentOption { // Use interface type
+func WithTools(toolExecutor *tools.ToolExecutor) ReActAgentOption {
 	return func(a *ReActAgent) {
 		if a.BaseAgent == nil {
 			a.BaseAgent = &BaseAgent{}
 		}
-		a.tools = &toolExecutor // Set on BaseAgent
+		a.tools = toolExecutor // Set on BaseAgent
 	}
 }
 
@@ -123,24 +239,58 @@ func WithMaxToolCalls(maxCalls int) ReActAgentOption {
 	}
 }
 
+// WithToolCalling enables native tool calling when the LLM implements llm.ToolCallingLLM.
+func WithToolCalling(enabled bool) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.ToolCalling = enabled
+	}
+}
+
+// WithHistoryManager sets the manager compacting the conversation before each LLM call.
+func WithHistoryManager(history *HistoryManager) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.history = history
+	}
+}
+
 // WithEventBus configures the agent with an event bus.
-func WithEventBus(eb *eventbus.EventBus, runID string) ReActAgentOption {
+func WithEventBus(eb *eventbus.EventBus) ReActAgentOption {
 	return func(a *ReActAgent) {
 		a.eventBus = eb
-		rid := runID
-		a.runID = &rid
 	}
 }
 
+// WithNodeID sets the node ID of the step and tool events of the agent. Defaults to
+// "react-agent".
+func WithNodeID(nodeID string) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.nodeID = nodeID
+	}
+}
+
+// WithStepOffset numbers the steps of the agent from offset+1 instead of 1, so that an
+// agent running inside a step of another agent doesn't reuse its step numbers.
+func WithStepOffset(offset int) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.stepOffset = offset
+	}
+}
+
+// SetEventBus attaches an event bus after the agent has been created.
+func (a *ReActAgent) SetEventBus(eb *eventbus.EventBus) {
+	WithEventBus(eb)(a)
+}
+
 // NewReActAgent creates a new ReActAgent with the given options.
 func NewReActAgent(options ...ReActAgentOption) (*ReActAgent, error) {
 	a := &ReActAgent{
 		MaxIterations: 10,
 		MaxToolCalls:  5,
+		nodeID:        "react-agent",
 		// Initialize BaseAgent (sensible defaults)
 		BaseAgent: &BaseAgent{
-			tools:  tools.NewToolExecutor(), // Default empty executor (assuming this constructor exists)
-			memory: memory.NewMockMemory(),  // Use MockMemory as set by user
+			tools:  tools.NewToolExecutor(), // Default empty executor
+			memory: memory.NewNoopMemory(),  // No history unless a memory is configured
 		},
 		// Removed scratchpad initialization
 	}
@@ -153,10 +303,10 @@ func NewReActAgent(options ...ReActAgentOption) (*ReActAgent, error) {
 	}
 	// Ensure BaseAgent fields are initialized if options didn't provide them
 	if a.tools == nil {
-		a.tools = tools.NewToolExecutor() // Assuming this constructor exists
+		a.tools = tools.NewToolExecutor()
 	}
 	if a.memory == nil {
-		a.memory = memory.NewMockMemory() // Use MockMemory as set by user
+		a.memory = memory.NewNoopMemory()
 	}
 
 	return a, nil
@@ -164,20 +314,19 @@ func NewReActAgent(options ...ReActAgentOption) (*ReActAgent, error) {
 
 // formatHistoryForPrompt retrieves recent memory and formats it for the ReAct prompt.
 func (a *ReActAgent) formatHistoryForPrompt(ctx context.Context) (string, error) {
-	// TODO(manuel): Define a proper method in the Memory interface (e.g., GetHistory)
-	// For now, assume a simple GetMessages or similar exists, or retrieve all.
-	// Let's assume GetMessages(limit int) exists for simplicity
-	messages, err := a.memory.Get(ctx, []string{})
+	// Memories that can't list their entries in order don't contribute history
+	historyMemory, ok := a.memory.(memory.History)
+	if !ok {
+		return "", nil
+	}
+	messages, err := historyMemory.GetHistory(ctx)
 	if err != nil {
-		// If GetMessages doesn't exist or fails, return empty string or handle differently
 		log.Warn().Err(err).Msg("Could not retrieve history from memory for prompt")
-		// return "", errors.Wrap(err, "failed to get history from memory")
 		return "(Could not retrieve history)", nil // Allow continuing without history
 	}
 
 	var history strings.Builder
-	for i := len(messages) - 1; i >= 0; i-- { // Reverse order for chronological display
-		msg := messages[i]
+	for _, msg := range messages { // GetHistory returns entries in chronological order
 		role := msg.Metadata["role"] // Assuming role is stored in metadata
 		content := msg.Content
 		// Simple formatting, similar to scratchpad
@@ -213,51 +362,94 @@ func (a *ReActAgent) formatHistoryForPrompt(ctx context.Context) (string, error)
 // Run executes the ReAct agent loop for a given initial prompt.
 func (a *ReActAgent) Run(ctx context.Context, goal string) (string, error) {
 	a.currentIteration = 0
-	// Removed a.scratchpad.Add(fmt.Sprintf("Prompt: %s", prompt))
-	if err := a.memory.Add(ctx, types.MemoryEntry{ // Add to main memory as well
-		ID:      fmt.Sprintf("react-prompt-%d", a.currentIteration),
-		Content: goal, // Store the goal/prompt directly
-		Metadata: map[string]string{
-			"role": "user",
-		},
-	}); err != nil {
-		log.Warn().Err(err).Msg("Failed to add memory entry for initial prompt")
+	ctx = execctx.WithAgentClass(ctx, ReActAgentClass)
+	a.startMemoryRun(ctx)
+
+	var saved reactCheckpoint
+	completedIterations, resumed, err := a.loadCheckpoint(ctx, ReactAgentType, &saved)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resume run")
 	}
 
-	// Build initial messages using conversation package
-	messages := []*conversation.Message{
-		conversation.NewChatMessage(conversation.RoleSystem, a.SystemPrompt),
-		conversation.NewChatMessage(conversation.RoleUser, goal),
+	var messages []*conversation.Message
+	if resumed {
+		if saved.Goal != goal {
+			log.Warn().Ctx(ctx).Str("goal", saved.Goal).Msg("Resumed run was started with a different goal, continuing the checkpointed conversation")
+		}
+		messages, err = llm.FromMessageRecords(saved.Messages)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to restore checkpointed conversation")
+		}
+		a.nativeToolCalling = saved.NativeToolCalling
+		a.restoreMemory(ctx, saved.Memory)
+	} else {
+		// History left in memory by previous runs is added once; the steps of this run
+		// are already part of the conversation.
+		previousHistory, err := a.formatHistoryForPrompt(ctx)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to format history for prompt")
+		}
+
+		// Removed a.scratchpad.Add(fmt.Sprintf("Prompt: %s", prompt))
+		a.addMemory(ctx, fmt.Sprintf("react-prompt-%d", a.currentIteration), goal, "user")
+
+		_, isToolCallingLLM := a.LLM.(llm.ToolCallingLLM)
+		a.nativeToolCalling = a.ToolCalling && isToolCallingLLM && len(a.tools.GetAllTools()) > 0
+
+		// Build initial messages using conversation package
+		messages = []*conversation.Message{
+			conversation.NewChatMessage(conversation.RoleSystem, a.buildSystemPrompt()),
+			conversation.NewChatMessage(conversation.RoleUser, goal),
+		}
+		if previousHistory != "" {
+			messages = append(messages, conversation.NewChatMessage(conversation.RoleUser, "History from previous runs:\n"+previousHistory))
+		}
 	}
 
-	for i := 0; i < a.MaxIterations; i++ {
+	for i := completedIterations; i < a.MaxIterations; i++ {
+		// The previous iteration is complete, a resumed run continues from here
+		if i > completedIterations {
+			a.saveReactCheckpoint(ctx, goal, i, messages)
+		}
 		a.currentIteration = i + 1
+		ctx := execctx.WithStep(ctx, a.step(), a.nodeID)
 		log.Info().Ctx(ctx).Int("iteration", a.currentIteration).Msg("Starting ReAct iteration")
 
 		// --- Emit StepStarted Event ---
 		if a.eventBus != nil {
 			stepPayload := &events.StepStartedPayload{
-				Step:     int32(a.currentIteration),
-				NodeId:   "react-agent",
+				Step:     int32(a.step()),
+				NodeId:   a.nodeID,
 				NodeGoal: goal, // Use initial prompt as goal for now
-				RootId:   *a.runID,
+				RootId:   execctx.FromContext(ctx).RunID,
 			}
-			err := a.eventBus.EmitStepStarted(ctx, stepPayload, a.runID)
+			err := a.eventBus.EmitStepStarted(ctx, stepPayload, nil)
 			if err != nil {
 				log.Warn().Err(err).Msg("Failed to emit StepStarted event")
 			}
 		}
 		startTime := time.Now()
 
-		// Add history context from memory to messages
-		historyContent, err := a.formatHistoryForPrompt(ctx)
-		if err != nil {
-			// Decide how to handle: stop or continue without history?
-			return "", errors.Wrap(err, "failed to format history for prompt")
-		}
-		if historyContent != "" {
-			// Add history as a user message, simulating the scratchpad context
-			messages = append(messages, conversation.NewChatMessage(conversation.RoleUser, "Current History:\n"+historyContent))
+		// Keep the conversation within the token budget
+		messages = a.history.Compact(ctx, messages)
+
+		if a.nativeToolCalling {
+			var finalAnswer string
+			var isFinal bool
+			messages, finalAnswer, isFinal, err = a.runToolCallingIteration(ctx, messages, startTime)
+			switch {
+			case errors.Is(err, llm.ErrToolCallingNotSupported):
+				log.Info().Ctx(ctx).Msg("LLM does not support native tool calling, falling back to text parsing")
+				a.nativeToolCalling = false
+				messages[0] = conversation.NewChatMessage(conversation.RoleSystem, a.buildSystemPrompt())
+			case err != nil:
+				return "", err
+			case isFinal:
+				a.deleteCheckpoint(ctx)
+				return finalAnswer, nil
+			default:
+				continue
+			}
 		}
 
 		// Reason + Act - Use Generate
@@ -265,13 +457,7 @@ func (a *ReActAgent) Run(ctx context.Context, goal string) (string, error) {
 		response, err := a.LLM.Generate(ctx, messages)
 		if err != nil {
 			// Log error to memory?
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:       fmt.Sprintf("react-llm-error-%d", a.currentIteration),
-				Content:  fmt.Sprintf("LLM Error: %v", err),
-				Metadata: map[string]string{"role": "system"},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for LLM error")
-			}
+			a.addMemory(ctx, fmt.Sprintf("react-llm-error-%d", a.currentIteration), fmt.Sprintf("LLM Error: %v", err), "system")
 			return "", errors.Wrapf(err, "LLM generate failed in iteration %d", a.currentIteration)
 		}
 		// Assuming response is *conversation.Message and Content is a *conversation.Content
@@ -282,28 +468,14 @@ func (a *ReActAgent) Run(ctx context.Context, goal string) (string, error) {
 		} else {
 			log.Warn().Msg("LLM returned nil response or content")
 			// Handle empty response? Add to memory and continue?
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:       fmt.Sprintf("react-llm-empty-%d", a.currentIteration),
-				Content:  "LLM returned empty response",
-				Metadata: map[string]string{"role": "system"},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for empty response")
-			}
+			a.addMemory(ctx, fmt.Sprintf("react-llm-empty-%d", a.currentIteration), "LLM returned empty response", "system")
 			continue
 		}
 
 		// Add assistant response to messages for next turn
 		messages = append(messages, response)
 		// Removed a.scratchpad.Add(fmt.Sprintf("LLM Response (%d): %s", a.currentIteration, responseContent))
-		if err := a.memory.Add(ctx, types.MemoryEntry{
-			ID:      fmt.Sprintf("react-llm-response-%d", a.currentIteration),
-			Content: responseContent, // Store the raw response content
-			Metadata: map[string]string{
-				"role": string(conversation.RoleAssistant), // Use conversation role
-			},
-		}); err != nil {
-			log.Warn().Err(err).Msg("Failed to add memory entry for LLM response")
-		}
+		a.addMemory(ctx, fmt.Sprintf("react-llm-response-%d", a.currentIteration), responseContent, string(conversation.RoleAssistant))
 
 		// Check for final answer or tool calls
 		thought, action, actionInput, isFinal, err := a.parseResponse(responseContent)
@@ -312,15 +484,7 @@ func (a *ReActAgent) Run(ctx context.Context, goal string) (string, error) {
 			log.Warn().Ctx(ctx).Err(err).Str("response", responseContent).Msg("Failed to parse LLM response, continuing loop")
 			parseErrorMsg := fmt.Sprintf("Error parsing response (%d): %v", a.currentIteration, err)
 			// Removed a.scratchpad.Add(parseErrorMsg)
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:      fmt.Sprintf("react-parse-error-%d", a.currentIteration),
-				Content: parseErrorMsg,
-				Metadata: map[string]string{
-					"role": "system",
-				},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for parse error")
-			}
+			a.addMemory(ctx, fmt.Sprintf("react-parse-error-%d", a.currentIteration), parseErrorMsg, "system")
 			// Potentially add the raw response back as an observation? Or just continue?
 			// messages = append(messages, conversation.NewChatMessage(conversation.RoleTool, fmt.Sprintf("Parsing Error: %v", err))) // Example
 			continue // Try again in the next iteration
@@ -328,44 +492,29 @@ func (a *ReActAgent) Run(ctx context.Context, goal string) (string, error) {
 
 		if thought != "" && thought != "(No specific thought found in response)" { // Only log meaningful thoughts
 			// Removed a.scratchpad.Add(thoughtMsg)
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:      fmt.Sprintf("react-thought-%d", a.currentIteration),
-				Content: fmt.Sprintf("Thought: %s", thought),
-				Metadata: map[string]string{
-					"role": string(conversation.RoleAssistant), // LLM's thought
-				},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for thought")
-			}
+			a.addMemory(ctx, fmt.Sprintf("react-thought-%d", a.currentIteration), fmt.Sprintf("Thought: %s", thought), string(conversation.RoleAssistant))
 		}
 
 		if isFinal {
 			log.Info().Ctx(ctx).Str("finalAnswer", actionInput).Int("iteration", a.currentIteration).Msg("ReAct agent finished")
 			finalMsg := fmt.Sprintf("Final Answer: %s", actionInput)
 			// Removed a.scratchpad.Add(finalMsg)
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:      fmt.Sprintf("react-final-answer-%d", a.currentIteration),
-				Content: finalMsg,
-				Metadata: map[string]string{
-					"role": string(conversation.RoleAssistant),
-				},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for final answer")
-			}
+			a.addMemory(ctx, fmt.Sprintf("react-final-answer-%d", a.currentIteration), finalMsg, string(conversation.RoleAssistant))
 			// --- Emit StepFinished Event (Final) ---
 			if a.eventBus != nil {
 				stepFinishedPayload := &events.StepFinishedPayload{
-					Step:            int32(a.currentIteration),
-					NodeId:          "react-agent",
+					Step:            int32(a.step()),
+					NodeId:          a.nodeID,
 					ActionName:      "FinalAnswer",
 					StatusAfter:     "FINISH",
 					DurationSeconds: time.Since(startTime).Seconds(),
 				}
-				err := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, a.runID)
+				err := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, nil)
 				if err != nil {
 					log.Warn().Err(err).Msg("Failed to emit StepFinished event")
 				}
 			}
+			a.deleteCheckpoint(ctx)
 			return actionInput, nil
 		}
 
@@ -376,13 +525,13 @@ func (a *ReActAgent) Run(ctx context.Context, goal string) (string, error) {
 			// --- Emit StepFinished Event (Thought Only) ---
 			if a.eventBus != nil {
 				stepFinishedPayload := &events.StepFinishedPayload{
-					Step:            int32(a.currentIteration),
-					NodeId:          "react-agent",
+					Step:            int32(a.step()),
+					NodeId:          a.nodeID,
 					ActionName:      "Thought", // Indicate thought step
 					StatusAfter:     "THOUGHT_RECEIVED",
 					DurationSeconds: time.Since(startTime).Seconds(),
 				}
-				err := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, a.runID)
+				err := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, nil)
 				if err != nil {
 					log.Warn().Err(err).Msg("Failed to emit StepFinished event for thought step")
 				}
@@ -392,45 +541,21 @@ func (a *ReActAgent) Run(ctx context.Context, goal string) (string, error) {
 
 		// Execute Action (Tool Call)
 		// Removed a.scratchpad.Add(actionMsg)
-		if err := a.memory.Add(ctx, types.MemoryEntry{
-			ID:      fmt.Sprintf("react-action-%d", a.currentIteration),
-			Content: fmt.Sprintf("Action: %s(%s)", action, actionInput),
-			Metadata: map[string]string{
-				"role": string(conversation.RoleAssistant), // LLM's requested action
-			},
-		}); err != nil {
-			log.Warn().Err(err).Msg("Failed to add memory entry for action")
-		}
+		a.addMemory(ctx, fmt.Sprintf("react-action-%d", a.currentIteration), fmt.Sprintf("Action: %s(%s)", action, actionInput), string(conversation.RoleAssistant))
 
-		toolResult, toolErr := a.executeTool(ctx, action, actionInput)
+		toolResult, toolErr := a.executeTool(ctx, "", action, actionInput)
 		stepStatus := "TOOL_EXECUTED"
 		observationMsg := "" // This will be the content for the tool message
 		if toolErr != nil {
 			log.Warn().Ctx(ctx).Err(toolErr).Str("tool", action).Msg("Tool execution failed")
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:      fmt.Sprintf("react-tool-error-%d", a.currentIteration),
-				Content: fmt.Sprintf("Tool Error [%s]: %v", action, toolErr), // More detailed in memory
-				Metadata: map[string]string{
-					"role": string(conversation.RoleTool),
-				},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for tool error")
-			}
+			a.addMemory(ctx, fmt.Sprintf("react-tool-error-%d", a.currentIteration), fmt.Sprintf("Tool Error [%s]: %v", action, toolErr), string(conversation.RoleTool))
 			stepStatus = "TOOL_ERROR"
 			observationMsg = fmt.Sprintf("Error: %v", toolErr) // Content for the tool message back to LLM
 		} else {
 			log.Info().Ctx(ctx).Str("tool", action).Str("result", toolResult).Msg("Tool executed successfully")
 			observationMsg = toolResult // Use raw tool result for observation message
 			// Removed a.scratchpad.Add(observationMsg)
-			if err := a.memory.Add(ctx, types.MemoryEntry{
-				ID:      fmt.Sprintf("react-observation-%d", a.currentIteration),
-				Content: fmt.Sprintf("Observation [%s]: %s", action, toolResult), // More detailed in memory
-				Metadata: map[string]string{
-					"role": string(conversation.RoleTool),
-				},
-			}); err != nil {
-				log.Warn().Err(err).Msg("Failed to add memory entry for observation")
-			}
+			a.addMemory(ctx, fmt.Sprintf("react-observation-%d", a.currentIteration), fmt.Sprintf("Observation [%s]: %s", action, toolResult), string(conversation.RoleTool))
 		}
 
 		// Add tool result/error back to LLM context using conversation.Message
@@ -442,14 +567,14 @@ func (a *ReActAgent) Run(ctx context.Context, goal string) (string, error) {
 		// --- Emit StepFinished Event (Iteration) ---
 		if a.eventBus != nil {
 			stepFinishedPayload := &events.StepFinishedPayload{
-				Step:            int32(a.currentIteration),
-				NodeId:          "react-agent",
+				Step:            int32(a.step()),
+				NodeId:          a.nodeID,
 				ActionName:      action,
 				StatusAfter:     stepStatus,
 				DurationSeconds: time.Since(startTime).Seconds(),
 				// TODO(manuel): Add observation/result summary?
 			}
-			err := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, a.runID)
+			err := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, nil)
 			if err != nil {
 				log.Warn().Err(err).Msg("Failed to emit StepFinished event")
 			}
@@ -459,73 +584,268 @@ func (a *ReActAgent) Run(ctx context.Context, goal string) (string, error) {
 	return "", errors.Errorf("agent stopped after %d iterations", a.MaxIterations)
 }
 
+// reactCheckpoint is the state of a ReAct run saved after each completed iteration
+type reactCheckpoint struct {
+	Goal              string              `json:"goal"`
+	NativeToolCalling bool                `json:"native_tool_calling"`
+	Messages          []llm.MessageRecord `json:"messages"`
+	Memory            []types.MemoryEntry `json:"memory,omitempty"`
+}
+
+// saveReactCheckpoint saves the conversation and memory after completedIterations
+// iterations, so that a resumed run does not repeat their LLM and tool calls.
+func (a *ReActAgent) saveReactCheckpoint(ctx context.Context, goal string, completedIterations int, messages []*conversation.Message) {
+	if a.checkpoints == nil {
+		return
+	}
+	savedMessages, err := llm.ToMessageRecords(messages)
+	if err != nil {
+		log.Warn().Err(err).Int("iteration", completedIterations).Msg("Failed to serialize conversation for checkpoint")
+		return
+	}
+	a.saveCheckpoint(ctx, ReactAgentType, completedIterations, &reactCheckpoint{
+		Goal:              goal,
+		NativeToolCalling: a.nativeToolCalling,
+		Messages:          savedMessages,
+		Memory:            a.memorySnapshot(ctx),
+	})
+}
+
 // executeTool finds and runs the specified tool using the BaseAgent's tool executor.
-func (a *ReActAgent) executeTool(ctx context.Context, toolName, toolInput string) (string, error) {
+// toolCallID is the provider's tool call ID in native tool calling mode; a new ID is
+// generated if it is empty.
+func (a *ReActAgent) executeTool(ctx context.Context, toolCallID, toolName, toolInput string) (string, error) {
+	responses, err := a.executeTools(ctx, []tools.ToolRequest{{
+		ID:       toolCallID,
+		ToolName: toolName,
+		Input:    toolInput,
+	}})
+	if err != nil {
+		return "", err
+	}
+	return responses[0].Result, responses[0].Error
+}
+
+// executeTools runs the requested tools concurrently through the tool executor,
+// emitting a ToolInvoked/ToolReturned event pair for every call. Responses are
+// returned in request order, tool failures are reported in ToolResponse.Error.
+func (a *ReActAgent) executeTools(ctx context.Context, requests []tools.ToolRequest) ([]tools.ToolResponse, error) {
 	if a.tools == nil {
-		return "", errors.New("tool executor not initialized in BaseAgent")
-	}
-
-	toolCallID := uuid.New().String()
-	nodeID := "react-agent" // Keep it simple for now
-
-	// --- Emit ToolInvoked Event ---
-	if a.eventBus != nil {
-		invokePayload := &events.ToolInvokedPayload{
-			ToolName:    toolName,
-			ApiName:     "Run", // Assuming Run method for tools used by executor
-			ArgsSummary: toolInput,
-			NodeId:      ptr(nodeID),
-			Step:        ptr(int32(a.currentIteration)),
-			AgentClass:  ptr("ReActAgent"),
-			ToolCallId:  toolCallID,
+		return nil, errors.New("tool executor not initialized in BaseAgent")
+	}
+
+	for i := range requests {
+		if requests[i].ID == "" {
+			requests[i].ID = uuid.New().String()
 		}
-		err := a.eventBus.EmitToolInvoked(ctx, invokePayload, a.runID)
-		if err != nil {
-			log.Warn().Err(err).Msg("Failed to emit ToolInvoked event")
+	}
+
+	hooks := &tools.ToolCallHooks{
+		OnApprovalRequested: func(ctx context.Context, req tools.ToolRequest) {
+			// --- Emit ToolApprovalRequested Event ---
+			if a.eventBus == nil {
+				return
+			}
+			approvalPayload := &events.ToolApprovalRequestedPayload{
+				ToolName:    req.ToolName,
+				ToolCallId:  req.ID,
+				ArgsSummary: req.Input,
+			}
+			err := a.eventBus.EmitToolApprovalRequested(ctx, approvalPayload, nil)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to emit ToolApprovalRequested event")
+			}
+		},
+		OnInvoked: func(ctx context.Context, req tools.ToolRequest) {
+			// --- Emit ToolInvoked Event ---
+			if a.eventBus == nil {
+				return
+			}
+			invokePayload := &events.ToolInvokedPayload{
+				ToolName:    req.ToolName,
+				ApiName:     "Run", // Assuming Run method for tools used by executor
+				ArgsSummary: req.Input,
+				ToolCallId:  req.ID,
+			}
+			err := a.eventBus.EmitToolInvoked(ctx, invokePayload, nil)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to emit ToolInvoked event")
+			}
+		},
+		OnReturned: func(ctx context.Context, req tools.ToolRequest, response tools.ToolResponse) {
+			// --- Emit ToolReturned Event ---
+			if a.eventBus == nil {
+				return
+			}
+			state := "SUCCESS"
+			var errorStr *string
+			resultSummary := response.Result // Simple summary for now
+			if response.Error != nil {
+				state = "ERROR"
+				es := response.Error.Error()
+				errorStr = &es
+				resultSummary = "Error: " + es // Provide error in summary if execution failed
+			}
+
+			returnPayload := &events.ToolReturnedPayload{
+				ToolName:        req.ToolName,
+				ApiName:         "Run", // Assuming Run
+				State:           state,
+				DurationSeconds: response.Duration.Seconds(),
+				ResultSummary:   resultSummary,
+				Error:           errorStr,
+				ToolCallId:      req.ID,
+			}
+			errEmit := a.eventBus.EmitToolReturned(ctx, returnPayload, nil)
+			if errEmit != nil {
+				log.Warn().Err(errEmit).Msg("Failed to emit ToolReturned event")
+			}
+		},
+	}
+
+	// Execute the tools via the BaseAgent's executor
+	responses := a.tools.ExecuteParallel(ctx, requests, hooks)
+	for i := range responses {
+		if responses[i].Error != nil {
+			responses[i].Error = errors.Wrapf(responses[i].Error, "tool '%s' execution failed", responses[i].ToolName)
 		}
 	}
-	startTime := time.Now()
 
-	// Execute the tool via the BaseAgent's executor
-	result, err := a.tools.ExecuteTool(ctx, toolName, toolInput) // Use BaseAgent's tools
-	duration := time.Since(startTime)
+	return responses, nil
+}
 
-	// --- Emit ToolReturned Event ---
-	if a.eventBus != nil {
-		state := "SUCCESS"
-		var errorStr *string
-		resultSummary := result // Simple summary for now
-		if err != nil {
-			state = "ERROR"
-			es := err.Error()
-			errorStr = &es
-			resultSummary = "Error: " + es // Provide error in summary if execution failed
+// buildSystemPrompt returns the system prompt for the current run. In text parsing
+// mode, the available tools and the expected Action format are appended.
+func (a *ReActAgent) buildSystemPrompt() string {
+	allTools := a.tools.GetAllTools()
+	if a.nativeToolCalling || len(allTools) == 0 {
+		return a.SystemPrompt
+	}
+
+	var sb strings.Builder
+	sb.WriteString(a.SystemPrompt)
+	sb.WriteString("\n\nYou have access to the following tools:\n")
+	for _, tool := range allTools {
+		fmt.Fprintf(&sb, "- %s: %s\n", tool.Name(), tool.Description())
+	}
+	sb.WriteString(`
+Use the following format:
+
+Thought: reason about what to do next
+Action: tool_name[tool input]
+
+After each action you will receive an observation. When you know the answer, respond with:
+
+Thought: I now know the answer
+Final Answer: the answer to the original request
+`)
+	return sb.String()
+}
+
+// runToolCallingIteration runs a single ReAct iteration using native tool calling.
+// The tools are advertised to the LLM, the tool use blocks of the response are executed
+// and their results are appended to messages with the matching tool call IDs.
+// A response without tool calls is treated as the final answer.
+func (a *ReActAgent) runToolCallingIteration(
+	ctx context.Context,
+	messages []*conversation.Message,
+	startTime time.Time,
+) ([]*conversation.Message, string, bool, error) {
+	toolCallingLLM := a.LLM.(llm.ToolCallingLLM)
+	responses, err := toolCallingLLM.GenerateWithTools(ctx, messages, a.tools.GetAllTools())
+	if err != nil {
+		if errors.Is(err, llm.ErrToolCallingNotSupported) {
+			return messages, "", false, err
 		}
+		a.addMemory(ctx, fmt.Sprintf("react-llm-error-%d", a.currentIteration), fmt.Sprintf("LLM Error: %v", err), "system")
+		return messages, "", false, errors.Wrapf(err, "LLM generate failed in iteration %d", a.currentIteration)
+	}
 
-		returnPayload := &events.ToolReturnedPayload{
-			ToolName:        toolName,
-			ApiName:         "Run", // Assuming Run
-			State:           state,
-			DurationSeconds: duration.Seconds(),
-			ResultSummary:   resultSummary,
-			Error:           errorStr,
-			NodeId:          ptr(nodeID),
-			Step:            ptr(int32(a.currentIteration)),
-			AgentClass:      ptr("ReActAgent"),
-			ToolCallId:      toolCallID,
+	var requests []tools.ToolRequest
+	var text strings.Builder
+	for _, response := range responses {
+		messages = append(messages, response)
+		switch c := response.Content.(type) {
+		case *conversation.ToolUseContent:
+			requests = append(requests, tools.ToolUseContentToToolRequest(c))
+		case *conversation.ChatMessageContent:
+			text.WriteString(c.Text)
 		}
-		errEmit := a.eventBus.EmitToolReturned(ctx, returnPayload, a.runID)
-		if errEmit != nil {
-			log.Warn().Err(errEmit).Msg("Failed to emit ToolReturned event")
+	}
+
+	responseContent := strings.TrimSpace(text.String())
+	if responseContent != "" {
+		a.addMemory(ctx, fmt.Sprintf("react-llm-response-%d", a.currentIteration), responseContent, string(conversation.RoleAssistant))
+	}
+
+	if len(requests) == 0 {
+		finalAnswer := responseContent
+		if finalMatch := finalRegex.FindStringSubmatch(responseContent); len(finalMatch) > 1 {
+			finalAnswer = strings.TrimSpace(finalMatch[1])
 		}
+		log.Info().Ctx(ctx).Str("finalAnswer", finalAnswer).Int("iteration", a.currentIteration).Msg("ReAct agent finished")
+		a.addMemory(ctx, fmt.Sprintf("react-final-answer-%d", a.currentIteration), fmt.Sprintf("Final Answer: %s", finalAnswer), string(conversation.RoleAssistant))
+		a.emitStepFinished(ctx, "FinalAnswer", "FINISH", startTime)
+		return messages, finalAnswer, true, nil
 	}
 
-	// Check for specific tool execution error vs. general error
+	// Every tool call needs a result, so calls beyond the limit are answered with an error
+	toExecute := requests
+	if a.MaxToolCalls > 0 && len(requests) > a.MaxToolCalls {
+		log.Warn().Ctx(ctx).Int("requested", len(requests)).Int("limit", a.MaxToolCalls).Msg("LLM requested more tool calls than allowed")
+		toExecute = requests[:a.MaxToolCalls]
+	}
+	toolResponses, err := a.executeTools(ctx, toExecute)
 	if err != nil {
-		return "", errors.Wrapf(err, "tool '%s' execution failed", toolName)
+		return messages, "", false, err
 	}
+	for _, req := range requests[len(toExecute):] {
+		toolResponses = append(toolResponses, tools.ToolResponse{
+			ID:       req.ID,
+			ToolName: req.ToolName,
+			Error:    errors.Errorf("tool call limit of %d per iteration exceeded", a.MaxToolCalls),
+		})
+	}
+
+	stepStatus := "TOOL_EXECUTED"
+	actionNames := make([]string, 0, len(requests))
+	for i, req := range requests {
+		response := toolResponses[i]
+		actionNames = append(actionNames, req.ToolName)
+		a.addMemory(ctx, fmt.Sprintf("react-action-%d-%d", a.currentIteration, i), fmt.Sprintf("Action: %s(%s)", req.ToolName, req.Input), string(conversation.RoleAssistant))
 
-	return result, nil
+		if response.Error != nil {
+			log.Warn().Ctx(ctx).Err(response.Error).Str("tool", req.ToolName).Msg("Tool execution failed")
+			a.addMemory(ctx, fmt.Sprintf("react-tool-error-%d-%d", a.currentIteration, i), fmt.Sprintf("Tool Error [%s]: %v", req.ToolName, response.Error), string(conversation.RoleTool))
+			stepStatus = "TOOL_ERROR"
+		} else {
+			log.Info().Ctx(ctx).Str("tool", req.ToolName).Str("result", response.Result).Msg("Tool executed successfully")
+			a.addMemory(ctx, fmt.Sprintf("react-observation-%d-%d", a.currentIteration, i), fmt.Sprintf("Observation [%s]: %s", req.ToolName, response.Result), string(conversation.RoleTool))
+		}
+
+		messages = append(messages, tools.ToolResponseToMessage(response))
+	}
+
+	a.emitStepFinished(ctx, strings.Join(actionNames, ","), stepStatus, startTime)
+	return messages, "", false, nil
+}
+
+// emitStepFinished emits a StepFinished event for the current iteration if an event bus is configured.
+func (a *ReActAgent) emitStepFinished(ctx context.Context, actionName, status string, startTime time.Time) {
+	if a.eventBus == nil {
+		return
+	}
+	stepFinishedPayload := &events.StepFinishedPayload{
+		Step:            int32(a.step()),
+		NodeId:          a.nodeID,
+		ActionName:      actionName,
+		StatusAfter:     status,
+		DurationSeconds: time.Since(startTime).Seconds(),
+	}
+	err := a.eventBus.EmitStepFinished(ctx, stepFinishedPayload, nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to emit StepFinished event")
+	}
 }
 
 // Simplified parsing logic for ReAct response (Thought, Action, Action Input, Final Answer).
@@ -580,3 +900,5 @@ func ptr[T any](v T) *T {
 
 // Ensure ReActAgent implements the Agent interface
 var _ Agent = (*ReActAgent)(nil)
+var _ ToolApprovalRequester = (*ReActAgent)(nil)
+var _ Checkpointer = (*ReActAgent)(nil)
@@ -0,0 +1,326 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/go-go-agent/goagent/checkpoint"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/go-go-golems/go-go-agent/goagent/memory"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
+	"github.com/pkg/errors"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// scriptedLLM returns its responses in order and records the conversations it receives.
+// Each response is the list of messages of one call. If nativeTools is false,
+// GenerateWithTools reports that tool calling is not supported.
+type scriptedLLM struct {
+	mu          sync.Mutex
+	responses   [][]*conversation.Message
+	nativeTools bool
+	calls       [][]*conversation.Message
+}
+
+var _ llm.ToolCallingLLM = (*scriptedLLM)(nil)
+
+func (s *scriptedLLM) next(messages []*conversation.Message) ([]*conversation.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, append([]*conversation.Message{}, messages...))
+	if len(s.responses) == 0 {
+		return nil, errors.New("no scripted response left")
+	}
+	response := s.responses[0]
+	s.responses = s.responses[1:]
+	return response, nil
+}
+
+func (s *scriptedLLM) Generate(ctx context.Context, messages []*conversation.Message) (*conversation.Message, error) {
+	response, err := s.next(messages)
+	if err != nil {
+		return nil, err
+	}
+	return response[len(response)-1], nil
+}
+
+func (s *scriptedLLM) GenerateWithTools(ctx context.Context, messages []*conversation.Message, agentTools []tools.Tool) ([]*conversation.Message, error) {
+	if !s.nativeTools {
+		return nil, llm.ErrToolCallingNotSupported
+	}
+	return s.next(messages)
+}
+
+func (s *scriptedLLM) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return nil, errors.New("embeddings are not scripted")
+}
+
+func textMessage(text string) []*conversation.Message {
+	return []*conversation.Message{conversation.NewChatMessage(conversation.RoleAssistant, text)}
+}
+
+func toolUseMessage(id, name, input string) *conversation.Message {
+	return conversation.NewMessage(&conversation.ToolUseContent{
+		ToolID: id,
+		Name:   name,
+		Input:  json.RawMessage(input),
+		Type:   "function",
+	})
+}
+
+// echoTool returns its input prefixed with "echo: " and records the inputs it received
+type echoTool struct {
+	mu     sync.Mutex
+	inputs []string
+}
+
+func (t *echoTool) Name() string        { return "echo" }
+func (t *echoTool) Description() string { return "Echoes its input" }
+func (t *echoTool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	return orderedmap.New[string, types.ParameterSchema]()
+}
+func (t *echoTool) Execute(ctx context.Context, input string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inputs = append(t.inputs, input)
+	return "echo: " + input, nil
+}
+
+func newTestReActAgent(t *testing.T, model llm.LLM, tool tools.Tool) *ReActAgent {
+	t.Helper()
+	executor := tools.NewToolExecutor()
+	executor.AddTool(tool)
+	a, err := NewReActAgent(
+		WithLLM(model),
+		WithSystemPrompt("You are a test agent."),
+		WithTools(executor),
+		WithToolCalling(true),
+		WithMaxIterations(5),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestReActNativeToolCalling(t *testing.T) {
+	model := &scriptedLLM{
+		nativeTools: true,
+		responses: [][]*conversation.Message{
+			{
+				conversation.NewChatMessage(conversation.RoleAssistant, "Let me check both."),
+				toolUseMessage("call-1", "echo", `{"q":"a"}`),
+				toolUseMessage("call-2", "echo", `{"q":"b"}`),
+			},
+			textMessage("Final Answer: a and b"),
+		},
+	}
+	tool := &echoTool{}
+	a := newTestReActAgent(t, model, tool)
+
+	answer, err := a.Run(context.Background(), "echo a and b")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if answer != "a and b" {
+		t.Errorf("answer = %q, want %q", answer, "a and b")
+	}
+	if len(tool.inputs) != 2 {
+		t.Fatalf("tool called %d times, want 2", len(tool.inputs))
+	}
+
+	// The system prompt does not describe the tools, they are advertised natively
+	if len(model.calls) != 2 {
+		t.Fatalf("LLM called %d times, want 2", len(model.calls))
+	}
+	if system := model.calls[0][0].Content.String(); strings.Contains(system, "Action:") {
+		t.Errorf("system prompt in native mode contains the text format: %q", system)
+	}
+
+	// The second call sees the tool calls followed by their results, matched by ID
+	results := map[string]string{}
+	for _, m := range model.calls[1] {
+		if c, ok := m.Content.(*conversation.ToolResultContent); ok {
+			results[c.ToolID] = c.Result
+		}
+	}
+	expected := map[string]string{
+		"call-1": `echo: {"q":"a"}`,
+		"call-2": `echo: {"q":"b"}`,
+	}
+	for id, result := range expected {
+		if results[id] != result {
+			t.Errorf("result of %s = %q, want %q", id, results[id], result)
+		}
+	}
+}
+
+func TestReActTextFallback(t *testing.T) {
+	model := &scriptedLLM{
+		responses: [][]*conversation.Message{
+			textMessage("Thought: I should echo.\nAction: echo[hello]"),
+			textMessage("Thought: I now know the answer\nFinal Answer: hello"),
+		},
+	}
+	tool := &echoTool{}
+	a := newTestReActAgent(t, model, tool)
+
+	answer, err := a.Run(context.Background(), "echo hello")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if answer != "hello" {
+		t.Errorf("answer = %q, want %q", answer, "hello")
+	}
+	if len(tool.inputs) != 1 || tool.inputs[0] != "hello" {
+		t.Errorf("tool inputs = %v, want [hello]", tool.inputs)
+	}
+
+	// After the fallback, the system prompt describes the tools and the text format
+	system := model.calls[0][0].Content.String()
+	if !strings.Contains(system, "- echo: Echoes its input") || !strings.Contains(system, "Action: tool_name[tool input]") {
+		t.Errorf("system prompt of the text fallback does not describe the tools: %q", system)
+	}
+	last := model.calls[1][len(model.calls[1])-1].Content.String()
+	if last != "echo: hello" {
+		t.Errorf("last message of the second call = %q, want the observation", last)
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	a := &ReActAgent{}
+	testCases := []struct {
+		response    string
+		thought     string
+		action      string
+		actionInput string
+		isFinal     bool
+	}{
+		{"Thought: search it\nAction: web_search[go generics]", "search it", "web_search", "go generics", false},
+		{"Thought: done\nFinal Answer: 42", "done", "FinalAnswer", "42", true},
+		{"Final Answer: multi\nline", "(No specific thought found in response)", "FinalAnswer", "multi\nline", true},
+		{"Thought: still thinking", "still thinking", "", "", false},
+	}
+	for _, tc := range testCases {
+		thought, action, actionInput, isFinal, err := a.parseResponse(tc.response)
+		if err != nil {
+			t.Errorf("parseResponse(%q) failed: %v", tc.response, err)
+			continue
+		}
+		if thought != tc.thought || action != tc.action || actionInput != tc.actionInput || isFinal != tc.isFinal {
+			t.Errorf("parseResponse(%q) = (%q, %q, %q, %v), want (%q, %q, %q, %v)",
+				tc.response, thought, action, actionInput, isFinal,
+				tc.thought, tc.action, tc.actionInput, tc.isFinal)
+		}
+	}
+}
+
+func TestReActMemoryAcrossRuns(t *testing.T) {
+	mem, err := memory.NewSQLiteVectorMemory(filepath.Join(t.TempDir(), "memory.db"), "test", llm.NewMockLLM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = mem.Close()
+	}()
+
+	model := &scriptedLLM{}
+	tool := &echoTool{}
+	a := newTestReActAgent(t, model, tool)
+	a.memory = mem
+
+	for _, run := range []struct{ runID, word string }{{"run-1", "apple"}, {"run-2", "pear"}} {
+		model.responses = append(model.responses,
+			textMessage("Action: echo["+run.word+"]"),
+			textMessage("Final Answer: "+run.word),
+		)
+		ctx := execctx.WithRunID(context.Background(), run.runID)
+		if _, err := a.Run(ctx, "echo "+run.word); err != nil {
+			t.Fatalf("run %s failed: %v", run.runID, err)
+		}
+	}
+
+	// The second run sees the history of the first one
+	var history string
+	for _, m := range model.calls[2] {
+		if strings.HasPrefix(m.Content.String(), "History from previous runs:") {
+			history = m.Content.String()
+		}
+	}
+	if !strings.Contains(history, "echo: apple") {
+		t.Errorf("second run did not receive the history of the first run: %q", history)
+	}
+
+	// Both runs keep their entries
+	entries, err := mem.GetHistory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := map[string]int{}
+	for _, entry := range entries {
+		runs[strings.SplitN(entry.ID, "/", 2)[0]]++
+	}
+	if runs["run-1"] == 0 || runs["run-1"] != runs["run-2"] {
+		t.Errorf("entries per run = %v, want the same number of entries for both runs", runs)
+	}
+}
+
+func TestReActResumeFromCheckpoint(t *testing.T) {
+	store, err := checkpoint.NewSQLiteStore(filepath.Join(t.TempDir(), "checkpoints.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// The first run calls the tool, then fails on its second LLM call
+	model := &scriptedLLM{
+		nativeTools: true,
+		responses:   [][]*conversation.Message{{toolUseMessage("call-1", "echo", `{"q":"a"}`)}},
+	}
+	tool := &echoTool{}
+	a := newTestReActAgent(t, model, tool)
+	a.SetCheckpointStore(store, "run-1", false)
+	if _, err := a.Run(context.Background(), "echo a"); err == nil {
+		t.Fatal("first run succeeded, want the error of the missing LLM response")
+	}
+	if len(tool.inputs) != 1 {
+		t.Fatalf("tool called %d times in the first run, want 1", len(tool.inputs))
+	}
+
+	resumedModel := &scriptedLLM{nativeTools: true, responses: [][]*conversation.Message{textMessage("Final Answer: a")}}
+	resumedTool := &echoTool{}
+	resumed := newTestReActAgent(t, resumedModel, resumedTool)
+	resumed.SetCheckpointStore(store, "run-1", true)
+	answer, err := resumed.Run(context.Background(), "echo a")
+	if err != nil {
+		t.Fatalf("resumed run failed: %v", err)
+	}
+	if answer != "a" {
+		t.Errorf("answer = %q, want %q", answer, "a")
+	}
+	if len(resumedTool.inputs) != 0 {
+		t.Errorf("resumed run repeated the tool calls %v", resumedTool.inputs)
+	}
+	// The resumed run continues the checkpointed conversation with the tool result
+	var result string
+	for _, m := range resumedModel.calls[0] {
+		if c, ok := m.Content.(*conversation.ToolResultContent); ok && c.ToolID == "call-1" {
+			result = c.Result
+		}
+	}
+	if result != `echo: {"q":"a"}` {
+		t.Errorf("resumed conversation has tool result %q, want the result of the first run", result)
+	}
+
+	// A completed run deletes its checkpoint
+	if cp, err := store.Load(context.Background(), "run-1"); err != nil || cp != nil {
+		t.Errorf("checkpoint after completion = %+v, %v, want none", cp, err)
+	}
+}
@@ -94,6 +94,8 @@ func init() {
 	RegisterAgentType(FileCollectionAgentType, &FileCollectionAgentFactory{})
 
 	RegisterAgentType(StructuredDataAgentType, &StructuredDataAgentFactory{})
+
+	RegisterAgentType(TaskDecompositionAgentType, &TaskDecompositionAgentFactory{})
 }
 
 type AgentFactory interface {
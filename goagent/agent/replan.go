@@ -0,0 +1,298 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// ReplanMode selects when a PlanAndExecuteAgent revises the remaining steps of its plan
+type ReplanMode string
+
+const (
+	// ReplanOnFailure revises the plan after a step fails, instead of stopping the run
+	ReplanOnFailure ReplanMode = "on-failure"
+	// ReplanAlways revises the plan after every step
+	ReplanAlways ReplanMode = "always"
+	// ReplanNever executes the initial plan and stops at the first failed step
+	ReplanNever ReplanMode = "never"
+)
+
+// PlanDiff lists the changes of a plan revision. Steps are matched by ID.
+type PlanDiff struct {
+	Added   []*Plan           `json:"added,omitempty"`
+	Removed []*Plan           `json:"removed,omitempty"`
+	Changed []*PlanStepChange `json:"changed,omitempty"`
+}
+
+// PlanStepChange is a step whose thought, action, input or dependencies were revised
+type PlanStepChange struct {
+	ID     string `json:"id"`
+	Before *Plan  `json:"before"`
+	After  *Plan  `json:"after"`
+}
+
+// IsEmpty returns true if the revision did not change the plan
+func (d *PlanDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffPlans compares the steps of two plans
+func DiffPlans(before, after []*Plan) *PlanDiff {
+	diff := &PlanDiff{}
+	afterSteps := map[string]*Plan{}
+	for _, step := range after {
+		afterSteps[step.ID] = step
+	}
+	beforeSteps := map[string]*Plan{}
+	for _, step := range before {
+		beforeSteps[step.ID] = step
+		revised, ok := afterSteps[step.ID]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, step)
+		case revised.Thought != step.Thought || revised.Action != step.Action ||
+			revised.ActionInput != step.ActionInput || revised.IsFinal != step.IsFinal ||
+			!slices.Equal(revised.DependsOn, step.DependsOn):
+			diff.Changed = append(diff.Changed, &PlanStepChange{ID: step.ID, Before: step, After: revised})
+		}
+	}
+	for _, step := range after {
+		if _, ok := beforeSteps[step.ID]; !ok {
+			diff.Added = append(diff.Added, step)
+		}
+	}
+	return diff
+}
+
+// canReplan returns true if the plan should be revised after a step
+func (a *PlanAndExecuteAgent) canReplan(failed bool) bool {
+	if a.planRevision >= a.MaxReplans {
+		return false
+	}
+	switch a.ReplanMode {
+	case ReplanAlways:
+		return true
+	case ReplanOnFailure:
+		return failed
+	default:
+		return false
+	}
+}
+
+// stepFailure is a step whose tool failed, with its error
+type stepFailure struct {
+	step *Plan
+	err  error
+}
+
+// describeFailures lists the failed steps with their errors
+func describeFailures(failures []stepFailure) string {
+	descriptions := make([]string, 0, len(failures))
+	for _, f := range failures {
+		descriptions = append(descriptions, fmt.Sprintf("step %s failed: %v", f.step.ID, f.err))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// failuresError is the error of a run that stops after steps failed
+func failuresError(failures []stepFailure) error {
+	if len(failures) == 1 {
+		return errors.Wrapf(failures[0].err, "tool execution failed at step %s", failures[0].step.ID)
+	}
+	return errors.Errorf("tool execution failed: %s", describeFailures(failures))
+}
+
+// failedDependency returns a step of plan from index start on, other than the final
+// step, that depends on a failed step, and the failed step. It returns nils if there
+// is none.
+func failedDependency(plan []*Plan, start int, failures []stepFailure) (*Plan, *Plan) {
+	failed := map[string]*Plan{}
+	for _, f := range failures {
+		failed[f.step.ID] = f.step
+	}
+	for i := start; i < len(plan); i++ {
+		if plan[i].IsFinal || plan[i].Action == "FinalAnswer" {
+			continue
+		}
+		for _, id := range stepDependencies(plan, i) {
+			if step, ok := failed[id]; ok {
+				return plan[i], step
+			}
+		}
+	}
+	return nil, nil
+}
+
+// replan asks the LLM to revise the steps of plan that are not done yet, given the
+// results of the done steps. The plan is revised after the step last completed, or
+// after the steps of failures failed. It returns the done steps followed by the
+// revised steps. Only revisions that change the plan count towards MaxReplans.
+//
+// Keeping the plan unchanged after a failure is an error if a step depends on a failed
+// step, since it would run on the error instead of the result it was planned for.
+// Final steps may depend on failed steps, the final answer says what could not be found.
+func (a *PlanAndExecuteAgent) replan(ctx context.Context, goal string, plan []*Plan, last *Plan, failures []stepFailure) ([]*Plan, error) {
+	ctx = execctx.WithAction(ctx, "Replan")
+	var completed, remaining []*Plan
+	for _, step := range plan {
+		if step.Done {
+			completed = append(completed, step)
+		} else {
+			remaining = append(remaining, step)
+		}
+	}
+
+	response, err := a.LLM.Generate(ctx, []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleSystem, a.replannerPrompt()),
+		conversation.NewChatMessage(conversation.RoleUser, replanRequest(goal, completed, remaining, failures)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "LLM call failed during replanning")
+	}
+	steps, err := parsePlan(response.Content.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// Done steps repeated by the replanner are dropped, their IDs identify their results
+	completedIDs := map[string]bool{}
+	for _, step := range completed {
+		completedIDs[step.ID] = true
+	}
+	remainingSteps := map[string]*Plan{}
+	for _, step := range remaining {
+		remainingSteps[step.ID] = step
+	}
+	var revised []*Plan
+	for _, step := range steps {
+		if completedIDs[step.ID] {
+			continue
+		}
+		step.Result = ""
+		step.Done = false
+		step.NodeID = ""
+		if previous, ok := remainingSteps[step.ID]; ok {
+			step.NodeID = previous.NodeID
+		}
+		revised = append(revised, step)
+	}
+
+	newPlan := make([]*Plan, 0, len(completed)+len(revised))
+	newPlan = append(newPlan, completed...)
+	newPlan = append(newPlan, revised...)
+	newPlan, err = a.validatePlan(newPlan, len(completed))
+	if err != nil {
+		return nil, err
+	}
+	revised = newPlan[len(completed):]
+
+	diff := DiffPlans(remaining, revised)
+	if diff.IsEmpty() {
+		if dependent, failed := failedDependency(newPlan, len(completed), failures); dependent != nil {
+			return nil, errors.Errorf("replanner kept the plan unchanged, step %s depends on failed step %s", dependent.ID, failed.ID)
+		}
+		log.Info().Ctx(ctx).Int("step", a.currentStep).Msg("Replanner kept the remaining plan")
+		return plan, nil
+	}
+
+	a.planRevision++
+	var reason string
+	if len(failures) > 0 {
+		reason = describeFailures(failures)
+	} else {
+		reason = fmt.Sprintf("step %s completed", last.ID)
+	}
+	log.Info().Ctx(ctx).
+		Int("revision", a.planRevision).
+		Int("added", len(diff.Added)).
+		Int("removed", len(diff.Removed)).
+		Int("changed", len(diff.Changed)).
+		Str("reason", reason).
+		Msg("Revised plan")
+	a.addMemory(ctx, fmt.Sprintf("plan-execute-replanner-%d", a.planRevision), fmt.Sprintf("Plan Revised (%s): %v", reason, revised), "planner")
+	a.emitPlanReceived(ctx, goal, newPlan, a.planRevision, diff, reason)
+	a.emitPlanGraph(ctx, newPlan)
+
+	return newPlan, nil
+}
+
+func (a *PlanAndExecuteAgent) replannerPrompt() string {
+	var sb strings.Builder
+	sb.WriteString(`You revise plans while they are being executed. Given a goal, the steps executed so
+far with their results and the remaining steps of the plan, return the steps that
+should be executed next. Keep the remaining steps that are still useful, and change,
+insert or drop steps as the results require. In particular, work around failed steps.
+`)
+	if toolNames := a.tools.GetToolNames(); len(toolNames) > 0 {
+		fmt.Fprintf(&sb, "\nSteps can use the following tools as action: %s.\n", strings.Join(toolNames, ", "))
+	}
+	sb.WriteString(`
+Output the steps as a JSON list of objects, each with "id", "thought", "action",
+"actionInput" and "depends_on" fields, like the plan. Keep the ids of unchanged steps
+and use new ids for new steps. Steps can depend on executed steps and use their results
+as {{steps.<id>.result}}. The final step's action should be "FinalAnswer", with a
+draft of the answer to the goal as actionInput. If the remaining steps need no change,
+return them unchanged.
+`)
+	return sb.String()
+}
+
+func replanRequest(goal string, completed, remaining []*Plan, failures []stepFailure) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Goal: %s\n\nExecuted steps:\n", goal)
+	for _, step := range completed {
+		fmt.Fprintf(&sb, "- Step %s: %s(%s)\n  Thought: %s\n  Result: %s\n", step.ID, step.Action, step.ActionInput, step.Thought, step.Result)
+	}
+	if len(failures) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, f := range failures {
+		fmt.Fprintf(&sb, "Step %s failed: %v\n", f.step.ID, f.err)
+	}
+	remainingJSON, err := json.MarshalIndent(remaining, "", "  ")
+	if err != nil {
+		remainingJSON = []byte("[]")
+	}
+	fmt.Fprintf(&sb, "\nRemaining steps:\n%s\n", remainingJSON)
+	return sb.String()
+}
+
+// emitPlanReceived publishes a revision of the plan. Revisions after the initial plan
+// include their diff against the previous plan and the reason of the revision.
+func (a *PlanAndExecuteAgent) emitPlanReceived(ctx context.Context, goal string, plan []*Plan, revision int, diff *PlanDiff, reason string) {
+	if a.eventBus == nil {
+		return
+	}
+	rawPlan, err := toStruct(struct {
+		Revision int       `json:"revision"`
+		Reason   string    `json:"reason,omitempty"`
+		Steps    []*Plan   `json:"steps"`
+		Diff     *PlanDiff `json:"diff,omitempty"`
+	}{revision, reason, plan, diff})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to convert plan for PlanReceived event")
+		return
+	}
+	nodeID := plannerNodeID
+	if revision > 0 {
+		nodeID = "plan-execute-replanner"
+	}
+	payload := &events.PlanReceivedPayload{
+		NodeId:   nodeID,
+		RawPlan:  rawPlan,
+		Step:     ptr(int32(a.currentStep)),
+		TaskGoal: ptr(goal),
+	}
+	if err := a.eventBus.EmitPlanReceived(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit PlanReceived event")
+	}
+}
@@ -0,0 +1,229 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/pkg/errors"
+)
+
+func TestDiffPlans(t *testing.T) {
+	before := []*Plan{
+		{ID: "1", Action: "echo", ActionInput: "a"},
+		{ID: "2", Action: "echo", ActionInput: "b", DependsOn: []string{"1"}},
+		{ID: "3", Action: "FinalAnswer", ActionInput: "done", IsFinal: true},
+	}
+
+	testCases := []struct {
+		name    string
+		after   []*Plan
+		added   []string
+		removed []string
+		changed []string
+	}{
+		{
+			name: "unchanged",
+			after: []*Plan{
+				{ID: "1", Action: "echo", ActionInput: "a"},
+				{ID: "2", Action: "echo", ActionInput: "b", DependsOn: []string{"1"}},
+				{ID: "3", Action: "FinalAnswer", ActionInput: "done", IsFinal: true},
+			},
+		},
+		{
+			// Results and node IDs are not part of the revision
+			name: "executed",
+			after: []*Plan{
+				{ID: "1", Action: "echo", ActionInput: "a", Result: "a", Done: true, NodeID: "node-1"},
+				{ID: "2", Action: "echo", ActionInput: "b", DependsOn: []string{"1"}},
+				{ID: "3", Action: "FinalAnswer", ActionInput: "done", IsFinal: true},
+			},
+		},
+		{
+			name: "changed input and dependencies",
+			after: []*Plan{
+				{ID: "1", Action: "echo", ActionInput: "c"},
+				{ID: "2", Action: "echo", ActionInput: "b", DependsOn: []string{}},
+				{ID: "3", Action: "FinalAnswer", ActionInput: "done", IsFinal: true},
+			},
+			changed: []string{"1", "2"},
+		},
+		{
+			name: "added and removed",
+			after: []*Plan{
+				{ID: "1", Action: "echo", ActionInput: "a"},
+				{ID: "4", Action: "echo", ActionInput: "d"},
+				{ID: "5", Action: "FinalAnswer", ActionInput: "done", IsFinal: true},
+			},
+			added:   []string{"4", "5"},
+			removed: []string{"2", "3"},
+		},
+	}
+	ids := func(steps []*Plan) []string {
+		var ids []string
+		for _, step := range steps {
+			ids = append(ids, step.ID)
+		}
+		return ids
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := DiffPlans(before, tc.after)
+			var changed []string
+			for _, change := range diff.Changed {
+				changed = append(changed, change.ID)
+			}
+			if !reflect.DeepEqual(ids(diff.Added), tc.added) || !reflect.DeepEqual(ids(diff.Removed), tc.removed) || !reflect.DeepEqual(changed, tc.changed) {
+				t.Errorf("DiffPlans() added %v, removed %v, changed %v, want %v, %v, %v",
+					ids(diff.Added), ids(diff.Removed), changed, tc.added, tc.removed, tc.changed)
+			}
+			if diff.IsEmpty() != (tc.added == nil && tc.removed == nil && tc.changed == nil) {
+				t.Errorf("IsEmpty() = %v for %+v", diff.IsEmpty(), diff)
+			}
+		})
+	}
+}
+
+func TestReplan(t *testing.T) {
+	model := &scriptedLLM{responses: [][]*conversation.Message{
+		// Repeats the done step 1 with another input, revises step 2 and replaces step 3
+		textMessage(`[
+			{"id": "1", "action": "echo", "actionInput": "x"},
+			{"id": "2", "action": "echo", "actionInput": "c", "depends_on": ["1"]},
+			{"id": "4", "action": "FinalAnswer", "actionInput": "{{steps.2.result}}", "depends_on": ["2"]}
+		]`),
+		// Keeps the remaining steps
+		textMessage(`[
+			{"id": "2", "action": "echo", "actionInput": "c", "depends_on": ["1"]},
+			{"id": "4", "action": "FinalAnswer", "actionInput": "{{steps.2.result}}", "depends_on": ["2"]}
+		]`),
+	}}
+	a := newTestPlanAndExecuteAgent(t, model, &echoTool{}, WithExecutorReplanMode(ReplanOnFailure), WithExecutorMaxReplans(1))
+
+	plan := []*Plan{
+		{ID: "1", Action: "echo", ActionInput: "a", Result: "Error: failed", Done: true, NodeID: "node-1"},
+		{ID: "2", Action: "echo", ActionInput: "b", DependsOn: []string{"1"}, NodeID: "node-2"},
+		{ID: "3", Action: "FinalAnswer", ActionInput: "{{steps.2.result}}", IsFinal: true, NodeID: "node-3"},
+	}
+	revised, err := a.replan(context.Background(), "echo", plan, nil, []stepFailure{{step: plan[0], err: errors.New("failed")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The done step keeps its result, the revised steps keep the node of the step they
+	// revise and the new step gets a new node
+	expected := []*Plan{
+		{ID: "1", Action: "echo", ActionInput: "a", Result: "Error: failed", Done: true, NodeID: "node-1"},
+		{ID: "2", Action: "echo", ActionInput: "c", DependsOn: []string{"1"}, NodeID: "node-2"},
+		{ID: "4", Action: "FinalAnswer", ActionInput: "{{steps.2.result}}", DependsOn: []string{"2"}, IsFinal: true},
+	}
+	if len(revised) != len(expected) {
+		t.Fatalf("revised plan has %d steps, want %d", len(revised), len(expected))
+	}
+	if nodeID := revised[2].NodeID; nodeID == "" || nodeID == "node-3" {
+		t.Errorf("new step has node %q, want a new node", nodeID)
+	}
+	expected[2].NodeID = revised[2].NodeID
+	for i, step := range revised {
+		if !reflect.DeepEqual(step, expected[i]) {
+			t.Errorf("step %d = %+v, want %+v", i, step, expected[i])
+		}
+	}
+	if a.planRevision != 1 || a.canReplan(true) {
+		t.Errorf("revision = %d, can replan = %v after a revision, want 1 and false", a.planRevision, a.canReplan(true))
+	}
+
+	// Revisions keeping the plan don't count towards MaxReplans
+	a.planRevision = 0
+	kept, err := a.replan(context.Background(), "echo", revised, revised[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(kept, revised) || a.planRevision != 0 || !a.canReplan(true) {
+		t.Errorf("unchanged revision = %+v, revision %d, can replan = %v, want the plan, 0 and true", kept, a.planRevision, a.canReplan(true))
+	}
+}
+
+func TestReplanUnchangedAfterFailure(t *testing.T) {
+	// The replanner returns the remaining steps unchanged
+	testCases := []struct {
+		name     string
+		plan     []*Plan
+		revision string
+		err      string
+	}{
+		{
+			// Step 2 would run on the error of step 1
+			name: "dependent step",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", ActionInput: "a", Result: "Error: failed", Done: true},
+				{ID: "2", Action: "echo", ActionInput: "{{steps.1.result}}"},
+				{ID: "3", Action: "FinalAnswer", ActionInput: "{{steps.2.result}}", IsFinal: true},
+			},
+			revision: `[
+				{"id": "2", "action": "echo", "actionInput": "{{steps.1.result}}"},
+				{"id": "3", "action": "FinalAnswer", "actionInput": "{{steps.2.result}}"}
+			]`,
+			err: "replanner kept the plan unchanged, step 2 depends on failed step 1",
+		},
+		{
+			// The final answer says what could not be found
+			name: "independent steps",
+			plan: []*Plan{
+				{ID: "1", Action: "echo", ActionInput: "a", Result: "Error: failed", Done: true, DependsOn: []string{}},
+				{ID: "2", Action: "echo", ActionInput: "b", DependsOn: []string{}},
+				{ID: "3", Action: "FinalAnswer", ActionInput: "{{steps.1.result}} {{steps.2.result}}", IsFinal: true},
+			},
+			revision: `[
+				{"id": "2", "action": "echo", "actionInput": "b", "depends_on": []},
+				{"id": "3", "action": "FinalAnswer", "actionInput": "{{steps.1.result}} {{steps.2.result}}"}
+			]`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &scriptedLLM{responses: [][]*conversation.Message{textMessage(tc.revision)}}
+			a := newTestPlanAndExecuteAgent(t, model, &echoTool{}, WithExecutorReplanMode(ReplanOnFailure))
+			_, err := a.replan(context.Background(), "echo", tc.plan, nil, []stepFailure{{step: tc.plan[0], err: errors.New("failed")}})
+			if tc.err == "" && err != nil {
+				t.Errorf("replan() = %v, want the unchanged plan", err)
+			}
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Errorf("replan() = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestReplanAfterParallelFailures(t *testing.T) {
+	// The replanner fails, so the run stops with the errors of the failed steps
+	model := &scriptedLLM{responses: [][]*conversation.Message{
+		textMessage(`[
+			{"id": "1", "action": "lookup", "actionInput": "a", "depends_on": []},
+			{"id": "2", "action": "lookup", "actionInput": "b", "depends_on": []},
+			{"id": "3", "action": "FinalAnswer", "actionInput": "{{steps.1.result}} {{steps.2.result}}"}
+		]`),
+	}}
+	a := newTestPlanAndExecuteAgent(t, model, &echoTool{},
+		WithExecutorReplanMode(ReplanOnFailure),
+		WithExecutorMaxParallelSteps(2),
+	)
+	a.tools.AddTool(&lookupTool{err: errors.New("lookup service unavailable")})
+
+	_, err := a.Run(context.Background(), "look up a and b")
+	if err == nil || !strings.Contains(err.Error(), "step 1 failed: ") || !strings.Contains(err.Error(), "step 2 failed: ") {
+		t.Errorf("Run() = %v, want the errors of steps 1 and 2", err)
+	}
+	if len(model.calls) != 2 {
+		t.Fatalf("made %d LLM calls, want the plan and one revision", len(model.calls))
+	}
+	// The revision is asked for once, with both failures
+	request := model.calls[1][1].Content.String()
+	for _, failure := range []string{"Step 1 failed: ", "Step 2 failed: "} {
+		if !strings.Contains(request, failure) {
+			t.Errorf("replan request does not contain %q: %s", failure, request)
+		}
+	}
+}
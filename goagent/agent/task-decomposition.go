@@ -0,0 +1,765 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const TaskDecompositionAgentType = "task-decomposition"
+
+// TaskDecompositionAgentClass is the agent class of the events of task decomposition agents
+const TaskDecompositionAgentClass = "TaskDecompositionAgent"
+
+// Node types of the task graph
+const (
+	// PlanNodeType nodes are decomposed into subtasks, or executed if they are simple enough
+	PlanNodeType = "PLAN_NODE"
+	// ExecuteNodeType nodes are executed without being decomposed
+	ExecuteNodeType = "EXECUTE_NODE"
+)
+
+// Task types of the nodes of the task graph
+const (
+	TaskTypeComposition = "COMPOSITION"
+	TaskTypeReasoning   = "REASONING"
+	TaskTypeRetrieval   = "RETRIEVAL"
+)
+
+// Node statuses, as displayed by the UI
+const (
+	NodeStatusNotReady = "NOT_READY"
+	NodeStatusReady    = "READY"
+	NodeStatusPlanning = "PLANNING"
+	NodeStatusDoing    = "DOING"
+	NodeStatusFinish   = "FINISH"
+	NodeStatusFailed   = "FAILED"
+)
+
+// TaskDecompositionAgentFactory creates TaskDecompositionAgent instances.
+type TaskDecompositionAgentFactory struct{}
+
+// TaskDecompositionAgentSettings holds configuration for the TaskDecompositionAgent.
+type TaskDecompositionAgentSettings struct {
+	MaxDepth         int  `glazed.parameter:"max-depth"`
+	MaxSubtasks      int  `glazed.parameter:"max-subtasks"`
+	MaxIterations    int  `glazed.parameter:"max-iterations"`
+	ToolCalling      bool `glazed.parameter:"tool-calling"`
+	MaxParallelTools int  `glazed.parameter:"max-parallel-tools"`
+	ToolTimeout      int  `glazed.parameter:"tool-timeout"`
+}
+
+// NewAgent creates a new TaskDecompositionAgent.
+func (f *TaskDecompositionAgentFactory) NewAgent(
+	ctx context.Context,
+	cmd Command,
+	parsedLayers *layers.ParsedLayers,
+	baseModel llm.LLM,
+) (Agent, error) {
+	var settings TaskDecompositionAgentSettings
+	err := parsedLayers.InitializeStruct(TaskDecompositionAgentType, &settings)
+	if err != nil {
+		return nil, err
+	}
+
+	agentOptions, err := cmd.RenderAgentOptions(parsedLayers.GetDataMap(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxDepth, ok := agentOptions["max-depth"].(int); ok {
+		settings.MaxDepth = maxDepth
+	}
+	if maxSubtasks, ok := agentOptions["max-subtasks"].(int); ok {
+		settings.MaxSubtasks = maxSubtasks
+	}
+	if maxIter, ok := agentOptions["max-iterations"].(int); ok {
+		settings.MaxIterations = maxIter
+	}
+	if toolCalling, ok := agentOptions["tool-calling"].(bool); ok {
+		settings.ToolCalling = toolCalling
+	}
+	if maxParallelTools, ok := agentOptions["max-parallel-tools"].(int); ok {
+		settings.MaxParallelTools = maxParallelTools
+	}
+	if toolTimeout, ok := agentOptions["tool-timeout"].(int); ok {
+		settings.ToolTimeout = toolTimeout
+	}
+
+	toolExecutor, err := NewToolExecutorFromCommand(
+		cmd,
+		agentOptions,
+		toolExecutorOptions(settings.MaxParallelTools, settings.ToolTimeout)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTaskDecompositionAgent(
+		WithTaskLLM(baseModel),
+		WithTaskSystemPrompt(cmd.GetSystemPrompt()),
+		WithTaskTools(toolExecutor),
+		WithTaskMaxDepth(settings.MaxDepth),
+		WithTaskMaxSubtasks(settings.MaxSubtasks),
+		WithTaskMaxIterations(settings.MaxIterations),
+		WithTaskToolCalling(settings.ToolCalling),
+	)
+}
+
+// CreateLayers defines the Glazed parameter layers for the TaskDecompositionAgent.
+func (f *TaskDecompositionAgentFactory) CreateLayers() ([]layers.ParameterLayer, error) {
+	definitions := []*parameters.ParameterDefinition{
+		parameters.NewParameterDefinition(
+			"max-depth",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Maximum number of decomposition levels below the root task"),
+			parameters.WithDefault(2),
+		),
+		parameters.NewParameterDefinition(
+			"max-subtasks",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Maximum number of subtasks a task is split into"),
+			parameters.WithDefault(5),
+		),
+		parameters.NewParameterDefinition(
+			"max-iterations",
+			parameters.ParameterTypeInteger,
+			parameters.WithHelp("Maximum number of ReAct iterations to execute a single task"),
+			parameters.WithDefault(10),
+		),
+		parameters.NewParameterDefinition(
+			"tool-calling",
+			parameters.ParameterTypeBool,
+			parameters.WithHelp("Use the provider's native tool calling to execute tasks (falls back to text parsing if unsupported)"),
+			parameters.WithDefault(true),
+		),
+	}
+	definitions = append(definitions, toolExecutionParameterDefinitions()...)
+
+	agentLayer, err := layers.NewParameterLayer(
+		TaskDecompositionAgentType,
+		"Task decomposition agent configuration",
+		layers.WithParameterDefinitions(definitions...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return []layers.ParameterLayer{agentLayer}, nil
+}
+
+// TaskNode is a task of the task graph. NIDs are hierarchical: the subtasks of the
+// root task are 1, 2, ..., those of task 2 are 2.1, 2.2, ...
+type TaskNode struct {
+	ID       string `json:"id"`
+	NID      string `json:"nid"`
+	NodeType string `json:"node_type"`
+	TaskType string `json:"task_type"`
+	Goal     string `json:"goal"`
+	Layer    int    `json:"layer"`
+	// DependsOn are the NIDs of the sibling tasks whose results this task needs
+	DependsOn []string    `json:"depends_on,omitempty"`
+	Result    string      `json:"result,omitempty"`
+	Subtasks  []*TaskNode `json:"subtasks,omitempty"`
+
+	outer  *TaskNode
+	status string
+}
+
+// TaskDecompositionAgent recursively splits a goal into subtasks of type retrieval,
+// reasoning or composition, executes the leaf tasks with a ReAct agent and aggregates
+// the results of subtasks into the result of their parent task. The task graph is
+// published as node and edge events.
+type TaskDecompositionAgent struct {
+	*BaseAgent
+	LLM           llm.LLM
+	SystemPrompt  string
+	MaxDepth      int
+	MaxSubtasks   int
+	MaxIterations int  // Maximum ReAct iterations per executed task
+	ToolCalling   bool // Use native tool calling to execute tasks
+	step          int
+	// Optional event bus
+	eventBus *eventbus.EventBus
+}
+
+// TaskDecompositionAgentOption defines functional options.
+type TaskDecompositionAgentOption func(*TaskDecompositionAgent)
+
+// WithTaskLLM sets the LLM used to plan, execute and aggregate tasks.
+func WithTaskLLM(llm llm.LLM) TaskDecompositionAgentOption {
+	return func(a *TaskDecompositionAgent) {
+		a.LLM = llm
+	}
+}
+
+// WithTaskSystemPrompt sets the system prompt prepended to the prompts of executed tasks.
+func WithTaskSystemPrompt(prompt string) TaskDecompositionAgentOption {
+	return func(a *TaskDecompositionAgent) {
+		a.SystemPrompt = prompt
+	}
+}
+
+// WithTaskTools sets the tools available to executed tasks.
+func WithTaskTools(toolExecutor *tools.ToolExecutor) TaskDecompositionAgentOption {
+	return func(a *TaskDecompositionAgent) {
+		a.tools = toolExecutor
+	}
+}
+
+// WithTaskMaxDepth sets the maximum number of decomposition levels.
+func WithTaskMaxDepth(maxDepth int) TaskDecompositionAgentOption {
+	return func(a *TaskDecompositionAgent) {
+		a.MaxDepth = maxDepth
+	}
+}
+
+// WithTaskMaxSubtasks sets the maximum number of subtasks per task.
+func WithTaskMaxSubtasks(maxSubtasks int) TaskDecompositionAgentOption {
+	return func(a *TaskDecompositionAgent) {
+		a.MaxSubtasks = maxSubtasks
+	}
+}
+
+// WithTaskMaxIterations sets the maximum number of ReAct iterations per executed task.
+func WithTaskMaxIterations(maxIter int) TaskDecompositionAgentOption {
+	return func(a *TaskDecompositionAgent) {
+		a.MaxIterations = maxIter
+	}
+}
+
+// WithTaskToolCalling enables native tool calling for executed tasks.
+func WithTaskToolCalling(enabled bool) TaskDecompositionAgentOption {
+	return func(a *TaskDecompositionAgent) {
+		a.ToolCalling = enabled
+	}
+}
+
+// WithTaskEventBus configures the agent with an event bus.
+func WithTaskEventBus(eb *eventbus.EventBus) TaskDecompositionAgentOption {
+	return func(a *TaskDecompositionAgent) {
+		a.eventBus = eb
+	}
+}
+
+// SetEventBus attaches an event bus after the agent has been created.
+func (a *TaskDecompositionAgent) SetEventBus(eb *eventbus.EventBus) {
+	WithTaskEventBus(eb)(a)
+}
+
+// NewTaskDecompositionAgent creates a new TaskDecompositionAgent.
+func NewTaskDecompositionAgent(options ...TaskDecompositionAgentOption) (*TaskDecompositionAgent, error) {
+	a := &TaskDecompositionAgent{
+		MaxDepth:      2,
+		MaxSubtasks:   5,
+		MaxIterations: 10,
+		ToolCalling:   true,
+		BaseAgent: &BaseAgent{
+			tools: tools.NewToolExecutor(),
+		},
+	}
+	for _, option := range options {
+		option(a)
+	}
+
+	if a.LLM == nil {
+		return nil, errors.New("LLM must be provided")
+	}
+	if a.tools == nil {
+		a.tools = tools.NewToolExecutor()
+	}
+	if a.MaxSubtasks < 1 {
+		return nil, errors.New("max-subtasks must be at least 1")
+	}
+
+	return a, nil
+}
+
+// Run decomposes the goal into a task graph, executes it and returns the result of
+// the root task.
+func (a *TaskDecompositionAgent) Run(ctx context.Context, goal string) (string, error) {
+	a.step = 0
+	ctx = execctx.WithAgentClass(ctx, TaskDecompositionAgentClass)
+	root := &TaskNode{
+		ID:       uuid.New().String(),
+		NodeType: PlanNodeType,
+		TaskType: TaskTypeComposition,
+		Goal:     goal,
+	}
+	if a.MaxDepth <= 0 {
+		root.NodeType = ExecuteNodeType
+	}
+	a.emitNodeCreated(ctx, root, root)
+
+	if err := a.solve(ctx, root, root, nil); err != nil {
+		return "", err
+	}
+	return root.Result, nil
+}
+
+// solve plans, executes or aggregates node. dependencies are the finished sibling
+// tasks node depends on.
+func (a *TaskDecompositionAgent) solve(ctx context.Context, node, root *TaskNode, dependencies []*TaskNode) error {
+	a.setStatus(ctx, node, NodeStatusReady)
+
+	if node.NodeType == PlanNodeType {
+		a.setStatus(ctx, node, NodeStatusPlanning)
+		subtasks, err := a.plan(ctx, node, root, dependencies)
+		if err != nil {
+			a.setStatus(ctx, node, NodeStatusFailed)
+			return err
+		}
+		if len(subtasks) > 0 {
+			a.addSubtasks(ctx, node, root, subtasks)
+			a.setStatus(ctx, node, NodeStatusDoing)
+			return a.solveSubtasks(ctx, node, root, dependencies)
+		}
+		log.Info().Ctx(ctx).Str("nid", node.NID).Msg("Task is simple enough to be executed directly")
+	}
+
+	a.setStatus(ctx, node, NodeStatusDoing)
+	result, err := a.execute(ctx, node, root, dependencies)
+	if err != nil {
+		a.setStatus(ctx, node, NodeStatusFailed)
+		return errors.Wrapf(err, "failed to execute task %s", displayNID(node))
+	}
+	a.finish(ctx, node, "execute", result)
+	return nil
+}
+
+// solveSubtasks solves the subtasks of node in order, then aggregates their results
+func (a *TaskDecompositionAgent) solveSubtasks(ctx context.Context, node, root *TaskNode, dependencies []*TaskNode) error {
+	byNID := map[string]*TaskNode{}
+	for _, subtask := range node.Subtasks {
+		var subtaskDependencies []*TaskNode
+		for _, nid := range subtask.DependsOn {
+			subtaskDependencies = append(subtaskDependencies, byNID[nid])
+		}
+		if err := a.solve(ctx, subtask, root, subtaskDependencies); err != nil {
+			a.setStatus(ctx, node, NodeStatusFailed)
+			return err
+		}
+		byNID[subtask.NID] = subtask
+	}
+
+	result, err := a.aggregate(ctx, node, root, dependencies)
+	if err != nil {
+		a.setStatus(ctx, node, NodeStatusFailed)
+		return errors.Wrapf(err, "failed to aggregate the results of task %s", displayNID(node))
+	}
+	a.finish(ctx, node, "aggregate", result)
+	return nil
+}
+
+// plannedSubtask is a subtask as returned by the planner
+type plannedSubtask struct {
+	ID         int    `json:"id"`
+	TaskType   string `json:"task_type"`
+	Goal       string `json:"goal"`
+	Dependency []int  `json:"dependency"`
+}
+
+type plannerResponse struct {
+	Subtasks []plannedSubtask `json:"subtasks"`
+}
+
+// plan asks the LLM to split node into subtasks. It returns no subtasks if the task
+// can be executed directly, or if the plan can't be parsed.
+func (a *TaskDecompositionAgent) plan(ctx context.Context, node, root *TaskNode, dependencies []*TaskNode) ([]plannedSubtask, error) {
+	a.step++
+	ctx = execctx.WithAction(execctx.WithStep(ctx, a.step, node.ID), "plan")
+	messages := []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleSystem, a.plannerPrompt()),
+		conversation.NewChatMessage(conversation.RoleUser, a.taskDescription(node, root, dependencies)),
+	}
+	response, err := a.LLM.Generate(ctx, messages)
+	if err != nil {
+		return nil, errors.Wrapf(err, "LLM call failed while planning task %s", displayNID(node))
+	}
+
+	content := llm.MessageText(response)
+	var parsed plannerResponse
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		log.Warn().Ctx(ctx).Str("nid", node.NID).Str("response", content).Msg("Planner response contains no JSON object, executing the task directly")
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &parsed); err != nil {
+		log.Warn().Ctx(ctx).Err(err).Str("nid", node.NID).Msg("Failed to parse plan, executing the task directly")
+		return nil, nil
+	}
+
+	subtasks := parsed.Subtasks
+	if len(subtasks) > a.MaxSubtasks {
+		log.Warn().Ctx(ctx).Str("nid", node.NID).Int("subtasks", len(subtasks)).Int("max", a.MaxSubtasks).Msg("Plan has too many subtasks, dropping the last ones")
+		subtasks = subtasks[:a.MaxSubtasks]
+	}
+	// A single subtask doing the whole task is no decomposition
+	if len(subtasks) == 1 {
+		return nil, nil
+	}
+	a.emitPlanReceived(ctx, node, subtasks)
+	return subtasks, nil
+}
+
+// addSubtasks creates the nodes of the subtasks of node. Dependencies on subtasks that
+// are not listed before the dependent subtask are dropped, so that subtasks can be
+// solved in order.
+func (a *TaskDecompositionAgent) addSubtasks(ctx context.Context, node, root *TaskNode, subtasks []plannedSubtask) {
+	nidByID := map[int]string{}
+	for i, planned := range subtasks {
+		subtask := &TaskNode{
+			ID:       uuid.New().String(),
+			NID:      childNID(node, i+1),
+			NodeType: PlanNodeType,
+			TaskType: normalizeTaskType(planned.TaskType, node.TaskType),
+			Goal:     planned.Goal,
+			Layer:    node.Layer + 1,
+			outer:    node,
+		}
+		if subtask.Layer >= a.MaxDepth {
+			subtask.NodeType = ExecuteNodeType
+		}
+		for _, dep := range planned.Dependency {
+			nid, ok := nidByID[dep]
+			if !ok {
+				log.Warn().Ctx(ctx).Str("nid", subtask.NID).Int("dependency", dep).Msg("Ignoring dependency on a later or unknown subtask")
+				continue
+			}
+			subtask.DependsOn = append(subtask.DependsOn, nid)
+		}
+		nidByID[planned.ID] = subtask.NID
+		node.Subtasks = append(node.Subtasks, subtask)
+
+		a.emitNodeCreated(ctx, subtask, root)
+		a.emitNodeAdded(ctx, node, subtask)
+	}
+
+	edgeCount := 0
+	byNID := map[string]*TaskNode{}
+	for _, subtask := range node.Subtasks {
+		for _, nid := range subtask.DependsOn {
+			a.emitEdgeAdded(ctx, node, byNID[nid], subtask)
+			edgeCount++
+		}
+		byNID[subtask.NID] = subtask
+	}
+	a.emitInnerGraphBuilt(ctx, node, edgeCount)
+}
+
+// execute runs node with a ReAct agent sharing the tools of this agent. The iterations
+// of the ReAct agent are numbered after the execute step.
+func (a *TaskDecompositionAgent) execute(ctx context.Context, node, root *TaskNode, dependencies []*TaskNode) (string, error) {
+	a.step++
+	ctx = execctx.WithAction(execctx.WithStep(ctx, a.step, node.ID), "execute")
+	executor, err := NewReActAgent(
+		WithLLM(a.LLM),
+		WithSystemPrompt(a.executorPrompt(node)),
+		WithTools(a.tools),
+		WithMaxIterations(a.MaxIterations),
+		WithToolCalling(a.ToolCalling),
+		WithNodeID(node.ID),
+		WithStepOffset(a.step),
+	)
+	if err != nil {
+		return "", err
+	}
+	if a.eventBus != nil {
+		executor.SetEventBus(a.eventBus)
+	}
+	result, err := executor.Run(ctx, a.taskDescription(node, root, dependencies))
+	// The iterations of the executor are steps of this agent
+	a.step += executor.currentIteration
+	return result, err
+}
+
+// aggregate combines the results of the subtasks of node into its result
+func (a *TaskDecompositionAgent) aggregate(ctx context.Context, node, root *TaskNode, dependencies []*TaskNode) (string, error) {
+	a.step++
+	ctx = execctx.WithAction(execctx.WithStep(ctx, a.step, node.ID), "aggregate")
+	var sb strings.Builder
+	sb.WriteString(a.taskDescription(node, root, dependencies))
+	sb.WriteString("\nThe task was split into subtasks with the following results:\n")
+	for _, subtask := range node.Subtasks {
+		fmt.Fprintf(&sb, "\n## Subtask %s (%s): %s\n%s\n", subtask.NID, subtask.TaskType, subtask.Goal, subtask.Result)
+	}
+
+	instruction := "Combine the results of the subtasks into the result of the task. Answer with the result only."
+	if node.TaskType == TaskTypeComposition {
+		instruction = "Write the complete text requested by the task, using the results of the subtasks. Answer with the text only."
+	}
+	if a.SystemPrompt != "" {
+		instruction = a.SystemPrompt + "\n\n" + instruction
+	}
+
+	response, err := a.LLM.Generate(ctx, []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleSystem, instruction),
+		conversation.NewChatMessage(conversation.RoleUser, sb.String()),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "LLM call failed during aggregation")
+	}
+	return llm.MessageText(response), nil
+}
+
+func (a *TaskDecompositionAgent) finish(ctx context.Context, node *TaskNode, actionName, result string) {
+	node.Result = result
+	a.emitNodeResultAvailable(ctx, node, actionName)
+	a.setStatus(ctx, node, NodeStatusFinish)
+}
+
+func (a *TaskDecompositionAgent) plannerPrompt() string {
+	var sb strings.Builder
+	sb.WriteString(`You split tasks into subtasks. Each subtask has one of the following task types:
+- RETRIEVAL: gather information, for example by searching
+- REASONING: analyse, compare or draw conclusions from information
+- COMPOSITION: write text
+`)
+	if allTools := a.tools.GetAllTools(); len(allTools) > 0 {
+		sb.WriteString("\nThe subtasks can use the following tools:\n")
+		for _, tool := range allTools {
+			fmt.Fprintf(&sb, "- %s: %s\n", tool.Name(), tool.Description())
+		}
+	}
+	fmt.Fprintf(&sb, `
+Answer with a JSON object listing at most %d subtasks:
+
+{"subtasks": [{"id": 1, "task_type": "RETRIEVAL", "goal": "...", "dependency": []}, {"id": 2, "task_type": "COMPOSITION", "goal": "...", "dependency": [1]}]}
+
+"dependency" lists the ids of earlier subtasks whose results the subtask needs.
+If the task is simple enough to be done in one step, answer {"subtasks": []}.
+`, a.MaxSubtasks)
+	return sb.String()
+}
+
+func (a *TaskDecompositionAgent) executorPrompt(node *TaskNode) string {
+	var instruction string
+	switch node.TaskType {
+	case TaskTypeRetrieval:
+		instruction = "Gather the information requested by the task, using the available tools if needed. Report the facts you found and their sources."
+	case TaskTypeReasoning:
+		instruction = "Reason carefully about the task and give your conclusions."
+	default:
+		instruction = "Write the text requested by the task."
+	}
+	prompt := instruction + "\nWhen you are done, answer with the result of the task, prefixed with \"Final Answer:\"."
+	if a.SystemPrompt != "" {
+		prompt = a.SystemPrompt + "\n\n" + prompt
+	}
+	return prompt
+}
+
+// taskDescription describes node, its place in the overall goal and the results of
+// the tasks it depends on
+func (a *TaskDecompositionAgent) taskDescription(node, root *TaskNode, dependencies []*TaskNode) string {
+	var sb strings.Builder
+	if node != root {
+		fmt.Fprintf(&sb, "Overall goal: %s\n", root.Goal)
+		for outer := node.outer; outer != nil && outer != root; outer = outer.outer {
+			fmt.Fprintf(&sb, "Part of task %s: %s\n", outer.NID, outer.Goal)
+		}
+	}
+	fmt.Fprintf(&sb, "Task (%s): %s\n", node.TaskType, node.Goal)
+	if len(dependencies) > 0 {
+		sb.WriteString("\nResults of the tasks this task depends on:\n")
+		for _, dep := range dependencies {
+			fmt.Fprintf(&sb, "\n## Task %s: %s\n%s\n", dep.NID, dep.Goal, dep.Result)
+		}
+	}
+	return sb.String()
+}
+
+func childNID(node *TaskNode, index int) string {
+	if node.NID == "" {
+		return strconv.Itoa(index)
+	}
+	return node.NID + "." + strconv.Itoa(index)
+}
+
+func displayNID(node *TaskNode) string {
+	if node.NID == "" {
+		return "root"
+	}
+	return node.NID
+}
+
+func normalizeTaskType(taskType, fallback string) string {
+	switch t := strings.ToUpper(strings.TrimSpace(taskType)); t {
+	case TaskTypeComposition, TaskTypeReasoning, TaskTypeRetrieval:
+		return t
+	default:
+		return fallback
+	}
+}
+
+func (a *TaskDecompositionAgent) setStatus(ctx context.Context, node *TaskNode, status string) {
+	oldStatus := node.status
+	if oldStatus == "" {
+		oldStatus = NodeStatusNotReady
+	}
+	node.status = status
+	if a.eventBus == nil || oldStatus == status {
+		return
+	}
+	payload := &events.NodeStatusChangePayload{
+		NodeId:    node.ID,
+		NodeGoal:  node.Goal,
+		OldStatus: oldStatus,
+		NewStatus: status,
+		Step:      ptr(int32(a.step)),
+	}
+	if err := a.eventBus.EmitNodeStatusChanged(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit NodeStatusChanged event")
+	}
+}
+
+func (a *TaskDecompositionAgent) emitNodeCreated(ctx context.Context, node, root *TaskNode) {
+	if a.eventBus == nil {
+		return
+	}
+	payload := &events.NodeCreatedPayload{
+		NodeId:            node.ID,
+		NodeNid:           node.NID,
+		NodeType:          node.NodeType,
+		TaskType:          node.TaskType,
+		TaskGoal:          node.Goal,
+		Layer:             int32(node.Layer),
+		RootNodeId:        root.ID,
+		InitialParentNids: node.DependsOn,
+		Step:              ptr(int32(a.step)),
+	}
+	if node.outer != nil {
+		payload.OuterNodeId = ptr(node.outer.ID)
+	}
+	if err := a.eventBus.EmitNodeCreated(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit NodeCreated event")
+	}
+}
+
+func (a *TaskDecompositionAgent) emitPlanReceived(ctx context.Context, node *TaskNode, subtasks []plannedSubtask) {
+	if a.eventBus == nil {
+		return
+	}
+	rawPlan, err := toStruct(plannerResponse{Subtasks: subtasks})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to convert plan for PlanReceived event")
+		return
+	}
+	payload := &events.PlanReceivedPayload{
+		NodeId:   node.ID,
+		RawPlan:  rawPlan,
+		Step:     ptr(int32(a.step)),
+		TaskType: ptr(node.TaskType),
+		TaskGoal: ptr(node.Goal),
+	}
+	if err := a.eventBus.EmitPlanReceived(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit PlanReceived event")
+	}
+}
+
+func (a *TaskDecompositionAgent) emitNodeAdded(ctx context.Context, owner, node *TaskNode) {
+	if a.eventBus == nil {
+		return
+	}
+	payload := &events.NodeAddedPayload{
+		GraphOwnerNodeId: owner.ID,
+		AddedNodeId:      node.ID,
+		AddedNodeNid:     node.NID,
+		Step:             ptr(int32(a.step)),
+		TaskType:         ptr(node.TaskType),
+		TaskGoal:         ptr(node.Goal),
+	}
+	if err := a.eventBus.EmitNodeAdded(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit NodeAdded event")
+	}
+}
+
+func (a *TaskDecompositionAgent) emitEdgeAdded(ctx context.Context, owner, parent, child *TaskNode) {
+	if a.eventBus == nil {
+		return
+	}
+	payload := &events.EdgeAddedPayload{
+		GraphOwnerNodeId: owner.ID,
+		ParentNodeId:     parent.ID,
+		ChildNodeId:      child.ID,
+		ParentNodeNid:    parent.NID,
+		ChildNodeNid:     child.NID,
+		Step:             ptr(int32(a.step)),
+		TaskType:         ptr(owner.TaskType),
+		TaskGoal:         ptr(owner.Goal),
+	}
+	if err := a.eventBus.EmitEdgeAdded(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit EdgeAdded event")
+	}
+}
+
+func (a *TaskDecompositionAgent) emitInnerGraphBuilt(ctx context.Context, node *TaskNode, edgeCount int) {
+	if a.eventBus == nil {
+		return
+	}
+	nodeIDs := make([]string, 0, len(node.Subtasks))
+	for _, subtask := range node.Subtasks {
+		nodeIDs = append(nodeIDs, subtask.ID)
+	}
+	payload := &events.InnerGraphBuiltPayload{
+		NodeId:    node.ID,
+		NodeCount: int32(len(node.Subtasks)),
+		EdgeCount: int32(edgeCount),
+		NodeIds:   nodeIDs,
+		Step:      ptr(int32(a.step)),
+		TaskType:  ptr(node.TaskType),
+		TaskGoal:  ptr(node.Goal),
+	}
+	if err := a.eventBus.EmitInnerGraphBuilt(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit InnerGraphBuilt event")
+	}
+}
+
+func (a *TaskDecompositionAgent) emitNodeResultAvailable(ctx context.Context, node *TaskNode, actionName string) {
+	if a.eventBus == nil {
+		return
+	}
+	payload := &events.NodeResultAvailablePayload{
+		NodeId:        node.ID,
+		ActionName:    actionName,
+		ResultSummary: node.Result,
+		Step:          ptr(int32(a.step)),
+		TaskType:      ptr(node.TaskType),
+		TaskGoal:      ptr(node.Goal),
+	}
+	if err := a.eventBus.EmitNodeResultAvailable(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit NodeResultAvailable event")
+	}
+}
+
+// toStruct converts v to a protobuf Struct through its JSON representation
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(m)
+}
+
+// Ensure TaskDecompositionAgent implements the relevant agent interfaces
+var _ Agent = (*TaskDecompositionAgent)(nil)
+var _ EventEmitter = (*TaskDecompositionAgent)(nil)
+var _ ToolApprovalRequester = (*TaskDecompositionAgent)(nil)
@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	events "github.com/go-go-golems/go-go-agent/proto"
+)
+
+// eventRecorder is a publisher decoding and keeping the events published to it
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []*events.Event
+}
+
+func (r *eventRecorder) Publish(topic string, messages ...*message.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range messages {
+		event, err := codec.DecodeMessage(msg)
+		if err != nil {
+			return err
+		}
+		r.events = append(r.events, event)
+	}
+	return nil
+}
+
+func (r *eventRecorder) Close() error {
+	return nil
+}
+
+func newTestEventBus(t *testing.T) (*eventbus.EventBus, *eventRecorder) {
+	t.Helper()
+	recorder := &eventRecorder{}
+	eb, err := eventbus.NewEventBus(eventbus.WithPublisher(recorder), eventbus.WithTopic("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return eb, recorder
+}
+
+func TestAddSubtasks(t *testing.T) {
+	a, err := NewTaskDecompositionAgent(WithTaskLLM(&scriptedLLM{}), WithTaskMaxDepth(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eb, recorder := newTestEventBus(t)
+	a.eventBus = eb
+
+	root := &TaskNode{ID: "root", NodeType: PlanNodeType, TaskType: TaskTypeComposition}
+	a.addSubtasks(context.Background(), root, root, []plannedSubtask{
+		{ID: 1, TaskType: "RETRIEVAL", Goal: "find facts", Dependency: []int{3}},
+		{ID: 2, TaskType: "reasoning", Goal: "analyse facts", Dependency: []int{1}},
+		{ID: 3, TaskType: "unknown", Goal: "write report", Dependency: []int{1, 2, 7}},
+	})
+
+	testCases := []struct {
+		nid       string
+		taskType  string
+		dependsOn []string
+	}{
+		// Dependencies on later subtasks are dropped, so that subtasks run in order
+		{"1", TaskTypeRetrieval, nil},
+		{"2", TaskTypeReasoning, []string{"1"}},
+		// Unknown task types default to the type of the parent, unknown ids are dropped
+		{"3", TaskTypeComposition, []string{"1", "2"}},
+	}
+	if len(root.Subtasks) != len(testCases) {
+		t.Fatalf("got %d subtasks, want %d", len(root.Subtasks), len(testCases))
+	}
+	for i, tc := range testCases {
+		subtask := root.Subtasks[i]
+		if subtask.NID != tc.nid || subtask.TaskType != tc.taskType || !reflect.DeepEqual(subtask.DependsOn, tc.dependsOn) {
+			t.Errorf("subtask %d = %s %s %v, want %s %s %v", i, subtask.NID, subtask.TaskType, subtask.DependsOn, tc.nid, tc.taskType, tc.dependsOn)
+		}
+		if subtask.Layer != 1 || subtask.NodeType != PlanNodeType {
+			t.Errorf("subtask %s is a %s node of layer %d, want a plan node of layer 1", subtask.NID, subtask.NodeType, subtask.Layer)
+		}
+	}
+
+	// One edge per kept dependency, from the subtask depended on to the dependent one
+	var edges [][2]string
+	for _, event := range recorder.events {
+		if edge := event.GetEdgeAdded(); edge != nil {
+			edges = append(edges, [2]string{edge.ParentNodeId, edge.ChildNodeId})
+		}
+	}
+	s := root.Subtasks
+	expected := [][2]string{{s[0].ID, s[1].ID}, {s[0].ID, s[2].ID}, {s[1].ID, s[2].ID}}
+	if !reflect.DeepEqual(edges, expected) {
+		t.Errorf("edges = %v, want %v", edges, expected)
+	}
+}
+
+func TestTaskDecompositionEvents(t *testing.T) {
+	model := &scriptedLLM{responses: [][]*conversation.Message{
+		textMessage(`{"subtasks": [{"id": 1, "task_type": "RETRIEVAL", "goal": "find facts", "dependency": []}, {"id": 2, "task_type": "COMPOSITION", "goal": "write report", "dependency": [1]}]}`),
+		// Subtask 1 calls the tool, then answers
+		textMessage("Thought: I need facts\nAction: echo[facts]"),
+		textMessage("Final Answer: the facts"),
+		// Subtask 2 answers right away
+		textMessage("Final Answer: the report"),
+		// Aggregation of the root task
+		textMessage("the final report"),
+	}}
+	executor := tools.NewToolExecutor()
+	executor.AddTool(&echoTool{})
+	eb, recorder := newTestEventBus(t)
+	a, err := NewTaskDecompositionAgent(
+		WithTaskLLM(model),
+		WithTaskTools(executor),
+		WithTaskMaxDepth(1),
+		WithTaskEventBus(eb),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := a.Run(context.Background(), "write a report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "the final report" {
+		t.Errorf("result = %q, want the aggregated result", result)
+	}
+
+	// Events are compared by type, step and node, with the nodes labelled by NID
+	type eventSummary struct {
+		eventType events.EventType
+		step      int32
+		node      string
+	}
+	labels := map[string]string{}
+	for _, event := range recorder.events {
+		if created := event.GetNodeCreated(); created != nil {
+			labels[created.NodeId] = "root"
+			if created.NodeNid != "" {
+				labels[created.NodeId] = created.NodeNid
+			}
+		}
+	}
+	var got []eventSummary
+	for _, event := range recorder.events {
+		step, _ := event.GetStep()
+		nodeID, _ := event.GetNodeID()
+		got = append(got, eventSummary{event.EventType, step, labels[nodeID]})
+	}
+
+	// Plan is step 1, the executions of subtasks 1 and 2 are steps 2 and 5 and the
+	// aggregation is step 7. The iterations of the ReAct agents executing the subtasks
+	// are the steps 3, 4 and 6.
+	expected := []eventSummary{
+		{events.EventType_EVENT_TYPE_NODE_CREATED, 0, "root"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 0, "root"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 0, "root"},
+		{events.EventType_EVENT_TYPE_PLAN_RECEIVED, 1, "root"},
+		{events.EventType_EVENT_TYPE_NODE_CREATED, 1, "1"},
+		{events.EventType_EVENT_TYPE_NODE_ADDED, 1, "1"},
+		{events.EventType_EVENT_TYPE_NODE_CREATED, 1, "2"},
+		{events.EventType_EVENT_TYPE_NODE_ADDED, 1, "2"},
+		{events.EventType_EVENT_TYPE_EDGE_ADDED, 1, "2"},
+		{events.EventType_EVENT_TYPE_INNER_GRAPH_BUILT, 1, "root"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 1, "root"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 1, "1"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 1, "1"},
+		{events.EventType_EVENT_TYPE_STEP_STARTED, 3, "1"},
+		{events.EventType_EVENT_TYPE_TOOL_INVOKED, 3, "1"},
+		{events.EventType_EVENT_TYPE_TOOL_RETURNED, 3, "1"},
+		{events.EventType_EVENT_TYPE_STEP_FINISHED, 3, "1"},
+		{events.EventType_EVENT_TYPE_STEP_STARTED, 4, "1"},
+		{events.EventType_EVENT_TYPE_STEP_FINISHED, 4, "1"},
+		{events.EventType_EVENT_TYPE_NODE_RESULT_AVAILABLE, 4, "1"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 4, "1"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 4, "2"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 4, "2"},
+		{events.EventType_EVENT_TYPE_STEP_STARTED, 6, "2"},
+		{events.EventType_EVENT_TYPE_STEP_FINISHED, 6, "2"},
+		{events.EventType_EVENT_TYPE_NODE_RESULT_AVAILABLE, 6, "2"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 6, "2"},
+		{events.EventType_EVENT_TYPE_NODE_RESULT_AVAILABLE, 7, "root"},
+		{events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, 7, "root"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("events =\n%v\nwant\n%v", got, expected)
+	}
+}
@@ -0,0 +1,323 @@
+// Package cassette records the LLM and tool calls of agent runs to a file and replays
+// them deterministically, so that agents can be run without network access.
+package cassette
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Mode tells whether a cassette records or replays calls
+type Mode string
+
+const (
+	// ModeRecord forwards calls and writes them to the cassette
+	ModeRecord Mode = "record"
+	// ModeReplay answers calls from the cassette without forwarding them
+	ModeReplay Mode = "replay"
+)
+
+// Modes lists the supported cassette modes
+var Modes = []string{string(ModeRecord), string(ModeReplay)}
+
+// MatchMode selects how replayed calls are matched with recorded interactions
+type MatchMode string
+
+const (
+	// MatchExact requires the same messages, or the same tool input
+	MatchExact MatchMode = "exact"
+	// MatchLastMessage only compares the last message of LLM calls. Tool and
+	// embedding inputs are compared exactly.
+	MatchLastMessage MatchMode = "last-message"
+	// MatchFuzzy picks the most similar interaction, if it is similar enough
+	MatchFuzzy MatchMode = "fuzzy"
+)
+
+// MatchModes lists the supported match modes
+var MatchModes = []string{string(MatchExact), string(MatchLastMessage), string(MatchFuzzy)}
+
+// DefaultFuzzyThreshold is the minimum similarity of a fuzzy match
+const DefaultFuzzyThreshold = 0.8
+
+// Kinds of interactions
+const (
+	KindGenerate          = "generate"
+	KindGenerateWithTools = "generate_with_tools"
+	KindEmbedding         = "embedding"
+	KindTool              = "tool"
+)
+
+// Interaction is a recorded call and its result
+type Interaction struct {
+	Kind string `json:"kind" yaml:"kind"`
+	// Messages are the messages sent to the LLM
+	Messages []llm.MessageRecord `json:"messages,omitempty" yaml:"messages,omitempty"`
+	// Tools are the names of the tools advertised to the LLM
+	Tools []string `json:"tools,omitempty" yaml:"tools,omitempty"`
+	// Response are the messages returned by the LLM
+	Response []llm.MessageRecord `json:"response,omitempty" yaml:"response,omitempty"`
+	// Tool is the name of the called tool
+	Tool string `json:"tool,omitempty" yaml:"tool,omitempty"`
+	// Input is the input of the tool, or the embedded text
+	Input string `json:"input,omitempty" yaml:"input,omitempty"`
+	// Output is the result of the tool
+	Output    string    `json:"output,omitempty" yaml:"output,omitempty"`
+	Embedding []float32 `json:"embedding,omitempty" yaml:"embedding,omitempty"`
+	// Error is the error returned by the call
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Cassette holds the interactions of a run. Cassettes are YAML files, or JSON files if
+// the file name ends in .json. It is safe for concurrent use.
+type Cassette struct {
+	path           string
+	mode           Mode
+	match          MatchMode
+	fuzzyThreshold float64
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+type cassetteFile struct {
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Option configures a Cassette
+type Option func(*Cassette)
+
+// WithMatchMode sets how replayed calls are matched. Defaults to MatchExact.
+func WithMatchMode(match MatchMode) Option {
+	return func(c *Cassette) {
+		c.match = match
+	}
+}
+
+// WithFuzzyThreshold sets the minimum similarity, between 0 and 1, of fuzzy matches
+func WithFuzzyThreshold(threshold float64) Option {
+	return func(c *Cassette) {
+		c.fuzzyThreshold = threshold
+	}
+}
+
+// ParseMode parses a cassette mode name
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case ModeRecord, ModeReplay:
+		return mode, nil
+	default:
+		return "", errors.Errorf("invalid cassette mode '%s' (expected %s or %s)", s, ModeRecord, ModeReplay)
+	}
+}
+
+// ParseMatchMode parses a match mode name
+func ParseMatchMode(s string) (MatchMode, error) {
+	switch match := MatchMode(s); match {
+	case MatchExact, MatchLastMessage, MatchFuzzy:
+		return match, nil
+	default:
+		return "", errors.Errorf("invalid cassette match mode '%s' (expected %s, %s or %s)", s, MatchExact, MatchLastMessage, MatchFuzzy)
+	}
+}
+
+// Open opens the cassette at path. In record mode the cassette starts empty and the
+// file is overwritten as calls are recorded; in replay mode the file must exist.
+func Open(path string, mode Mode, options ...Option) (*Cassette, error) {
+	c := &Cassette{
+		path:           path,
+		mode:           mode,
+		match:          MatchExact,
+		fuzzyThreshold: DefaultFuzzyThreshold,
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	switch mode {
+	case ModeRecord:
+		return c, nil
+	case ModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read cassette %s", path)
+		}
+		var f cassetteFile
+		if isJSON(path) {
+			err = json.Unmarshal(data, &f)
+		} else {
+			err = yaml.Unmarshal(data, &f)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse cassette %s", path)
+		}
+		c.interactions = f.Interactions
+		c.used = make([]bool, len(f.Interactions))
+		return c, nil
+	default:
+		return nil, errors.Errorf("invalid cassette mode '%s'", mode)
+	}
+}
+
+// Mode returns the mode of the cassette
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Interactions returns the recorded interactions
+func (c *Cassette) Interactions() []*Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Interaction(nil), c.interactions...)
+}
+
+// record appends an interaction and rewrites the cassette file, so that the calls of
+// an interrupted run are kept.
+func (c *Cassette) record(interaction *Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, interaction)
+	c.used = append(c.used, true)
+	return c.save()
+}
+
+func (c *Cassette) save() error {
+	f := cassetteFile{Interactions: c.interactions}
+	var data []byte
+	var err error
+	if isJSON(c.path) {
+		data, err = json.MarshalIndent(f, "", "  ")
+	} else {
+		data, err = yaml.Marshal(f)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize cassette")
+	}
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.Wrapf(err, "failed to create directory of cassette %s", c.path)
+		}
+	}
+	return errors.Wrapf(os.WriteFile(c.path, data, 0644), "failed to write cassette %s", c.path)
+}
+
+// find returns the recorded interaction answering req. Unused interactions are
+// preferred, in recording order, so that repeated calls replay the recorded sequence.
+func (c *Cassette) find(req *Interaction) (*Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	best, bestUsed := -1, -1
+	bestScore, bestUsedScore := 0.0, 0.0
+	for i, interaction := range c.interactions {
+		score, ok := c.score(interaction, req)
+		if !ok {
+			continue
+		}
+		if !c.used[i] && (best == -1 || score > bestScore) {
+			best, bestScore = i, score
+		}
+		if c.used[i] && (bestUsed == -1 || score > bestUsedScore) {
+			bestUsed, bestUsedScore = i, score
+		}
+	}
+	if best == -1 {
+		best = bestUsed
+	}
+	if best == -1 {
+		return nil, errors.Errorf("no %s interaction in cassette %s matches %s", req.Kind, c.path, describe(req))
+	}
+	c.used[best] = true
+	return c.interactions[best], nil
+}
+
+// score tells whether interaction answers req, and how similar their inputs are
+func (c *Cassette) score(interaction, req *Interaction) (float64, bool) {
+	if interaction.Kind != req.Kind || interaction.Tool != req.Tool {
+		return 0, false
+	}
+
+	recorded, requested := interaction.Input, req.Input
+	if req.Kind == KindGenerate || req.Kind == KindGenerateWithTools {
+		if strings.Join(interaction.Tools, ",") != strings.Join(req.Tools, ",") {
+			return 0, false
+		}
+		if c.match == MatchLastMessage {
+			recorded, requested = lastMessage(interaction.Messages), lastMessage(req.Messages)
+		} else {
+			recorded, requested = renderMessages(interaction.Messages), renderMessages(req.Messages)
+		}
+	}
+
+	if c.match == MatchFuzzy {
+		score := similarity(recorded, requested)
+		return score, score >= c.fuzzyThreshold
+	}
+	return 1, recorded == requested
+}
+
+func renderMessages(messages []llm.MessageRecord) string {
+	lines := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		lines = append(lines, msg.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func lastMessage(messages []llm.MessageRecord) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	return messages[len(messages)-1].String()
+}
+
+// similarity is the Jaccard index of the sets of lowercased words of a and b
+func similarity(a, b string) float64 {
+	wordsA, wordsB := wordSet(a), wordSet(b)
+	if len(wordsA) == 0 && len(wordsB) == 0 {
+		return 1
+	}
+	common := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(wordsA)+len(wordsB)-common)
+}
+
+func wordSet(s string) map[string]bool {
+	ret := map[string]bool{}
+	for _, w := range strings.Fields(strings.ToLower(s)) {
+		ret[w] = true
+	}
+	return ret
+}
+
+func describe(req *Interaction) string {
+	var s string
+	switch req.Kind {
+	case KindGenerate, KindGenerateWithTools:
+		s = lastMessage(req.Messages)
+	case KindTool:
+		s = req.Tool + "(" + req.Input + ")"
+	default:
+		s = req.Input
+	}
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return "'" + s + "'"
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
@@ -0,0 +1,191 @@
+package cassette
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/go-go-agent/goagent/agent"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/pkg/errors"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// upperLLM answers with the uppercased text of the last message and counts its calls
+type upperLLM struct {
+	calls int
+}
+
+func (m *upperLLM) Generate(ctx context.Context, messages []*conversation.Message) (*conversation.Message, error) {
+	m.calls++
+	return conversation.NewChatMessage(conversation.RoleAssistant, "ANSWER TO "+llm.MessageText(messages[len(messages)-1])), nil
+}
+
+func (m *upperLLM) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	m.calls++
+	return []float32{float32(len(text))}, nil
+}
+
+// weatherTool reports the same weather for every location
+type weatherTool struct {
+	calls int
+}
+
+func (t *weatherTool) Name() string        { return "get_weather" }
+func (t *weatherTool) Description() string { return "Returns the weather of a location" }
+func (t *weatherTool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	return orderedmap.New[string, types.ParameterSchema]()
+}
+func (t *weatherTool) Execute(ctx context.Context, input string) (string, error) {
+	t.calls++
+	if input == "" {
+		return "", errors.New("missing location")
+	}
+	return "sunny in " + input, nil
+}
+
+func chat(role conversation.Role, text string) *conversation.Message {
+	return conversation.NewChatMessage(role, text)
+}
+
+// recordedCall is the conversation recorded by TestRecordReplay
+var recordedCall = []*conversation.Message{
+	chat(conversation.RoleSystem, "You are a weather agent."),
+	chat(conversation.RoleUser, "what is the weather like in paris today"),
+}
+
+func TestRecordReplay(t *testing.T) {
+	for _, name := range []string{"cassette.yaml", "cassette.json"} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), name)
+
+			recorder, err := Open(path, ModeRecord)
+			if err != nil {
+				t.Fatal(err)
+			}
+			model, tool := &upperLLM{}, &weatherTool{}
+			recordedLLM, recordedTool := recorder.WrapLLM(model), recorder.WrapTool(tool)
+			if _, err := recordedLLM.Generate(ctx, recordedCall); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := recordedLLM.GenerateEmbedding(ctx, "paris"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := recordedTool.Execute(ctx, "paris"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := recordedTool.Execute(ctx, ""); err == nil {
+				t.Fatal("tool without location succeeded")
+			}
+			if len(recorder.Interactions()) != 4 {
+				t.Fatalf("recorded %d interactions, want 4", len(recorder.Interactions()))
+			}
+
+			player, err := Open(path, ModeReplay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replayedLLM, replayedTool := player.WrapLLM(nil), player.WrapTool(&weatherTool{})
+			response, err := replayedLLM.Generate(ctx, recordedCall)
+			if err != nil || llm.MessageText(response) != "ANSWER TO what is the weather like in paris today" {
+				t.Errorf("replayed Generate() = %v, %v, want the recorded answer", response, err)
+			}
+			if embedding, err := replayedLLM.GenerateEmbedding(ctx, "paris"); err != nil || len(embedding) != 1 || embedding[0] != 5 {
+				t.Errorf("replayed GenerateEmbedding() = %v, %v, want [5]", embedding, err)
+			}
+			if output, err := replayedTool.Execute(ctx, "paris"); err != nil || output != "sunny in paris" {
+				t.Errorf("replayed tool = %q, %v, want the recorded output", output, err)
+			}
+			if _, err := replayedTool.Execute(ctx, ""); err == nil || err.Error() != "missing location" {
+				t.Errorf("replayed tool error = %v, want the recorded error", err)
+			}
+			if model.calls != 2 || tool.calls != 2 {
+				t.Errorf("replay called the LLM %d and the tool %d times after recording, want 2 and 2", model.calls, tool.calls)
+			}
+		})
+	}
+}
+
+func TestReplayMatchModes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	recorder, err := Open(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.WrapLLM(&upperLLM{}).Generate(context.Background(), recordedCall); err != nil {
+		t.Fatal(err)
+	}
+
+	otherSystemPrompt := []*conversation.Message{
+		chat(conversation.RoleSystem, "You are a helpful agent."),
+		chat(conversation.RoleUser, "what is the weather like in paris today"),
+	}
+	reworded := []*conversation.Message{
+		chat(conversation.RoleSystem, "You are a weather agent."),
+		chat(conversation.RoleUser, "what is the weather like in paris right now"),
+	}
+	otherQuestion := []*conversation.Message{
+		chat(conversation.RoleSystem, "You are a weather agent."),
+		chat(conversation.RoleUser, "book a flight to berlin"),
+	}
+
+	testCases := []struct {
+		match    MatchMode
+		messages []*conversation.Message
+		found    bool
+	}{
+		{MatchExact, recordedCall, true},
+		{MatchExact, otherSystemPrompt, false},
+		{MatchLastMessage, otherSystemPrompt, true},
+		{MatchLastMessage, reworded, false},
+		{MatchFuzzy, reworded, true},
+		{MatchFuzzy, otherQuestion, false},
+	}
+	for _, tc := range testCases {
+		player, err := Open(path, ModeReplay, WithMatchMode(tc.match))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = player.WrapLLM(nil).Generate(context.Background(), tc.messages)
+		if found := err == nil; found != tc.found {
+			t.Errorf("%s match of %q: found = %v (%v), want %v", tc.match, llm.MessageText(tc.messages[1]), found, err, tc.found)
+		}
+	}
+}
+
+func TestReplayFixture(t *testing.T) {
+	// testdata/weather.yaml holds a ReAct run calling get_weather with native tool
+	// calling. LLM calls are matched on their last message so that changes of the
+	// system prompt don't invalidate the cassette.
+	c, err := Open(filepath.Join("testdata", "weather.yaml"), ModeReplay, WithMatchMode(MatchLastMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := &weatherTool{}
+	executor := tools.NewToolExecutor()
+	executor.AddTool(c.WrapTool(tool))
+	a, err := agent.NewReActAgent(
+		agent.WithLLM(c.WrapLLM(nil)),
+		agent.WithTools(executor),
+		agent.WithToolCalling(true),
+		agent.WithMaxIterations(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := a.Run(context.Background(), "What is the weather in Paris?")
+	if err != nil {
+		t.Fatalf("replayed run failed: %v", err)
+	}
+	if answer != "It is sunny in Paris." {
+		t.Errorf("answer = %q, want the recorded answer", answer)
+	}
+	if tool.calls != 0 {
+		t.Errorf("replay executed the tool %d times", tool.calls)
+	}
+}
@@ -0,0 +1,200 @@
+package cassette
+
+import (
+	"context"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// LLM records the calls of a wrapped LLM to a cassette, or replays them from it
+type LLM struct {
+	cassette *Cassette
+	model    llm.LLM
+}
+
+var _ llm.StreamingLLM = (*LLM)(nil)
+var _ llm.ToolCallingLLM = (*LLM)(nil)
+var _ llm.ConfigurableLLM = (*LLM)(nil)
+
+// WrapLLM wraps model. In replay mode model is never called and may be nil.
+func (c *Cassette) WrapLLM(model llm.LLM) *LLM {
+	return &LLM{cassette: c, model: model}
+}
+
+// Generate records or replays a call of Generate
+func (l *LLM) Generate(ctx context.Context, messages []*conversation.Message) (*conversation.Message, error) {
+	return l.GenerateStream(ctx, messages, nil)
+}
+
+// GenerateStream records or replays a call of Generate. Replayed responses are reported
+// as a single delta.
+func (l *LLM) GenerateStream(ctx context.Context, messages []*conversation.Message, onDelta llm.DeltaHandler) (*conversation.Message, error) {
+	req, err := newLLMInteraction(KindGenerate, messages, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.cassette.mode == ModeReplay {
+		responses, err := l.replay(req)
+		if err != nil {
+			return nil, err
+		}
+		response := responses[len(responses)-1]
+		if onDelta != nil {
+			if text := llm.MessageText(response); text != "" {
+				onDelta(ctx, llm.Delta{Text: text, AccumulatedLength: len(text)})
+			}
+		}
+		return response, nil
+	}
+
+	if l.model == nil {
+		return nil, errors.New("cassette in record mode has no LLM to record")
+	}
+	response, err := llm.GenerateStream(ctx, l.model, messages, onDelta)
+	l.record(ctx, req, []*conversation.Message{response}, err)
+	return response, err
+}
+
+// GenerateWithTools records or replays a call of GenerateWithTools. Recording an LLM
+// without tool calling support records llm.ErrToolCallingNotSupported.
+func (l *LLM) GenerateWithTools(ctx context.Context, messages []*conversation.Message, agentTools []tools.Tool) ([]*conversation.Message, error) {
+	req, err := newLLMInteraction(KindGenerateWithTools, messages, agentTools)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.cassette.mode == ModeReplay {
+		return l.replay(req)
+	}
+
+	if l.model == nil {
+		return nil, errors.New("cassette in record mode has no LLM to record")
+	}
+	var responses []*conversation.Message
+	toolCallingLLM, ok := l.model.(llm.ToolCallingLLM)
+	if ok {
+		responses, err = toolCallingLLM.GenerateWithTools(ctx, messages, agentTools)
+	} else {
+		err = llm.ErrToolCallingNotSupported
+	}
+	l.record(ctx, req, responses, err)
+	return responses, err
+}
+
+// WithStepSettings wraps a copy of the wrapped LLM using stepSettings. Its calls are
+// recorded to, or replayed from, the same cassette.
+func (l *LLM) WithStepSettings(stepSettings *settings.StepSettings) (llm.LLM, error) {
+	if l.model == nil {
+		// Replayed calls don't depend on the settings of the model
+		return l, nil
+	}
+	configurable, ok := l.model.(llm.ConfigurableLLM)
+	if !ok {
+		return nil, errors.Errorf("LLM %T can't be configured with other step settings", l.model)
+	}
+	model, err := configurable.WithStepSettings(stepSettings)
+	if err != nil {
+		return nil, err
+	}
+	return l.cassette.WrapLLM(model), nil
+}
+
+// GenerateEmbedding records or replays a call of GenerateEmbedding
+func (l *LLM) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	req := &Interaction{Kind: KindEmbedding, Input: text}
+
+	if l.cassette.mode == ModeReplay {
+		interaction, err := l.cassette.find(req)
+		if err != nil {
+			return nil, err
+		}
+		if interaction.Error != "" {
+			return nil, replayError(interaction.Error)
+		}
+		return interaction.Embedding, nil
+	}
+
+	if l.model == nil {
+		return nil, errors.New("cassette in record mode has no LLM to record")
+	}
+	embedding, err := l.model.GenerateEmbedding(ctx, text)
+	req.Embedding = embedding
+	if err != nil {
+		req.Error = err.Error()
+	}
+	if ctx.Err() == nil {
+		if recordErr := l.cassette.record(req); recordErr != nil {
+			log.Warn().Err(recordErr).Msg("Failed to record embedding call")
+		}
+	}
+	return embedding, err
+}
+
+func newLLMInteraction(kind string, messages []*conversation.Message, agentTools []tools.Tool) (*Interaction, error) {
+	records, err := llm.ToMessageRecords(messages)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to record LLM call")
+	}
+	req := &Interaction{Kind: kind, Messages: records}
+	for _, tool := range agentTools {
+		req.Tools = append(req.Tools, tool.Name())
+	}
+	return req, nil
+}
+
+func (l *LLM) replay(req *Interaction) ([]*conversation.Message, error) {
+	interaction, err := l.cassette.find(req)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, replayError(interaction.Error)
+	}
+	responses, err := llm.FromMessageRecords(interaction.Response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to replay LLM response")
+	}
+	if len(responses) == 0 {
+		return nil, errors.New("recorded LLM call has no response")
+	}
+	return responses, nil
+}
+
+// record adds a call to the cassette. Calls interrupted by the end of the run are not
+// recorded, and recording failures are logged.
+func (l *LLM) record(ctx context.Context, req *Interaction, responses []*conversation.Message, callErr error) {
+	if ctx.Err() != nil {
+		return
+	}
+	switch {
+	case errors.Is(callErr, llm.ErrToolCallingNotSupported):
+		req.Error = llm.ErrToolCallingNotSupported.Error()
+	case callErr != nil:
+		req.Error = callErr.Error()
+	default:
+		records, err := llm.ToMessageRecords(responses)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to record LLM response")
+			return
+		}
+		req.Response = records
+	}
+	if err := l.cassette.record(req); err != nil {
+		log.Warn().Err(err).Msg("Failed to record LLM call")
+	}
+}
+
+// replayError turns a recorded error message back into an error. Errors that agents
+// check for, such as llm.ErrToolCallingNotSupported, are returned as is.
+func replayError(message string) error {
+	if message == llm.ErrToolCallingNotSupported.Error() {
+		return llm.ErrToolCallingNotSupported
+	}
+	return errors.New(message)
+}
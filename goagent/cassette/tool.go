@@ -0,0 +1,77 @@
+package cassette
+
+import (
+	"context"
+
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// Tool records the calls of a wrapped tool to a cassette, or replays them from it.
+// Name, description, parameters and approval mode are those of the wrapped tool.
+type Tool struct {
+	cassette *Cassette
+	tool     tools.Tool
+}
+
+var _ tools.Tool = (*Tool)(nil)
+var _ tools.ApprovalModeProvider = (*Tool)(nil)
+
+// WrapTool wraps tool. In replay mode the tool is never executed.
+func (c *Cassette) WrapTool(tool tools.Tool) tools.Tool {
+	return &Tool{cassette: c, tool: tool}
+}
+
+// Name returns the name of the wrapped tool
+func (t *Tool) Name() string {
+	return t.tool.Name()
+}
+
+// Description returns the description of the wrapped tool
+func (t *Tool) Description() string {
+	return t.tool.Description()
+}
+
+// Parameters returns the parameters schema of the wrapped tool
+func (t *Tool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	return t.tool.Parameters()
+}
+
+// DefaultApprovalMode returns the default approval mode of the wrapped tool
+func (t *Tool) DefaultApprovalMode() tools.ApprovalMode {
+	if provider, ok := t.tool.(tools.ApprovalModeProvider); ok {
+		return provider.DefaultApprovalMode()
+	}
+	return tools.ApprovalAuto
+}
+
+// Execute records or replays a call of the tool
+func (t *Tool) Execute(ctx context.Context, input string) (string, error) {
+	req := &Interaction{Kind: KindTool, Tool: t.tool.Name(), Input: input}
+
+	if t.cassette.mode == ModeReplay {
+		interaction, err := t.cassette.find(req)
+		if err != nil {
+			return "", err
+		}
+		if interaction.Error != "" {
+			return "", errors.New(interaction.Error)
+		}
+		return interaction.Output, nil
+	}
+
+	output, err := t.tool.Execute(ctx, input)
+	if ctx.Err() == nil {
+		req.Output = output
+		if err != nil {
+			req.Error = err.Error()
+		}
+		if recordErr := t.cassette.record(req); recordErr != nil {
+			log.Warn().Err(recordErr).Str("tool", t.tool.Name()).Msg("Failed to record tool call")
+		}
+	}
+	return output, err
+}
@@ -0,0 +1,116 @@
+// Package checkpoint stores the state of agent runs so that interrupted runs can be
+// resumed from their last completed step.
+package checkpoint
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/go-go-golems/go-go-agent/internal/db"
+	"github.com/pkg/errors"
+)
+
+// Checkpoint is the saved state of a run after its last completed step
+type Checkpoint struct {
+	// RunID is the ID of the checkpointed run
+	RunID string `json:"run_id"`
+	// AgentType is the type of the agent that saved the checkpoint, e.g. "react"
+	AgentType string `json:"agent_type"`
+	// Step is the number of steps completed when the checkpoint was saved
+	Step int `json:"step"`
+	// State is the agent-specific state, as JSON
+	State json.RawMessage `json:"state"`
+	// UpdatedAt is the time the checkpoint was saved
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store saves and loads checkpoints. Only the latest checkpoint of a run is kept.
+type Store interface {
+	// Save replaces the checkpoint of cp.RunID
+	Save(ctx context.Context, cp *Checkpoint) error
+	// Load returns the checkpoint of a run, or nil if there is none
+	Load(ctx context.Context, runID string) (*Checkpoint, error)
+	// Delete removes the checkpoint of a run
+	Delete(ctx context.Context, runID string) error
+	// Close releases the resources of the store
+	Close() error
+}
+
+const sqliteCheckpointSchema = `
+CREATE TABLE IF NOT EXISTS checkpoints (
+    run_id TEXT PRIMARY KEY,
+    agent_type TEXT NOT NULL,
+    step INTEGER NOT NULL,
+    state TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+`
+
+// SQLiteStore is a Store backed by a SQLite database
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+var _ Store = &SQLiteStore{}
+
+// NewSQLiteStore opens (or creates) the checkpoint database at dbPath
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	sqlDB, err := db.OpenSQLite(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := sqlDB.Exec(sqliteCheckpointSchema); err != nil {
+		_ = sqlDB.Close()
+		return nil, errors.Wrap(err, "failed to create checkpoint schema")
+	}
+
+	return &SQLiteStore{db: sqlDB}, nil
+}
+
+// Save replaces the checkpoint of cp.RunID. UpdatedAt is set to the current time.
+func (s *SQLiteStore) Save(ctx context.Context, cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now().UTC()
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO checkpoints (run_id, agent_type, step, state, updated_at)
+        VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT(run_id) DO UPDATE SET
+            agent_type = excluded.agent_type,
+            step = excluded.step,
+            state = excluded.state,
+            updated_at = excluded.updated_at`,
+		cp.RunID, cp.AgentType, cp.Step, string(cp.State), cp.UpdatedAt,
+	)
+	return errors.Wrapf(err, "failed to save checkpoint of run %s", cp.RunID)
+}
+
+// Load returns the checkpoint of a run, or nil if there is none
+func (s *SQLiteStore) Load(ctx context.Context, runID string) (*Checkpoint, error) {
+	cp := &Checkpoint{RunID: runID}
+	var state string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT agent_type, step, state, updated_at FROM checkpoints WHERE run_id = ?`,
+		runID,
+	).Scan(&cp.AgentType, &cp.Step, &state, &cp.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load checkpoint of run %s", runID)
+	}
+	cp.State = json.RawMessage(state)
+	return cp, nil
+}
+
+// Delete removes the checkpoint of a run
+func (s *SQLiteStore) Delete(ctx context.Context, runID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM checkpoints WHERE run_id = ?`, runID)
+	return errors.Wrapf(err, "failed to delete checkpoint of run %s", runID)
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
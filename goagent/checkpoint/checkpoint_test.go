@@ -0,0 +1,70 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if cp, err := store.Load(ctx, "run-1"); err != nil || cp != nil {
+		t.Errorf("Load() of a run without checkpoint = %+v, %v, want nil", cp, err)
+	}
+
+	// Saving again replaces the checkpoint of the run
+	before := time.Now().UTC().Add(-time.Second)
+	for step := 1; step <= 2; step++ {
+		state := json.RawMessage(fmt.Sprintf(`{"messages": ["step %d"]}`, step))
+		if err := store.Save(ctx, &Checkpoint{RunID: "run-1", AgentType: "react", Step: step, State: state}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Save(ctx, &Checkpoint{RunID: "run-2", AgentType: "plan-and-execute", Step: 1, State: json.RawMessage(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Checkpoints are kept when the store is opened again
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	cp, err := store.Load(ctx, "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp == nil || cp.RunID != "run-1" || cp.AgentType != "react" || cp.Step != 2 || string(cp.State) != `{"messages": ["step 2"]}` {
+		t.Fatalf("Load() = %+v, want the checkpoint of step 2", cp)
+	}
+	if cp.UpdatedAt.Before(before) {
+		t.Errorf("checkpoint updated at %s, want the time it was saved", cp.UpdatedAt)
+	}
+
+	// Deleting a checkpoint keeps the ones of other runs
+	if err := store.Delete(ctx, "run-1"); err != nil {
+		t.Fatal(err)
+	}
+	if cp, err := store.Load(ctx, "run-1"); err != nil || cp != nil {
+		t.Errorf("Load() of a deleted checkpoint = %+v, %v, want nil", cp, err)
+	}
+	if cp, err := store.Load(ctx, "run-2"); err != nil || cp == nil || cp.AgentType != "plan-and-execute" {
+		t.Errorf("Load() of another run = %+v, %v, want its checkpoint", cp, err)
+	}
+	if err := store.Delete(ctx, "run-3"); err != nil {
+		t.Errorf("Delete() of a run without checkpoint = %v", err)
+	}
+}
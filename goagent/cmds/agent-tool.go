@@ -0,0 +1,306 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
+	"github.com/go-go-golems/go-go-agent/goagent/agent"
+	"github.com/go-go-golems/go-go-agent/goagent/cassette"
+	"github.com/go-go-golems/go-go-agent/goagent/runs"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// DefaultMaxDelegationDepth is the default number of nested agent runs an agent tool
+// can be called from: with 3, a supervisor can delegate to an agent, which can
+// delegate to another agent, which can delegate once more.
+const DefaultMaxDelegationDepth = 3
+
+// agentRun is the state of a running agent command that is passed to the agent tools
+// it calls through the context, so that the runs they start are nested in it.
+type agentRun struct {
+	runID string
+	// depth is 0 for a top-level run, and 1 more than its parent for a delegated run
+	depth    int
+	eventBus *eventbus.EventBus
+	approver tools.Approver
+	control  *runs.Control
+	// layerValues are the values of the layers inherited by delegated runs, such as
+	// the LLM settings
+	layerValues map[string]map[string]interface{}
+	// cassette records or replays the calls of the run, and of the delegated runs
+	// without cassette of their own
+	cassette *cassette.Cassette
+	// children counts the delegated runs, to number their IDs
+	children atomic.Int32
+}
+
+type agentRunKey struct{}
+
+func withAgentRun(ctx context.Context, run *agentRun) context.Context {
+	return context.WithValue(ctx, agentRunKey{}, run)
+}
+
+func agentRunFromContext(ctx context.Context) *agentRun {
+	run, _ := ctx.Value(agentRunKey{}).(*agentRun)
+	return run
+}
+
+// newAgentRun returns the state of the run of cfg passed to its agent tools
+func newAgentRun(cfg *runConfig, parsedLayers *layers.ParsedLayers, eb *eventbus.EventBus, c *cassette.Cassette) *agentRun {
+	run := &agentRun{
+		runID:       cfg.runID,
+		eventBus:    eb,
+		approver:    cfg.approver,
+		control:     cfg.control,
+		layerValues: inheritedLayerValues(parsedLayers),
+		cassette:    c,
+	}
+	if cfg.parent != nil {
+		run.depth = cfg.parent.depth + 1
+	}
+	return run
+}
+
+// inheritedLayerValues returns the values of the layers of parsedLayers that delegated
+// runs inherit. The command parameters, the run settings and the settings of the agent
+// types are specific to each command and are not inherited. The cassette of the run is
+// passed to delegated runs with the agentRun instead.
+func inheritedLayerValues(parsedLayers *layers.ParsedLayers) map[string]map[string]interface{} {
+	ret := map[string]map[string]interface{}{}
+	parsedLayers.ForEach(func(slug string, layer *layers.ParsedLayer) {
+		if slug == layers.DefaultSlug || slug == RunLayerSlug {
+			return
+		}
+		if _, err := agent.GetAgentFactory(slug); err == nil {
+			return
+		}
+		ret[slug] = layer.Parameters.ToMap()
+	})
+	return ret
+}
+
+// AgentTool exposes an agent command as a tool, so that other agents can delegate
+// tasks to it. Its parameters are the flags and arguments of the command.
+//
+// Called from the run of another agent command, the command runs as a nested run: its
+// ID is the ID of the parent run followed by a sequence number, it inherits the LLM
+// settings, tool approver and pause control of the parent, and its events are published
+// with the events of the parent. Its run_started event records the ID of the parent
+// run and of the tool call. Unless it has its own, it records its calls to the cassette
+// of the parent, or replays them from it.
+//
+// A delegated run usually takes longer than the tool timeout of the calling agent, which
+// is meant for single tool calls, so agent tools have their own timeout.
+type AgentTool struct {
+	command  *AgentCommand
+	maxDepth int
+	timeout  time.Duration
+}
+
+var _ tools.Tool = &AgentTool{}
+var _ tools.TimeoutProvider = &AgentTool{}
+
+// NewAgentTool creates a tool running command. Supported config keys: max-depth, the
+// maximum depth of nested runs the tool can be called from, and timeout, the timeout in
+// seconds of a delegated run (default 0, no timeout).
+func NewAgentTool(command *AgentCommand, config map[string]interface{}) (*AgentTool, error) {
+	t := &AgentTool{
+		command:  command,
+		maxDepth: DefaultMaxDelegationDepth,
+	}
+	if v, ok := config["max-depth"]; ok {
+		maxDepth, ok := v.(int)
+		if !ok || maxDepth < 1 {
+			return nil, errors.Errorf("max-depth of agent tool %s must be a positive integer, got %v", command.Name, v)
+		}
+		t.maxDepth = maxDepth
+	}
+	if v, ok := config["timeout"]; ok {
+		timeout, ok := v.(int)
+		if !ok || timeout < 0 {
+			return nil, errors.Errorf("timeout of agent tool %s must be a number of seconds, got %v", command.Name, v)
+		}
+		t.timeout = time.Duration(timeout) * time.Second
+	}
+	return t, nil
+}
+
+// RegisterAgentTools registers the agent commands of commands as tools in the global
+// tool registry, under their command name. Commands whose name is already used by a
+// tool are skipped.
+func RegisterAgentTools(commands []cmds.Command) {
+	registry := tools.GetGlobalToolRegistry()
+	for _, c := range commands {
+		var command *AgentCommand
+		switch ac := c.(type) {
+		case *WriterAgentCommand:
+			command = ac.AgentCommand
+		case *GlazedAgentCommand:
+			command = ac.AgentCommand
+		case *AgentCommand:
+			command = ac
+		default:
+			continue
+		}
+		if _, ok := registry.Get(command.Name); ok {
+			log.Warn().Str("command", command.Name).Msg("A tool with the name of the agent command already exists, the command is not available as tool")
+			continue
+		}
+		registry.Register(command.Name, tools.ToolFactoryFunc(func(config map[string]interface{}) (tools.Tool, error) {
+			return NewAgentTool(command, config)
+		}))
+	}
+}
+
+// Name returns the name of the command
+func (t *AgentTool) Name() string {
+	return t.command.Name
+}
+
+// Description returns the description of the command
+func (t *AgentTool) Description() string {
+	description := t.command.Short
+	if t.command.Long != "" {
+		description = strings.TrimSpace(description + "\n\n" + t.command.Long)
+	}
+	return description
+}
+
+// Timeout returns the timeout of a delegated run, replacing the tool timeout of the
+// calling agent
+func (t *AgentTool) Timeout() time.Duration {
+	return t.timeout
+}
+
+// Parameters returns the flags and arguments of the command
+func (t *AgentTool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	om := orderedmap.New[string, types.ParameterSchema]()
+	for _, p := range t.commandParameters() {
+		schema := types.ParameterSchema{
+			Type:        "string",
+			Description: p.Help,
+			Required:    p.Required,
+			Enum:        p.Choices,
+		}
+		switch p.Type {
+		case parameters.ParameterTypeInteger:
+			schema.Type = "integer"
+		case parameters.ParameterTypeFloat:
+			schema.Type = "number"
+		case parameters.ParameterTypeBool:
+			schema.Type = "boolean"
+		case parameters.ParameterTypeKeyValue:
+			schema.Type = "object"
+		case parameters.ParameterTypeIntegerList:
+			schema.Type, schema.ItemType = "array", "integer"
+		case parameters.ParameterTypeFloatList:
+			schema.Type, schema.ItemType = "array", "number"
+		default:
+			if p.Type.IsList() {
+				schema.Type, schema.ItemType = "array", "string"
+			}
+		}
+		om.Set(p.Name, schema)
+	}
+	return om
+}
+
+// commandParameters returns the flags followed by the arguments of the command
+func (t *AgentTool) commandParameters() []*parameters.ParameterDefinition {
+	ret := t.command.GetDefaultFlags().ToList()
+	return append(ret, t.command.GetDefaultArguments().ToList()...)
+}
+
+// Execute runs the command with the parameters of input, a JSON object. Input that is
+// not a JSON object is used as the value of the only required parameter of the
+// command, if it has one. The result is the output of the command.
+func (t *AgentTool) Execute(ctx context.Context, input string) (string, error) {
+	parent := agentRunFromContext(ctx)
+	if parent != nil && parent.depth+1 > t.maxDepth {
+		return "", errors.Errorf("agent %s cannot be called from a run nested %d levels deep (max-depth %d)", t.command.Name, parent.depth, t.maxDepth)
+	}
+
+	params, err := t.parseInput(input)
+	if err != nil {
+		return "", err
+	}
+
+	var layerValues map[string]map[string]interface{}
+	options := []RunOption{WithRunMode("delegated")}
+	if parent != nil {
+		toolCallID, _ := tools.ToolCallIDFromContext(ctx)
+		layerValues = parent.layerValues
+		options = append(options,
+			WithRunID(fmt.Sprintf("%s.%d", parent.runID, parent.children.Add(1))),
+			WithToolApprover(parent.approver),
+			withParentRun(parent, toolCallID),
+		)
+		if parent.control != nil {
+			options = append(options, WithRunControl(parent.control))
+		}
+	}
+
+	parsedLayers, err := t.command.ParseParameters(params, layerValues)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid parameters for agent %s", t.command.Name)
+	}
+
+	var output bytes.Buffer
+	if err := t.command.RunIntoWriter(ctx, parsedLayers, &output, options...); err != nil {
+		return "", errors.Wrapf(err, "agent %s failed", t.command.Name)
+	}
+	// The output of a cancelled or timed out run is incomplete
+	if err := ctx.Err(); err != nil {
+		return "", errors.Wrapf(err, "agent %s did not finish", t.command.Name)
+	}
+	return strings.TrimSpace(output.String()), nil
+}
+
+// parseInput returns the parameter values of input and checks that the required
+// parameters are set
+func (t *AgentTool) parseInput(input string) (map[string]interface{}, error) {
+	params := t.commandParameters()
+
+	values := map[string]interface{}{}
+	trimmed := strings.TrimSpace(input)
+	if strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal([]byte(trimmed), &values); err != nil {
+			return nil, errors.Wrap(err, "invalid input")
+		}
+	} else {
+		var required []*parameters.ParameterDefinition
+		for _, p := range params {
+			if p.Required {
+				required = append(required, p)
+			}
+		}
+		if len(required) != 1 {
+			return nil, errors.Errorf("input of agent %s must be a JSON object with its parameters", t.command.Name)
+		}
+		if required[0].Type.IsList() {
+			values[required[0].Name] = []interface{}{input}
+		} else {
+			values[required[0].Name] = input
+		}
+	}
+
+	for _, p := range params {
+		if _, ok := values[p.Name]; !ok && p.Required {
+			return nil, errors.Errorf("missing required parameter %s of agent %s", p.Name, t.command.Name)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,254 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
+	"github.com/go-go-golems/go-go-agent/goagent/cassette"
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+)
+
+func newTestAgentTool(t *testing.T, config map[string]interface{}, arguments ...*parameters.ParameterDefinition) *AgentTool {
+	t.Helper()
+	description := cmds.NewCommandDescription("research",
+		cmds.WithShort("Researches a topic"),
+		cmds.WithFlags(
+			parameters.NewParameterDefinition("depth", parameters.ParameterTypeInteger, parameters.WithHelp("Depth of the research"), parameters.WithDefault(2)),
+			parameters.NewParameterDefinition("format", parameters.ParameterTypeChoice, parameters.WithChoices("text", "markdown")),
+			parameters.NewParameterDefinition("sources", parameters.ParameterTypeStringList),
+		),
+		cmds.WithArguments(arguments...),
+	)
+	command, err := NewAgentCommand(description, WithAgentType("react"), WithPrompt("Research {{.topic}}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool, err := NewAgentTool(command, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tool
+}
+
+func TestAgentToolParameters(t *testing.T) {
+	tool := newTestAgentTool(t, nil,
+		parameters.NewParameterDefinition("topic", parameters.ParameterTypeString, parameters.WithHelp("Topic to research"), parameters.WithRequired(true)),
+	)
+
+	expected := map[string]types.ParameterSchema{
+		"depth":   {Type: "integer", Description: "Depth of the research"},
+		"format":  {Type: "string", Enum: []string{"text", "markdown"}},
+		"sources": {Type: "array", ItemType: "string"},
+		"topic":   {Type: "string", Description: "Topic to research", Required: true},
+	}
+	var names []string
+	for pair := tool.Parameters().Oldest(); pair != nil; pair = pair.Next() {
+		names = append(names, pair.Key)
+		if !reflect.DeepEqual(pair.Value, expected[pair.Key]) {
+			t.Errorf("parameter %s = %+v, want %+v", pair.Key, pair.Value, expected[pair.Key])
+		}
+	}
+	// Flags come before arguments
+	if !reflect.DeepEqual(names, []string{"depth", "format", "sources", "topic"}) {
+		t.Errorf("parameters = %v, want the flags followed by the arguments", names)
+	}
+}
+
+func TestAgentToolParseInput(t *testing.T) {
+	topic := parameters.NewParameterDefinition("topic", parameters.ParameterTypeString, parameters.WithRequired(true))
+	topics := parameters.NewParameterDefinition("topics", parameters.ParameterTypeStringList, parameters.WithRequired(true))
+	audience := parameters.NewParameterDefinition("audience", parameters.ParameterTypeString, parameters.WithRequired(true))
+
+	testCases := []struct {
+		name      string
+		arguments []*parameters.ParameterDefinition
+		input     string
+		expected  map[string]interface{}
+		err       string
+	}{
+		{
+			name:      "json object",
+			arguments: []*parameters.ParameterDefinition{topic},
+			input:     ` {"topic": "go generics", "depth": 3}`,
+			expected:  map[string]interface{}{"topic": "go generics", "depth": float64(3)},
+		},
+		{
+			name:      "text for the required parameter",
+			arguments: []*parameters.ParameterDefinition{topic},
+			input:     "go generics",
+			expected:  map[string]interface{}{"topic": "go generics"},
+		},
+		{
+			name:      "text for a required list",
+			arguments: []*parameters.ParameterDefinition{topics},
+			input:     "go generics",
+			expected:  map[string]interface{}{"topics": []interface{}{"go generics"}},
+		},
+		{
+			name:      "invalid json",
+			arguments: []*parameters.ParameterDefinition{topic},
+			input:     `{"topic": `,
+			err:       "invalid input: unexpected end of JSON input",
+		},
+		{
+			name:      "missing required parameter",
+			arguments: []*parameters.ParameterDefinition{topic},
+			input:     `{"depth": 3}`,
+			err:       "missing required parameter topic of agent research",
+		},
+		{
+			name:      "text with several required parameters",
+			arguments: []*parameters.ParameterDefinition{topic, audience},
+			input:     "go generics",
+			err:       "input of agent research must be a JSON object with its parameters",
+		},
+		{
+			name:  "text without required parameter",
+			input: "go generics",
+			err:   "input of agent research must be a JSON object with its parameters",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tool := newTestAgentTool(t, nil, tc.arguments...)
+			values, err := tool.parseInput(tc.input)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("parseInput() = %v, want error %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(values, tc.expected) {
+				t.Errorf("parseInput() = %v, want %v", values, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAgentToolMaxDepth(t *testing.T) {
+	tool := newTestAgentTool(t, map[string]interface{}{"max-depth": 2})
+
+	testCases := []struct {
+		depth   int
+		refused bool
+	}{
+		{depth: 0},
+		{depth: 1},
+		{depth: 2, refused: true},
+	}
+	for _, tc := range testCases {
+		ctx := withAgentRun(context.Background(), &agentRun{runID: "run", depth: tc.depth})
+		// Allowed calls fail on their input instead, before the command runs
+		_, err := tool.Execute(ctx, "not a json object")
+		if err == nil {
+			t.Fatalf("Execute() from depth %d succeeded", tc.depth)
+		}
+		if refused := strings.Contains(err.Error(), "cannot be called from a run nested"); refused != tc.refused {
+			t.Errorf("Execute() from depth %d = %v, want refused: %v", tc.depth, err, tc.refused)
+		}
+	}
+}
+
+func TestNewAgentToolConfig(t *testing.T) {
+	testCases := []struct {
+		config   map[string]interface{}
+		maxDepth int
+		timeout  time.Duration
+		err      bool
+	}{
+		{config: nil, maxDepth: DefaultMaxDelegationDepth},
+		{config: map[string]interface{}{"max-depth": 1, "timeout": 600}, maxDepth: 1, timeout: 10 * time.Minute},
+		{config: map[string]interface{}{"max-depth": 0}, err: true},
+		{config: map[string]interface{}{"max-depth": "2"}, err: true},
+		{config: map[string]interface{}{"timeout": -1}, err: true},
+	}
+	command, err := NewAgentCommand(cmds.NewCommandDescription("research"), WithAgentType("react"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		tool, err := NewAgentTool(command, tc.config)
+		if (err != nil) != tc.err {
+			t.Errorf("NewAgentTool(%v) = %v, want error: %v", tc.config, err, tc.err)
+			continue
+		}
+		if err == nil && (tool.maxDepth != tc.maxDepth || tool.Timeout() != tc.timeout) {
+			t.Errorf("NewAgentTool(%v) has max depth %d and timeout %s, want %d and %s", tc.config, tool.maxDepth, tool.Timeout(), tc.maxDepth, tc.timeout)
+		}
+	}
+}
+
+// researchCassette is a cassette answering the research of go generics
+const researchCassette = `
+interactions:
+  - kind: generate
+    messages:
+      - kind: chat
+        role: user
+        text: Research go generics
+    response:
+      - kind: chat
+        role: assistant
+        text: "Final Answer: Go generics use type parameters."
+`
+
+// newResearchParentRun returns a parent run replaying researchCassette. Its LLM
+// settings have a placeholder API key, delegated runs never call their LLM.
+func newResearchParentRun(t *testing.T) *agentRun {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "research.yaml")
+	if err := os.WriteFile(path, []byte(researchCassette), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := cassette.Open(path, cassette.ModeReplay, cassette.WithMatchMode(cassette.MatchLastMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &agentRun{
+		runID:       "run",
+		layerValues: map[string]map[string]interface{}{"openai-chat": {"openai-api-key": "test-key"}},
+		cassette:    c,
+	}
+}
+
+func TestAgentToolReplaysParentCassette(t *testing.T) {
+	tool := newTestAgentTool(t, nil,
+		parameters.NewParameterDefinition("topic", parameters.ParameterTypeString, parameters.WithRequired(true)),
+	)
+	// The delegated run has no cassette of its own, its call is answered by the
+	// cassette of the parent
+	ctx := withAgentRun(context.Background(), newResearchParentRun(t))
+	output, err := tool.Execute(ctx, "go generics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "Go generics use type parameters." {
+		t.Errorf("Execute() = %q, want the replayed answer", output)
+	}
+}
+
+func TestAgentToolTimeout(t *testing.T) {
+	tool := newTestAgentTool(t, nil,
+		parameters.NewParameterDefinition("topic", parameters.ParameterTypeString, parameters.WithRequired(true)),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	ctx = withAgentRun(ctx, newResearchParentRun(t))
+
+	output, err := tool.Execute(ctx, "go generics")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Execute() of a timed out run = %q, %v, want the timeout", output, err)
+	}
+}
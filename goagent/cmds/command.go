@@ -8,11 +8,10 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ThreeDotsLabs/watermill/message"
-	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
-	"github.com/go-go-golems/geppetto/pkg/helpers"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/glazed/pkg/cmds"
 	"github.com/go-go-golems/glazed/pkg/cmds/layers"
@@ -23,15 +22,20 @@ import (
 	"github.com/go-go-golems/go-go-agent/goagent/agent"
 	"github.com/go-go-golems/go-go-agent/goagent/llm"
 	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/go-go-golems/go-go-agent/internal/redis"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
 	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
+	"github.com/go-go-golems/go-go-agent/pkg/pricing"
 	events "github.com/go-go-golems/go-go-agent/proto"
 	pinocchio_cmds "github.com/go-go-golems/pinocchio/pkg/cmds"
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // AgentCommand is a command that encapsulates agent execution configuration.
@@ -121,6 +125,33 @@ func NewAgentCommand(
 	}
 	description.Layers.AppendLayers(geppettoLayers...)
 
+	runLayer, err := NewRunParameterLayer()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create run layer")
+	}
+	description.Layers.AppendLayers(runLayer)
+
+	// Layers selecting and configuring the destinations of the events of the run
+	eventSinksLayer, err := NewEventSinksParameterLayer()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create event sinks layer")
+	}
+	redisLayer, err := redis.NewRedisLayer()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create redis layer")
+	}
+	streamLayer, err := redis.NewStreamLayer()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create stream layer")
+	}
+	description.Layers.AppendLayers(eventSinksLayer, redisLayer, streamLayer)
+
+	tracingLayer, err := NewTracingParameterLayer()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create tracing layer")
+	}
+	description.Layers.AppendLayers(tracingLayer)
+
 	ret := &AgentCommand{
 		CommandDescription: description,
 	}
@@ -197,61 +228,64 @@ func (a *AgentCommand) prepareLlmAndEventBus(
 	ctx context.Context,
 	parsedLayers *layers.ParsedLayers,
 	runMode RunMode,
-	runID string, // Pass runID for event association
+	cfg *runConfig,
 ) (llm.LLM, *settings.StepSettings, *eventbus.EventBus, *message.Router, string, error) {
+	runID := cfg.runID
 	// Create StepSettings from parsed layers for LLM creation
 	stepSettings, err := settings.NewStepSettingsFromParsedLayers(parsedLayers)
 	if err != nil {
 		return nil, nil, nil, nil, "", errors.Wrap(err, "failed to create step settings from parsed layers")
 	}
 
-	llmOptions := []llm.GeppettoLLMOption{
-		llm.WithRunID(runID),
+	var llmOptions []llm.GeppettoLLMOption
+
+	runSettings, err := GetRunSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return nil, nil, nil, nil, "", err
+	}
+	llmOptions = append(llmOptions, llm.WithDeltaInterval(time.Duration(runSettings.StreamIntervalMs)*time.Millisecond))
+	if cfg.control != nil {
+		llmOptions = append(llmOptions, llm.WithPauseGate(cfg.control))
+	}
+	if runSettings.MaxCost > 0 {
+		prices, err := pricing.LoadPriceTableOrDefault(runSettings.PriceFile)
+		if err != nil {
+			return nil, nil, nil, nil, "", err
+		}
+		llmOptions = append(llmOptions, llm.WithBudget(pricing.NewBudget(prices, runSettings.MaxCost)))
 	}
 	var eb *eventbus.EventBus
 	var router *message.Router
 	var topicID string
 
 	// Setup EventBus and Router only for Writer Mode
-	if runMode == RunModeWriter {
-		// Create a simple GoChannel pub/sub for local event handling
-		logger := helpers.NewWatermill(log.Logger)
-		pubSub := gochannel.NewGoChannel(gochannel.Config{}, logger)
-
-		topicID = fmt.Sprintf("%s-agent-events-%s", a.Name, runID)
-
-		// Create the EventBus
+	if runMode == RunModeWriter && cfg.parent != nil {
+		// Delegated runs publish their events with the events of their parent, if any
+		eb = cfg.parent.eventBus
+		if eb != nil {
+			llmOptions = append(llmOptions, llm.WithEventBus(eb))
+		}
+	} else if runMode == RunModeWriter && cfg.publisher != nil {
+		// Events go to the publisher of the caller, nothing is printed
+		topicID = cfg.topic
 		eb, err = eventbus.NewEventBus(
-			eventbus.WithPublisher(pubSub),
+			eventbus.WithPublisher(cfg.publisher),
 			eventbus.WithTopic(topicID),
-			// Use DefaultJSONEncoder for human-readable stdout printing
-			eventbus.WithEncoder(eventbus.DefaultJSONEncoder),
+			eventbus.WithEncoder(cfg.encoder),
 		)
 		if err != nil {
 			return nil, nil, nil, nil, "", errors.Wrap(err, "failed to create event bus")
 		}
-
-		// Create and configure the router
-		router, err = message.NewRouter(message.RouterConfig{}, logger)
+		llmOptions = append(llmOptions, llm.WithEventBus(eb))
+	} else if runMode == RunModeWriter {
+		// Events go to the sinks selected on the command line
+		eb, router, topicID, err = a.newEventBus(ctx, parsedLayers, runID)
 		if err != nil {
-			_ = eb.Close() // Attempt to close event bus on error
-			return nil, nil, nil, nil, "", errors.Wrap(err, "failed to create message router")
+			return nil, nil, nil, nil, "", err
+		}
+		if eb != nil {
+			llmOptions = append(llmOptions, llm.WithEventBus(eb))
 		}
-
-		// Add the stdout printing handler
-		handlerName := "stdout-event-printer-" + runID
-		log.Info().Str("handler", handlerName).Str("topic", topicID).Msg("Registering stdout event handler")
-		router.AddHandler(
-			handlerName,
-			topicID,
-			pubSub, // Subscribe to the same pub/sub
-			topicID,
-			pubSub,             // Publish ACKs/NACKs to the same pub/sub (for potential future use)
-			StdoutEventHandler, // Use the new handler
-		)
-
-		// Configure LLM to use the event bus
-		llmOptions = append(llmOptions, llm.WithEventBus(eb))
 	}
 
 	// Prepare LLM model using Geppetto LLM
@@ -274,18 +308,22 @@ func (a *AgentCommand) prepareLlmAndEventBus(
 
 // StdoutEventHandler is a Watermill handler that prints received events to stdout.
 func StdoutEventHandler(msg *message.Message) ([]*message.Message, error) {
-	// Decode the message payload into an Event
-	event := &events.Event{}
-	opts := protojson.UnmarshalOptions{
-		DiscardUnknown: true, // Be lenient with unknown fields
-	}
-	err := opts.Unmarshal(msg.Payload, event)
+	// Decode the message payload into an Event, in whichever format it was encoded
+	event, err := codec.DecodeMessage(msg)
 	if err != nil {
 		log.Error().Err(err).Str("msg_uuid", msg.UUID).Msg("Failed to unmarshal event from message payload")
 		// Nack the message? Or Ack and log? For stdout printing, Ack is probably fine.
 		return nil, err // Don't requeue
 	}
 
+	// Streamed output is printed as it arrives instead of as an event block
+	if delta := event.GetLlmCallDelta(); delta != nil {
+		printStreamDelta(delta)
+		msg.Ack()
+		return nil, nil
+	}
+	endStreamOutput()
+
 	// Pretty print the event to stdout
 	outputStr, err := prettyPrintEvent(event)
 	if err != nil {
@@ -305,6 +343,42 @@ func StdoutEventHandler(msg *message.Message) ([]*message.Message, error) {
 	return nil, nil
 }
 
+// stdoutStream tracks the LLM call whose deltas are being printed, so that the next
+// event block starts on a new line.
+var stdoutStream struct {
+	mu     sync.Mutex
+	callID string
+}
+
+// printStreamDelta prints the partial output of an LLM call, with a header when a
+// new call starts streaming.
+func printStreamDelta(delta *events.LlmCallDeltaPayload) {
+	stdoutStream.mu.Lock()
+	defer stdoutStream.mu.Unlock()
+
+	if stdoutStream.callID != delta.CallId {
+		if stdoutStream.callID != "" {
+			fmt.Fprintln(os.Stdout)
+		}
+		fmt.Fprintf(os.Stdout, "--- Streaming %s (call %s)\n", delta.Model, delta.CallId)
+		stdoutStream.callID = delta.CallId
+	}
+	if _, err := fmt.Fprint(os.Stdout, delta.Delta); err != nil {
+		log.Error().Err(err).Msg("Failed to write output to stdout")
+	}
+}
+
+// endStreamOutput terminates the line of streamed output, if any
+func endStreamOutput() {
+	stdoutStream.mu.Lock()
+	defer stdoutStream.mu.Unlock()
+
+	if stdoutStream.callID != "" {
+		fmt.Fprintln(os.Stdout)
+		stdoutStream.callID = ""
+	}
+}
+
 // prettyPrintEvent formats an event for readable stdout logging.
 func prettyPrintEvent(event *events.Event) (string, error) {
 	var sb strings.Builder
@@ -335,8 +409,12 @@ func prettyPrintEvent(event *events.Event) (string, error) {
 		payloadProto = p.LlmCallStarted
 	case *events.Event_LlmCallCompleted:
 		payloadProto = p.LlmCallCompleted
+	case *events.Event_LlmCallDelta:
+		payloadProto = p.LlmCallDelta
 	case *events.Event_ToolInvoked:
 		payloadProto = p.ToolInvoked
+	case *events.Event_ToolApprovalRequested:
+		payloadProto = p.ToolApprovalRequested
 	case *events.Event_ToolReturned:
 		payloadProto = p.ToolReturned
 	case *events.Event_NodeCreated:
@@ -393,42 +471,85 @@ func (gac *GlazedAgentCommand) RunIntoGlazeProcessor(
 	parsedLayers *layers.ParsedLayers,
 	gp middlewares.Processor,
 ) error {
-	runID := uuid.New().String()
+	return gac.AgentCommand.RunIntoGlazeProcessor(ctx, parsedLayers, gp)
+}
+
+// RunIntoGlazeProcessor runs an agent producing structured data and adds its rows to
+// gp. Options set the run ID and the tool approver; the agent emits no events.
+func (a *AgentCommand) RunIntoGlazeProcessor(
+	ctx context.Context,
+	parsedLayers *layers.ParsedLayers,
+	gp middlewares.Processor,
+	options ...RunOption,
+) error {
+	cfg := newRunConfig(options...)
+	runSettings, err := GetRunSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return err
+	}
+	checkpoints, err := cfg.openCheckpointStore(runSettings)
+	if err != nil {
+		return err
+	}
+	if checkpoints != nil {
+		defer func() {
+			_ = checkpoints.Close()
+		}()
+	}
+	runID := cfg.runID
+	// Events emitted during the run are associated with runID
+	ctx = execctx.WithRunID(ctx, runID)
+	runCassette, err := openCassette(runSettings)
+	if err != nil {
+		return err
+	}
 	// 1. Prepare LLM (no event bus/router for Glazed mode)
-	llmModel, _, _, _, _, err := gac.AgentCommand.prepareLlmAndEventBus(ctx, parsedLayers, RunModeGlazed, runID)
+	llmModel, _, _, _, _, err := a.prepareLlmAndEventBus(ctx, parsedLayers, RunModeGlazed, cfg)
 	if err != nil {
 		return errors.Wrap(err, "failed to prepare LLM")
 	}
+	if runCassette != nil {
+		llmModel = runCassette.WrapLLM(llmModel)
+	}
 
 	// 2. Get Agent Factory
-	factory, err := agent.GetAgentFactory(gac.AgentCommand.AgentType)
+	factory, err := agent.GetAgentFactory(a.AgentType)
 	if err != nil {
-		return errors.Wrapf(err, "failed to get agent factory for type '%s'", gac.AgentCommand.AgentType)
+		return errors.Wrapf(err, "failed to get agent factory for type '%s'", a.AgentType)
 	}
 
 	// 3. Create Agent Instance using Factory - pass the AgentCommand directly
-	agentInstance, err := factory.NewAgent(ctx, gac.AgentCommand, parsedLayers, llmModel)
+	agentInstance, err := factory.NewAgent(ctx, a, parsedLayers, llmModel)
 	if err != nil {
 		return errors.Wrap(err, "failed to create agent instance")
 	}
+	if requester, ok := agentInstance.(agent.ToolApprovalRequester); ok {
+		requester.SetToolApprover(cfg.approver)
+	}
+	if err := attachCheckpointStore(agentInstance, checkpoints, runID, runSettings.Resume != ""); err != nil {
+		return err
+	}
+	attachCassette(agentInstance, runCassette)
 
 	// 4. Render the initial prompt using parameters
-	initialPrompt, err := gac.AgentCommand.renderInitialPrompt(parsedLayers)
+	initialPrompt, err := a.renderInitialPrompt(parsedLayers)
 	if err != nil {
 		return errors.Wrap(err, "failed to render initial prompt")
 	}
+	// Agent tools called by the agent run nested in this run
+	ctx = withAgentRun(ctx, newAgentRun(cfg, parsedLayers, nil, runCassette))
 
 	// 5. Type assert the agent to GlazedAgent
 	glazedAgent, ok := agentInstance.(agent.GlazedAgent)
 	if !ok {
-		return errors.Errorf("agent type '%s' (%T) does not support Glazed output (does not implement GlazedAgent interface)", gac.AgentCommand.AgentType, agentInstance)
+		return errors.Errorf("agent type '%s' (%T) does not support Glazed output (does not implement GlazedAgent interface)", a.AgentType, agentInstance)
 	}
 
 	// 6. Run the agent's specific Glazed processor method
-	log.Info().Str("agentType", gac.AgentCommand.AgentType).Str("runID", runID).Msg("Running GlazedAgent logic")
+	log.Info().Str("agentType", a.AgentType).Str("runID", runID).Msg("Running GlazedAgent logic")
 	err = glazedAgent.RunIntoGlazeProcessor(ctx, initialPrompt, gp)
 	if err != nil {
-		log.Error().Err(err).Str("agentType", gac.AgentCommand.AgentType).Str("runID", runID).Msg("GlazedAgent RunIntoGlazeProcessor failed")
+		log.Error().Err(err).Str("agentType", a.AgentType).Str("runID", runID).Msg("GlazedAgent RunIntoGlazeProcessor failed")
 	}
 
 	return err // Return the error from the agent run
@@ -440,20 +561,60 @@ func (wac *WriterAgentCommand) RunIntoWriter(
 	parsedLayers *layers.ParsedLayers,
 	w io.Writer, // Target writer for the final agent output
 ) error {
-	// 1. Setup context, errgroup, and run ID
-	runID := uuid.New().String()
+	return wac.AgentCommand.RunIntoWriter(ctx, parsedLayers, w)
+}
+
+// RunIntoWriter runs the agent and writes its result to w. By default events are
+// printed to stdout; options set the run ID, redirect the events to a publisher and
+// attach a Control to pause the run. A cancelled run returns nil, unless it is
+// delegated: the agent tool that started it then returns the cancellation.
+func (a *AgentCommand) RunIntoWriter(
+	ctx context.Context,
+	parsedLayers *layers.ParsedLayers,
+	w io.Writer, // Target writer for the final agent output
+	options ...RunOption,
+) error {
+	// 1. Setup context, errgroup, and run ID. A resumed run keeps its ID.
+	cfg := newRunConfig(options...)
+	runSettings, err := GetRunSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return err
+	}
+	checkpoints, err := cfg.openCheckpointStore(runSettings)
+	if err != nil {
+		return err
+	}
+	if checkpoints != nil {
+		defer func() {
+			_ = checkpoints.Close()
+		}()
+	}
+	runID := cfg.runID
+	// Events emitted during the run are associated with runID
+	ctx = execctx.WithRunID(ctx, runID)
+	runCassette, err := openCassette(runSettings)
+	if err != nil {
+		return err
+	}
+	if runCassette == nil && cfg.parent != nil {
+		runCassette = cfg.parent.cassette
+	}
 	ctx, cancel := context.WithCancel(ctx)
 	eg, ctx := errgroup.WithContext(ctx)
 	defer cancel()
 
 	// 2. Prepare LLM, EventBus, and Router for Writer Mode
-	llmModel, _, eb, router, _, err := wac.AgentCommand.prepareLlmAndEventBus(ctx, parsedLayers, RunModeWriter, runID)
+	llmModel, _, eb, router, _, err := a.prepareLlmAndEventBus(ctx, parsedLayers, RunModeWriter, cfg)
 	if err != nil {
 		return errors.Wrap(err, "failed to prepare LLM and event bus")
 	}
-	// Ensure event bus and router are closed eventually
+	if runCassette != nil {
+		llmModel = runCassette.WrapLLM(llmModel)
+	}
+	// Ensure event bus and router are closed eventually. The event bus of a delegated
+	// run belongs to its parent
 	defer func() {
-		if eb != nil {
+		if eb != nil && cfg.parent == nil {
 			_ = eb.Close()
 		}
 		if router != nil {
@@ -462,57 +623,95 @@ func (wac *WriterAgentCommand) RunIntoWriter(
 	}()
 
 	// 3. Get Agent Factory
-	factory, err := agent.GetAgentFactory(wac.AgentCommand.AgentType)
+	factory, err := agent.GetAgentFactory(a.AgentType)
 	if err != nil {
-		return errors.Wrapf(err, "failed to get agent factory for type '%s'", wac.AgentCommand.AgentType)
+		return errors.Wrapf(err, "failed to get agent factory for type '%s'", a.AgentType)
 	}
 
 	// 4. Create Agent Instance using Factory
-	agentInstance, err := factory.NewAgent(ctx, wac.AgentCommand, parsedLayers, llmModel)
+	agentInstance, err := factory.NewAgent(ctx, a, parsedLayers, llmModel)
 	if err != nil {
 		return errors.Wrap(err, "failed to create agent instance")
 	}
+	if emitter, ok := agentInstance.(agent.EventEmitter); ok && eb != nil {
+		emitter.SetEventBus(eb)
+	}
+	if requester, ok := agentInstance.(agent.ToolApprovalRequester); ok {
+		requester.SetToolApprover(cfg.approver)
+	}
+	if err := attachCheckpointStore(agentInstance, checkpoints, runID, runSettings.Resume != ""); err != nil {
+		return err
+	}
+	attachCassette(agentInstance, runCassette)
 
 	// 5. Render the initial prompt
-	initialPrompt, err := wac.AgentCommand.renderInitialPrompt(parsedLayers)
+	initialPrompt, err := a.renderInitialPrompt(parsedLayers)
 	if err != nil {
 		return errors.Wrap(err, "failed to render initial prompt")
 	}
-
-	// Start the router in a background goroutine
-	eg.Go(func() error {
-		log.Info().Str("runID", runID).Msg("Starting event router for WriterAgent")
-		defer func() {
-			log.Info().Str("runID", runID).Msg("Event router closing")
-		}()
-		runErr := router.Run(ctx) // Use the cancellable context
-		log.Info().Err(runErr).Str("runID", runID).Msg("Event router stopped")
-		if runErr != nil && !errors.Is(runErr, context.Canceled) && !errors.Is(runErr, context.DeadlineExceeded) {
-			return runErr // Return actual router errors
-		}
-		return nil
-	})
+	// Agent tools called by the agent run nested in this run
+	ctx = withAgentRun(ctx, newAgentRun(cfg, parsedLayers, eb, runCassette))
+
+	// Start the router in a background goroutine, if events are printed locally
+	if router != nil {
+		eg.Go(func() error {
+			log.Info().Str("runID", runID).Msg("Starting event router for WriterAgent")
+			defer func() {
+				log.Info().Str("runID", runID).Msg("Event router closing")
+			}()
+			runErr := router.Run(ctx) // Use the cancellable context
+			log.Info().Err(runErr).Str("runID", runID).Msg("Event router stopped")
+			if runErr != nil && !errors.Is(runErr, context.Canceled) && !errors.Is(runErr, context.DeadlineExceeded) {
+				return runErr // Return actual router errors
+			}
+			return nil
+		})
+	}
 
 	// Run the agent logic in a separate goroutine
 	eg.Go(func() error {
 		defer cancel() // Ensure cancellation propagates if agent finishes/errors first
 		// Wait for the router to be running
-		select {
-		case <-router.Running():
-			log.Info().Str("agentType", wac.AgentCommand.AgentType).Str("runID", runID).Msg("Event router is running, proceeding with agent run")
-		case <-ctx.Done():
-			log.Warn().Str("agentType", wac.AgentCommand.AgentType).Str("runID", runID).Msg("Context cancelled before router started")
-			return ctx.Err()
-		case <-time.After(5 * time.Second): // Timeout for router startup
-			log.Error().Str("runID", runID).Msg("Timeout waiting for event router to start")
-			return errors.New("timeout waiting for event router to start")
+		if router != nil {
+			select {
+			case <-router.Running():
+				log.Info().Str("agentType", a.AgentType).Str("runID", runID).Msg("Event router is running, proceeding with agent run")
+			case <-ctx.Done():
+				log.Warn().Str("agentType", a.AgentType).Str("runID", runID).Msg("Context cancelled before router started")
+				return ctx.Err()
+			case <-time.After(5 * time.Second): // Timeout for router startup
+				log.Error().Str("runID", runID).Msg("Timeout waiting for event router to start")
+				return errors.New("timeout waiting for event router to start")
+			}
+		}
+
+		if eb != nil {
+			startPayload := &events.RunStartedPayload{
+				RunMode:      cfg.runMode,
+				TimestampUtc: timestamppb.Now(),
+			}
+			input := map[string]interface{}{
+				"command": a.Name,
+				"prompt":  initialPrompt,
+			}
+			if cfg.parent != nil {
+				input["parent_run_id"] = cfg.parent.runID
+				input["parent_tool_call_id"] = cfg.parentToolCallID
+			}
+			if inputData, err := structpb.NewStruct(input); err == nil {
+				startPayload.InputData = inputData
+			}
+			if err := eb.EmitRunStarted(ctx, startPayload, &runID); err != nil {
+				log.Warn().Err(err).Str("runID", runID).Msg("Failed to emit RunStarted event")
+			}
 		}
 
 		// Run the agent's standard Run method
-		log.Info().Str("agentType", wac.AgentCommand.AgentType).Str("runID", runID).Msg("Running WriterAgent logic")
+		log.Info().Str("agentType", a.AgentType).Str("runID", runID).Msg("Running WriterAgent logic")
+		runStartTime := time.Now()
 		resultStr, agentErr := agentInstance.Run(ctx, initialPrompt)
 		if agentErr != nil {
-			log.Error().Err(agentErr).Str("agentType", wac.AgentCommand.AgentType).Str("runID", runID).Msg("WriterAgent Run failed")
+			log.Error().Err(agentErr).Str("agentType", a.AgentType).Str("runID", runID).Msg("WriterAgent Run failed")
 			// Emit a run error event if possible
 			if eb != nil {
 				errPayload := &events.RunErrorPayload{
@@ -524,22 +723,19 @@ func (wac *WriterAgentCommand) RunIntoWriter(
 			}
 			return errors.Wrap(agentErr, "failed to run agent")
 		} else {
-			log.Info().Str("agentType", wac.AgentCommand.AgentType).Str("runID", runID).Msg("WriterAgent logic finished")
+			log.Info().Str("agentType", a.AgentType).Str("runID", runID).Msg("WriterAgent logic finished")
 		}
 
 		// Write the final result string to the provided writer
 		_, writeErr := fmt.Fprintln(w, resultStr)
 		if writeErr != nil {
-			log.Error().Err(writeErr).Str("agentType", wac.AgentCommand.AgentType).Str("runID", runID).Msg("Failed to write agent result")
+			log.Error().Err(writeErr).Str("agentType", a.AgentType).Str("runID", runID).Msg("Failed to write agent result")
 			return errors.Wrap(writeErr, "failed to write agent result")
 		}
 
-		// Emit run finished event if possible
+		// Emit run finished event with the stats gathered from the run's events
 		if eb != nil {
-			// TODO(manuel): Gather actual stats for RunFinishedPayload
-			finPayload := &events.RunFinishedPayload{
-				// Populate with actual stats later
-			}
+			finPayload := eb.RunFinishedPayload(runID, time.Since(runStartTime))
 			_ = eb.EmitRunFinished(context.Background(), finPayload, &runID)
 		}
 
@@ -552,6 +748,9 @@ func (wac *WriterAgentCommand) RunIntoWriter(
 	if waitErr != nil {
 		if errors.Is(waitErr, context.Canceled) || errors.Is(waitErr, context.DeadlineExceeded) {
 			log.Info().Str("runID", runID).Msg("Agent/Router execution cancelled or timed out (WriterAgent)")
+			if cfg.parent != nil {
+				return errors.Wrap(waitErr, "delegated run cancelled or timed out")
+			}
 			return nil
 		}
 		log.Error().Err(waitErr).Str("runID", runID).Msg("Agent execution or router shutdown failed (WriterAgent)")
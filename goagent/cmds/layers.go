@@ -1,8 +1,14 @@
 package cmds
 
 import (
+	"time"
+
 	"github.com/go-go-golems/glazed/pkg/cmds/layers"
 	"github.com/go-go-golems/glazed/pkg/cmds/parameters"
+	"github.com/go-go-golems/go-go-agent/goagent/cassette"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/go-go-golems/go-go-agent/goagent/tracing"
+	"github.com/go-go-golems/go-go-agent/pkg/eventlog"
 	"github.com/pkg/errors"
 )
 
@@ -68,3 +74,219 @@ func GetAgentSettingsFromParsedLayers(parsedLayers *layers.ParsedLayers) (*Agent
 	}
 	return s, nil
 }
+
+// RunLayerSlug is the unique identifier for the parameter layer holding the run
+// settings shared by all agent commands
+const RunLayerSlug = "goagent-run"
+
+// RunSettings holds the settings controlling a single agent run
+type RunSettings struct {
+	MaxCost          float64 `glazed.parameter:"max-cost"`
+	PriceFile        string  `glazed.parameter:"price-file"`
+	StreamIntervalMs int     `glazed.parameter:"stream-interval-ms"`
+	Checkpoint       bool    `glazed.parameter:"checkpoint"`
+	CheckpointDB     string  `glazed.parameter:"checkpoint-db"`
+	Resume           string  `glazed.parameter:"resume"`
+	Cassette         string  `glazed.parameter:"cassette"`
+	CassetteMode     string  `glazed.parameter:"cassette-mode"`
+	CassetteMatch    string  `glazed.parameter:"cassette-match"`
+}
+
+// NewRunParameterLayer creates the parameter layer for run settings
+func NewRunParameterLayer() (layers.ParameterLayer, error) {
+	return layers.NewParameterLayer(
+		RunLayerSlug,
+		"Agent run options",
+		layers.WithParameterDefinitions(
+			parameters.NewParameterDefinition(
+				"max-cost",
+				parameters.ParameterTypeFloat,
+				parameters.WithHelp("Abort the run once its LLM calls cost more than this many USD (0 = no limit)"),
+				parameters.WithDefault(0.0),
+			),
+			parameters.NewParameterDefinition(
+				"price-file",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("YAML file with model prices in USD per million tokens (defaults to the built-in table)"),
+				parameters.WithDefault(""),
+			),
+			parameters.NewParameterDefinition(
+				"stream-interval-ms",
+				parameters.ParameterTypeInteger,
+				parameters.WithHelp("Minimum time in milliseconds between two streamed output events of an LLM call (0 = every chunk)"),
+				parameters.WithDefault(int(llm.DefaultDeltaInterval/time.Millisecond)),
+			),
+			parameters.NewParameterDefinition(
+				"checkpoint",
+				parameters.ParameterTypeBool,
+				parameters.WithHelp("Save the state of the agent after each step, so that the run can be continued with --resume"),
+				parameters.WithDefault(false),
+			),
+			parameters.NewParameterDefinition(
+				"checkpoint-db",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("SQLite database holding the checkpoints of runs"),
+				parameters.WithDefault("./goagent-checkpoints.db"),
+			),
+			parameters.NewParameterDefinition(
+				"resume",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("ID of an interrupted run to continue from its last completed step (implies --checkpoint)"),
+				parameters.WithDefault(""),
+			),
+			parameters.NewParameterDefinition(
+				"cassette",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("YAML or JSON file to record the LLM and tool calls of the run to, or to replay them from"),
+				parameters.WithDefault(""),
+			),
+			parameters.NewParameterDefinition(
+				"cassette-mode",
+				parameters.ParameterTypeChoice,
+				parameters.WithHelp("Record the calls to the cassette, or replay them without calling the LLM and tools"),
+				parameters.WithDefault(string(cassette.ModeReplay)),
+				parameters.WithChoices(cassette.Modes...),
+			),
+			parameters.NewParameterDefinition(
+				"cassette-match",
+				parameters.ParameterTypeChoice,
+				parameters.WithHelp("How replayed calls are matched with the recorded ones"),
+				parameters.WithDefault(string(cassette.MatchExact)),
+				parameters.WithChoices(cassette.MatchModes...),
+			),
+		),
+	)
+}
+
+// GetRunSettingsFromParsedLayers extracts run settings from parsed layers
+func GetRunSettingsFromParsedLayers(parsedLayers *layers.ParsedLayers) (*RunSettings, error) {
+	s := &RunSettings{}
+	if err := parsedLayers.InitializeStruct(RunLayerSlug, s); err != nil {
+		return nil, errors.Wrap(err, "failed to initialize run settings from parsed layers")
+	}
+	return s, nil
+}
+
+// EventSinksLayerSlug is the unique identifier for the parameter layer selecting where
+// the events of agent runs started from the command line are published
+const EventSinksLayerSlug = "goagent-events"
+
+// Event sinks of agent runs
+const (
+	// EventSinkStdout prints the events to stdout
+	EventSinkStdout = "stdout"
+	// EventSinkRedisStream adds the events to the Redis stream of the stream layer
+	EventSinkRedisStream = "redis-stream"
+	// EventSinkRedisPubSub publishes the events on the Redis Pub/Sub channel of the run
+	EventSinkRedisPubSub = "redis-pubsub"
+	// EventSinkJSONL appends the events to a rotating JSONL file per run
+	EventSinkJSONL = "jsonl"
+)
+
+// EventSinkSettings holds the destinations of the events of a run
+type EventSinkSettings struct {
+	Sinks              []string `glazed.parameter:"event-sinks"`
+	EventLogDir        string   `glazed.parameter:"event-log-dir"`
+	EventLogMaxSize    int      `glazed.parameter:"event-log-max-size"`
+	EventLogMaxBackups int      `glazed.parameter:"event-log-max-backups"`
+}
+
+// NewEventSinksParameterLayer creates the parameter layer selecting the event sinks
+func NewEventSinksParameterLayer() (layers.ParameterLayer, error) {
+	return layers.NewParameterLayer(
+		EventSinksLayerSlug,
+		"Agent event options",
+		layers.WithParameterDefinitions(
+			parameters.NewParameterDefinition(
+				"event-sinks",
+				parameters.ParameterTypeChoiceList,
+				parameters.WithHelp("Where to publish the events of the run: stdout, the Redis stream or Pub/Sub channels the server consumes (configured by the redis and stream flags), and a JSONL file per run"),
+				parameters.WithDefault([]string{EventSinkStdout}),
+				parameters.WithChoices(EventSinkStdout, EventSinkRedisStream, EventSinkRedisPubSub, EventSinkJSONL),
+			),
+			parameters.NewParameterDefinition(
+				"event-log-dir",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("Directory of the JSONL files of the jsonl sink, one <run ID>.jsonl file per run"),
+				parameters.WithDefault("./goagent-events"),
+			),
+			parameters.NewParameterDefinition(
+				"event-log-max-size",
+				parameters.ParameterTypeInteger,
+				parameters.WithHelp("Size in megabytes at which the JSONL file of a run is rotated"),
+				parameters.WithDefault(eventlog.DefaultMaxSize),
+			),
+			parameters.NewParameterDefinition(
+				"event-log-max-backups",
+				parameters.ParameterTypeInteger,
+				parameters.WithHelp("Number of rotated JSONL files kept per run (0 = keep all)"),
+				parameters.WithDefault(eventlog.DefaultMaxBackups),
+			),
+		),
+	)
+}
+
+// GetEventSinkSettingsFromParsedLayers extracts the event sink settings from parsed layers
+func GetEventSinkSettingsFromParsedLayers(parsedLayers *layers.ParsedLayers) (*EventSinkSettings, error) {
+	s := &EventSinkSettings{}
+	if err := parsedLayers.InitializeStruct(EventSinksLayerSlug, s); err != nil {
+		return nil, errors.Wrap(err, "failed to initialize event sink settings from parsed layers")
+	}
+	return s, nil
+}
+
+// TracingLayerSlug is the unique identifier for the parameter layer configuring the
+// export of the spans of agent runs
+const TracingLayerSlug = "goagent-tracing"
+
+// TracingSettings holds the OpenTelemetry export settings of a run
+type TracingSettings struct {
+	Exporter     string `glazed.parameter:"trace-exporter"`
+	OTLPEndpoint string `glazed.parameter:"trace-otlp-endpoint"`
+	File         string `glazed.parameter:"trace-file"`
+	ServiceName  string `glazed.parameter:"trace-service-name"`
+}
+
+// NewTracingParameterLayer creates the parameter layer configuring tracing
+func NewTracingParameterLayer() (layers.ParameterLayer, error) {
+	return layers.NewParameterLayer(
+		TracingLayerSlug,
+		"Agent tracing options",
+		layers.WithParameterDefinitions(
+			parameters.NewParameterDefinition(
+				"trace-exporter",
+				parameters.ParameterTypeChoice,
+				parameters.WithHelp("Export the spans of the run, its steps, LLM calls and tool calls to an OTLP/HTTP endpoint or to a file of OTLP/JSON lines"),
+				parameters.WithDefault(tracing.ExporterNone),
+				parameters.WithChoices(tracing.Exporters...),
+			),
+			parameters.NewParameterDefinition(
+				"trace-otlp-endpoint",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("OTLP/HTTP endpoint of the otlp exporter, such as a local collector, Jaeger or Tempo"),
+				parameters.WithDefault(tracing.DefaultOTLPEndpoint),
+			),
+			parameters.NewParameterDefinition(
+				"trace-file",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("File the file exporter appends the spans to"),
+				parameters.WithDefault("./goagent-traces.jsonl"),
+			),
+			parameters.NewParameterDefinition(
+				"trace-service-name",
+				parameters.ParameterTypeString,
+				parameters.WithHelp("Service name of the spans"),
+				parameters.WithDefault(tracing.DefaultServiceName),
+			),
+		),
+	)
+}
+
+// GetTracingSettingsFromParsedLayers extracts the tracing settings from parsed layers
+func GetTracingSettingsFromParsedLayers(parsedLayers *layers.ParsedLayers) (*TracingSettings, error) {
+	s := &TracingSettings{}
+	if err := parsedLayers.InitializeStruct(TracingLayerSlug, s); err != nil {
+		return nil, errors.Wrap(err, "failed to initialize tracing settings from parsed layers")
+	}
+	return s, nil
+}
@@ -0,0 +1,212 @@
+package cmds
+
+import (
+	"os"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/cmds/middlewares"
+	"github.com/go-go-golems/go-go-agent/goagent/agent"
+	"github.com/go-go-golems/go-go-agent/goagent/cassette"
+	"github.com/go-go-golems/go-go-agent/goagent/checkpoint"
+	"github.com/go-go-golems/go-go-agent/goagent/runs"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// runConfig holds the settings of a single agent run
+type runConfig struct {
+	runID     string
+	runIDSet  bool
+	runMode   string
+	publisher message.Publisher
+	topic     string
+	encoder   func(event *events.Event) ([]byte, error)
+	control   *runs.Control
+	approver  tools.Approver
+	// parent is the run that delegated this run through an AgentTool
+	parent           *agentRun
+	parentToolCallID string
+}
+
+// RunOption configures a single run of an AgentCommand
+type RunOption func(*runConfig)
+
+// WithRunID sets the ID of the run. Defaults to a new UUID.
+func WithRunID(runID string) RunOption {
+	return func(c *runConfig) {
+		c.runID = runID
+		c.runIDSet = true
+	}
+}
+
+// WithRunMode sets the run mode reported in the run_started event. Defaults to "cli".
+func WithRunMode(runMode string) RunOption {
+	return func(c *runConfig) {
+		c.runMode = runMode
+	}
+}
+
+// WithEventPublisher publishes the events of the run to topic with publisher, encoded
+// with encoder, instead of printing them to stdout.
+func WithEventPublisher(publisher message.Publisher, topic string, encoder func(event *events.Event) ([]byte, error)) RunOption {
+	return func(c *runConfig) {
+		c.publisher = publisher
+		c.topic = topic
+		c.encoder = encoder
+	}
+}
+
+// WithRunControl lets control pause and resume the run
+func WithRunControl(control *runs.Control) RunOption {
+	return func(c *runConfig) {
+		c.control = control
+	}
+}
+
+// WithToolApprover decides the tool calls of the run that require approval. Defaults
+// to asking on the terminal.
+func WithToolApprover(approver tools.Approver) RunOption {
+	return func(c *runConfig) {
+		c.approver = approver
+	}
+}
+
+// withParentRun nests the run in parent, the run whose tool call toolCallID started
+// it. The events of the run are published with the events of parent.
+func withParentRun(parent *agentRun, toolCallID string) RunOption {
+	return func(c *runConfig) {
+		c.parent = parent
+		c.parentToolCallID = toolCallID
+	}
+}
+
+func newRunConfig(options ...RunOption) *runConfig {
+	c := &runConfig{
+		runMode: "cli",
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if c.runID == "" {
+		c.runID = uuid.New().String()
+	}
+	if c.approver == nil {
+		c.approver = tools.NewTerminalApprover(os.Stdin, os.Stderr)
+	}
+	return c
+}
+
+// openCheckpointStore opens the checkpoint store if the run is checkpointed or resumed,
+// and returns nil otherwise. A resumed run keeps the ID of the run it continues, so it
+// must be called before the run ID is used.
+func (c *runConfig) openCheckpointStore(runSettings *RunSettings) (checkpoint.Store, error) {
+	if runSettings.Resume != "" {
+		if c.runIDSet && c.runID != runSettings.Resume {
+			return nil, errors.Errorf("cannot resume run %s as run %s", runSettings.Resume, c.runID)
+		}
+		c.runID = runSettings.Resume
+	} else if !runSettings.Checkpoint {
+		return nil, nil
+	}
+
+	store, err := checkpoint.NewSQLiteStore(runSettings.CheckpointDB)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open checkpoint database %s", runSettings.CheckpointDB)
+	}
+	return store, nil
+}
+
+// attachCheckpointStore lets agentInstance checkpoint the run in store. Resuming a run
+// with an agent that doesn't support checkpoints is an error.
+func attachCheckpointStore(agentInstance agent.Agent, store checkpoint.Store, runID string, resume bool) error {
+	if store == nil {
+		return nil
+	}
+	checkpointer, ok := agentInstance.(agent.Checkpointer)
+	if !ok {
+		if resume {
+			return errors.Errorf("agent %T cannot resume runs", agentInstance)
+		}
+		log.Warn().Str("runID", runID).Msgf("Agent %T does not support checkpoints, the run will not be checkpointed", agentInstance)
+		return nil
+	}
+	checkpointer.SetCheckpointStore(store, runID, resume)
+	log.Info().Str("runID", runID).Bool("resume", resume).Msg("Checkpointing run")
+	return nil
+}
+
+// openCassette opens the cassette recording or replaying the calls of the run, and
+// returns nil if no cassette is configured.
+func openCassette(runSettings *RunSettings) (*cassette.Cassette, error) {
+	if runSettings.Cassette == "" {
+		return nil, nil
+	}
+	mode, err := cassette.ParseMode(runSettings.CassetteMode)
+	if err != nil {
+		return nil, err
+	}
+	match, err := cassette.ParseMatchMode(runSettings.CassetteMatch)
+	if err != nil {
+		return nil, err
+	}
+	return cassette.Open(runSettings.Cassette, mode, cassette.WithMatchMode(match))
+}
+
+// attachCassette lets the cassette record or replay the tool calls of agentInstance.
+// Agent tools are not wrapped: the runs they delegate to record and replay their own
+// calls on the cassette, so that replaying reproduces the nested runs.
+func attachCassette(agentInstance agent.Agent, c *cassette.Cassette) {
+	if c == nil {
+		return
+	}
+	wrapper, ok := agentInstance.(agent.ToolWrapper)
+	if !ok {
+		log.Warn().Msgf("Agent %T does not support wrapping its tools, its tool calls bypass the cassette", agentInstance)
+		return
+	}
+	wrapper.WrapTools(func(tool tools.Tool) tools.Tool {
+		if _, ok := tool.(*AgentTool); ok {
+			return tool
+		}
+		return c.WrapTool(tool)
+	})
+}
+
+// ParseParameters parses parameter values for the command without a command line.
+// parameters are the values of the default layer, layerValues those of other layers by
+// slug. Missing parameters are read from PINOCCHIO_* environment variables, then set to
+// their defaults.
+func (a *AgentCommand) ParseParameters(
+	parameters map[string]interface{},
+	layerValues map[string]map[string]interface{},
+) (*layers.ParsedLayers, error) {
+	values := map[string]map[string]interface{}{}
+	for slug, v := range layerValues {
+		values[slug] = v
+	}
+	if len(parameters) > 0 {
+		defaults := map[string]interface{}{}
+		for k, v := range values[layers.DefaultSlug] {
+			defaults[k] = v
+		}
+		for k, v := range parameters {
+			defaults[k] = v
+		}
+		values[layers.DefaultSlug] = defaults
+	}
+
+	parsedLayers := layers.NewParsedLayers()
+	err := middlewares.ExecuteMiddlewares(a.Layers, parsedLayers,
+		middlewares.UpdateFromMap(values),
+		middlewares.UpdateFromEnv("PINOCCHIO"),
+		middlewares.SetFromDefaults(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parsedLayers, nil
+}
@@ -0,0 +1,253 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/go-go-golems/geppetto/pkg/helpers"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/go-go-agent/goagent/tracing"
+	"github.com/go-go-golems/go-go-agent/internal/redis"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/eventlog"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// eventSink is a destination of the events of a run, other than stdout
+type eventSink struct {
+	name      string
+	publisher message.Publisher
+	topic     string
+	format    codec.Format
+}
+
+// newEventBus creates the event bus of a run started from the command line, publishing
+// to the sinks selected with --event-sinks:
+//   - stdout prints the events through a local router running StdoutEventHandler,
+//     which is returned with the topic of the bus
+//   - redis-stream and redis-pubsub publish the events as model.Event JSON, the format
+//     consumed by the server, to the stream or to the Pub/Sub channel of the run
+//   - jsonl appends the events as protojson to the file of the run in --event-log-dir
+//
+// With a --trace-exporter, the events are also recorded as spans by a
+// tracing.SpanRecorder. The router is nil without the stdout sink, and the event bus
+// is nil without sinks.
+func (a *AgentCommand) newEventBus(
+	ctx context.Context,
+	parsedLayers *layers.ParsedLayers,
+	runID string,
+) (*eventbus.EventBus, *message.Router, string, error) {
+	sinkSettings, err := GetEventSinkSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	sinks, err := openRedisEventSinks(ctx, parsedLayers, sinkSettings.Sinks, runID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if slices.Contains(sinkSettings.Sinks, EventSinkJSONL) {
+		logSink, err := openEventLogSink(sinkSettings, runID)
+		if err != nil {
+			closeEventSinks(sinks)
+			return nil, nil, "", err
+		}
+		sinks = append(sinks, *logSink)
+	}
+	traceSink, err := openTraceSink(ctx, parsedLayers, runID)
+	if err != nil {
+		closeEventSinks(sinks)
+		return nil, nil, "", err
+	}
+	if traceSink != nil {
+		sinks = append(sinks, *traceSink)
+	}
+
+	var options []eventbus.EventBusOption
+	for _, s := range sinks {
+		options = append(options, eventbus.WithSink(s.publisher, s.topic, s.format))
+	}
+
+	if !slices.Contains(sinkSettings.Sinks, EventSinkStdout) {
+		if len(sinks) == 0 {
+			return nil, nil, "", nil
+		}
+		// The first sink is the publisher of the bus
+		eb, err := eventbus.NewEventBus(
+			append([]eventbus.EventBusOption{
+				eventbus.WithPublisher(sinks[0].publisher),
+				eventbus.WithTopic(sinks[0].topic),
+				eventbus.WithFormat(sinks[0].format),
+			}, options[1:]...)...,
+		)
+		if err != nil {
+			closeEventSinks(sinks)
+			return nil, nil, "", errors.Wrap(err, "failed to create event bus")
+		}
+		return eb, nil, sinks[0].topic, nil
+	}
+
+	// Create a simple GoChannel pub/sub for local event handling
+	logger := helpers.NewWatermill(log.Logger)
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, logger)
+
+	topicID := fmt.Sprintf("%s-agent-events-%s", a.Name, runID)
+
+	// Create the EventBus
+	eb, err := eventbus.NewEventBus(
+		append([]eventbus.EventBusOption{
+			eventbus.WithPublisher(pubSub),
+			eventbus.WithTopic(topicID),
+			// Use protojson for human-readable stdout printing
+			eventbus.WithFormat(codec.FormatProtoJSON),
+		}, options...)...,
+	)
+	if err != nil {
+		closeEventSinks(sinks)
+		return nil, nil, "", errors.Wrap(err, "failed to create event bus")
+	}
+
+	// Create and configure the router
+	router, err := message.NewRouter(message.RouterConfig{}, logger)
+	if err != nil {
+		_ = eb.Close() // Attempt to close event bus on error
+		return nil, nil, "", errors.Wrap(err, "failed to create message router")
+	}
+
+	// Add the stdout printing handler
+	handlerName := "stdout-event-printer-" + runID
+	log.Info().Str("handler", handlerName).Str("topic", topicID).Msg("Registering stdout event handler")
+	router.AddHandler(
+		handlerName,
+		topicID,
+		pubSub, // Subscribe to the same pub/sub
+		topicID,
+		pubSub,             // Publish ACKs/NACKs to the same pub/sub (for potential future use)
+		StdoutEventHandler, // Use the new handler
+	)
+
+	return eb, router, topicID, nil
+}
+
+// openRedisEventSinks connects to Redis for the Redis sinks of sinks, with the settings
+// of the redis and stream layers. Stream sinks add the events to the stream named by
+// --stream-name, Pub/Sub sinks publish them on the channel of the run matching
+// --topic-pattern, agent_events:<runID> by default.
+func openRedisEventSinks(
+	ctx context.Context,
+	parsedLayers *layers.ParsedLayers,
+	sinks []string,
+	runID string,
+) ([]eventSink, error) {
+	var transports []redis.TransportType
+	for _, s := range sinks {
+		switch s {
+		case EventSinkRedisStream:
+			transports = append(transports, redis.TransportStream)
+		case EventSinkRedisPubSub:
+			transports = append(transports, redis.TransportPubSub)
+		}
+	}
+	if len(transports) == 0 {
+		return nil, nil
+	}
+
+	redisSettings, err := redis.GetRedisSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get redis settings")
+	}
+	streamSettings, err := redis.GetStreamSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get stream settings")
+	}
+
+	var ret []eventSink
+	for _, transport := range transports {
+		config := redis.DefaultRouterConfig()
+		config.RedisURL = redisSettings.URL
+		config.RedisPassword = redisSettings.Password
+		config.RedisDB = redisSettings.DB
+		config.RedisMaxRetries = redisSettings.MaxRetries
+		config.RedisDialTimeout = redisSettings.DialTimeout
+		config.TransportType = transport
+
+		sink := eventSink{
+			name:   fmt.Sprintf("redis %s %s", transport, redisSettings.URL),
+			topic:  streamSettings.StreamName,
+			format: codec.FormatModelJSON,
+		}
+		if transport == redis.TransportPubSub {
+			sink.topic = redis.PubSubChannel(streamSettings.TopicPattern, runID)
+		}
+		sink.publisher, err = redis.NewPublisher(ctx, config, log.Logger)
+		if err != nil {
+			closeEventSinks(ret)
+			return nil, errors.Wrapf(err, "failed to open event sink %s", sink.name)
+		}
+		log.Info().Str("sink", sink.name).Str("topic", sink.topic).Str("runID", runID).Msg("Publishing run events to Redis")
+		ret = append(ret, sink)
+	}
+	return ret, nil
+}
+
+// openEventLogSink creates the sink appending the events of the run to its JSONL file
+// in --event-log-dir
+func openEventLogSink(settings *EventSinkSettings, runID string) (*eventSink, error) {
+	publisher, err := eventlog.NewPublisher(
+		settings.EventLogDir,
+		eventlog.WithMaxSize(settings.EventLogMaxSize),
+		eventlog.WithMaxBackups(settings.EventLogMaxBackups),
+	)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("path", publisher.Path(runID)).Str("runID", runID).Msg("Logging run events")
+	return &eventSink{
+		name:      "jsonl " + settings.EventLogDir,
+		publisher: publisher,
+		topic:     runID,
+		format:    codec.FormatProto,
+	}, nil
+}
+
+// openTraceSink creates the sink recording the events of the run as spans exported
+// with the settings of the tracing layer, nil without --trace-exporter
+func openTraceSink(ctx context.Context, parsedLayers *layers.ParsedLayers, runID string) (*eventSink, error) {
+	tracingSettings, err := GetTracingSettingsFromParsedLayers(parsedLayers)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []tracing.ProviderOption{tracing.WithServiceName(tracingSettings.ServiceName)}
+	switch tracingSettings.Exporter {
+	case tracing.ExporterNone:
+		return nil, nil
+	case tracing.ExporterOTLP:
+		options = append(options, tracing.WithOTLPEndpoint(tracingSettings.OTLPEndpoint))
+	case tracing.ExporterFile:
+		options = append(options, tracing.WithFile(tracingSettings.File))
+	}
+	provider, err := tracing.NewTracerProvider(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("exporter", tracingSettings.Exporter).Str("runID", runID).Msg("Exporting run spans")
+	return &eventSink{
+		name:      "tracing " + tracingSettings.Exporter,
+		publisher: tracing.NewSpanRecorder(provider),
+		topic:     runID,
+		format:    codec.FormatProto,
+	}, nil
+}
+
+func closeEventSinks(sinks []eventSink) {
+	for _, s := range sinks {
+		if err := s.publisher.Close(); err != nil {
+			log.Warn().Err(err).Str("sink", s.name).Msg("Failed to close event sink")
+		}
+	}
+}
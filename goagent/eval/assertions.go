@@ -0,0 +1,202 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AssertionResult is the outcome of an assertion. Score is between 0 and 1; only
+// llm-judge assertions have scores other than 0 or 1.
+type AssertionResult struct {
+	Type        string  `json:"type"`
+	Description string  `json:"description"`
+	Passed      bool    `json:"passed"`
+	Score       float64 `json:"score"`
+	Message     string  `json:"message,omitempty"`
+}
+
+func (a *Assertion) describe() string {
+	switch a.Type {
+	case AssertRegex:
+		return fmt.Sprintf("regex /%s/", a.Pattern)
+	case AssertJSONPath:
+		switch {
+		case a.Pattern != "":
+			return fmt.Sprintf("json-path %s =~ /%s/", a.Path, a.Pattern)
+		case a.Expected != nil:
+			return fmt.Sprintf("json-path %s == %s", a.Path, jsonString(a.Expected))
+		default:
+			return fmt.Sprintf("json-path %s exists", a.Path)
+		}
+	default:
+		return a.Type
+	}
+}
+
+func passed(a *Assertion) AssertionResult {
+	return AssertionResult{Type: a.Type, Description: a.describe(), Passed: true, Score: 1}
+}
+
+func failed(a *Assertion, format string, args ...interface{}) AssertionResult {
+	return AssertionResult{Type: a.Type, Description: a.describe(), Message: fmt.Sprintf(format, args...)}
+}
+
+// checkExact compares output with the expected value, ignoring surrounding whitespace
+func checkExact(a *Assertion, output string) AssertionResult {
+	expected := strings.TrimSpace(fmt.Sprint(a.Expected))
+	if strings.TrimSpace(output) != expected {
+		return failed(a, "output differs from the expected value")
+	}
+	return passed(a)
+}
+
+func checkRegex(a *Assertion, output string) AssertionResult {
+	re, err := regexp.Compile(a.Pattern)
+	if err != nil {
+		return failed(a, "invalid pattern: %v", err)
+	}
+	if !re.MatchString(output) {
+		return failed(a, "output does not match")
+	}
+	return passed(a)
+}
+
+// checkJSONPath checks the value at the path of the assertion in data, the parsed
+// output of the case. dataErr is the error of parsing output that is not JSON.
+func checkJSONPath(a *Assertion, data interface{}, dataErr error) AssertionResult {
+	if dataErr != nil {
+		return failed(a, "output is not JSON: %v", dataErr)
+	}
+	path, err := parseJSONPath(a.Path)
+	if err != nil {
+		return failed(a, "%v", err)
+	}
+	value, err := path.lookup(data)
+	if err != nil {
+		return failed(a, "%v", err)
+	}
+
+	switch {
+	case a.Pattern != "":
+		re, err := regexp.Compile(a.Pattern)
+		if err != nil {
+			return failed(a, "invalid pattern: %v", err)
+		}
+		s, ok := value.(string)
+		if !ok {
+			s = jsonString(value)
+		}
+		if !re.MatchString(s) {
+			return failed(a, "value %s does not match", jsonString(value))
+		}
+	case a.Expected != nil:
+		if jsonString(value) != jsonString(a.Expected) {
+			return failed(a, "value is %s", jsonString(value))
+		}
+	}
+	return passed(a)
+}
+
+// jsonString renders v as compact JSON, so that values decoded from YAML and JSON
+// compare equal
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// jsonPath is a parsed path of object keys and array indexes, such as
+// $.forecast[0]['wind speed']
+type jsonPath []interface{}
+
+func parseJSONPath(s string) (jsonPath, error) {
+	var ret jsonPath
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "$")
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, errors.Errorf("empty key in JSON path '%s'", s)
+			}
+			ret = append(ret, rest[:end])
+			rest = rest[end:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, errors.Errorf("unclosed bracket in JSON path '%s'", s)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				ret = append(ret, inner[1:len(inner)-1])
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, errors.Errorf("invalid index '%s' in JSON path '%s'", inner, s)
+			}
+			ret = append(ret, index)
+		default:
+			return nil, errors.Errorf("invalid JSON path '%s' (expected . or [ at '%s')", s, rest)
+		}
+	}
+	return ret, nil
+}
+
+func (p jsonPath) lookup(data interface{}) (interface{}, error) {
+	current := data
+	for i, segment := range p {
+		switch s := segment.(type) {
+		case string:
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("%s is not an object", p[:i])
+			}
+			v, ok := m[s]
+			if !ok {
+				return nil, errors.Errorf("%s not found", p[:i+1])
+			}
+			current = v
+		case int:
+			a, ok := current.([]interface{})
+			if !ok {
+				return nil, errors.Errorf("%s is not an array", p[:i])
+			}
+			if s >= len(a) {
+				return nil, errors.Errorf("%s not found (array has %d elements)", p[:i+1], len(a))
+			}
+			current = a[s]
+		}
+	}
+	return current, nil
+}
+
+func (p jsonPath) String() string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, segment := range p {
+		switch s := segment.(type) {
+		case string:
+			if strings.ContainsAny(s, " .[]'\"") {
+				fmt.Fprintf(&sb, "[%q]", s)
+			} else {
+				sb.WriteString("." + s)
+			}
+		case int:
+			fmt.Fprintf(&sb, "[%d]", s)
+		}
+	}
+	return sb.String()
+}
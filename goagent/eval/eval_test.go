@@ -0,0 +1,197 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestCheckExact(t *testing.T) {
+	testCases := []struct {
+		expected interface{}
+		output   string
+		passed   bool
+	}{
+		{"Paris", "Paris", true},
+		{"Paris", "  Paris\n", true},
+		{"Paris", "paris", false},
+		{42, "42", true},
+		{"Paris", "Paris, France", false},
+	}
+	for _, tc := range testCases {
+		a := &Assertion{Type: AssertExact, Expected: tc.expected}
+		if got := checkExact(a, tc.output); got.Passed != tc.passed {
+			t.Errorf("checkExact(%v, %q) passed = %v, want %v", tc.expected, tc.output, got.Passed, tc.passed)
+		}
+	}
+}
+
+func TestCheckRegex(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		output  string
+		passed  bool
+		message string
+	}{
+		{"(?i)paris", "It is sunny in PARIS", true, ""},
+		{"°|degrees", "24 degrees", true, ""},
+		{"^sunny$", "sunny and warm", false, "output does not match"},
+		{"(unclosed", "anything", false, "invalid pattern: error parsing regexp: missing closing ): `(unclosed`"},
+	}
+	for _, tc := range testCases {
+		a := &Assertion{Type: AssertRegex, Pattern: tc.pattern}
+		got := checkRegex(a, tc.output)
+		if got.Passed != tc.passed || got.Message != tc.message {
+			t.Errorf("checkRegex(%s, %q) = %v %q, want %v %q", tc.pattern, tc.output, got.Passed, got.Message, tc.passed, tc.message)
+		}
+	}
+}
+
+func TestCheckJSONPath(t *testing.T) {
+	output := `{"city": "Paris", "forecast": [{"temp": 24, "wind speed": "12 km/h"}], "tags": ["sunny"]}`
+	var data interface{}
+	if err := json.Unmarshal([]byte(output), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		assertion *Assertion
+		dataErr   error
+		passed    bool
+		message   string
+	}{
+		{
+			name:      "exists",
+			assertion: &Assertion{Path: "$.city"},
+			passed:    true,
+		},
+		{
+			name:      "expected string",
+			assertion: &Assertion{Path: "$.city", Expected: "Paris"},
+			passed:    true,
+		},
+		{
+			// Numbers decoded from YAML compare equal to those decoded from JSON
+			name:      "expected number",
+			assertion: &Assertion{Path: "$.forecast[0].temp", Expected: 24},
+			passed:    true,
+		},
+		{
+			name:      "quoted key",
+			assertion: &Assertion{Path: "$.forecast[0]['wind speed']", Pattern: "km/h$"},
+			passed:    true,
+		},
+		{
+			name:      "pattern on a non-string value",
+			assertion: &Assertion{Path: "$.tags", Pattern: `\["sunny"\]`},
+			passed:    true,
+		},
+		{
+			name:      "different value",
+			assertion: &Assertion{Path: "$.city", Expected: "Tokyo"},
+			message:   `value is "Paris"`,
+		},
+		{
+			name:      "missing key",
+			assertion: &Assertion{Path: "$.country"},
+			message:   "$.country not found",
+		},
+		{
+			name:      "index out of range",
+			assertion: &Assertion{Path: "$.forecast[1]"},
+			message:   "$.forecast[1] not found (array has 1 elements)",
+		},
+		{
+			name:      "key of an array",
+			assertion: &Assertion{Path: "$.tags.first"},
+			message:   "$.tags is not an object",
+		},
+		{
+			name:      "output is not JSON",
+			assertion: &Assertion{Path: "$.city"},
+			dataErr:   errors.New("invalid character"),
+			message:   "output is not JSON: invalid character",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.assertion.Type = AssertJSONPath
+			got := checkJSONPath(tc.assertion, data, tc.dataErr)
+			if got.Passed != tc.passed || got.Message != tc.message {
+				t.Errorf("checkJSONPath() = %v %q, want %v %q", got.Passed, got.Message, tc.passed, tc.message)
+			}
+		})
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	for _, path := range []string{"$.a..b", "$.a[0", "$.a[-1]", "$a"} {
+		if _, err := parseJSONPath(path); err == nil {
+			t.Errorf("parseJSONPath(%s) succeeded, want an error", path)
+		}
+	}
+}
+
+func TestReportCompare(t *testing.T) {
+	baseline := &Report{Cases: []*CaseResult{
+		{Name: "same", Passed: true, Score: 1, Output: "sunny"},
+		{Name: "regressed", Passed: true, Score: 1, Output: "sunny"},
+		{Name: "fixed", Passed: false, Score: 0.5, Output: "rainy"},
+		{Name: "changed", Passed: true, Score: 0.9, Output: "line 1\nline 2\nline 3"},
+		{Name: "removed", Passed: true, Score: 1},
+	}}
+	report := &Report{Cases: []*CaseResult{
+		{Name: "same", Passed: true, Score: 1, Output: "sunny"},
+		{Name: "regressed", Passed: false, Score: 0, Output: "cloudy"},
+		{Name: "fixed", Passed: true, Score: 1, Output: "rainy"},
+		{Name: "changed", Passed: true, Score: 0.9, Output: "line 1\nline two\nline 3"},
+		{Name: "new", Passed: true, Score: 1},
+	}}
+	report.Compare(baseline)
+
+	expected := []CaseChange{
+		{Name: "same", Status: StatusUnchanged, BaselineScore: 1, Score: 1},
+		{Name: "regressed", Status: StatusRegressed, BaselineScore: 1, Score: 0, OutputDiff: "- sunny\n+ cloudy\n"},
+		{Name: "fixed", Status: StatusFixed, BaselineScore: 0.5, Score: 1},
+		{Name: "changed", Status: StatusChanged, BaselineScore: 0.9, Score: 0.9, OutputDiff: "  line 1\n- line 2\n+ line two\n  line 3\n"},
+		{Name: "new", Status: StatusNew, Score: 1},
+		{Name: "removed", Status: StatusRemoved, BaselineScore: 1},
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("got %d changes, want %d", len(report.Changes), len(expected))
+	}
+	for i, change := range report.Changes {
+		if *change != expected[i] {
+			t.Errorf("change %d = %+v, want %+v", i, *change, expected[i])
+		}
+	}
+}
+
+func TestWeatherSuiteReplay(t *testing.T) {
+	// The example suite replays its committed cassettes without network access
+	suite, err := LoadSuite(filepath.Join("..", "examples", "evals", "weather.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The LLM is still created from the settings, which require an API key
+	for _, c := range suite.Cases {
+		c.Layers = map[string]map[string]interface{}{"openai-chat": {"openai-api-key": "replay"}}
+	}
+	runner, err := NewRunner(suite, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range report.Cases {
+		if !c.Passed {
+			t.Errorf("case %s failed: %s %+v", c.Name, c.Error, c.Assertions)
+		}
+	}
+}
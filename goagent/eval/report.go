@@ -0,0 +1,244 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Report holds the results of a suite run
+type Report struct {
+	Suite     string        `json:"suite"`
+	Command   string        `json:"command"`
+	Mode      string        `json:"mode"`
+	CreatedAt time.Time     `json:"created_at"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	Score     float64       `json:"score"`
+	Cases     []*CaseResult `json:"cases"`
+	// Changes compares the cases with those of the baseline report
+	Changes []*CaseChange `json:"changes,omitempty"`
+}
+
+// CaseResult is the outcome of a case. Its score is the average of the scores of its
+// assertions; cases that fail to run score 0.
+type CaseResult struct {
+	Name       string            `json:"name"`
+	Passed     bool              `json:"passed"`
+	Score      float64           `json:"score"`
+	Output     string            `json:"output"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+}
+
+// Status of a case compared with the baseline
+const (
+	StatusNew       = "new"
+	StatusRemoved   = "removed"
+	StatusFixed     = "fixed"
+	StatusRegressed = "regressed"
+	StatusChanged   = "changed"
+	StatusUnchanged = "unchanged"
+)
+
+// CaseChange compares a case with its baseline result
+type CaseChange struct {
+	Name          string  `json:"name"`
+	Status        string  `json:"status"`
+	BaselineScore float64 `json:"baseline_score"`
+	Score         float64 `json:"score"`
+	// OutputDiff is a line diff from the baseline output to the output, empty if the
+	// output did not change
+	OutputDiff string `json:"output_diff,omitempty"`
+}
+
+func (r *Report) summarize() {
+	r.Passed, r.Failed, r.Score = 0, 0, 0
+	for _, c := range r.Cases {
+		if c.Passed {
+			r.Passed++
+		} else {
+			r.Failed++
+		}
+		r.Score += c.Score
+	}
+	if len(r.Cases) > 0 {
+		r.Score /= float64(len(r.Cases))
+	}
+}
+
+// LoadReport reads a JSON report. It returns nil if the file does not exist.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read report %s", path)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse report %s", path)
+	}
+	return &report, nil
+}
+
+// Save writes the report as JSON to path
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize report")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory of report %s", path)
+	}
+	return errors.Wrapf(os.WriteFile(path, append(data, '\n'), 0644), "failed to write report %s", path)
+}
+
+// Compare sets the changes of the report relative to baseline
+func (r *Report) Compare(baseline *Report) {
+	r.Changes = nil
+	baselineCases := map[string]*CaseResult{}
+	for _, c := range baseline.Cases {
+		baselineCases[c.Name] = c
+	}
+
+	for _, c := range r.Cases {
+		change := &CaseChange{Name: c.Name, Score: c.Score}
+		b, ok := baselineCases[c.Name]
+		delete(baselineCases, c.Name)
+		switch {
+		case !ok:
+			change.Status = StatusNew
+		case b.Passed && !c.Passed:
+			change.Status = StatusRegressed
+		case !b.Passed && c.Passed:
+			change.Status = StatusFixed
+		case b.Output != c.Output || math.Abs(b.Score-c.Score) > 1e-9:
+			change.Status = StatusChanged
+		default:
+			change.Status = StatusUnchanged
+		}
+		if ok {
+			change.BaselineScore = b.Score
+			change.OutputDiff = lineDiff(b.Output, c.Output)
+		}
+		r.Changes = append(r.Changes, change)
+	}
+
+	for _, b := range baseline.Cases {
+		if _, ok := baselineCases[b.Name]; ok {
+			r.Changes = append(r.Changes, &CaseChange{Name: b.Name, Status: StatusRemoved, BaselineScore: b.Score})
+		}
+	}
+}
+
+// WriteText writes a human readable summary of the report to w
+func (r *Report) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Suite %s (%s, %s): %d/%d cases passed, score %.2f\n",
+		r.Suite, r.Command, r.Mode, r.Passed, len(r.Cases), r.Score)
+
+	changes := map[string]*CaseChange{}
+	for _, change := range r.Changes {
+		changes[change.Name] = change
+	}
+
+	for _, c := range r.Cases {
+		status := "PASS"
+		if !c.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&sb, "\n%s %s (score %.2f, %dms)\n", status, c.Name, c.Score, c.DurationMs)
+		if c.Error != "" {
+			fmt.Fprintf(&sb, "  error: %s\n", c.Error)
+		}
+		for _, a := range c.Assertions {
+			mark := "ok  "
+			if !a.Passed {
+				mark = "fail"
+			}
+			fmt.Fprintf(&sb, "  %s %s", mark, a.Description)
+			if a.Type == AssertLLMJudge {
+				fmt.Fprintf(&sb, " (score %.2f)", a.Score)
+			}
+			if a.Message != "" {
+				fmt.Fprintf(&sb, ": %s", a.Message)
+			}
+			sb.WriteString("\n")
+		}
+		if change, ok := changes[c.Name]; ok && change.Status != StatusUnchanged {
+			fmt.Fprintf(&sb, "  %s", change.Status)
+			if change.Status != StatusNew {
+				fmt.Fprintf(&sb, " (baseline score %.2f)", change.BaselineScore)
+			}
+			sb.WriteString("\n")
+			if change.OutputDiff != "" {
+				sb.WriteString("  output diff:\n")
+				for _, line := range strings.Split(strings.TrimRight(change.OutputDiff, "\n"), "\n") {
+					sb.WriteString("    " + line + "\n")
+				}
+			}
+		}
+	}
+
+	for _, change := range r.Changes {
+		if change.Status == StatusRemoved {
+			fmt.Fprintf(&sb, "\nremoved %s (baseline score %.2f)\n", change.Name, change.BaselineScore)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// lineDiff returns the lines removed from a (prefixed with "- ") and added in b
+// (prefixed with "+ "), with the common lines around them. It is empty if a and b
+// are equal.
+func lineDiff(a, b string) string {
+	if a == b {
+		return ""
+	}
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of linesA[i:] and linesB[j:]
+	lcs := make([][]int, len(linesA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(linesB)+1)
+	}
+	for i := len(linesA) - 1; i >= 0; i-- {
+		for j := len(linesB) - 1; j >= 0; j-- {
+			if linesA[i] == linesB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(linesA) || j < len(linesB) {
+		switch {
+		case i < len(linesA) && j < len(linesB) && linesA[i] == linesB[j]:
+			sb.WriteString("  " + linesA[i] + "\n")
+			i++
+			j++
+		case i < len(linesA) && (j == len(linesB) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + linesA[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + linesB[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,322 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	glazed_types "github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/go-go-agent/goagent/cassette"
+	goagentcmds "github.com/go-go-golems/go-go-agent/goagent/cmds"
+	"github.com/go-go-golems/go-go-agent/goagent/llm"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Mode selects where the LLM and tool calls of the cases are answered from
+type Mode string
+
+const (
+	// ModeReplay replays the calls from the cassettes of the cases, without network access
+	ModeReplay Mode = "replay"
+	// ModeRecord runs the calls and records them to the cassettes of the cases
+	ModeRecord Mode = "record"
+	// ModeLive runs the calls without cassettes
+	ModeLive Mode = "live"
+)
+
+// Modes lists the supported evaluation modes
+var Modes = []string{string(ModeReplay), string(ModeRecord), string(ModeLive)}
+
+const judgeSystemPrompt = `You grade the output of an AI agent against a rubric.
+Answer with a single JSON object of the form {"score": <number between 0 and 1>, "reason": "<one sentence>"} and nothing else.`
+
+// Runner runs the cases of a suite through an agent command
+type Runner struct {
+	suite   *Suite
+	command *goagentcmds.AgentCommand
+	glazed  bool
+	mode    Mode
+}
+
+// NewRunner loads the agent command of suite
+func NewRunner(suite *Suite, mode Mode) (*Runner, error) {
+	path := suite.CommandPath()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read agent command %s", path)
+	}
+	commands, err := goagentcmds.LoadFromYAML(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load agent command %s", path)
+	}
+	if len(commands) == 0 {
+		return nil, errors.Errorf("%s contains no command", path)
+	}
+
+	r := &Runner{suite: suite, mode: mode}
+	switch c := commands[0].(type) {
+	case *goagentcmds.WriterAgentCommand:
+		r.command = c.AgentCommand
+	case *goagentcmds.GlazedAgentCommand:
+		r.command = c.AgentCommand
+		r.glazed = true
+	default:
+		return nil, errors.Errorf("%s is not an agent command", path)
+	}
+	return r, nil
+}
+
+// Run runs all cases of the suite, one after the other
+func (r *Runner) Run(ctx context.Context) (*Report, error) {
+	report := &Report{
+		Suite:     r.suite.Name,
+		Command:   r.command.Name,
+		Mode:      string(r.mode),
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, c := range r.suite.Cases {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		log.Info().Str("suite", r.suite.Name).Str("case", c.Name).Msg("Running evaluation case")
+		report.Cases = append(report.Cases, r.runCase(ctx, c))
+	}
+	report.summarize()
+	return report, nil
+}
+
+func (r *Runner) runCase(ctx context.Context, c *Case) *CaseResult {
+	result := &CaseResult{Name: c.Name}
+	start := time.Now()
+	defer func() {
+		result.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	parsedLayers, err := r.parseParameters(c)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	output, err := r.runCommand(ctx, parsedLayers)
+	result.Output = output
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var judgeCassette *cassette.Cassette
+	var data interface{}
+	dataErr := json.Unmarshal([]byte(output), &data)
+	result.Passed = true
+	result.Score = 1
+	if len(c.Assertions) > 0 {
+		result.Score = 0
+	}
+	for _, a := range c.Assertions {
+		var ar AssertionResult
+		switch a.Type {
+		case AssertExact:
+			ar = checkExact(a, output)
+		case AssertRegex:
+			ar = checkRegex(a, output)
+		case AssertJSONPath:
+			ar = checkJSONPath(a, data, dataErr)
+		case AssertLLMJudge:
+			if judgeCassette == nil && r.mode != ModeLive {
+				judgeCassette, err = cassette.Open(r.suite.JudgeCassettePath(c), cassette.Mode(r.mode))
+				if err != nil {
+					ar = failed(a, "%v", err)
+					break
+				}
+			}
+			ar = r.judge(ctx, c, parsedLayers, judgeCassette, a, output)
+		}
+		result.Assertions = append(result.Assertions, ar)
+		result.Passed = result.Passed && ar.Passed
+		result.Score += ar.Score / float64(len(c.Assertions))
+	}
+	return result
+}
+
+// parseParameters parses the parameters of c. Outside of live mode, the run replays
+// or records the cassette of the case.
+func (r *Runner) parseParameters(c *Case) (*layers.ParsedLayers, error) {
+	layerValues := map[string]map[string]interface{}{}
+	for slug, values := range c.Layers {
+		layerValues[slug] = values
+	}
+	if r.mode != ModeLive {
+		runValues := map[string]interface{}{}
+		for k, v := range layerValues[goagentcmds.RunLayerSlug] {
+			runValues[k] = v
+		}
+		runValues["cassette"] = r.suite.CassettePath(c)
+		runValues["cassette-mode"] = string(r.mode)
+		layerValues[goagentcmds.RunLayerSlug] = runValues
+	}
+	parsedLayers, err := r.command.ParseParameters(c.Parameters, layerValues)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid parameters")
+	}
+	return parsedLayers, nil
+}
+
+// runCommand runs the agent and returns its output. The rows of structured data
+// agents are merged into a single JSON object.
+func (r *Runner) runCommand(ctx context.Context, parsedLayers *layers.ParsedLayers) (string, error) {
+	options := []goagentcmds.RunOption{
+		goagentcmds.WithToolApprover(&staticApprover{approve: r.suite.ApproveTools}),
+	}
+
+	if r.glazed {
+		collector := &rowCollector{values: map[string]interface{}{}}
+		if err := r.command.RunIntoGlazeProcessor(ctx, parsedLayers, collector, options...); err != nil {
+			return "", err
+		}
+		b, err := json.MarshalIndent(collector.values, "", "  ")
+		if err != nil {
+			return "", errors.Wrap(err, "failed to serialize agent output")
+		}
+		return string(b), nil
+	}
+
+	var buf bytes.Buffer
+	options = append(options,
+		goagentcmds.WithRunMode("eval"),
+		goagentcmds.WithEventPublisher(discardPublisher{}, "eval-events", eventbus.ModelJSONEncoder),
+	)
+	if err := r.command.RunIntoWriter(ctx, parsedLayers, &buf, options...); err != nil {
+		return buf.String(), err
+	}
+	if err := ctx.Err(); err != nil {
+		return buf.String(), err
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+type judgeVerdict struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// judge asks the LLM of the case to score output against the rubric of a. Judge calls
+// are recorded to, and replayed from, their own cassette.
+func (r *Runner) judge(
+	ctx context.Context,
+	c *Case,
+	parsedLayers *layers.ParsedLayers,
+	judgeCassette *cassette.Cassette,
+	a *Assertion,
+	output string,
+) AssertionResult {
+	var model llm.LLM
+	if r.mode != ModeReplay {
+		stepSettings, err := settings.NewStepSettingsFromParsedLayers(parsedLayers)
+		if err != nil {
+			return failed(a, "failed to create judge settings: %v", err)
+		}
+		model, err = llm.NewGeppettoLLM(stepSettings)
+		if err != nil {
+			return failed(a, "failed to create judge LLM: %v", err)
+		}
+	}
+	if judgeCassette != nil {
+		model = judgeCassette.WrapLLM(model)
+	}
+
+	prompt := "Rubric:\n" + a.Rubric + "\n\n"
+	if len(c.Parameters) > 0 {
+		prompt += "Input parameters:\n" + jsonString(c.Parameters) + "\n\n"
+	}
+	prompt += "Output:\n" + output
+	response, err := model.Generate(ctx, []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleSystem, judgeSystemPrompt),
+		conversation.NewChatMessage(conversation.RoleUser, prompt),
+	})
+	if err != nil {
+		return failed(a, "judge failed: %v", err)
+	}
+
+	text := llm.MessageText(response)
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	var verdict judgeVerdict
+	if start == -1 || end < start {
+		return failed(a, "judge answer is not JSON: %s", text)
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &verdict); err != nil {
+		return failed(a, "failed to parse judge answer: %v", err)
+	}
+	verdict.Score = clamp(verdict.Score)
+
+	threshold := a.Threshold
+	if threshold == 0 {
+		threshold = DefaultJudgeThreshold
+	}
+	return AssertionResult{
+		Type:        a.Type,
+		Description: a.describe(),
+		Passed:      verdict.Score >= threshold,
+		Score:       verdict.Score,
+		Message:     verdict.Reason,
+	}
+}
+
+func clamp(score float64) float64 {
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+// rowCollector merges the rows of a structured data agent into one object
+type rowCollector struct {
+	values map[string]interface{}
+}
+
+func (c *rowCollector) AddRow(_ context.Context, row glazed_types.Row) error {
+	for pair := row.Oldest(); pair != nil; pair = pair.Next() {
+		c.values[pair.Key] = pair.Value
+	}
+	return nil
+}
+
+func (c *rowCollector) Close(context.Context) error {
+	return nil
+}
+
+// staticApprover approves or rejects all tool calls that require approval
+type staticApprover struct {
+	approve bool
+}
+
+func (a *staticApprover) RequestApproval(_ context.Context, _ tools.ApprovalRequest) (tools.ApprovalDecision, error) {
+	if a.approve {
+		return tools.ApprovalDecision{Approved: true}, nil
+	}
+	return tools.ApprovalDecision{Reason: "tool calls are not approved during evaluation (set approve-tools in the suite)"}, nil
+}
+
+// discardPublisher drops the events of evaluated runs
+type discardPublisher struct{}
+
+func (discardPublisher) Publish(string, ...*message.Message) error {
+	return nil
+}
+
+func (discardPublisher) Close() error {
+	return nil
+}
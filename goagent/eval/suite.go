@@ -0,0 +1,168 @@
+// Package eval runs agent commands against suites of cases with expected outputs,
+// scores the results and compares them with a baseline report. Cases replay the LLM
+// and tool calls recorded in cassettes, so that suites run offline.
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a set of cases run against one agent command
+type Suite struct {
+	Name string `yaml:"name"`
+	// Command is the agent command YAML file, relative to the suite file
+	Command string `yaml:"command"`
+	// Cassettes is the directory holding the cassette of each case, relative to the
+	// suite file. Defaults to cassettes.
+	Cassettes string `yaml:"cassettes,omitempty"`
+	// ApproveTools approves the tool calls that require approval. Otherwise they are
+	// rejected.
+	ApproveTools bool    `yaml:"approve-tools,omitempty"`
+	Cases        []*Case `yaml:"cases"`
+
+	dir string
+}
+
+// Case is a single run of the command and the assertions on its output
+type Case struct {
+	// Name identifies the case and names its cassettes
+	Name string `yaml:"name"`
+	// Parameters are the flags and arguments of the command
+	Parameters map[string]interface{} `yaml:"parameters,omitempty"`
+	// Layers sets parameters of other layers by layer slug, e.g. "ai-chat"
+	Layers     map[string]map[string]interface{} `yaml:"layers,omitempty"`
+	Assertions []*Assertion                      `yaml:"assertions,omitempty"`
+}
+
+// Assertion types
+const (
+	// AssertExact compares the output, without leading and trailing whitespace, with Expected
+	AssertExact = "exact"
+	// AssertRegex matches the output against Pattern
+	AssertRegex = "regex"
+	// AssertJSONPath selects the value at Path in the JSON output and compares it with
+	// Expected, or matches it against Pattern
+	AssertJSONPath = "json-path"
+	// AssertLLMJudge asks an LLM to score the output against Rubric
+	AssertLLMJudge = "llm-judge"
+)
+
+// DefaultJudgeThreshold is the minimum score of a passing llm-judge assertion
+const DefaultJudgeThreshold = 0.7
+
+// Assertion is a check of the output of a case
+type Assertion struct {
+	Type     string      `yaml:"type"`
+	Expected interface{} `yaml:"expected,omitempty"`
+	Pattern  string      `yaml:"pattern,omitempty"`
+	Path     string      `yaml:"path,omitempty"`
+	Rubric   string      `yaml:"rubric,omitempty"`
+	// Threshold is the minimum score of a passing llm-judge assertion. Defaults to
+	// DefaultJudgeThreshold.
+	Threshold float64 `yaml:"threshold,omitempty"`
+}
+
+// LoadSuite reads and validates the suite file at path
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read suite %s", path)
+	}
+	var suite Suite
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse suite %s", path)
+	}
+	suite.dir = filepath.Dir(path)
+	if suite.Name == "" {
+		suite.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if suite.Cassettes == "" {
+		suite.Cassettes = "cassettes"
+	}
+	if err := suite.validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid suite %s", path)
+	}
+	return &suite, nil
+}
+
+func (s *Suite) validate() error {
+	if s.Command == "" {
+		return errors.New("no command")
+	}
+	if len(s.Cases) == 0 {
+		return errors.New("no cases")
+	}
+	names := map[string]bool{}
+	for i, c := range s.Cases {
+		if c.Name == "" {
+			return errors.Errorf("case %d has no name", i)
+		}
+		if strings.ContainsAny(c.Name, `/\`) {
+			return errors.Errorf("case name '%s' contains a path separator", c.Name)
+		}
+		if names[c.Name] {
+			return errors.Errorf("duplicate case '%s'", c.Name)
+		}
+		names[c.Name] = true
+		for j, a := range c.Assertions {
+			if err := a.validate(); err != nil {
+				return errors.Wrapf(err, "assertion %d of case '%s'", j, c.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Assertion) validate() error {
+	switch a.Type {
+	case AssertExact:
+		if a.Expected == nil {
+			return errors.New("exact assertion without expected value")
+		}
+	case AssertRegex:
+		if a.Pattern == "" {
+			return errors.New("regex assertion without pattern")
+		}
+	case AssertJSONPath:
+		if a.Path == "" {
+			return errors.New("json-path assertion without path")
+		}
+		if _, err := parseJSONPath(a.Path); err != nil {
+			return err
+		}
+	case AssertLLMJudge:
+		if a.Rubric == "" {
+			return errors.New("llm-judge assertion without rubric")
+		}
+	default:
+		return errors.Errorf("unknown assertion type '%s'", a.Type)
+	}
+	return nil
+}
+
+// CommandPath returns the path of the agent command file
+func (s *Suite) CommandPath() string {
+	return s.resolve(s.Command)
+}
+
+// CassettePath returns the cassette of the agent calls of a case
+func (s *Suite) CassettePath(c *Case) string {
+	return filepath.Join(s.resolve(s.Cassettes), c.Name+".yaml")
+}
+
+// JudgeCassettePath returns the cassette of the llm-judge calls of a case
+func (s *Suite) JudgeCassettePath(c *Case) string {
+	return filepath.Join(s.resolve(s.Cassettes), c.Name+"-judge.yaml")
+}
+
+func (s *Suite) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.dir, path)
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	gepevents "github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/chat"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// deltaStream listens to the partial completions published by a chat step and forwards
+// them, throttled, to the event bus and to a DeltaHandler.
+type deltaStream struct {
+	pubSub   *gochannel.GoChannel
+	throttle *deltaThrottle
+	done     chan struct{}
+}
+
+// startDeltaStream subscribes to the events of chatStep. It returns nil if there is
+// nobody to forward deltas to.
+func (g *GeppettoLLM) startDeltaStream(ctx context.Context, chatStep chat.Step, callID string, onDelta DeltaHandler) (*deltaStream, error) {
+	if g.eventBus == nil && onDelta == nil {
+		return nil, nil
+	}
+
+	// Blocking until the subscriber acks keeps the chunks in order
+	pubSub := gochannel.NewGoChannel(gochannel.Config{
+		BlockPublishUntilSubscriberAck: true,
+	}, watermill.NopLogger{})
+	topic := "llm-deltas-" + callID
+
+	msgs, err := pubSub.Subscribe(ctx, topic)
+	if err != nil {
+		_ = pubSub.Close()
+		return nil, errors.Wrap(err, "failed to subscribe to chat step events")
+	}
+	if err := chatStep.AddPublishedTopic(pubSub, topic); err != nil {
+		_ = pubSub.Close()
+		return nil, errors.Wrapf(err, "failed to add published topic %s", topic)
+	}
+
+	s := &deltaStream{
+		pubSub: pubSub,
+		done:   make(chan struct{}),
+	}
+	s.throttle = newDeltaThrottle(callID, g.deltaInterval, func(delta Delta) {
+		g.emitDelta(ctx, delta)
+		if onDelta != nil {
+			onDelta(ctx, delta)
+		}
+	})
+
+	go func() {
+		defer close(s.done)
+		for msg := range msgs {
+			msg.Ack()
+			e, err := gepevents.NewEventFromJson(msg.Payload)
+			if err != nil {
+				log.Debug().Err(err).Str("callID", callID).Msg("Skipping undecodable chat step event")
+				continue
+			}
+			if partial, ok := e.(*gepevents.EventPartialCompletion); ok {
+				s.throttle.Add(partial.Delta)
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+// Close stops listening and emits the output not reported yet. final is the complete
+// text of the response.
+func (s *deltaStream) Close(final string) {
+	if s == nil {
+		return
+	}
+	if err := s.pubSub.Close(); err != nil {
+		log.Warn().Err(err).Msg("Failed to close delta stream")
+	}
+	<-s.done
+	s.throttle.Finish(final)
+}
+
+// emitDelta emits delta as a llm_call_delta event if an EventBus is configured
+func (g *GeppettoLLM) emitDelta(ctx context.Context, delta Delta) {
+	if g.eventBus == nil {
+		return
+	}
+	ec := execctx.FromContext(ctx)
+	payload := &events.LlmCallDeltaPayload{
+		CallId:            delta.CallID,
+		AgentClass:        agentClass(ec),
+		Model:             g.modelName(),
+		Delta:             delta.Text,
+		Sequence:          int32(delta.Sequence),
+		AccumulatedLength: int32(delta.AccumulatedLength),
+		Step:              ec.StepPtr(),
+		NodeId:            ec.NodeIDPtr(),
+	}
+	if err := g.eventBus.EmitLlmCallDelta(ctx, payload, nil); err != nil {
+		log.Warn().Err(err).Msg("Failed to emit LlmCallDelta event")
+	}
+}
+
+var _ StreamingLLM = (*GeppettoLLM)(nil)
@@ -2,6 +2,8 @@ package llm
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/go-go-golems/geppetto/pkg/embeddings"
@@ -9,6 +11,7 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/chat"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/pricing"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
@@ -23,9 +26,21 @@ type GeppettoLLM struct {
 	publisher message.Publisher
 	// topicID is the topic to publish events to if configured
 	topicID string
-	// Optional event bus for structured events
+	// Optional event bus for structured events. Events are associated with the run,
+	// step and node of the execution context of each call.
 	eventBus *eventbus.EventBus
-	runID    *string // To associate events with a specific run
+	// Optional budget charged with the cost of every call
+	budget *pricing.Budget
+	// Minimum time between two streamed deltas of a call
+	deltaInterval time.Duration
+	// Optional gate waited on before every call, used to pause runs
+	pauseGate PauseGate
+}
+
+// PauseGate blocks calls while the run they belong to is paused
+type PauseGate interface {
+	// Wait returns once the run may continue, or with an error if ctx is done first
+	Wait(ctx context.Context) error
 }
 
 // GeppettoLLMOption defines a function type for configuring GeppettoLLM.
@@ -46,6 +61,59 @@ func WithPublisherAndTopic(publisher message.Publisher, topicID string) Geppetto
 	}
 }
 
+// WithBudget charges the cost of every call to budget. Calls fail once the budget is exceeded.
+func WithBudget(budget *pricing.Budget) GeppettoLLMOption {
+	return func(llm *GeppettoLLM) error {
+		llm.budget = budget
+		return nil
+	}
+}
+
+// WithDeltaInterval sets the minimum time between two deltas streamed for a call.
+// Chunks received in between are merged. An interval of 0 forwards every chunk.
+func WithDeltaInterval(interval time.Duration) GeppettoLLMOption {
+	return func(llm *GeppettoLLM) error {
+		if interval < 0 {
+			return errors.New("delta interval cannot be negative")
+		}
+		llm.deltaInterval = interval
+		return nil
+	}
+}
+
+// WithPauseGate makes every call wait on gate before it starts
+func WithPauseGate(gate PauseGate) GeppettoLLMOption {
+	return func(llm *GeppettoLLM) error {
+		llm.pauseGate = gate
+		return nil
+	}
+}
+
+// ConfigurableLLM is implemented by LLMs that can create a copy of themselves with other
+// step settings, for example to use another model for a part of an agent.
+type ConfigurableLLM interface {
+	LLM
+	// WithStepSettings returns a copy of the LLM using stepSettings for chat calls
+	WithStepSettings(stepSettings *settings.StepSettings) (LLM, error)
+}
+
+// WithStepSettings returns a copy of g using stepSettings for chat calls. The copy keeps
+// the embedding provider, event bus, budget and pause gate of g.
+func (g *GeppettoLLM) WithStepSettings(stepSettings *settings.StepSettings) (LLM, error) {
+	if stepSettings == nil {
+		return nil, errors.New("settings cannot be nil")
+	}
+	ret := *g
+	ret.stepSettings = stepSettings.Clone()
+	ret.stepSettings.Chat.Stream = true
+	return &ret, nil
+}
+
+// modelName returns the "apiType/engine" string identifying the model in events and price tables
+func (g *GeppettoLLM) modelName() string {
+	return fmt.Sprintf("%s/%v", *g.stepSettings.Chat.ApiType, *g.stepSettings.Chat.Engine)
+}
+
 // createChatStep creates a new chat step, potentially configured to publish events.
 func createChatStep(
 	stepSettings *settings.StepSettings,
@@ -101,3 +169,4 @@ func (g *GeppettoLLM) GenerateEmbedding(ctx context.Context, text string) ([]flo
 }
 
 var _ LLM = (*GeppettoLLM)(nil)
+var _ ConfigurableLLM = (*GeppettoLLM)(nil)
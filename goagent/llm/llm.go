@@ -3,12 +3,15 @@ package llm
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-go-golems/geppetto/pkg/conversation"
 	"github.com/go-go-golems/geppetto/pkg/embeddings"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/chat"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
 	events "github.com/go-go-golems/go-go-agent/proto"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -28,14 +31,6 @@ func WithEventBus(eb *eventbus.EventBus) GeppettoLLMOption {
 	}
 }
 
-// WithRunID sets the Run ID for associating events.
-func WithRunID(runID string) GeppettoLLMOption {
-	return func(g *GeppettoLLM) error {
-		g.runID = &runID
-		return nil
-	}
-}
-
 // NewGeppettoLLM creates a new GeppettoLLM instance.
 func NewGeppettoLLM(settings *settings.StepSettings, options ...GeppettoLLMOption) (*GeppettoLLM, error) {
 	if settings == nil {
@@ -55,6 +50,7 @@ func NewGeppettoLLM(settings *settings.StepSettings, options ...GeppettoLLMOptio
 	s := &GeppettoLLM{
 		stepSettings:      stepSettings,
 		embeddingProvider: embeddingProvider,
+		deltaInterval:     DefaultDeltaInterval,
 	}
 
 	for _, option := range options {
@@ -67,6 +63,17 @@ func NewGeppettoLLM(settings *settings.StepSettings, options ...GeppettoLLMOptio
 	return s, nil
 }
 
+// defaultAgentClass is the agent class of the events of calls made outside of an agent
+const defaultAgentClass = "GeppettoLLM"
+
+// agentClass returns the agent class of the events of a call made in ec
+func agentClass(ec execctx.ExecutionContext) string {
+	if ec.AgentClass != "" {
+		return ec.AgentClass
+	}
+	return defaultAgentClass
+}
+
 // convertToEventsMessages converts []*Message to []*events.LlmMessage for event logging.
 func convertToEventsMessages(msgs []*conversation.Message) []*events.LlmMessage {
 	res := make([]*events.LlmMessage, len(msgs))
@@ -74,11 +81,20 @@ func convertToEventsMessages(msgs []*conversation.Message) []*events.LlmMessage
 		// TODO(manuel): Handle multi-content messages for logging?
 		// For now, just concatenate text content.
 		content := ""
-		if m.Content.ContentType() == conversation.ContentTypeChatMessage {
-			content += m.Content.String() + "\\n"
+		role := ""
+		switch c := m.Content.(type) {
+		case *conversation.ChatMessageContent:
+			content += c.String() + "\\n"
+			role = string(c.Role)
+		case *conversation.ToolUseContent:
+			content = fmt.Sprintf("%s(%s)", c.Name, string(c.Input))
+			role = string(conversation.RoleAssistant)
+		case *conversation.ToolResultContent:
+			content = c.Result
+			role = string(conversation.RoleTool)
 		}
 		res[i] = &events.LlmMessage{
-			Role:    string(m.Content.(*conversation.ChatMessageContent).Role),
+			Role:    role,
 			Content: content,
 		}
 	}
@@ -88,7 +104,38 @@ func convertToEventsMessages(msgs []*conversation.Message) []*events.LlmMessage
 // Chat sends messages to the LLM and returns the response.
 // It also emits LLM call events if an EventBus is configured.
 func (g *GeppettoLLM) Generate(ctx context.Context, messages []*conversation.Message) (*conversation.Message, error) {
+	return g.GenerateStream(ctx, messages, nil)
+}
+
+// GenerateStream sends messages to the LLM, calls onDelta with the partial response as
+// it is streamed and returns the complete response. Deltas are also emitted as
+// llm_call_delta events if an EventBus is configured.
+func (g *GeppettoLLM) GenerateStream(ctx context.Context, messages []*conversation.Message, onDelta DeltaHandler) (*conversation.Message, error) {
+	chatStep, err := createChatStep(g.stepSettings, g.publisher, g.topicID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create chat step")
+	}
+
+	responses, err := g.runChatStep(ctx, chatStep, messages, onDelta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the final result message
+	return responses[len(responses)-1], nil
+}
+
+// runChatStep runs a chat step over messages, emitting LlmCallStarted/LlmCallDelta/LlmCallCompleted
+// events, and returns all messages produced by the step. The returned slice is never empty on success.
+func (g *GeppettoLLM) runChatStep(ctx context.Context, chatStep chat.Step, messages []*conversation.Message, onDelta DeltaHandler) ([]*conversation.Message, error) {
+	if g.pauseGate != nil {
+		if err := g.pauseGate.Wait(ctx); err != nil {
+			return nil, errors.Wrap(err, "run stopped while paused")
+		}
+	}
+
 	callID := uuid.New().String() // Unique ID for this specific call
+	ec := execctx.FromContext(ctx)
 
 	// --- Emit LlmCallStarted event ---
 	if g.eventBus != nil {
@@ -105,53 +152,77 @@ func (g *GeppettoLLM) Generate(ctx context.Context, messages []*conversation.Mes
 		}
 
 		startPayload := &events.LlmCallStartedPayload{
-			AgentClass:    "GeppettoLLM", // Or derive from context if possible later
-			Model:         fmt.Sprintf("%s/%v", *g.stepSettings.Chat.ApiType, *g.stepSettings.Chat.Engine),
+			AgentClass:    agentClass(ec),
+			Model:         g.modelName(),
 			Prompt:        convertToEventsMessages(messages), // Use converted []*events.LlmMessage
 			PromptPreview: promptPreview,
+			Step:          ec.StepPtr(),
+			NodeId:        ec.NodeIDPtr(),
+			ActionName:    ec.ActionNamePtr(),
 			CallId:        callID,
-			// Step, NodeId, ActionName might be added from context later
 		}
-		err := g.eventBus.EmitLlmCallStarted(ctx, startPayload, g.runID)
+		err := g.eventBus.EmitLlmCallStarted(ctx, startPayload, nil)
 		if err != nil {
 			// Log error but continue execution
 			log.Warn().Err(err).Msg("Failed to emit LlmCallStarted event")
 		}
 	}
 
-	chatStep, err := createChatStep(g.stepSettings, g.publisher, g.topicID)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create chat step")
+	stream, streamErr := g.startDeltaStream(ctx, chatStep, callID, onDelta)
+	if streamErr != nil {
+		// Streaming is best effort, the call itself still works without it
+		log.Warn().Err(streamErr).Str("callID", callID).Msg("Failed to stream LLM call deltas")
 	}
 
 	// --- Start LLM Call ---
 	startTime := time.Now()
 	result, err := chatStep.Start(ctx, messages)
+
+	// Collect the result messages
+	var responses []*conversation.Message
+	if err == nil && result != nil {
+		for _, r := range result.Return() {
+			responseMsg, valueErr := r.Value()
+			if valueErr != nil {
+				err = errors.Wrap(valueErr, "failed to get result value")
+				break
+			}
+			if responseMsg != nil {
+				responses = append(responses, responseMsg)
+			}
+		}
+	}
 	duration := time.Since(startTime)
 
-	results := result.Return()
+	// Emit the rest of the streamed output before the completed event
+	stream.Close(responseText(responses))
+
+	var tokenUsage *events.TokenUsage
+	var budgetErr error
+	if err == nil && len(responses) > 0 {
+		tokenUsage = tokenUsageFromResponses(messages, responses)
+		if g.budget != nil {
+			_, budgetErr = g.budget.Charge(g.modelName(), int(tokenUsage.PromptTokens), int(tokenUsage.CompletionTokens))
+		}
+	}
 
 	// --- Emit LlmCallCompleted event ---
 	if g.eventBus != nil {
 		var responseStr string
 		var resultSummary string
 		var errorStr *string
-		var tokenUsage *events.TokenUsage
 
 		if err != nil {
 			s := "Error: " + err.Error()
 			errorStr = &s
 			resultSummary = s
-		} else if len(results) > 0 {
-			responseMsg, err := results[len(results)-1].Value()
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to get result value")
+		} else if len(responses) > 0 {
+			parts := make([]string, 0, len(responses))
+			for _, responseMsg := range responses {
+				parts = append(parts, responseMsg.Content.String())
 			}
-			responseStr = responseMsg.Content.String()
+			responseStr = strings.Join(parts, "\n")
 			resultSummary = summarizeResult(responseStr)
-			// TODO(manuel): Extract token usage if available from Geppetto result metadata
-			// meta := result.Metadata()
-			// if meta != nil { ... }
 		} else {
 			s := "Received nil result and nil error"
 			errorStr = &s
@@ -159,17 +230,19 @@ func (g *GeppettoLLM) Generate(ctx context.Context, messages []*conversation.Mes
 		}
 
 		completePayload := &events.LlmCallCompletedPayload{
-			AgentClass:      "GeppettoLLM",
-			Model:           fmt.Sprintf("%s/%v", *g.stepSettings.Chat.ApiType, *g.stepSettings.Chat.Engine),
+			AgentClass:      agentClass(ec),
+			Model:           g.modelName(),
 			DurationSeconds: duration.Seconds(),
 			Response:        responseStr,
 			ResultSummary:   resultSummary,
 			Error:           errorStr,
+			Step:            ec.StepPtr(),
+			NodeId:          ec.NodeIDPtr(),
 			TokenUsage:      tokenUsage,
+			ActionName:      ec.ActionNamePtr(),
 			CallId:          callID,
-			// Step, NodeId, ActionName might be added from context later
 		}
-		errEmit := g.eventBus.EmitLlmCallCompleted(ctx, completePayload, g.runID)
+		errEmit := g.eventBus.EmitLlmCallCompleted(ctx, completePayload, nil)
 		if errEmit != nil {
 			// Log error but don't overwrite original execution error
 			log.Warn().Err(errEmit).Msg("Failed to emit LlmCallCompleted event")
@@ -179,6 +252,9 @@ func (g *GeppettoLLM) Generate(ctx context.Context, messages []*conversation.Mes
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to generate")
 	}
+	if budgetErr != nil {
+		return nil, budgetErr
+	}
 
 	// --- Handle LLM Call Result ---
 	// Need to handle the case where the stream finishes (err == helpers.ErrCannotReadStreamEnd)
@@ -188,17 +264,51 @@ func (g *GeppettoLLM) Generate(ctx context.Context, messages []*conversation.Mes
 		return nil, errors.New("geppetto step returned nil result and nil error unexpectedly")
 	}
 
-	if len(results) == 0 {
+	if len(responses) == 0 {
 		return nil, errors.New("no results returned")
 	}
 
-	responseMsg, err := results[len(results)-1].Value()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get final result value")
+	return responses, nil
+}
+
+// tokenUsageFromResponses returns the token usage reported by the provider in the
+// metadata of the response messages. Counts the provider did not report are estimated
+// from the prompt and response text.
+func tokenUsageFromResponses(prompt []*conversation.Message, responses []*conversation.Message) *events.TokenUsage {
+	usage := &events.TokenUsage{}
+	for _, response := range responses {
+		// All messages of a single call carry the usage of the whole call
+		if response.LLMMessageMetadata == nil || response.LLMMessageMetadata.Usage == nil {
+			continue
+		}
+		reported := response.LLMMessageMetadata.Usage
+		usage.PromptTokens = max(usage.PromptTokens, int32(reported.InputTokens))
+		usage.CompletionTokens = max(usage.CompletionTokens, int32(reported.OutputTokens))
 	}
 
-	// Convert the final result message
-	return responseMsg, nil
+	if usage.PromptTokens == 0 {
+		usage.PromptTokens = int32(EstimateMessagesTokens(prompt))
+	}
+	if usage.CompletionTokens == 0 {
+		completionTokens := 0
+		for _, response := range responses {
+			completionTokens += EstimateTokens(MessageText(response))
+		}
+		usage.CompletionTokens = int32(completionTokens)
+	}
+
+	return usage
+}
+
+// responseText returns the text of the chat messages among responses, leaving out tool calls
+func responseText(responses []*conversation.Message) string {
+	var sb strings.Builder
+	for _, response := range responses {
+		if c, ok := response.Content.(*conversation.ChatMessageContent); ok {
+			sb.WriteString(c.Text)
+		}
+	}
+	return sb.String()
 }
 
 // summarizeResult creates a short summary of the result string.
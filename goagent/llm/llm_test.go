@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/go-go-golems/geppetto/pkg/steps"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	ai_types "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/go-go-golems/go-go-agent/pkg/pricing"
+)
+
+func TestTokenUsageFromResponses(t *testing.T) {
+	prompt := []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleSystem, "You are a helpful agent."), // 6 + 4 tokens
+		conversation.NewChatMessage(conversation.RoleUser, "Hi"),                         // 1 + 4 tokens
+	}
+	withUsage := func(text string, input, output int) *conversation.Message {
+		return conversation.NewChatMessage(conversation.RoleAssistant, text,
+			conversation.WithLLMMessageMetadata(&conversation.LLMMessageMetadata{
+				Usage: &conversation.Usage{InputTokens: input, OutputTokens: output},
+			}))
+	}
+
+	testCases := []struct {
+		name       string
+		responses  []*conversation.Message
+		prompt     int32
+		completion int32
+	}{
+		{
+			name:       "reported by the provider",
+			responses:  []*conversation.Message{withUsage("Hello there", 120, 30)},
+			prompt:     120,
+			completion: 30,
+		},
+		{
+			name:       "not reported",
+			responses:  []*conversation.Message{conversation.NewChatMessage(conversation.RoleAssistant, "Hello there")},
+			prompt:     15,
+			completion: 3,
+		},
+		{
+			name:       "only prompt tokens reported",
+			responses:  []*conversation.Message{withUsage("Hello there", 120, 0)},
+			prompt:     120,
+			completion: 3,
+		},
+		{
+			// All messages of a call carry the usage of the whole call, it is not summed
+			name: "several messages of one call",
+			responses: []*conversation.Message{
+				withUsage("Reading", 200, 40),
+				conversation.NewMessage(&conversation.ToolUseContent{ToolID: "1", Name: "read_file", Input: []byte(`{}`)},
+					conversation.WithLLMMessageMetadata(&conversation.LLMMessageMetadata{
+						Usage: &conversation.Usage{InputTokens: 200, OutputTokens: 40},
+					})),
+			},
+			prompt:     200,
+			completion: 40,
+		},
+		{
+			name: "estimated tool calls",
+			responses: []*conversation.Message{
+				conversation.NewMessage(&conversation.ToolUseContent{ToolID: "1", Name: "read_file", Input: []byte(`{"path":"a.go"}`)}),
+			},
+			prompt:     15,
+			completion: 7, // read_file({"path":"a.go"})
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			usage := tokenUsageFromResponses(prompt, tc.responses)
+			if usage.PromptTokens != tc.prompt || usage.CompletionTokens != tc.completion {
+				t.Errorf("usage = %d prompt, %d completion tokens, want %d, %d",
+					usage.PromptTokens, usage.CompletionTokens, tc.prompt, tc.completion)
+			}
+		})
+	}
+}
+
+// fakeChatStep returns its messages without calling a provider
+type fakeChatStep struct {
+	messages []*conversation.Message
+}
+
+func (s *fakeChatStep) Start(ctx context.Context, messages conversation.Conversation) (steps.StepResult[*conversation.Message], error) {
+	return resolveMessages(s.messages), nil
+}
+
+func (s *fakeChatStep) AddPublishedTopic(publisher message.Publisher, topic string) error {
+	return nil
+}
+
+func newTestGeppettoLLM(t *testing.T, engine string, options ...GeppettoLLMOption) *GeppettoLLM {
+	t.Helper()
+	stepSettings, err := settings.NewStepSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiType := ai_types.ApiTypeOpenAI
+	stepSettings.Chat.ApiType = &apiType
+	stepSettings.Chat.Engine = &engine
+
+	g := &GeppettoLLM{stepSettings: stepSettings, deltaInterval: DefaultDeltaInterval}
+	for _, option := range options {
+		if err := option(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func TestBudgetStopsCalls(t *testing.T) {
+	// 1000 prompt and 500 completion tokens of gpt-4o cost $0.0075
+	budget := pricing.NewBudget(pricing.DefaultPriceTable(), 0.02)
+	g := newTestGeppettoLLM(t, "gpt-4o", WithBudget(budget))
+	step := &fakeChatStep{messages: []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleAssistant, "done",
+			conversation.WithLLMMessageMetadata(&conversation.LLMMessageMetadata{
+				Usage: &conversation.Usage{InputTokens: 1000, OutputTokens: 500},
+			})),
+	}}
+	prompt := []*conversation.Message{conversation.NewChatMessage(conversation.RoleUser, "hi")}
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.runChatStep(context.Background(), step, prompt, nil); err != nil {
+			t.Fatalf("call %d failed: %v", i+1, err)
+		}
+	}
+	_, err := g.runChatStep(context.Background(), step, prompt, nil)
+	var exceeded *pricing.BudgetExceededError
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("call over the budget returned %v, want a BudgetExceededError", err)
+	}
+	if got := budget.Spent(); got < 0.0224 || got > 0.0226 {
+		t.Errorf("budget spent $%f, want $0.0225", got)
+	}
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	"github.com/pkg/errors"
+)
+
+// Kinds of message records
+const (
+	MessageKindChat       = "chat"
+	MessageKindToolUse    = "tool_use"
+	MessageKindToolResult = "tool_result"
+)
+
+// MessageRecord is the serializable form of a conversation message, used to save
+// conversations in checkpoints and cassettes. Only the parts sent to the model are
+// kept: the text of chat messages, tool calls and tool results.
+type MessageRecord struct {
+	Kind     string `json:"kind" yaml:"kind"`
+	Role     string `json:"role,omitempty" yaml:"role,omitempty"`
+	Text     string `json:"text,omitempty" yaml:"text,omitempty"`
+	ToolID   string `json:"tool_id,omitempty" yaml:"tool_id,omitempty"`
+	ToolName string `json:"tool_name,omitempty" yaml:"tool_name,omitempty"`
+	Input    string `json:"input,omitempty" yaml:"input,omitempty"`
+	Result   string `json:"result,omitempty" yaml:"result,omitempty"`
+}
+
+// String renders the record like MessageRole and MessageText render the message
+func (r MessageRecord) String() string {
+	switch r.Kind {
+	case MessageKindToolUse:
+		return fmt.Sprintf("%s: %s(%s)", conversation.RoleAssistant, r.ToolName, r.Input)
+	case MessageKindToolResult:
+		return fmt.Sprintf("%s: %s", conversation.RoleTool, r.Result)
+	default:
+		return fmt.Sprintf("%s: %s", r.Role, r.Text)
+	}
+}
+
+// ToMessageRecords converts conversation messages to their serializable form
+func ToMessageRecords(messages []*conversation.Message) ([]MessageRecord, error) {
+	ret := make([]MessageRecord, 0, len(messages))
+	for _, msg := range messages {
+		if msg == nil || msg.Content == nil {
+			continue
+		}
+		switch c := msg.Content.(type) {
+		case *conversation.ChatMessageContent:
+			ret = append(ret, MessageRecord{Kind: MessageKindChat, Role: string(c.Role), Text: c.Text})
+		case *conversation.ToolUseContent:
+			ret = append(ret, MessageRecord{Kind: MessageKindToolUse, ToolID: c.ToolID, ToolName: c.Name, Input: string(c.Input)})
+		case *conversation.ToolResultContent:
+			ret = append(ret, MessageRecord{Kind: MessageKindToolResult, ToolID: c.ToolID, Result: c.Result})
+		default:
+			return nil, errors.Errorf("cannot record message content of type %T", c)
+		}
+	}
+	return ret, nil
+}
+
+// FromMessageRecords converts message records back to conversation messages
+func FromMessageRecords(records []MessageRecord) ([]*conversation.Message, error) {
+	ret := make([]*conversation.Message, 0, len(records))
+	for _, r := range records {
+		switch r.Kind {
+		case MessageKindChat:
+			ret = append(ret, conversation.NewChatMessage(conversation.Role(r.Role), r.Text))
+		case MessageKindToolUse:
+			ret = append(ret, conversation.NewMessage(&conversation.ToolUseContent{
+				ToolID: r.ToolID,
+				Name:   r.ToolName,
+				Input:  json.RawMessage(r.Input),
+			}))
+		case MessageKindToolResult:
+			ret = append(ret, conversation.NewMessage(&conversation.ToolResultContent{
+				ToolID: r.ToolID,
+				Result: r.Result,
+			}))
+		default:
+			return nil, errors.Errorf("unknown message record kind '%s'", r.Kind)
+		}
+	}
+	return ret, nil
+}
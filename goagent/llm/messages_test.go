@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+)
+
+func TestMessageRecordsRoundTrip(t *testing.T) {
+	messages := []*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleSystem, "You are a helpful agent."),
+		conversation.NewChatMessage(conversation.RoleUser, "What is the weather in Paris?"),
+		conversation.NewMessage(&conversation.ToolUseContent{ToolID: "call-1", Name: "weather", Input: json.RawMessage(`{"city":"Paris"}`)}),
+		conversation.NewMessage(&conversation.ToolResultContent{ToolID: "call-1", Result: "sunny"}),
+		conversation.NewChatMessage(conversation.RoleAssistant, "It is sunny."),
+	}
+
+	records, err := ToMessageRecords(append(messages, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []MessageRecord{
+		{Kind: MessageKindChat, Role: "system", Text: "You are a helpful agent."},
+		{Kind: MessageKindChat, Role: "user", Text: "What is the weather in Paris?"},
+		{Kind: MessageKindToolUse, ToolID: "call-1", ToolName: "weather", Input: `{"city":"Paris"}`},
+		{Kind: MessageKindToolResult, ToolID: "call-1", Result: "sunny"},
+		{Kind: MessageKindChat, Role: "assistant", Text: "It is sunny."},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("ToMessageRecords() = %+v, want %+v", records, expected)
+	}
+
+	// Records are saved as JSON in checkpoints
+	b, err := json.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []MessageRecord
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := FromMessageRecords(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != len(messages) {
+		t.Fatalf("FromMessageRecords() returned %d messages, want %d", len(restored), len(messages))
+	}
+	for i, msg := range restored {
+		if !reflect.DeepEqual(msg.Content, messages[i].Content) {
+			t.Errorf("message %d = %+v, want %+v", i, msg.Content, messages[i].Content)
+		}
+	}
+}
+
+func TestMessageRecordsErrors(t *testing.T) {
+	image := conversation.NewMessage(&conversation.ImageContent{ImageURL: "https://example.com/cat.png"})
+	if _, err := ToMessageRecords([]*conversation.Message{image}); err == nil {
+		t.Error("ToMessageRecords() of an image succeeded")
+	}
+	if _, err := FromMessageRecords([]MessageRecord{{Kind: "image"}}); err == nil {
+		t.Error("FromMessageRecords() of an unknown kind succeeded")
+	}
+}
+
+func TestMessageRecordString(t *testing.T) {
+	testCases := []struct {
+		record   MessageRecord
+		expected string
+	}{
+		{MessageRecord{Kind: MessageKindChat, Role: "user", Text: "Hi"}, "user: Hi"},
+		{MessageRecord{Kind: MessageKindToolUse, ToolName: "weather", Input: `{"city":"Paris"}`}, `assistant: weather({"city":"Paris"})`},
+		{MessageRecord{Kind: MessageKindToolResult, Result: "sunny"}, "tool: sunny"},
+	}
+	for _, tc := range testCases {
+		if s := tc.record.String(); s != tc.expected {
+			t.Errorf("String() = %q, want %q", s, tc.expected)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"strings"
 	"sync"
 
 	"github.com/go-go-golems/geppetto/pkg/conversation"
@@ -15,7 +16,7 @@ type MockLLM struct {
 	embeddings      map[string][]float32
 }
 
-var _ LLM = &MockLLM{}
+var _ StreamingLLM = &MockLLM{}
 
 // NewMockLLM creates a new MockLLM
 func NewMockLLM() *MockLLM {
@@ -82,6 +83,35 @@ func (m *MockLLM) Generate(ctx context.Context, messages []*conversation.Message
 	return conversation.NewChatMessage(conversation.RoleAssistant, "I don't know how to respond to that."), nil
 }
 
+// GenerateStream streams the chunks added with AddStreamResponse as deltas. Inputs
+// without stream response behave like Generate, as a single delta.
+func (m *MockLLM) GenerateStream(ctx context.Context, messages []*conversation.Message, onDelta DeltaHandler) (*conversation.Message, error) {
+	m.mu.RLock()
+	chunks, ok := m.streamResponses[messagesToKey(messages)]
+	m.mu.RUnlock()
+
+	if !ok {
+		response, err := m.Generate(ctx, messages)
+		if err != nil {
+			return nil, err
+		}
+		if onDelta != nil {
+			text := MessageText(response)
+			onDelta(ctx, Delta{Text: text, AccumulatedLength: len(text)})
+		}
+		return response, nil
+	}
+
+	var sb strings.Builder
+	for i, chunk := range chunks {
+		sb.WriteString(chunk)
+		if onDelta != nil {
+			onDelta(ctx, Delta{Text: chunk, Sequence: i, AccumulatedLength: sb.Len()})
+		}
+	}
+	return conversation.NewChatMessage(conversation.RoleAssistant, sb.String()), nil
+}
+
 // GenerateEmbedding generates an embedding for the given text
 func (m *MockLLM) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	m.mu.RLock()
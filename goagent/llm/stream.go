@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+)
+
+// DefaultDeltaInterval is the minimum time between two deltas reported for a call.
+// Chunks received in between are merged into the next delta.
+const DefaultDeltaInterval = 100 * time.Millisecond
+
+// Delta is a piece of the partial output of a streaming LLM call
+type Delta struct {
+	// CallID identifies the call, matching the call_id of its llm_call_* events
+	CallID string
+	// Text is the output received since the previous delta
+	Text string
+	// Sequence numbers the deltas of a call, starting at 0
+	Sequence int
+	// AccumulatedLength is the length of the output received so far, including Text
+	AccumulatedLength int
+}
+
+// DeltaHandler receives the deltas of a streaming call
+type DeltaHandler func(ctx context.Context, delta Delta)
+
+// StreamingLLM is an LLM that reports its output while it is being generated
+type StreamingLLM interface {
+	LLM
+	// GenerateStream works like Generate and calls onDelta with the partial output
+	// as it arrives. The concatenated deltas equal the text of the returned message.
+	GenerateStream(ctx context.Context, messages []*conversation.Message, onDelta DeltaHandler) (*conversation.Message, error)
+}
+
+// GenerateStream calls model.GenerateStream if model supports streaming. Otherwise it
+// calls Generate and reports the whole response as a single delta.
+func GenerateStream(ctx context.Context, model LLM, messages []*conversation.Message, onDelta DeltaHandler) (*conversation.Message, error) {
+	if s, ok := model.(StreamingLLM); ok {
+		return s.GenerateStream(ctx, messages, onDelta)
+	}
+
+	response, err := model.Generate(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	if onDelta != nil {
+		if text := MessageText(response); text != "" {
+			onDelta(ctx, Delta{Text: text, AccumulatedLength: len(text)})
+		}
+	}
+	return response, nil
+}
+
+// deltaThrottle merges the chunks of a streaming call into deltas that are at least
+// interval apart, keeping the number of delta events per call bounded.
+type deltaThrottle struct {
+	callID      string
+	interval    time.Duration
+	emit        func(Delta)
+	pending     strings.Builder
+	accumulated strings.Builder
+	sequence    int
+	lastEmit    time.Time
+}
+
+func newDeltaThrottle(callID string, interval time.Duration, emit func(Delta)) *deltaThrottle {
+	return &deltaThrottle{
+		callID:   callID,
+		interval: interval,
+		emit:     emit,
+	}
+}
+
+// Add records a chunk and emits the pending output if interval has passed since the
+// last delta.
+func (t *deltaThrottle) Add(chunk string) {
+	if chunk == "" {
+		return
+	}
+	t.pending.WriteString(chunk)
+	t.accumulated.WriteString(chunk)
+	if time.Since(t.lastEmit) >= t.interval {
+		t.flush()
+	}
+}
+
+// Finish emits the pending output. If final extends the output received so far, the
+// missing suffix is emitted too, so that chunks dropped by the transport are not lost.
+func (t *deltaThrottle) Finish(final string) {
+	if received := t.accumulated.String(); len(final) > len(received) && strings.HasPrefix(final, received) {
+		missing := final[len(received):]
+		t.pending.WriteString(missing)
+		t.accumulated.WriteString(missing)
+	}
+	t.flush()
+}
+
+func (t *deltaThrottle) flush() {
+	if t.pending.Len() == 0 {
+		return
+	}
+	t.emit(Delta{
+		CallID:            t.callID,
+		Text:              t.pending.String(),
+		Sequence:          t.sequence,
+		AccumulatedLength: t.accumulated.Len(),
+	})
+	t.pending.Reset()
+	t.sequence++
+	t.lastEmit = time.Now()
+}
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	gepevents "github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/steps"
+)
+
+func TestDeltaThrottle(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval time.Duration
+		chunks   []string
+		final    string
+		expected []Delta
+	}{
+		{
+			name:     "every chunk without interval",
+			interval: 0,
+			chunks:   []string{"Hel", "lo", "", " world"},
+			final:    "Hello world",
+			expected: []Delta{
+				{CallID: "call", Text: "Hel", Sequence: 0, AccumulatedLength: 3},
+				{CallID: "call", Text: "lo", Sequence: 1, AccumulatedLength: 5},
+				{CallID: "call", Text: " world", Sequence: 2, AccumulatedLength: 11},
+			},
+		},
+		{
+			// The first chunk is emitted right away, the next ones are merged until Finish
+			name:     "chunks merged within the interval",
+			interval: time.Hour,
+			chunks:   []string{"Hel", "lo", " world"},
+			final:    "Hello world",
+			expected: []Delta{
+				{CallID: "call", Text: "Hel", Sequence: 0, AccumulatedLength: 3},
+				{CallID: "call", Text: "lo world", Sequence: 1, AccumulatedLength: 11},
+			},
+		},
+		{
+			name:     "missing suffix emitted on finish",
+			interval: 0,
+			chunks:   []string{"Hello"},
+			final:    "Hello world",
+			expected: []Delta{
+				{CallID: "call", Text: "Hello", Sequence: 0, AccumulatedLength: 5},
+				{CallID: "call", Text: " world", Sequence: 1, AccumulatedLength: 11},
+			},
+		},
+		{
+			name:     "final not extending the chunks",
+			interval: 0,
+			chunks:   []string{"Hello"},
+			final:    "Goodbye",
+			expected: []Delta{
+				{CallID: "call", Text: "Hello", Sequence: 0, AccumulatedLength: 5},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var deltas []Delta
+			throttle := newDeltaThrottle("call", tc.interval, func(delta Delta) {
+				deltas = append(deltas, delta)
+			})
+			for _, chunk := range tc.chunks {
+				throttle.Add(chunk)
+			}
+			throttle.Finish(tc.final)
+			if !reflect.DeepEqual(deltas, tc.expected) {
+				t.Errorf("deltas = %+v, want %+v", deltas, tc.expected)
+			}
+		})
+	}
+}
+
+// streamingChatStep publishes its chunks as partial completion events before
+// returning their concatenation
+type streamingChatStep struct {
+	chunks    []string
+	publisher message.Publisher
+	topic     string
+}
+
+func (s *streamingChatStep) Start(ctx context.Context, messages conversation.Conversation) (steps.StepResult[*conversation.Message], error) {
+	completion := ""
+	for _, chunk := range s.chunks {
+		completion += chunk
+		payload, err := json.Marshal(gepevents.NewPartialCompletionEvent(gepevents.EventMetadata{}, nil, chunk, completion))
+		if err != nil {
+			return nil, err
+		}
+		if err := s.publisher.Publish(s.topic, message.NewMessage(watermill.NewUUID(), payload)); err != nil {
+			return nil, err
+		}
+	}
+	return resolveMessages([]*conversation.Message{
+		conversation.NewChatMessage(conversation.RoleAssistant, completion),
+	}), nil
+}
+
+func (s *streamingChatStep) AddPublishedTopic(publisher message.Publisher, topic string) error {
+	s.publisher = publisher
+	s.topic = topic
+	return nil
+}
+
+func TestGenerateStreamDeltas(t *testing.T) {
+	g := newTestGeppettoLLM(t, "gpt-4o", WithDeltaInterval(time.Hour))
+	step := &streamingChatStep{chunks: []string{"The ", "answer ", "is ", "42"}}
+
+	var deltas []Delta
+	responses, err := g.runChatStep(context.Background(), step,
+		[]*conversation.Message{conversation.NewChatMessage(conversation.RoleUser, "question")},
+		func(ctx context.Context, delta Delta) {
+			deltas = append(deltas, delta)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One delta for the first chunk, one for the rest merged within the interval
+	var texts []string
+	for _, delta := range deltas {
+		texts = append(texts, delta.Text)
+	}
+	if expected := []string{"The ", "answer is 42"}; !reflect.DeepEqual(texts, expected) {
+		t.Errorf("deltas = %q, want %q", texts, expected)
+	}
+	if got := strings.Join(texts, ""); got != MessageText(responses[0]) {
+		t.Errorf("concatenated deltas %q differ from the response %q", got, MessageText(responses[0]))
+	}
+	for i, delta := range deltas {
+		if delta.Sequence != i || delta.CallID == "" || delta.CallID != deltas[0].CallID {
+			t.Errorf("delta %d = %+v, want sequence %d of a single call", i, delta, i)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"fmt"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+)
+
+// charsPerToken is the average number of characters per token for English text with
+// current BPE tokenizers. It is only used to estimate counts when the provider does
+// not report them.
+const charsPerToken = 4
+
+// messageOverheadTokens approximates the tokens providers add per message for role
+// and formatting markers.
+const messageOverheadTokens = 4
+
+// EstimateTokens returns an approximate token count for text
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateMessageTokens returns an approximate token count for a message
+func EstimateMessageTokens(msg *conversation.Message) int {
+	return EstimateTokens(MessageText(msg)) + messageOverheadTokens
+}
+
+// EstimateMessagesTokens returns an approximate token count for a list of messages
+func EstimateMessagesTokens(msgs []*conversation.Message) int {
+	total := 0
+	for _, msg := range msgs {
+		total += EstimateMessageTokens(msg)
+	}
+	return total
+}
+
+// MessageText returns the text of a message as sent to the model. Tool calls are
+// rendered as name(input).
+func MessageText(msg *conversation.Message) string {
+	if msg == nil || msg.Content == nil {
+		return ""
+	}
+	switch c := msg.Content.(type) {
+	case *conversation.ChatMessageContent:
+		return c.Text
+	case *conversation.ToolUseContent:
+		return fmt.Sprintf("%s(%s)", c.Name, string(c.Input))
+	case *conversation.ToolResultContent:
+		return c.Result
+	default:
+		return msg.Content.String()
+	}
+}
+
+// MessageRole returns the role of a message. Tool calls are assistant messages and
+// tool results are tool messages.
+func MessageRole(msg *conversation.Message) conversation.Role {
+	if msg == nil || msg.Content == nil {
+		return ""
+	}
+	switch c := msg.Content.(type) {
+	case *conversation.ChatMessageContent:
+		return c.Role
+	case *conversation.ToolUseContent:
+		return conversation.RoleAssistant
+	case *conversation.ToolResultContent:
+		return conversation.RoleTool
+	default:
+		return ""
+	}
+}
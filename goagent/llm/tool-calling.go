@@ -0,0 +1,258 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+	gepevents "github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/geppetto/pkg/helpers"
+	"github.com/go-go-golems/geppetto/pkg/steps"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/chat"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/claude"
+	claudeapi "github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/openai"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	ai_types "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	go_openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrToolCallingNotSupported is returned by GenerateWithTools when the configured
+// provider has no native tool calling support. Callers should fall back to text-based
+// tool invocation.
+var ErrToolCallingNotSupported = errors.New("native tool calling is not supported by this provider")
+
+// ToolCallingLLM is implemented by LLMs that can advertise tools through the provider's
+// native tool calling schema.
+type ToolCallingLLM interface {
+	LLM
+	// GenerateWithTools returns all messages produced for the turn: assistant text as
+	// conversation.ChatMessageContent and one conversation.ToolUseContent per tool call.
+	GenerateWithTools(ctx context.Context, messages []*conversation.Message, agentTools []tools.Tool) ([]*conversation.Message, error)
+}
+
+// GenerateWithTools sends messages to the LLM while advertising agentTools using the
+// provider's native schema (OpenAI functions or Claude tools).
+func (g *GeppettoLLM) GenerateWithTools(
+	ctx context.Context,
+	messages []*conversation.Message,
+	agentTools []tools.Tool,
+) ([]*conversation.Message, error) {
+	chatStep, err := createToolChatStep(g.stepSettings, agentTools, g.publisher, g.topicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.runChatStep(ctx, chatStep, messages, nil)
+}
+
+// createToolChatStep creates a provider specific chat step with the given tools attached.
+// The step returns the text of the response followed by one ToolUseContent message per
+// tool call.
+func createToolChatStep(
+	stepSettings *settings.StepSettings,
+	agentTools []tools.Tool,
+	publisher message.Publisher,
+	topicID string,
+) (chat.Step, error) {
+	if stepSettings.Chat.ApiType == nil {
+		return nil, errors.New("no api type configured for chat step")
+	}
+
+	var step chat.Step
+	switch *stepSettings.Chat.ApiType {
+	case ai_types.ApiTypeClaude:
+		claudeTools := make([]claudeapi.Tool, 0, len(agentTools))
+		for _, tool := range agentTools {
+			claudeTools = append(claudeTools, tools.ToolToClaudeTool(tool))
+		}
+		// The claude step only reports tool calls as events of streamed responses
+		streamSettings := stepSettings.Clone()
+		streamSettings.Chat.Stream = true
+		claudeStep, err := claude.NewChatStep(streamSettings, claudeTools)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create claude chat step")
+		}
+		step = &claudeToolStep{step: claudeStep}
+
+	case ai_types.ApiTypeOpenAI:
+		openaiTools := make([]go_openai.Tool, 0, len(agentTools))
+		for _, tool := range agentTools {
+			openaiTools = append(openaiTools, tools.ToolToOpenAITool(tool))
+		}
+		openaiStep, err := openai.NewChatWithToolsStep(stepSettings, openaiTools)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create openai chat step")
+		}
+		step = &openAIToolStep{step: openaiStep}
+
+	default:
+		return nil, ErrToolCallingNotSupported
+	}
+
+	if publisher != nil && topicID != "" {
+		err := step.AddPublishedTopic(publisher, topicID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to add published topic %s", topicID)
+		}
+	}
+
+	return step, nil
+}
+
+// openAIToolStep adapts the OpenAI tool calling step, whose result holds the tool calls
+// of the completion, to chat.Step
+type openAIToolStep struct {
+	step *openai.ChatWithToolsStep
+}
+
+var _ chat.Step = (*openAIToolStep)(nil)
+
+// Start runs the completion and returns its text and tool calls as messages
+func (s *openAIToolStep) Start(ctx context.Context, messages conversation.Conversation) (steps.StepResult[*conversation.Message], error) {
+	result, err := s.step.Start(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*conversation.Message
+	for _, r := range result.Return() {
+		completion, err := r.Value()
+		if err != nil {
+			return steps.Reject[*conversation.Message](err), nil
+		}
+		var calls []*conversation.ToolUseContent
+		for _, toolCall := range completion.ToolCalls {
+			calls = append(calls, &conversation.ToolUseContent{
+				ToolID: toolCall.ID,
+				Name:   toolCall.Function.Name,
+				Input:  json.RawMessage(toolCall.Function.Arguments),
+				Type:   "function",
+			})
+		}
+		ret = append(ret, toolCallMessages(completion.Content, nil, calls)...)
+	}
+	return resolveMessages(ret), nil
+}
+
+// AddPublishedTopic publishes the events of the step to topic
+func (s *openAIToolStep) AddPublishedTopic(publisher message.Publisher, topic string) error {
+	return s.step.AddPublishedTopic(publisher, topic)
+}
+
+// claudeToolStep adapts the Claude chat step to return the tool calls it publishes as
+// events along with the text of the response
+type claudeToolStep struct {
+	step *claude.ChatStep
+}
+
+var _ chat.Step = (*claudeToolStep)(nil)
+
+// Start runs the completion and returns its text and tool calls as messages
+func (s *claudeToolStep) Start(ctx context.Context, messages conversation.Conversation) (steps.StepResult[*conversation.Message], error) {
+	// Blocking until the subscriber acks guarantees that all the tool calls are
+	// collected once the result is returned
+	pubSub := gochannel.NewGoChannel(gochannel.Config{
+		BlockPublishUntilSubscriberAck: true,
+	}, watermill.NopLogger{})
+	defer func() {
+		_ = pubSub.Close()
+	}()
+	topic := "tool-calls-" + uuid.New().String()
+	msgs, err := pubSub.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to subscribe to chat step events")
+	}
+	if err := s.step.AddPublishedTopic(pubSub, topic); err != nil {
+		return nil, errors.Wrapf(err, "failed to add published topic %s", topic)
+	}
+
+	var calls []*conversation.ToolUseContent
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range msgs {
+			msg.Ack()
+			e, err := gepevents.NewEventFromJson(msg.Payload)
+			if err != nil {
+				continue
+			}
+			if toolCall, ok := e.(*gepevents.EventToolCall); ok {
+				calls = append(calls, &conversation.ToolUseContent{
+					ToolID: toolCall.ToolCall.ID,
+					Name:   toolCall.ToolCall.Name,
+					Input:  json.RawMessage(toolCall.ToolCall.Input),
+				})
+			}
+		}
+	}()
+
+	result, err := s.step.Start(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	var responses []*conversation.Message
+	for _, r := range result.Return() {
+		response, err := r.Value()
+		if err != nil {
+			return steps.Reject[*conversation.Message](err), nil
+		}
+		responses = append(responses, response)
+	}
+	_ = pubSub.Close()
+	<-done
+
+	var ret []*conversation.Message
+	for i, response := range responses {
+		var responseCalls []*conversation.ToolUseContent
+		if i == len(responses)-1 {
+			responseCalls = calls
+		}
+		ret = append(ret, toolCallMessages(response.Content.String(), response.LLMMessageMetadata, responseCalls)...)
+	}
+	return resolveMessages(ret), nil
+}
+
+// AddPublishedTopic publishes the events of the step to topic
+func (s *claudeToolStep) AddPublishedTopic(publisher message.Publisher, topic string) error {
+	return s.step.AddPublishedTopic(publisher, topic)
+}
+
+// toolCallMessages returns the assistant message holding text, if any, followed by a
+// message per tool call. The messages carry the metadata of the call, with its usage.
+func toolCallMessages(text string, metadata *conversation.LLMMessageMetadata, calls []*conversation.ToolUseContent) []*conversation.Message {
+	var options []conversation.MessageOption
+	if metadata != nil {
+		options = append(options, conversation.WithLLMMessageMetadata(metadata))
+	}
+
+	var ret []*conversation.Message
+	if text != "" || len(calls) == 0 {
+		ret = append(ret, conversation.NewChatMessage(conversation.RoleAssistant, text, options...))
+	}
+	for _, call := range calls {
+		if len(call.Input) == 0 {
+			call.Input = json.RawMessage("{}")
+		}
+		ret = append(ret, conversation.NewMessage(call, options...))
+	}
+	return ret
+}
+
+// resolveMessages returns a step result holding messages
+func resolveMessages(messages []*conversation.Message) steps.StepResult[*conversation.Message] {
+	c := make(chan helpers.Result[*conversation.Message], len(messages))
+	for _, m := range messages {
+		c <- helpers.NewValueResult(m)
+	}
+	close(c)
+	return steps.NewStepResult[*conversation.Message](c)
+}
+
+var _ ToolCallingLLM = (*GeppettoLLM)(nil)
@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/conversation"
+)
+
+func TestToolCallMessages(t *testing.T) {
+	metadata := &conversation.LLMMessageMetadata{Usage: &conversation.Usage{InputTokens: 10, OutputTokens: 5}}
+	calls := []*conversation.ToolUseContent{
+		{ToolID: "call-1", Name: "read_file", Input: []byte(`{"path":"a"}`)},
+		{ToolID: "call-2", Name: "list_files"},
+	}
+
+	testCases := []struct {
+		name     string
+		text     string
+		calls    []*conversation.ToolUseContent
+		expected []string
+	}{
+		{"text only", "hello", nil, []string{"text:hello"}},
+		{"empty response", "", nil, []string{"text:"}},
+		{"tool calls only", "", calls, []string{"tool:call-1:read_file:{\"path\":\"a\"}", "tool:call-2:list_files:{}"}},
+		{"text and tool calls", "Reading", calls[:1], []string{"text:Reading", "tool:call-1:read_file:{\"path\":\"a\"}"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			messages := toolCallMessages(tc.text, metadata, tc.calls)
+			if len(messages) != len(tc.expected) {
+				t.Fatalf("got %d messages, want %d", len(messages), len(tc.expected))
+			}
+			for i, m := range messages {
+				var got string
+				switch c := m.Content.(type) {
+				case *conversation.ChatMessageContent:
+					if c.Role != conversation.RoleAssistant {
+						t.Errorf("message %d has role %s, want assistant", i, c.Role)
+					}
+					got = "text:" + c.Text
+				case *conversation.ToolUseContent:
+					got = "tool:" + c.ToolID + ":" + c.Name + ":" + string(c.Input)
+				}
+				if got != tc.expected[i] {
+					t.Errorf("message %d = %q, want %q", i, got, tc.expected[i])
+				}
+				if m.LLMMessageMetadata != metadata {
+					t.Errorf("message %d does not carry the metadata of the call", i)
+				}
+			}
+		})
+	}
+}
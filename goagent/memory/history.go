@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/google/uuid"
+)
+
+// HistoryMemory is an in-process memory that keeps entries in insertion order.
+// Search does a simple case-insensitive keyword match, favouring recent entries.
+type HistoryMemory struct {
+	mu      sync.RWMutex
+	entries []types.MemoryEntry
+	// latest maps an entry ID to the index of the most recent entry with that ID
+	latest map[string]int
+}
+
+var _ Memory = &HistoryMemory{}
+var _ History = &HistoryMemory{}
+
+// NewHistoryMemory creates a new HistoryMemory
+func NewHistoryMemory() *HistoryMemory {
+	return &HistoryMemory{
+		latest: make(map[string]int),
+	}
+}
+
+// Add appends a memory to the history
+func (m *HistoryMemory) Add(ctx context.Context, memory types.MemoryEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if memory.ID == "" {
+		memory.ID = uuid.New().String()
+	}
+
+	m.entries = append(m.entries, memory)
+	m.latest[memory.ID] = len(m.entries) - 1
+	return nil
+}
+
+// Get retrieves memories by ID. If several entries share an ID, the most recent one is returned.
+func (m *HistoryMemory) Get(ctx context.Context, ids []string) ([]types.MemoryEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []types.MemoryEntry
+	for _, id := range ids {
+		if idx, ok := m.latest[id]; ok {
+			entries = append(entries, m.entries[idx])
+		}
+	}
+	return entries, nil
+}
+
+// GetHistory returns all entries, oldest first
+func (m *HistoryMemory) GetHistory(ctx context.Context) ([]types.MemoryEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make([]types.MemoryEntry, len(m.entries))
+	copy(entries, m.entries)
+	return entries, nil
+}
+
+// Search returns the entries containing the most query terms, most recent first on ties
+func (m *HistoryMemory) Search(ctx context.Context, query string, limit int) ([]types.MemoryEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return []types.MemoryEntry{}, nil
+	}
+
+	type scoredEntry struct {
+		index int
+		score int
+	}
+
+	var scored []scoredEntry
+	for i, entry := range m.entries {
+		content := strings.ToLower(entry.Content)
+		score := 0
+		for _, term := range terms {
+			if strings.Contains(content, term) {
+				score++
+			}
+		}
+		if score > 0 {
+			scored = append(scored, scoredEntry{index: i, score: score})
+		}
+	}
+
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].index > scored[j].index
+	})
+
+	if limit > 0 && len(scored) > limit {
+		scored = scored[:limit]
+	}
+
+	results := make([]types.MemoryEntry, 0, len(scored))
+	for _, s := range scored {
+		results = append(results, m.entries[s.index])
+	}
+	return results, nil
+}
+
+// Clear clears all memories
+func (m *HistoryMemory) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = nil
+	m.latest = make(map[string]int)
+	return nil
+}
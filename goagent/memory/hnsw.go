@@ -0,0 +1,420 @@
+package memory
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// HNSWConfig holds the parameters of an HNSWIndex. Larger values improve recall at
+// the cost of memory and latency.
+type HNSWConfig struct {
+	// M is the number of neighbours kept per node on the upper layers (2*M on layer 0)
+	M int
+	// EfConstruction is the size of the candidate list used while inserting
+	EfConstruction int
+	// EfSearch is the size of the candidate list used while searching
+	EfSearch int
+	// Seed seeds the level generator, making the graph reproducible
+	Seed int64
+}
+
+// DefaultHNSWConfig returns parameters giving good recall for typical embedding sizes
+func DefaultHNSWConfig() HNSWConfig {
+	return HNSWConfig{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       64,
+		Seed:           1,
+	}
+}
+
+// hnswNode is a vector in the graph. friends[l] holds the neighbours on layer l.
+type hnswNode struct {
+	id      string
+	vector  []float32
+	friends [][]uint32
+}
+
+// HNSWIndex is an approximate nearest neighbour index using cosine distance, based on
+// "Efficient and robust approximate nearest neighbor search using Hierarchical
+// Navigable Small World graphs" (Malkov & Yashunin). It supports incremental
+// inserts and deletes and is safe for concurrent use.
+type HNSWIndex struct {
+	mu        sync.RWMutex
+	config    HNSWConfig
+	levelMult float64
+	rng       *rand.Rand
+
+	// nodes is indexed by internal node number; deleted nodes are nil
+	nodes      []*hnswNode
+	ids        map[string]uint32
+	entryPoint uint32
+	maxLevel   int
+}
+
+// NewHNSWIndex creates an empty index. Zero fields of config take their default value.
+func NewHNSWIndex(config HNSWConfig) *HNSWIndex {
+	defaults := DefaultHNSWConfig()
+	if config.M <= 1 {
+		config.M = defaults.M
+	}
+	if config.EfConstruction <= 0 {
+		config.EfConstruction = defaults.EfConstruction
+	}
+	if config.EfSearch <= 0 {
+		config.EfSearch = defaults.EfSearch
+	}
+
+	return &HNSWIndex{
+		config:    config,
+		levelMult: 1 / math.Log(float64(config.M)),
+		rng:       rand.New(rand.NewSource(config.Seed)),
+		ids:       make(map[string]uint32),
+		maxLevel:  -1,
+	}
+}
+
+// Len returns the number of vectors in the index
+func (h *HNSWIndex) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.ids)
+}
+
+// Reset removes all vectors from the index
+func (h *HNSWIndex) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nodes = nil
+	h.ids = make(map[string]uint32)
+	h.maxLevel = -1
+}
+
+// Add inserts a vector, replacing any vector previously stored under id
+func (h *HNSWIndex) Add(id string, vector []float32) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.ids[id]; ok {
+		h.delete(id)
+	}
+
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+	node := &hnswNode{
+		id:      id,
+		vector:  normalize(vector),
+		friends: make([][]uint32, level+1),
+	}
+	n := uint32(len(h.nodes))
+	h.nodes = append(h.nodes, node)
+	h.ids[id] = n
+
+	if h.maxLevel < 0 {
+		h.entryPoint = n
+		h.maxLevel = level
+		return
+	}
+
+	entryPoints := []hnswCandidate{{node: h.entryPoint, distance: h.distance(node.vector, h.entryPoint)}}
+	for l := h.maxLevel; l > level; l-- {
+		entryPoints = h.searchLayer(node.vector, entryPoints, 1, l)
+	}
+
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(node.vector, entryPoints, h.config.EfConstruction, l)
+		neighbours := h.selectNeighbours(candidates, h.config.M)
+		node.friends[l] = make([]uint32, 0, len(neighbours))
+		for _, neighbour := range neighbours {
+			node.friends[l] = append(node.friends[l], neighbour.node)
+			h.connect(neighbour.node, n, l)
+		}
+		entryPoints = candidates
+	}
+
+	if level > h.maxLevel {
+		h.entryPoint = n
+		h.maxLevel = level
+	}
+}
+
+// Delete removes the vector stored under id. The neighbours of the removed node are
+// reconnected to each other so that the graph stays navigable.
+func (h *HNSWIndex) Delete(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.delete(id)
+}
+
+func (h *HNSWIndex) delete(id string) bool {
+	n, ok := h.ids[id]
+	if !ok {
+		return false
+	}
+	node := h.nodes[n]
+	h.nodes[n] = nil
+	delete(h.ids, id)
+
+	for l, friends := range node.friends {
+		for _, f := range friends {
+			friend := h.nodes[f]
+			if friend == nil {
+				continue
+			}
+			// Candidate neighbours are the friend's remaining links plus the links of
+			// the removed node, which were close to it.
+			candidates := make([]uint32, 0, len(friend.friends[l])+len(friends))
+			candidates = append(candidates, friend.friends[l]...)
+			candidates = append(candidates, friends...)
+			h.setFriends(f, l, candidates)
+		}
+	}
+
+	if n == h.entryPoint {
+		h.maxLevel = -1
+		for i, other := range h.nodes {
+			if other != nil && len(other.friends)-1 > h.maxLevel {
+				h.entryPoint = uint32(i)
+				h.maxLevel = len(other.friends) - 1
+			}
+		}
+	}
+	if len(h.ids) == 0 {
+		h.nodes = nil
+	}
+
+	return true
+}
+
+// HNSWResult is a search hit
+type HNSWResult struct {
+	ID string
+	// Similarity is the cosine similarity between the query and the vector
+	Similarity float32
+}
+
+// Search returns the (approximately) k most similar vectors to query, most similar first
+func (h *HNSWIndex) Search(query []float32, k int) []HNSWResult {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.maxLevel < 0 || k <= 0 {
+		return nil
+	}
+
+	q := normalize(query)
+	entryPoints := []hnswCandidate{{node: h.entryPoint, distance: h.distance(q, h.entryPoint)}}
+	for l := h.maxLevel; l > 0; l-- {
+		entryPoints = h.searchLayer(q, entryPoints, 1, l)
+	}
+	candidates := h.searchLayer(q, entryPoints, max(h.config.EfSearch, k), 0)
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	results := make([]HNSWResult, 0, len(candidates))
+	for _, c := range candidates {
+		results = append(results, HNSWResult{
+			ID:         h.nodes[c.node].id,
+			Similarity: 1 - c.distance,
+		})
+	}
+	return results
+}
+
+// connect adds a link from node a to node b on layer l, pruning a's links if needed
+func (h *HNSWIndex) connect(a, b uint32, l int) {
+	node := h.nodes[a]
+	node.friends[l] = append(node.friends[l], b)
+	if len(node.friends[l]) > h.maxFriends(l) {
+		h.setFriends(a, l, node.friends[l])
+	}
+}
+
+// setFriends replaces the links of node n on layer l with the best of candidates
+func (h *HNSWIndex) setFriends(n uint32, l int, candidates []uint32) {
+	node := h.nodes[n]
+	seen := make(map[uint32]struct{}, len(candidates))
+	scored := make([]hnswCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if _, ok := seen[c]; ok || c == n || h.nodes[c] == nil {
+			continue
+		}
+		seen[c] = struct{}{}
+		scored = append(scored, hnswCandidate{node: c, distance: h.distance(node.vector, c)})
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].distance < scored[j].distance
+	})
+
+	selected := h.selectNeighbours(scored, h.maxFriends(l))
+	friends := make([]uint32, 0, len(selected))
+	for _, s := range selected {
+		friends = append(friends, s.node)
+	}
+	node.friends[l] = friends
+}
+
+func (h *HNSWIndex) maxFriends(l int) int {
+	if l == 0 {
+		return 2 * h.config.M
+	}
+	return h.config.M
+}
+
+// selectNeighbours picks up to m neighbours from candidates (sorted by distance),
+// preferring candidates that are closer to the base node than to any already selected
+// neighbour. This keeps links spread out in different directions. Remaining slots
+// are filled with the closest discarded candidates.
+func (h *HNSWIndex) selectNeighbours(candidates []hnswCandidate, m int) []hnswCandidate {
+	if len(candidates) <= m {
+		return candidates
+	}
+
+	selected := make([]hnswCandidate, 0, m)
+	var discarded []hnswCandidate
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		good := true
+		for _, s := range selected {
+			if h.distance(h.nodes[c.node].vector, s.node) < c.distance {
+				good = false
+				break
+			}
+		}
+		if good {
+			selected = append(selected, c)
+		} else {
+			discarded = append(discarded, c)
+		}
+	}
+	for _, c := range discarded {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// searchLayer runs a best-first search on layer l starting at entryPoints and returns
+// up to ef nodes closest to q, sorted by distance.
+func (h *HNSWIndex) searchLayer(q []float32, entryPoints []hnswCandidate, ef int, l int) []hnswCandidate {
+	visited := make(map[uint32]struct{}, ef*4)
+	candidates := &hnswMinHeap{}
+	results := &hnswMaxHeap{}
+
+	for _, ep := range entryPoints {
+		if _, ok := visited[ep.node]; ok {
+			continue
+		}
+		visited[ep.node] = struct{}{}
+		heap.Push(candidates, ep)
+		heap.Push(results, ep)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && c.distance > (*results)[0].distance {
+			break
+		}
+
+		node := h.nodes[c.node]
+		if l >= len(node.friends) {
+			continue
+		}
+		for _, f := range node.friends[l] {
+			if _, ok := visited[f]; ok {
+				continue
+			}
+			visited[f] = struct{}{}
+			if h.nodes[f] == nil {
+				continue
+			}
+
+			d := h.distance(q, f)
+			if results.Len() < ef || d < (*results)[0].distance {
+				heap.Push(candidates, hnswCandidate{node: f, distance: d})
+				heap.Push(results, hnswCandidate{node: f, distance: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := make([]hnswCandidate, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(hnswCandidate)
+	}
+	return sorted
+}
+
+// distance returns the cosine distance between q and node n. Vectors are stored
+// normalized, so this is 1 minus their dot product.
+func (h *HNSWIndex) distance(q []float32, n uint32) float32 {
+	v := h.nodes[n].vector
+	var dot float32
+	for i := 0; i < len(q) && i < len(v); i++ {
+		dot += q[i] * v[i]
+	}
+	return 1 - dot
+}
+
+// normalize returns a unit length copy of v. Zero vectors are returned unchanged.
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	ret := make([]float32, len(v))
+	if norm == 0 {
+		copy(ret, v)
+		return ret
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i, x := range v {
+		ret[i] = x * scale
+	}
+	return ret
+}
+
+type hnswCandidate struct {
+	node     uint32
+	distance float32
+}
+
+// hnswMinHeap pops the closest candidate first
+type hnswMinHeap []hnswCandidate
+
+func (h hnswMinHeap) Len() int            { return len(h) }
+func (h hnswMinHeap) Less(i, j int) bool  { return h[i].distance < h[j].distance }
+func (h hnswMinHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *hnswMinHeap) Push(x interface{}) { *h = append(*h, x.(hnswCandidate)) }
+func (h *hnswMinHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// hnswMaxHeap pops the farthest candidate first
+type hnswMaxHeap []hnswCandidate
+
+func (h hnswMaxHeap) Len() int            { return len(h) }
+func (h hnswMaxHeap) Less(i, j int) bool  { return h[i].distance > h[j].distance }
+func (h hnswMaxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *hnswMaxHeap) Push(x interface{}) { *h = append(*h, x.(hnswCandidate)) }
+func (h *hnswMaxHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
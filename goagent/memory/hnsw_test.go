@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+)
+
+const testDimensions = 64
+
+// staticEmbedder returns the vector registered for a text, so that benchmarks
+// measure search cost rather than embedding cost.
+type staticEmbedder map[string][]float32
+
+func (e staticEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	v, ok := e[text]
+	if !ok {
+		return nil, fmt.Errorf("no embedding for %q", text)
+	}
+	return v, nil
+}
+
+func randomVectors(rng *rand.Rand, n int) [][]float32 {
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		v := make([]float32, testDimensions)
+		for j := range v {
+			v[j] = rng.Float32()*2 - 1
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+// bruteForceTopK returns the ids ("v<i>") of the k vectors most similar to query
+func bruteForceTopK(vectors [][]float32, deleted map[int]bool, query []float32, k int) []string {
+	type scored struct {
+		id         string
+		similarity float32
+	}
+	var all []scored
+	for i, v := range vectors {
+		if deleted[i] {
+			continue
+		}
+		all = append(all, scored{id: fmt.Sprintf("v%d", i), similarity: cosineSimilarity(query, v)})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].similarity > all[j].similarity
+	})
+	ids := make([]string, 0, k)
+	for i := 0; i < k && i < len(all); i++ {
+		ids = append(ids, all[i].id)
+	}
+	return ids
+}
+
+func recall(index *HNSWIndex, vectors [][]float32, deleted map[int]bool, queries [][]float32, k int) float64 {
+	found := 0
+	total := 0
+	for _, q := range queries {
+		expected := map[string]bool{}
+		for _, id := range bruteForceTopK(vectors, deleted, q, k) {
+			expected[id] = true
+		}
+		for _, hit := range index.Search(q, k) {
+			if expected[hit.ID] {
+				found++
+			}
+		}
+		total += len(expected)
+	}
+	return float64(found) / float64(total)
+}
+
+func TestHNSWIndex_Recall(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	vectors := randomVectors(rng, 2000)
+	queries := randomVectors(rng, 50)
+
+	index := NewHNSWIndex(DefaultHNSWConfig())
+	for i, v := range vectors {
+		index.Add(fmt.Sprintf("v%d", i), v)
+	}
+	if index.Len() != len(vectors) {
+		t.Fatalf("expected %d vectors, got %d", len(vectors), index.Len())
+	}
+
+	if r := recall(index, vectors, nil, queries, 10); r < 0.9 {
+		t.Errorf("recall@10 = %.3f, expected at least 0.9", r)
+	}
+}
+
+func TestHNSWIndex_Delete(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	vectors := randomVectors(rng, 1000)
+	queries := randomVectors(rng, 50)
+
+	index := NewHNSWIndex(DefaultHNSWConfig())
+	for i, v := range vectors {
+		index.Add(fmt.Sprintf("v%d", i), v)
+	}
+
+	deleted := map[int]bool{}
+	for i := 0; i < len(vectors); i += 3 {
+		if !index.Delete(fmt.Sprintf("v%d", i)) {
+			t.Fatalf("expected v%d to be deleted", i)
+		}
+		deleted[i] = true
+	}
+	if index.Delete("v0") {
+		t.Errorf("deleting v0 twice should report false")
+	}
+	if index.Len() != len(vectors)-len(deleted) {
+		t.Fatalf("expected %d vectors, got %d", len(vectors)-len(deleted), index.Len())
+	}
+
+	for _, q := range queries {
+		for _, hit := range index.Search(q, 10) {
+			var i int
+			_, _ = fmt.Sscanf(hit.ID, "v%d", &i)
+			if deleted[i] {
+				t.Fatalf("search returned deleted vector %s", hit.ID)
+			}
+		}
+	}
+
+	if r := recall(index, vectors, deleted, queries, 10); r < 0.9 {
+		t.Errorf("recall@10 after deletes = %.3f, expected at least 0.9", r)
+	}
+
+	// Deleting everything leaves a usable, empty index
+	for i := range vectors {
+		index.Delete(fmt.Sprintf("v%d", i))
+	}
+	if hits := index.Search(queries[0], 10); len(hits) != 0 {
+		t.Errorf("expected no hits from an empty index, got %d", len(hits))
+	}
+	index.Add("again", vectors[0])
+	if hits := index.Search(vectors[0], 1); len(hits) != 1 || hits[0].ID != "again" {
+		t.Errorf("expected to find the re-added vector, got %v", hits)
+	}
+}
+
+func TestHNSWIndex_AddReplaces(t *testing.T) {
+	index := NewHNSWIndex(DefaultHNSWConfig())
+	index.Add("a", []float32{1, 0})
+	index.Add("b", []float32{0, 1})
+	index.Add("a", []float32{0, 1})
+
+	if index.Len() != 2 {
+		t.Fatalf("expected 2 vectors, got %d", index.Len())
+	}
+	hits := index.Search([]float32{1, 0}, 2)
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits, got %d", len(hits))
+	}
+	if hits[0].Similarity > 0.001 || hits[1].Similarity > 0.001 {
+		t.Errorf("expected both vectors to be orthogonal to the query, got %v", hits)
+	}
+}
+
+func newBenchmarkMemory(b *testing.B, n int, options ...SimpleVectorMemoryOption) (*SimpleVectorMemory, []string) {
+	rng := rand.New(rand.NewSource(1))
+	embedder := staticEmbedder{}
+	for i, v := range randomVectors(rng, n) {
+		embedder[fmt.Sprintf("entry %d", i)] = v
+	}
+	queries := make([]string, 100)
+	for i, v := range randomVectors(rng, len(queries)) {
+		queries[i] = fmt.Sprintf("query %d", i)
+		embedder[queries[i]] = v
+	}
+
+	m, err := NewSimpleVectorMemory(embedder, options...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		content := fmt.Sprintf("entry %d", i)
+		if err := m.Add(ctx, types.MemoryEntry{ID: content, Content: content}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return m, queries
+}
+
+func benchmarkSearch(b *testing.B, n int, options ...SimpleVectorMemoryOption) {
+	m, queries := newBenchmarkMemory(b, n, options...)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.Search(ctx, queries[i%len(queries)], 10); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSearch_BruteForce_1k(b *testing.B)  { benchmarkSearch(b, 1000) }
+func BenchmarkSearch_BruteForce_10k(b *testing.B) { benchmarkSearch(b, 10000) }
+
+func BenchmarkSearch_HNSW_1k(b *testing.B) {
+	benchmarkSearch(b, 1000, WithHNSWIndex(DefaultHNSWConfig()))
+}
+
+func BenchmarkSearch_HNSW_10k(b *testing.B) {
+	benchmarkSearch(b, 10000, WithHNSWIndex(DefaultHNSWConfig()))
+}
+
+func BenchmarkHNSWIndex_Insert(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	vectors := randomVectors(rng, b.N)
+	index := NewHNSWIndex(DefaultHNSWConfig())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Add(fmt.Sprintf("v%d", i), vectors[i])
+	}
+}
+
+func BenchmarkHNSWIndex_Delete(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	vectors := randomVectors(rng, b.N)
+	index := NewHNSWIndex(DefaultHNSWConfig())
+	for i, v := range vectors {
+		index.Add(fmt.Sprintf("v%d", i), v)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Delete(fmt.Sprintf("v%d", i))
+	}
+}
@@ -3,6 +3,7 @@ package memory
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-go-golems/go-go-agent/goagent/types"
 )
@@ -21,3 +22,101 @@ type Memory interface {
 	// Clear clears all memories
 	Clear(ctx context.Context) error
 }
+
+// History is implemented by memories that can list their entries in insertion order.
+// Agents use it to replay their scratchpad into the prompt.
+type History interface {
+	// GetHistory returns all entries, oldest first
+	GetHistory(ctx context.Context) ([]types.MemoryEntry, error)
+}
+
+// FilteredSearcher is implemented by memories that can restrict a search to the entries
+// whose metadata contains all key/value pairs of filter.
+type FilteredSearcher interface {
+	SearchWithFilter(ctx context.Context, query string, limit int, filter map[string]string) ([]types.MemoryEntry, error)
+}
+
+// Embedder generates vector embeddings for text. It is satisfied by llm.LLM.
+type Embedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+// Memory types selectable with the memory-type parameter
+const (
+	MemoryTypeNone        = "none"
+	MemoryTypeSimple      = "simple"
+	MemoryTypeVectorStore = "vectorstore"
+	MemoryTypeHNSW        = "hnsw"
+	MemoryTypeSQLite      = "sqlite"
+)
+
+// MemoryTypes lists the supported memory types
+var MemoryTypes = []string{MemoryTypeNone, MemoryTypeSimple, MemoryTypeVectorStore, MemoryTypeHNSW, MemoryTypeSQLite}
+
+type memoryConfig struct {
+	dbPath     string
+	namespace  string
+	hnswConfig HNSWConfig
+}
+
+// MemoryOption configures the memory created by NewMemoryFromType
+type MemoryOption func(*memoryConfig)
+
+// WithDBPath sets the database file used by the sqlite memory
+func WithDBPath(dbPath string) MemoryOption {
+	return func(c *memoryConfig) {
+		c.dbPath = dbPath
+	}
+}
+
+// WithNamespace sets the namespace used by the sqlite memory
+func WithNamespace(namespace string) MemoryOption {
+	return func(c *memoryConfig) {
+		c.namespace = namespace
+	}
+}
+
+// WithHNSWConfig sets the index parameters used by the hnsw memory
+func WithHNSWConfig(config HNSWConfig) MemoryOption {
+	return func(c *memoryConfig) {
+		c.hnswConfig = config
+	}
+}
+
+// NewMemoryFromType creates the memory implementation for the given memory type.
+// embedder is only used by the vectorstore, hnsw and sqlite memories.
+func NewMemoryFromType(memoryType string, embedder Embedder, options ...MemoryOption) (Memory, error) {
+	config := &memoryConfig{
+		hnswConfig: DefaultHNSWConfig(),
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	switch memoryType {
+	case "", MemoryTypeNone:
+		return NewNoopMemory(), nil
+	case MemoryTypeSimple:
+		return NewHistoryMemory(), nil
+	case MemoryTypeVectorStore:
+		if embedder == nil {
+			return nil, fmt.Errorf("memory type %s requires an embedding provider", memoryType)
+		}
+		return NewSimpleVectorMemory(embedder)
+	case MemoryTypeHNSW:
+		if embedder == nil {
+			return nil, fmt.Errorf("memory type %s requires an embedding provider", memoryType)
+		}
+		return NewSimpleVectorMemory(embedder, WithHNSWIndex(config.hnswConfig))
+	case MemoryTypeSQLite:
+		if embedder == nil {
+			return nil, fmt.Errorf("memory type %s requires an embedding provider", memoryType)
+		}
+		if config.dbPath == "" {
+			return nil, fmt.Errorf("memory type %s requires a database path", memoryType)
+		}
+		return NewSQLiteVectorMemory(config.dbPath, config.namespace, embedder)
+	default:
+		return nil, fmt.Errorf("unknown memory type: %s (supported: %v)", memoryType, MemoryTypes)
+	}
+}
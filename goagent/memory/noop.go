@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+)
+
+// NoopMemory implements the Memory interface without storing anything
+type NoopMemory struct{}
+
+var _ Memory = &NoopMemory{}
+var _ History = &NoopMemory{}
+
+// NewNoopMemory creates a new NoopMemory
+func NewNoopMemory() *NoopMemory {
+	return &NoopMemory{}
+}
+
+// Add discards the memory
+func (m *NoopMemory) Add(ctx context.Context, memory types.MemoryEntry) error {
+	return nil
+}
+
+// Get always returns no memories
+func (m *NoopMemory) Get(ctx context.Context, ids []string) ([]types.MemoryEntry, error) {
+	return []types.MemoryEntry{}, nil
+}
+
+// Search always returns no memories
+func (m *NoopMemory) Search(ctx context.Context, query string, limit int) ([]types.MemoryEntry, error) {
+	return []types.MemoryEntry{}, nil
+}
+
+// GetHistory always returns no memories
+func (m *NoopMemory) GetHistory(ctx context.Context) ([]types.MemoryEntry, error) {
+	return []types.MemoryEntry{}, nil
+}
+
+// Clear does nothing
+func (m *NoopMemory) Clear(ctx context.Context) error {
+	return nil
+}
@@ -15,19 +15,36 @@ import (
 type SimpleVectorMemory struct {
 	mu      sync.RWMutex
 	entries map[string]types.MemoryEntry
-	llm     interface {
-		GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+	// order keeps entry IDs in insertion order for GetHistory
+	order []string
+	llm   Embedder
+	// index is used by Search when set; otherwise all entries are scored
+	index *HNSWIndex
+}
+
+var _ Memory = &SimpleVectorMemory{}
+var _ History = &SimpleVectorMemory{}
+
+// SimpleVectorMemoryOption configures a SimpleVectorMemory
+type SimpleVectorMemoryOption func(*SimpleVectorMemory)
+
+// WithHNSWIndex makes Search use an approximate HNSW index instead of scoring every entry
+func WithHNSWIndex(config HNSWConfig) SimpleVectorMemoryOption {
+	return func(m *SimpleVectorMemory) {
+		m.index = NewHNSWIndex(config)
 	}
 }
 
 // NewSimpleVectorMemory creates a new SimpleVectorMemory
-func NewSimpleVectorMemory(llm interface {
-	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
-}) (*SimpleVectorMemory, error) {
-	return &SimpleVectorMemory{
+func NewSimpleVectorMemory(llm Embedder, options ...SimpleVectorMemoryOption) (*SimpleVectorMemory, error) {
+	m := &SimpleVectorMemory{
 		entries: make(map[string]types.MemoryEntry),
 		llm:     llm,
-	}, nil
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m, nil
 }
 
 // Add adds a memory to the system
@@ -51,9 +68,45 @@ func (m *SimpleVectorMemory) Add(ctx context.Context, memory types.MemoryEntry)
 		}
 	}
 
-	_ = embedding
+	memory.Embedding = embedding
 
+	if _, ok := m.entries[memory.ID]; !ok {
+		m.order = append(m.order, memory.ID)
+	}
 	m.entries[memory.ID] = memory
+	if m.index != nil {
+		m.index.Add(memory.ID, embedding)
+	}
+	return nil
+}
+
+// Delete removes memories by ID
+func (m *SimpleVectorMemory) Delete(ctx context.Context, ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := map[string]bool{}
+	for _, id := range ids {
+		if _, ok := m.entries[id]; !ok {
+			continue
+		}
+		delete(m.entries, id)
+		removed[id] = true
+		if m.index != nil {
+			m.index.Delete(id)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	order := m.order[:0]
+	for _, id := range m.order {
+		if !removed[id] {
+			order = append(order, id)
+		}
+	}
+	m.order = order
 	return nil
 }
 
@@ -71,6 +124,18 @@ func (m *SimpleVectorMemory) Get(ctx context.Context, ids []string) ([]types.Mem
 	return entries, nil
 }
 
+// GetHistory returns all entries, oldest first
+func (m *SimpleVectorMemory) GetHistory(ctx context.Context) ([]types.MemoryEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make([]types.MemoryEntry, 0, len(m.order))
+	for _, id := range m.order {
+		entries = append(entries, m.entries[id])
+	}
+	return entries, nil
+}
+
 // cosineSimilarity calculates the cosine similarity between two vectors
 func cosineSimilarity(a, b []float32) float32 {
 	var dotProduct float32
@@ -100,6 +165,14 @@ func (m *SimpleVectorMemory) Search(ctx context.Context, query string, limit int
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
+	if m.index != nil {
+		var results []types.MemoryEntry
+		for _, hit := range m.index.Search(queryEmbedding, limit) {
+			results = append(results, m.entries[hit.ID])
+		}
+		return results, nil
+	}
+
 	type similarityEntry struct {
 		entry      types.MemoryEntry
 		similarity float32
@@ -139,5 +212,9 @@ func (m *SimpleVectorMemory) Clear(ctx context.Context) error {
 	defer m.mu.Unlock()
 
 	m.entries = make(map[string]types.MemoryEntry)
+	m.order = nil
+	if m.index != nil {
+		m.index.Reset()
+	}
 	return nil
 }
@@ -0,0 +1,314 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/go-go-golems/go-go-agent/internal/db"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// sqliteMemorySchema creates the tables used by SQLiteVectorMemory.
+// Entries are keyed by (namespace, id); metadata is stored in a separate table
+// so that searches can filter on it in SQL.
+const sqliteMemorySchema = `
+CREATE TABLE IF NOT EXISTS memory_entries (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    namespace TEXT NOT NULL,
+    id TEXT NOT NULL,
+    content TEXT NOT NULL,
+    embedding BLOB,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (namespace, id)
+);
+
+CREATE TABLE IF NOT EXISTS memory_metadata (
+    namespace TEXT NOT NULL,
+    id TEXT NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    PRIMARY KEY (namespace, id, key),
+    FOREIGN KEY (namespace, id) REFERENCES memory_entries(namespace, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_memory_metadata_key_value ON memory_metadata(namespace, key, value);
+`
+
+// SQLiteVectorMemory implements a persistent vector memory stored in SQLite.
+// All operations are scoped to a namespace, so several agents or commands can
+// share one database file without seeing each other's entries.
+type SQLiteVectorMemory struct {
+	db        *sql.DB
+	namespace string
+	llm       Embedder
+}
+
+var _ Memory = &SQLiteVectorMemory{}
+var _ History = &SQLiteVectorMemory{}
+var _ FilteredSearcher = &SQLiteVectorMemory{}
+
+// NewSQLiteVectorMemory opens (or creates) the SQLite database at dbPath and returns
+// a memory scoped to namespace.
+func NewSQLiteVectorMemory(dbPath string, namespace string, llm Embedder) (*SQLiteVectorMemory, error) {
+	if namespace == "" {
+		return nil, errors.New("sqlite memory requires a namespace")
+	}
+
+	sqlDB, err := db.OpenSQLite(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := sqlDB.Exec(sqliteMemorySchema); err != nil {
+		_ = sqlDB.Close()
+		return nil, errors.Wrap(err, "failed to create memory schema")
+	}
+
+	return &SQLiteVectorMemory{
+		db:        sqlDB,
+		namespace: namespace,
+		llm:       llm,
+	}, nil
+}
+
+// Close closes the underlying database connection
+func (m *SQLiteVectorMemory) Close() error {
+	return m.db.Close()
+}
+
+// Add adds a memory to the system. Adding an entry with an existing ID replaces it
+// and moves it to the end of the history.
+func (m *SQLiteVectorMemory) Add(ctx context.Context, memory types.MemoryEntry) error {
+	if memory.ID == "" {
+		memory.ID = uuid.New().String()
+	}
+
+	if len(memory.Embedding) == 0 {
+		embedding, err := m.llm.GenerateEmbedding(ctx, memory.Content)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate embedding")
+		}
+		memory.Embedding = embedding
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// A replaced entry is deleted and inserted again, so that it gets a new seq and
+	// GetHistory returns it at its new position
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM memory_metadata WHERE namespace = ? AND id = ?`,
+		m.namespace, memory.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to replace metadata of memory entry %s", memory.ID)
+	}
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM memory_entries WHERE namespace = ? AND id = ?`,
+		m.namespace, memory.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to replace memory entry %s", memory.ID)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO memory_entries (namespace, id, content, embedding, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, m.namespace, memory.ID, memory.Content, encodeEmbedding(memory.Embedding), time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "failed to insert memory entry %s", memory.ID)
+	}
+	for key, value := range memory.Metadata {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO memory_metadata (namespace, id, key, value) VALUES (?, ?, ?, ?)`,
+			m.namespace, memory.ID, key, value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to insert metadata %s of memory entry %s", key, memory.ID)
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit memory entry")
+}
+
+// Get retrieves memories by ID
+func (m *SQLiteVectorMemory) Get(ctx context.Context, ids []string) ([]types.MemoryEntry, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := []interface{}{m.namespace}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	query := `SELECT id, content, embedding FROM memory_entries
+		WHERE namespace = ? AND id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)
+		ORDER BY seq`
+
+	return m.queryEntries(ctx, query, args...)
+}
+
+// GetHistory returns all entries of the namespace, oldest first
+func (m *SQLiteVectorMemory) GetHistory(ctx context.Context) ([]types.MemoryEntry, error) {
+	return m.queryEntries(ctx,
+		`SELECT id, content, embedding FROM memory_entries WHERE namespace = ? ORDER BY seq`,
+		m.namespace)
+}
+
+// Search searches for similar memories
+func (m *SQLiteVectorMemory) Search(ctx context.Context, query string, limit int) ([]types.MemoryEntry, error) {
+	return m.SearchWithFilter(ctx, query, limit, nil)
+}
+
+// SearchWithFilter searches for similar memories among the entries whose metadata
+// contains all key/value pairs of filter.
+func (m *SQLiteVectorMemory) SearchWithFilter(
+	ctx context.Context,
+	query string,
+	limit int,
+	filter map[string]string,
+) ([]types.MemoryEntry, error) {
+	queryEmbedding, err := m.llm.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate embedding")
+	}
+
+	sqlQuery := `SELECT e.id, e.content, e.embedding FROM memory_entries e WHERE e.namespace = ?`
+	args := []interface{}{m.namespace}
+
+	// Sort the keys so that the generated statement is stable
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sqlQuery += ` AND EXISTS (SELECT 1 FROM memory_metadata md
+			WHERE md.namespace = e.namespace AND md.id = e.id AND md.key = ? AND md.value = ?)`
+		args = append(args, key, filter[key])
+	}
+
+	candidates, err := m.queryEntries(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return rankBySimilarity(queryEmbedding, candidates, limit), nil
+}
+
+// Clear removes all memories of the namespace
+func (m *SQLiteVectorMemory) Clear(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `DELETE FROM memory_entries WHERE namespace = ?`, m.namespace)
+	return errors.Wrap(err, "failed to clear memory")
+}
+
+// queryEntries runs a query returning (id, content, embedding) rows and
+// loads the metadata of the returned entries.
+func (m *SQLiteVectorMemory) queryEntries(ctx context.Context, query string, args ...interface{}) ([]types.MemoryEntry, error) {
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query memory entries")
+	}
+
+	var entries []types.MemoryEntry
+	index := map[string]int{}
+	for rows.Next() {
+		var entry types.MemoryEntry
+		var embedding []byte
+		if err := rows.Scan(&entry.ID, &entry.Content, &embedding); err != nil {
+			_ = rows.Close()
+			return nil, errors.Wrap(err, "failed to scan memory entry")
+		}
+		entry.Embedding = decodeEmbedding(embedding)
+		index[entry.ID] = len(entries)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, errors.Wrap(err, "failed to iterate memory entries")
+	}
+	_ = rows.Close()
+
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	metadataRows, err := m.db.QueryContext(ctx,
+		`SELECT id, key, value FROM memory_metadata WHERE namespace = ?`, m.namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query memory metadata")
+	}
+	defer func() {
+		_ = metadataRows.Close()
+	}()
+
+	for metadataRows.Next() {
+		var id, key, value string
+		if err := metadataRows.Scan(&id, &key, &value); err != nil {
+			return nil, errors.Wrap(err, "failed to scan memory metadata")
+		}
+		i, ok := index[id]
+		if !ok {
+			continue
+		}
+		if entries[i].Metadata == nil {
+			entries[i].Metadata = map[string]string{}
+		}
+		entries[i].Metadata[key] = value
+	}
+
+	return entries, errors.Wrap(metadataRows.Err(), "failed to iterate memory metadata")
+}
+
+// rankBySimilarity returns the limit entries most similar to queryEmbedding, highest first
+func rankBySimilarity(queryEmbedding []float32, entries []types.MemoryEntry, limit int) []types.MemoryEntry {
+	similarities := make([]float32, len(entries))
+	for i, entry := range entries {
+		similarities[i] = cosineSimilarity(queryEmbedding, entry.Embedding)
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return similarities[order[i]] > similarities[order[j]]
+	})
+
+	if limit >= 0 && len(order) > limit {
+		order = order[:limit]
+	}
+
+	results := make([]types.MemoryEntry, 0, len(order))
+	for _, i := range order {
+		results = append(results, entries[i])
+	}
+	return results
+}
+
+// encodeEmbedding serializes an embedding as little-endian float32 values
+func encodeEmbedding(embedding []float32) []byte {
+	buf := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// decodeEmbedding is the inverse of encodeEmbedding
+func decodeEmbedding(buf []byte) []float32 {
+	embedding := make([]float32, len(buf)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return embedding
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+)
+
+// lengthEmbedder embeds a text as its length, enough for tests that don't rank entries
+type lengthEmbedder struct{}
+
+func (lengthEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text)), 1}, nil
+}
+
+func historyIDs(t *testing.T, m History) []string {
+	t.Helper()
+	entries, err := m.GetHistory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.ID+"="+entry.Content)
+	}
+	return ids
+}
+
+func TestSQLiteVectorMemoryReplace(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "memory.db")
+	m, err := NewSQLiteVectorMemory(dbPath, "test", lengthEmbedder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = m.Close()
+	}()
+
+	for _, entry := range []types.MemoryEntry{
+		{ID: "a", Content: "first", Metadata: map[string]string{"role": "user"}},
+		{ID: "b", Content: "second"},
+		{ID: "a", Content: "replaced", Metadata: map[string]string{"role": "tool"}},
+	} {
+		if err := m.Add(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The replaced entry moves to the end of the history
+	if got, want := historyIDs(t, m), []string{"b=second", "a=replaced"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("history = %v, want %v", got, want)
+	}
+
+	// The metadata of the replaced entry is replaced too
+	for role, expected := range map[string]int{"user": 0, "tool": 1} {
+		entries, err := m.SearchWithFilter(ctx, "query", 10, map[string]string{"role": role})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != expected {
+			t.Errorf("%d entries with role %s, want %d", len(entries), role, expected)
+		}
+	}
+
+	// Entries persist across connections, scoped to their namespace
+	other, err := NewSQLiteVectorMemory(dbPath, "other", lengthEmbedder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = other.Close()
+	}()
+	if got := historyIDs(t, other); len(got) != 0 {
+		t.Errorf("namespace other has entries %v", got)
+	}
+	reopened, err := NewSQLiteVectorMemory(dbPath, "test", lengthEmbedder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = reopened.Close()
+	}()
+	if got, want := historyIDs(t, reopened), []string{"b=second", "a=replaced"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("history after reopening = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+// Package runs provides the controls used to pause and resume a running agent.
+package runs
+
+import (
+	"context"
+	"sync"
+)
+
+// Control pauses and resumes a run. Agents do not stop mid-step: a paused run blocks
+// in Wait, which is called before every LLM call. Cancellation is done through the
+// context of the run. Control is safe for concurrent use.
+type Control struct {
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{}
+}
+
+// NewControl creates a Control for a run that is not paused
+func NewControl() *Control {
+	return &Control{}
+}
+
+// Pause pauses the run at its next Wait. It returns false if the run was already paused.
+func (c *Control) Pause() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused {
+		return false
+	}
+	c.paused = true
+	c.resumed = make(chan struct{})
+	return true
+}
+
+// Resume lets a paused run continue. It returns false if the run was not paused.
+func (c *Control) Resume() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.paused {
+		return false
+	}
+	c.paused = false
+	close(c.resumed)
+	return true
+}
+
+// Paused reports whether the run is paused
+func (c *Control) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Wait blocks while the run is paused. It returns the context error if ctx is done
+// before the run is resumed.
+func (c *Control) Wait(ctx context.Context) error {
+	c.mu.Lock()
+	paused, resumed := c.paused, c.resumed
+	c.mu.Unlock()
+
+	if !paused {
+		return ctx.Err()
+	}
+	select {
+	case <-resumed:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
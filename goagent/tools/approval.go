@@ -0,0 +1,331 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ApprovalMode tells whether a tool may run without sign-off
+type ApprovalMode string
+
+const (
+	// ApprovalAuto runs the tool without asking
+	ApprovalAuto ApprovalMode = "auto"
+	// ApprovalRequired asks the Approver before every call of the tool
+	ApprovalRequired ApprovalMode = "required"
+	// ApprovalDenied never runs the tool
+	ApprovalDenied ApprovalMode = "denied"
+)
+
+// ParseApprovalMode parses an approval mode name
+func ParseApprovalMode(s string) (ApprovalMode, error) {
+	switch mode := ApprovalMode(s); mode {
+	case ApprovalAuto, ApprovalRequired, ApprovalDenied:
+		return mode, nil
+	default:
+		return "", errors.Errorf("invalid tool approval mode '%s' (expected %s, %s or %s)", s, ApprovalAuto, ApprovalRequired, ApprovalDenied)
+	}
+}
+
+// ApprovalModeProvider is implemented by tools that need a different approval mode
+// than auto when the policy does not mention them, e.g. tools with side effects.
+type ApprovalModeProvider interface {
+	DefaultApprovalMode() ApprovalMode
+}
+
+// ApprovalPolicy assigns an approval mode to each tool
+type ApprovalPolicy struct {
+	// Default applies to tools missing from Tools. If empty, tools use their own
+	// default mode, or auto.
+	Default ApprovalMode
+	// Tools maps tool names to their approval mode
+	Tools map[string]ApprovalMode
+}
+
+// ParseApprovalPolicy parses the `tool-approval` agent option:
+//
+//	tool-approval:
+//	  default: auto
+//	  tools:
+//	    write_file: required
+//	    web_search: denied
+func ParseApprovalPolicy(raw map[string]interface{}) (*ApprovalPolicy, error) {
+	policy := &ApprovalPolicy{Tools: map[string]ApprovalMode{}}
+	for key, value := range raw {
+		switch key {
+		case "default":
+			s, ok := value.(string)
+			if !ok {
+				return nil, errors.Errorf("default tool approval mode must be a string, got %T", value)
+			}
+			mode, err := ParseApprovalMode(s)
+			if err != nil {
+				return nil, err
+			}
+			policy.Default = mode
+		case "tools":
+			modes, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("tool approval modes must be a map, got %T", value)
+			}
+			for name, v := range modes {
+				s, ok := v.(string)
+				if !ok {
+					return nil, errors.Errorf("approval mode of tool '%s' must be a string, got %T", name, v)
+				}
+				mode, err := ParseApprovalMode(s)
+				if err != nil {
+					return nil, errors.Wrapf(err, "tool '%s'", name)
+				}
+				policy.Tools[name] = mode
+			}
+		default:
+			return nil, errors.Errorf("unknown tool approval key '%s' (expected default or tools)", key)
+		}
+	}
+	return policy, nil
+}
+
+// Mode returns the approval mode of tool
+func (p *ApprovalPolicy) Mode(tool Tool) ApprovalMode {
+	if p != nil {
+		if mode, ok := p.Tools[tool.Name()]; ok {
+			return mode
+		}
+		if p.Default != "" {
+			return p.Default
+		}
+	}
+	if provider, ok := tool.(ApprovalModeProvider); ok {
+		return provider.DefaultApprovalMode()
+	}
+	return ApprovalAuto
+}
+
+// ApprovalRequest describes a tool call waiting for approval
+type ApprovalRequest struct {
+	ToolCallID string `json:"tool_call_id"`
+	ToolName   string `json:"tool_name"`
+	Input      string `json:"input"`
+}
+
+// ApprovalDecision is the answer to an ApprovalRequest
+type ApprovalDecision struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Approver decides whether tool calls that require approval may run
+type Approver interface {
+	// RequestApproval blocks until the call is approved or rejected, or ctx is done
+	RequestApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+}
+
+// ToolDeniedError is the error of a tool call that was denied by the policy or rejected
+type ToolDeniedError struct {
+	ToolName string
+	Reason   string
+}
+
+func (e *ToolDeniedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("call to tool %s was not approved", e.ToolName)
+	}
+	return fmt.Sprintf("call to tool %s was not approved: %s", e.ToolName, e.Reason)
+}
+
+// TerminalApprover asks for approval on a terminal. Requests are asked one at a time.
+type TerminalApprover struct {
+	mu        sync.Mutex
+	in        io.Reader
+	out       io.Writer
+	startOnce sync.Once
+	// reads asks the reader for the answer to a question, lines returns it
+	reads chan int
+	lines chan terminalLine
+	// question numbers the questions, to recognize answers to cancelled ones
+	question int
+	reading  bool
+	closed   bool
+}
+
+type terminalLine struct {
+	question int
+	text     string
+	err      error
+}
+
+// NewTerminalApprover creates a TerminalApprover reading answers from in and writing
+// questions to out.
+func NewTerminalApprover(in io.Reader, out io.Writer) *TerminalApprover {
+	return &TerminalApprover{
+		in:    in,
+		out:   out,
+		reads: make(chan int, 1),
+		lines: make(chan terminalLine, 1),
+	}
+}
+
+// readLines reads the answers in the background, since reading cannot be interrupted
+// when a request is cancelled. Lines are only read when a question asks for one, so
+// input written ahead answers the following questions in order.
+func (a *TerminalApprover) readLines() {
+	reader := bufio.NewReader(a.in)
+	for question := range a.reads {
+		text, err := reader.ReadString('\n')
+		a.lines <- terminalLine{question, text, err}
+		if err != nil {
+			close(a.lines)
+			return
+		}
+	}
+}
+
+// RequestApproval prints the tool call and reads a yes/no answer. Anything but an
+// answer starting with "y" rejects the call. An answer to an earlier request that was
+// cancelled is discarded and the question is asked again.
+func (a *TerminalApprover) RequestApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return ApprovalDecision{}, err
+	}
+	if a.closed {
+		return ApprovalDecision{Approved: false, Reason: "no answer on the terminal"}, nil
+	}
+	a.startOnce.Do(func() { go a.readLines() })
+	a.question++
+
+	if _, err := fmt.Fprintf(a.out, "\nTool %s wants to run with input:\n  %s\nApprove? [y/N] ", req.ToolName, req.Input); err != nil {
+		return ApprovalDecision{}, errors.Wrap(err, "failed to ask for tool approval")
+	}
+
+	for {
+		// A line still being read for a cancelled request is received below
+		if !a.reading {
+			a.reads <- a.question
+			a.reading = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return ApprovalDecision{}, ctx.Err()
+		case line, ok := <-a.lines:
+			a.reading = false
+			if !ok {
+				a.closed = true
+				return ApprovalDecision{Approved: false, Reason: "no answer on the terminal"}, nil
+			}
+			if line.err != nil {
+				a.closed = true
+			}
+			if line.question != a.question {
+				// The answer to a cancelled request
+				if a.closed {
+					return ApprovalDecision{Approved: false, Reason: "no answer on the terminal"}, nil
+				}
+				if _, err := fmt.Fprint(a.out, "Approve? [y/N] "); err != nil {
+					return ApprovalDecision{}, errors.Wrap(err, "failed to ask for tool approval")
+				}
+				continue
+			}
+			if line.err != nil && line.text == "" {
+				return ApprovalDecision{Approved: false, Reason: "no answer on the terminal"}, nil
+			}
+			answer := strings.ToLower(strings.TrimSpace(line.text))
+			if strings.HasPrefix(answer, "y") {
+				return ApprovalDecision{Approved: true}, nil
+			}
+			return ApprovalDecision{Approved: false, Reason: "rejected by user"}, nil
+		}
+	}
+}
+
+// ApprovalQueue is an Approver whose requests are decided by another goroutine, for
+// example an HTTP handler. It is safe for concurrent use.
+type ApprovalQueue struct {
+	mu      sync.Mutex
+	pending map[string]*pendingApproval
+}
+
+type pendingApproval struct {
+	request  ApprovalRequest
+	decision chan ApprovalDecision
+}
+
+// ErrNoPendingApproval is returned by ApprovalQueue.Decide for unknown tool calls
+var ErrNoPendingApproval = errors.New("no pending approval for tool call")
+
+// NewApprovalQueue creates an empty ApprovalQueue
+func NewApprovalQueue() *ApprovalQueue {
+	return &ApprovalQueue{
+		pending: map[string]*pendingApproval{},
+	}
+}
+
+// RequestApproval adds req to the pending requests and waits for Decide. Requests are
+// decided by their tool call ID, which must be set and not be pending already.
+func (q *ApprovalQueue) RequestApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	if req.ToolCallID == "" {
+		return ApprovalDecision{}, errors.Errorf("approval request for tool %s has no tool call ID", req.ToolName)
+	}
+	p := &pendingApproval{
+		request:  req,
+		decision: make(chan ApprovalDecision, 1),
+	}
+	q.mu.Lock()
+	if _, ok := q.pending[req.ToolCallID]; ok {
+		q.mu.Unlock()
+		return ApprovalDecision{}, errors.Errorf("approval for tool call %s is already pending", req.ToolCallID)
+	}
+	q.pending[req.ToolCallID] = p
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		delete(q.pending, req.ToolCallID)
+		q.mu.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ApprovalDecision{}, ctx.Err()
+	case decision := <-p.decision:
+		return decision, nil
+	}
+}
+
+// Decide answers the pending request of a tool call
+func (q *ApprovalQueue) Decide(toolCallID string, decision ApprovalDecision) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.pending[toolCallID]
+	if !ok {
+		return errors.Wrapf(ErrNoPendingApproval, "tool call %s", toolCallID)
+	}
+	delete(q.pending, toolCallID)
+	p.decision <- decision
+	return nil
+}
+
+// Pending returns the requests waiting for a decision, sorted by tool call ID
+func (q *ApprovalQueue) Pending() []ApprovalRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ret := make([]ApprovalRequest, 0, len(q.pending))
+	for _, p := range q.pending {
+		ret = append(ret, p.request)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ToolCallID < ret[j].ToolCallID })
+	return ret
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApprovalQueueToolCallIDs(t *testing.T) {
+	queue := NewApprovalQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := queue.RequestApproval(ctx, ApprovalRequest{ToolName: "write"}); err == nil {
+		t.Error("RequestApproval() without tool call ID succeeded")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := queue.RequestApproval(ctx, ApprovalRequest{ToolCallID: "1", ToolName: "write"})
+		done <- err
+	}()
+	for len(queue.Pending()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// A second request with the same ID would replace the first one, which could
+	// then never be decided
+	if _, err := queue.RequestApproval(ctx, ApprovalRequest{ToolCallID: "1", ToolName: "rm"}); err == nil {
+		t.Error("RequestApproval() with a pending tool call ID succeeded")
+	}
+	if pending := queue.Pending(); len(pending) != 1 || pending[0].ToolName != "write" {
+		t.Errorf("pending requests = %+v, want the first request", pending)
+	}
+	if err := queue.Decide("1", ApprovalDecision{Approved: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("RequestApproval() = %v, want the decision", err)
+	}
+}
+
+// readQuestion reads the output of a TerminalApprover up to the next question
+func readQuestion(t *testing.T, out *bufio.Reader) string {
+	t.Helper()
+	var b strings.Builder
+	for !strings.HasSuffix(b.String(), "[y/N] ") {
+		c, err := out.ReadByte()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func TestTerminalApproverCancelledRequest(t *testing.T) {
+	in, answers := io.Pipe()
+	questions, out := io.Pipe()
+	defer answers.Close()
+	a := NewTerminalApprover(in, out)
+	output := bufio.NewReader(questions)
+
+	type result struct {
+		decision ApprovalDecision
+		err      error
+	}
+	request := func(ctx context.Context, toolName string) chan result {
+		done := make(chan result, 1)
+		go func() {
+			decision, err := a.RequestApproval(ctx, ApprovalRequest{ToolCallID: toolName, ToolName: toolName})
+			done <- result{decision, err}
+		}()
+		return done
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := request(ctx, "rm")
+	readQuestion(t, output)
+	cancel()
+	if r := <-done; !errors.Is(r.err, context.Canceled) {
+		t.Fatalf("cancelled RequestApproval() = %+v, want the cancellation", r)
+	}
+	// The answer to the cancelled question arrives late
+	if _, err := io.WriteString(answers, "y\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	done = request(context.Background(), "write")
+	if question := readQuestion(t, output); !strings.Contains(question, "Tool write") {
+		t.Errorf("question = %q, want the write call", question)
+	}
+	// The late answer is discarded and the question asked again
+	if question := readQuestion(t, output); question != "Approve? [y/N] " {
+		t.Errorf("question = %q, want it asked again", question)
+	}
+	if _, err := io.WriteString(answers, "n\n"); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-done; r.err != nil || r.decision.Approved {
+		t.Errorf("RequestApproval() = %+v, want the call rejected", r)
+	}
+}
+
+func TestTerminalApproverAnswersAhead(t *testing.T) {
+	a := NewTerminalApprover(strings.NewReader("y\nno\n"), io.Discard)
+	expected := []ApprovalDecision{
+		{Approved: true},
+		{Approved: false, Reason: "rejected by user"},
+		{Approved: false, Reason: "no answer on the terminal"},
+		{Approved: false, Reason: "no answer on the terminal"},
+	}
+	for i, e := range expected {
+		decision, err := a.RequestApproval(context.Background(), ApprovalRequest{ToolCallID: "1", ToolName: "write"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision != e {
+			t.Errorf("decision %d = %+v, want %+v", i, decision, e)
+		}
+	}
+}
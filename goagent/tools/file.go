@@ -0,0 +1,302 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// baseDirectoryFromConfig reads the "base-directory" key from a tool config,
+// defaulting to the current working directory.
+func baseDirectoryFromConfig(config map[string]interface{}) (string, error) {
+	baseDir := "."
+	if v, ok := config["base-directory"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("base-directory must be a string, got %T", v)
+		}
+		baseDir = s
+	}
+	return filepath.Abs(baseDir)
+}
+
+// resolvePath resolves path relative to baseDir and makes sure it does not escape it.
+func resolvePath(baseDir, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	full := filepath.Join(baseDir, filepath.Clean("/"+path))
+	if full != baseDir && !strings.HasPrefix(full, baseDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside of %s", path, baseDir)
+	}
+	return full, nil
+}
+
+// ReadFileTool reads files below a base directory
+type ReadFileTool struct {
+	baseDir string
+}
+
+var _ Tool = &ReadFileTool{}
+
+// NewReadFileTool creates a new ReadFileTool. Supported config keys: base-directory.
+func NewReadFileTool(config map[string]interface{}) (*ReadFileTool, error) {
+	baseDir, err := baseDirectoryFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ReadFileTool{baseDir: baseDir}, nil
+}
+
+// Name returns the name of the tool
+func (t *ReadFileTool) Name() string {
+	return "read_file"
+}
+
+// Description returns the description of the tool
+func (t *ReadFileTool) Description() string {
+	return "Read the content of a file"
+}
+
+// Execute executes the tool with the given input
+func (t *ReadFileTool) Execute(ctx context.Context, input string) (string, error) {
+	args := struct {
+		Path string `json:"path"`
+	}{}
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return "", fmt.Errorf("invalid input: %w", err)
+	}
+
+	path, err := resolvePath(t.baseDir, args.Path)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Parameters returns the parameters schema of the tool
+func (t *ReadFileTool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	om := orderedmap.New[string, types.ParameterSchema]()
+	om.Set("path", types.ParameterSchema{
+		Type:        "string",
+		Description: "The path of the file to read",
+		Required:    true,
+	})
+	return om
+}
+
+// WriteFileTool writes files below a base directory
+type WriteFileTool struct {
+	baseDir string
+}
+
+var _ Tool = &WriteFileTool{}
+
+// NewWriteFileTool creates a new WriteFileTool. Supported config keys: base-directory.
+func NewWriteFileTool(config map[string]interface{}) (*WriteFileTool, error) {
+	baseDir, err := baseDirectoryFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &WriteFileTool{baseDir: baseDir}, nil
+}
+
+// Name returns the name of the tool
+func (t *WriteFileTool) Name() string {
+	return "write_file"
+}
+
+// Description returns the description of the tool
+func (t *WriteFileTool) Description() string {
+	return "Write content to a file, creating parent directories as needed"
+}
+
+// Execute executes the tool with the given input
+func (t *WriteFileTool) Execute(ctx context.Context, input string) (string, error) {
+	args := struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}{}
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return "", fmt.Errorf("invalid input: %w", err)
+	}
+
+	path, err := resolvePath(t.baseDir, args.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(args.Content), 0644); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Wrote %d bytes to %s", len(args.Content), args.Path), nil
+}
+
+// Parameters returns the parameters schema of the tool
+func (t *WriteFileTool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	om := orderedmap.New[string, types.ParameterSchema]()
+	om.Set("path", types.ParameterSchema{
+		Type:        "string",
+		Description: "The path of the file to write",
+		Required:    true,
+	})
+	om.Set("content", types.ParameterSchema{
+		Type:        "string",
+		Description: "The content to write",
+		Required:    true,
+	})
+	return om
+}
+
+// DefaultMaxSearchResults is the default number of paths returned by SearchFilesTool
+const DefaultMaxSearchResults = 100
+
+// maxSearchedFileSize is the size above which files are not searched for content
+const maxSearchedFileSize = 1 << 20
+
+// SearchFilesTool finds files below a base directory by name and content
+type SearchFilesTool struct {
+	baseDir    string
+	maxResults int
+}
+
+var _ Tool = &SearchFilesTool{}
+
+// NewSearchFilesTool creates a new SearchFilesTool. Supported config keys:
+// base-directory, max-results.
+func NewSearchFilesTool(config map[string]interface{}) (*SearchFilesTool, error) {
+	baseDir, err := baseDirectoryFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	t := &SearchFilesTool{baseDir: baseDir, maxResults: DefaultMaxSearchResults}
+	if v, ok := config["max-results"]; ok {
+		maxResults, ok := v.(int)
+		if !ok || maxResults < 1 {
+			return nil, fmt.Errorf("max-results must be a positive integer, got %v", v)
+		}
+		t.maxResults = maxResults
+	}
+	return t, nil
+}
+
+// Name returns the name of the tool
+func (t *SearchFilesTool) Name() string {
+	return "search_files"
+}
+
+// Description returns the description of the tool
+func (t *SearchFilesTool) Description() string {
+	return "Find files whose name matches a glob pattern and, optionally, whose content contains a text. Returns their paths, one per line"
+}
+
+// Execute executes the tool with the given input
+func (t *SearchFilesTool) Execute(ctx context.Context, input string) (string, error) {
+	args := struct {
+		Pattern   string `json:"pattern"`
+		Contains  string `json:"contains"`
+		Directory string `json:"directory"`
+	}{}
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return "", fmt.Errorf("invalid input: %w", err)
+	}
+	if args.Pattern == "" {
+		args.Pattern = "*"
+	}
+	if _, err := filepath.Match(args.Pattern, ""); err != nil {
+		return "", fmt.Errorf("invalid pattern %s: %w", args.Pattern, err)
+	}
+
+	root := t.baseDir
+	if args.Directory != "" {
+		var err error
+		if root, err = resolvePath(t.baseDir, args.Directory); err != nil {
+			return "", err
+		}
+	}
+
+	var matches []string
+	truncated := false
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() {
+			// Skip hidden directories such as .git
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ok, _ := filepath.Match(args.Pattern, d.Name()); !ok {
+			return nil
+		}
+		if args.Contains != "" {
+			info, err := d.Info()
+			if err != nil || info.Size() > maxSearchedFileSize {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil || !strings.Contains(string(content), args.Contains) {
+				return nil
+			}
+		}
+		if len(matches) == t.maxResults {
+			truncated = true
+			return filepath.SkipAll
+		}
+		rel, err := filepath.Rel(t.baseDir, path)
+		if err != nil {
+			return err
+		}
+		matches = append(matches, rel)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(matches) == 0 {
+		return "No files found", nil
+	}
+	ret := strings.Join(matches, "\n")
+	if truncated {
+		ret += fmt.Sprintf("\n(only the first %d files are listed)", t.maxResults)
+	}
+	return ret, nil
+}
+
+// Parameters returns the parameters schema of the tool
+func (t *SearchFilesTool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	om := orderedmap.New[string, types.ParameterSchema]()
+	om.Set("pattern", types.ParameterSchema{
+		Type:        "string",
+		Description: "Glob pattern matched against file names, such as *.go (default: all files)",
+	})
+	om.Set("contains", types.ParameterSchema{
+		Type:        "string",
+		Description: "Only list the files containing this text",
+	})
+	om.Set("directory", types.ParameterSchema{
+		Type:        "string",
+		Description: "Directory to search in (default: the whole base directory)",
+	})
+	return om
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ToolFactory creates a tool instance from per-command configuration.
+// The config map comes from the `tool-config` section of a command's agent options
+// and may be empty.
+type ToolFactory interface {
+	NewTool(config map[string]interface{}) (Tool, error)
+}
+
+// ToolFactoryFunc adapts a plain function to the ToolFactory interface.
+type ToolFactoryFunc func(config map[string]interface{}) (Tool, error)
+
+// NewTool calls f(config)
+func (f ToolFactoryFunc) NewTool(config map[string]interface{}) (Tool, error) {
+	return f(config)
+}
+
+// ToolRegistry manages the registration and retrieval of tool factories
+type ToolRegistry interface {
+	// Register registers a new tool factory with the given name
+	Register(name string, factory ToolFactory)
+
+	// Get retrieves a tool factory by name
+	Get(name string) (ToolFactory, bool)
+
+	// List returns a list of all registered tool names
+	List() []string
+}
+
+// DefaultToolRegistry is the default implementation of ToolRegistry
+type DefaultToolRegistry struct {
+	factories map[string]ToolFactory
+	mu        sync.RWMutex
+}
+
+// NewToolRegistry creates a new DefaultToolRegistry
+func NewToolRegistry() *DefaultToolRegistry {
+	return &DefaultToolRegistry{
+		factories: make(map[string]ToolFactory),
+	}
+}
+
+// Register registers a new tool factory with the given name
+func (r *DefaultToolRegistry) Register(name string, factory ToolFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[name] = factory
+}
+
+// Get retrieves a tool factory by name
+func (r *DefaultToolRegistry) Get(name string) (ToolFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.factories[name]
+	return factory, ok
+}
+
+// List returns a sorted list of all registered tool names
+func (r *DefaultToolRegistry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Global tool registry
+var globalToolRegistry = NewToolRegistry()
+
+// GetGlobalToolRegistry returns the global tool registry
+func GetGlobalToolRegistry() ToolRegistry {
+	return globalToolRegistry
+}
+
+// RegisterTool registers a tool factory with the global registry
+func RegisterTool(name string, factory ToolFactory) {
+	globalToolRegistry.Register(name, factory)
+}
+
+// GetToolFactory retrieves a tool factory from the global registry
+func GetToolFactory(name string) (ToolFactory, error) {
+	factory, ok := globalToolRegistry.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("tool not found: %s (available tools: %v)", name, globalToolRegistry.List())
+	}
+	return factory, nil
+}
+
+// NewToolsFromNames instantiates the named tools from the global registry.
+// configs maps a tool name to the configuration passed to its factory.
+func NewToolsFromNames(names []string, configs map[string]map[string]interface{}) ([]Tool, error) {
+	ret := make([]Tool, 0, len(names))
+	for _, name := range names {
+		factory, err := GetToolFactory(name)
+		if err != nil {
+			return nil, err
+		}
+		config := configs[name]
+		if config == nil {
+			config = map[string]interface{}{}
+		}
+		tool, err := factory.NewTool(config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create tool '%s'", name)
+		}
+		ret = append(ret, tool)
+	}
+	return ret, nil
+}
+
+// init registers the built-in tools
+func init() {
+	webSearchFactory := ToolFactoryFunc(func(config map[string]interface{}) (Tool, error) {
+		return NewWebSearchTool(), nil
+	})
+	RegisterTool("web-search", webSearchFactory)
+	RegisterTool("web_search", webSearchFactory)
+
+	RegisterTool("read_file", ToolFactoryFunc(func(config map[string]interface{}) (Tool, error) {
+		return NewReadFileTool(config)
+	}))
+	RegisterTool("write_file", ToolFactoryFunc(func(config map[string]interface{}) (Tool, error) {
+		return NewWriteFileTool(config)
+	}))
+	RegisterTool("search_files", ToolFactoryFunc(func(config map[string]interface{}) (Tool, error) {
+		return NewSearchFilesTool(config)
+	}))
+}
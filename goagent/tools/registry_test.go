@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestToolRegistry(t *testing.T) {
+	r := NewToolRegistry()
+	factory := ToolFactoryFunc(func(config map[string]interface{}) (Tool, error) {
+		return NewWebSearchTool(), nil
+	})
+	r.Register("b", factory)
+	r.Register("a", factory)
+
+	if _, ok := r.Get("a"); !ok {
+		t.Errorf("Get(a) did not find the registered factory")
+	}
+	if _, ok := r.Get("c"); ok {
+		t.Errorf("Get(c) found an unregistered factory")
+	}
+	if got, want := r.List(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
+
+func TestNewToolsFromNames(t *testing.T) {
+	dir := t.TempDir()
+	tools, err := NewToolsFromNames(
+		[]string{"web-search", "read_file"},
+		map[string]map[string]interface{}{"read_file": {"base-directory": dir}},
+	)
+	if err != nil {
+		t.Fatalf("NewToolsFromNames failed: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name() != "web_search" || tools[1].Name() != "read_file" {
+		t.Fatalf("unexpected tools %v", tools)
+	}
+	if got := tools[1].(*ReadFileTool).baseDir; got != dir {
+		t.Errorf("read_file base directory = %s, want %s", got, dir)
+	}
+
+	_, err = NewToolsFromNames([]string{"read_file", "no_such_tool"}, nil)
+	if err == nil {
+		t.Fatalf("NewToolsFromNames with an unknown tool did not fail")
+	}
+	if !strings.Contains(err.Error(), "tool not found: no_such_tool") || !strings.Contains(err.Error(), "read_file") {
+		t.Errorf("error %q does not name the unknown tool and the available tools", err)
+	}
+
+	_, err = NewToolsFromNames([]string{"read_file"}, map[string]map[string]interface{}{"read_file": {"base-directory": 1}})
+	if err == nil {
+		t.Errorf("NewToolsFromNames with an invalid config did not fail")
+	}
+}
+
+func TestSearchFilesTool(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"main.go":          "package main\nfunc main() {}\n",
+		"pkg/util.go":      "package pkg\n",
+		"pkg/util_test.go": "package pkg\nfunc TestMain() {}\n",
+		"README.md":        "func main",
+		".git/config.go":   "func main",
+	} {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tool, err := NewSearchFilesTool(map[string]interface{}{"base-directory": dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`{"pattern": "*.go"}`, "main.go\npkg/util.go\npkg/util_test.go"},
+		{`{"pattern": "*.go", "contains": "func "}`, "main.go\npkg/util_test.go"},
+		{`{"pattern": "*.go", "directory": "pkg"}`, "pkg/util.go\npkg/util_test.go"},
+		{`{"pattern": "*.py"}`, "No files found"},
+		// Paths are resolved below the base directory
+		{`{"pattern": "util.go", "directory": "../.."}`, "pkg/util.go"},
+	}
+	for _, tc := range testCases {
+		got, err := tool.Execute(context.Background(), tc.input)
+		if err != nil {
+			t.Errorf("Execute(%s) failed: %v", tc.input, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("Execute(%s) = %q, want %q", tc.input, got, tc.expected)
+		}
+	}
+}
+
+// TestExampleCommandTools checks that the tools of the example commands are
+// registered, or are other example commands, which are available as agent tools
+func TestExampleCommandTools(t *testing.T) {
+	files, err := filepath.Glob("../examples/commands/*.yaml")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no example commands found: %v", err)
+	}
+
+	type command struct {
+		Name  string   `yaml:"name"`
+		Tools []string `yaml:"tools"`
+	}
+	var commands []command
+	names := map[string]bool{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		for {
+			var c command
+			err := decoder.Decode(&c)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("invalid command YAML %s: %v", file, err)
+			}
+			commands = append(commands, c)
+			names[c.Name] = true
+		}
+	}
+
+	for _, c := range commands {
+		for _, tool := range c.Tools {
+			if _, ok := GetGlobalToolRegistry().Get(tool); !ok && !names[tool] {
+				t.Errorf("tool %s of example command %s is not registered", tool, c.Name)
+			}
+		}
+	}
+}
@@ -3,13 +3,19 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/go-go-golems/geppetto/pkg/conversation"
 	claudeapi "github.com/go-go-golems/geppetto/pkg/steps/ai/claude/api"
+	"github.com/google/uuid"
 	go_openai "github.com/sashabaranov/go-openai"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
 
 	"github.com/go-go-golems/go-go-agent/goagent/types"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
 )
 
 // Tool interface defines the functionality of a tool
@@ -27,16 +33,68 @@ type Tool interface {
 	Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema]
 }
 
+// TimeoutProvider is implemented by tools whose calls need another timeout than the
+// tool timeout of the executor, e.g. tools running a whole agent. A timeout <= 0 means
+// no timeout.
+type TimeoutProvider interface {
+	Timeout() time.Duration
+}
+
 // ToolExecutor executes tools in parallel
 type ToolExecutor struct {
 	tools map[string]Tool
+	// maxConcurrency limits the number of tools running at the same time in ExecuteParallel (0 = unlimited)
+	maxConcurrency int
+	// toolTimeout bounds the duration of a single tool execution (0 = no timeout)
+	toolTimeout time.Duration
+	// approvalPolicy decides which tools need approval before they run (nil = all auto)
+	approvalPolicy *ApprovalPolicy
+	// approver decides the calls that require approval. Without approver, these calls are denied.
+	approver Approver
+}
+
+// ToolExecutorOption is a functional option for configuring a ToolExecutor
+type ToolExecutorOption func(*ToolExecutor)
+
+// WithMaxConcurrency limits the number of tools ExecuteParallel runs at the same time.
+// A value <= 0 means unlimited.
+func WithMaxConcurrency(maxConcurrency int) ToolExecutorOption {
+	return func(e *ToolExecutor) {
+		e.maxConcurrency = maxConcurrency
+	}
+}
+
+// WithToolTimeout sets the maximum duration of a single tool execution.
+// A value <= 0 disables the timeout.
+func WithToolTimeout(timeout time.Duration) ToolExecutorOption {
+	return func(e *ToolExecutor) {
+		e.toolTimeout = timeout
+	}
+}
+
+// WithApprovalPolicy sets the approval mode of the tools
+func WithApprovalPolicy(policy *ApprovalPolicy) ToolExecutorOption {
+	return func(e *ToolExecutor) {
+		e.approvalPolicy = policy
+	}
+}
+
+// WithApprover sets the Approver deciding the calls that require approval
+func WithApprover(approver Approver) ToolExecutorOption {
+	return func(e *ToolExecutor) {
+		e.approver = approver
+	}
 }
 
 // NewToolExecutor creates a new ToolExecutor
-func NewToolExecutor() *ToolExecutor {
-	return &ToolExecutor{
+func NewToolExecutor(options ...ToolExecutorOption) *ToolExecutor {
+	e := &ToolExecutor{
 		tools: make(map[string]Tool),
 	}
+	for _, option := range options {
+		option(e)
+	}
+	return e
 }
 
 // AddTool adds a tool to the executor
@@ -49,31 +107,129 @@ func (e *ToolExecutor) GetTool(name string) Tool {
 	return e.tools[name]
 }
 
-// GetAllTools returns all tools
+// GetAllTools returns all tools, sorted by name
 func (e *ToolExecutor) GetAllTools() []Tool {
 	var tools []Tool
-	for _, tool := range e.tools {
-		tools = append(tools, tool)
+	for _, name := range e.GetToolNames() {
+		tools = append(tools, e.tools[name])
 	}
 	return tools
 }
 
-// GetToolNames returns the names of all tools
+// GetToolNames returns the sorted names of all tools
 func (e *ToolExecutor) GetToolNames() []string {
 	var names []string
 	for name := range e.tools {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
-// ExecuteTool executes a tool with the given name and input
-func (e *ToolExecutor) ExecuteTool(ctx context.Context, name, input string) (string, error) {
-	tool := e.tools[name]
-	if tool == nil {
-		return "", fmt.Errorf("tool not found: %s", name)
+// SetApprover sets the Approver deciding the calls that require approval. Commands
+// use it to attach the approver of a run to the executor created by the agent.
+func (e *ToolExecutor) SetApprover(approver Approver) {
+	e.approver = approver
+}
+
+// WrapTools replaces every tool of the executor with wrap(tool), e.g. to record or
+// replay the tool calls. The wrapped tool must keep the name of the tool.
+func (e *ToolExecutor) WrapTools(wrap func(Tool) Tool) {
+	for name, tool := range e.tools {
+		e.tools[name] = wrap(tool)
 	}
-	return tool.Execute(ctx, input)
+}
+
+// ExecuteTool executes a tool with the given name and input, with the tool name as
+// action of the execution context. The call gets a generated ID, so that approvals can
+// be requested and decided for it, and runs like a single request of ExecuteParallel,
+// calling hooks (which may be nil) around it.
+func (e *ToolExecutor) ExecuteTool(ctx context.Context, name, input string, hooks *ToolCallHooks) (string, error) {
+	responses := e.ExecuteParallel(ctx, []ToolRequest{{
+		ID:       uuid.New().String(),
+		ToolName: name,
+		Input:    input,
+	}}, hooks)
+	return responses[0].Result, responses[0].Error
+}
+
+// checkApproval applies the approval policy to a call of tool. It blocks while the
+// approver decides, and returns a *ToolDeniedError if the call may not run.
+func (e *ToolExecutor) checkApproval(ctx context.Context, tool Tool, req ToolRequest, hooks *ToolCallHooks) error {
+	switch e.approvalPolicy.Mode(tool) {
+	case ApprovalAuto:
+		return nil
+	case ApprovalDenied:
+		return &ToolDeniedError{ToolName: tool.Name(), Reason: "denied by policy"}
+	}
+
+	if e.approver == nil {
+		return &ToolDeniedError{ToolName: tool.Name(), Reason: "approval required but no approver is configured"}
+	}
+	if hooks != nil && hooks.OnApprovalRequested != nil {
+		hooks.OnApprovalRequested(ctx, req)
+	}
+	decision, err := e.approver.RequestApproval(ctx, ApprovalRequest{
+		ToolCallID: req.ID,
+		ToolName:   req.ToolName,
+		Input:      req.Input,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get approval for tool %s: %w", tool.Name(), err)
+	}
+	if !decision.Approved {
+		return &ToolDeniedError{ToolName: tool.Name(), Reason: decision.Reason}
+	}
+	return nil
+}
+
+// executeWithTimeout runs the tool, returning early with an error if the tool timeout
+// expires or ctx is cancelled, even if the tool itself ignores its context. Tools
+// implementing TimeoutProvider use their own timeout.
+func (e *ToolExecutor) executeWithTimeout(ctx context.Context, tool Tool, input string) (string, error) {
+	timeout := e.toolTimeout
+	if provider, ok := tool.(TimeoutProvider); ok {
+		timeout = provider.Timeout()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		value string
+		err   error
+	}
+	resultChan := make(chan result, 1)
+	go func() {
+		value, err := tool.Execute(ctx, input)
+		resultChan <- result{value, err}
+	}()
+
+	select {
+	case r := <-resultChan:
+		return r.value, r.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("tool %s timed out after %s", tool.Name(), timeout)
+		}
+		return "", ctx.Err()
+	}
+}
+
+type toolCallIDKey struct{}
+
+// WithToolCallID returns a copy of ctx carrying the ID of the tool call it executes
+func WithToolCallID(ctx context.Context, toolCallID string) context.Context {
+	return context.WithValue(ctx, toolCallIDKey{}, toolCallID)
+}
+
+// ToolCallIDFromContext returns the ID of the tool call executed with ctx. Tools run by
+// ExecuteParallel receive the ID of their ToolRequest.
+func ToolCallIDFromContext(ctx context.Context) (string, bool) {
+	toolCallID, ok := ctx.Value(toolCallIDKey{}).(string)
+	return toolCallID, ok
 }
 
 // ToolRequest represents a request to execute a tool
@@ -90,11 +246,28 @@ type ToolResponse struct {
 	ToolName string                 `json:"tool_name"`
 	Result   string                 `json:"result,omitempty"`
 	Error    error                  `json:"error,omitempty"`
+	Duration time.Duration          `json:"duration,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// ExecuteParallel executes multiple tools in parallel
-func (e *ToolExecutor) ExecuteParallel(ctx context.Context, requests []ToolRequest) []ToolResponse {
+// ToolCallHooks are called around each tool execution in ExecuteParallel.
+// They are called from the goroutine running the tool and must be safe for concurrent use.
+type ToolCallHooks struct {
+	// OnApprovalRequested is called when the call waits for approval, before OnInvoked
+	OnApprovalRequested func(ctx context.Context, req ToolRequest)
+	// OnInvoked is called right before the tool is executed. It is not called for calls
+	// of unknown tools and denied calls, which never run.
+	OnInvoked func(ctx context.Context, req ToolRequest)
+	// OnReturned is called after the tool returned, with the response including its
+	// duration, or with the error of a call of an unknown tool or a denied call
+	OnReturned func(ctx context.Context, req ToolRequest, response ToolResponse)
+}
+
+// ExecuteParallel executes multiple tools in parallel, honoring the executor's
+// concurrency limit and per-tool timeout. Responses are returned in request order.
+// hooks may be nil. The hooks and the tool of a request run with the tool name as
+// action of the execution context.
+func (e *ToolExecutor) ExecuteParallel(ctx context.Context, requests []ToolRequest, hooks *ToolCallHooks) []ToolResponse {
 	responses := make([]ToolResponse, len(requests))
 
 	// Create a channel for results
@@ -103,6 +276,11 @@ func (e *ToolExecutor) ExecuteParallel(ctx context.Context, requests []ToolReque
 		response ToolResponse
 	}, len(requests))
 
+	var semaphore chan struct{}
+	if e.maxConcurrency > 0 {
+		semaphore = make(chan struct{}, e.maxConcurrency)
+	}
+
 	// Execute each tool in a goroutine
 	for i, req := range requests {
 		go func(i int, req ToolRequest) {
@@ -110,20 +288,46 @@ func (e *ToolExecutor) ExecuteParallel(ctx context.Context, requests []ToolReque
 			response.ID = req.ID // Propagate ID
 			response.ToolName = req.ToolName
 			response.Metadata = req.Metadata // Propagate Metadata
+			ctx := execctx.WithAction(ctx, req.ToolName)
+
+			send := func(response ToolResponse) {
+				if hooks != nil && hooks.OnReturned != nil {
+					hooks.OnReturned(ctx, req, response)
+				}
+				resultChan <- struct {
+					index    int
+					response ToolResponse
+				}{i, response}
+			}
 
+			// Calls of unknown or denied tools never run, they only return their error.
+			// Calls waiting for approval do not hold a slot of the concurrency limit.
 			tool := e.tools[req.ToolName]
 			if tool == nil {
 				response.Error = fmt.Errorf("tool not found: %s", req.ToolName)
-			} else {
-				result, err := tool.Execute(ctx, req.Input)
-				response.Result = result
+				send(response)
+				return
+			}
+			if err := e.checkApproval(ctx, tool, req, hooks); err != nil {
 				response.Error = err
+				send(response)
+				return
+			}
+
+			if semaphore != nil {
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
 			}
 
-			resultChan <- struct {
-				index    int
-				response ToolResponse
-			}{i, response}
+			if hooks != nil && hooks.OnInvoked != nil {
+				hooks.OnInvoked(ctx, req)
+			}
+			startTime := time.Now()
+			result, err := e.executeWithTimeout(WithToolCallID(ctx, req.ID), tool, req.Input)
+			response.Result = result
+			response.Error = err
+			response.Duration = time.Since(startTime)
+			send(response)
 		}(i, req)
 	}
 
@@ -144,9 +348,10 @@ type JSONSchema struct {
 }
 
 type ParameterField struct {
-	Type        string `json:"type"`
-	Description string `json:"description,omitempty"`
-	// Add other fields as needed for more complex schemas (e.g., Enum, Items for arrays)
+	Type        string          `json:"type"`
+	Description string          `json:"description,omitempty"`
+	Enum        []string        `json:"enum,omitempty"`
+	Items       *ParameterField `json:"items,omitempty"` // Schema of the elements of array parameters
 }
 
 // toolParametersToJSONSchema converts the tool's parameters into a JSON schema object.
@@ -157,10 +362,15 @@ func toolParametersToJSONSchema(params *orderedmap.OrderedMap[string, types.Para
 	for pair := params.Oldest(); pair != nil; pair = pair.Next() {
 		name := pair.Key
 		schema := pair.Value
-		properties[name] = ParameterField{
+		field := ParameterField{
 			Type:        schema.Type,
 			Description: schema.Description,
+			Enum:        schema.Enum,
+		}
+		if schema.ItemType != "" {
+			field.Items = &ParameterField{Type: schema.ItemType}
 		}
+		properties[name] = field
 		if schema.Required {
 			required = append(required, name)
 		}
@@ -211,3 +421,25 @@ func ClaudeToolUseToToolRequest(toolUse claudeapi.ToolUseContent) ToolRequest {
 		Input:    toolUse.Input,
 	}
 }
+
+// ToolUseContentToToolRequest converts a conversation ToolUseContent block into a ToolRequest.
+func ToolUseContentToToolRequest(toolUse *conversation.ToolUseContent) ToolRequest {
+	return ToolRequest{
+		ID:       toolUse.ToolID,
+		ToolName: toolUse.Name,
+		Input:    string(toolUse.Input),
+	}
+}
+
+// ToolResponseToMessage converts a ToolResponse into a tool result message carrying
+// the ID of the tool call it answers.
+func ToolResponseToMessage(response ToolResponse) *conversation.Message {
+	result := response.Result
+	if response.Error != nil {
+		result = fmt.Sprintf("Error: %v", response.Error)
+	}
+	return conversation.NewMessage(&conversation.ToolResultContent{
+		ToolID: response.ID,
+		Result: result,
+	})
+}
@@ -0,0 +1,284 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-go-agent/goagent/types"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// testTool runs fn as its Execute method
+type testTool struct {
+	name string
+	fn   func(ctx context.Context, input string) (string, error)
+}
+
+func (t *testTool) Name() string        { return t.name }
+func (t *testTool) Description() string { return "test tool" }
+func (t *testTool) Parameters() *orderedmap.OrderedMap[string, types.ParameterSchema] {
+	return orderedmap.New[string, types.ParameterSchema]()
+}
+func (t *testTool) Execute(ctx context.Context, input string) (string, error) {
+	return t.fn(ctx, input)
+}
+
+// concurrencyTool records the highest number of its calls running at the same time
+type concurrencyTool struct {
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (c *concurrencyTool) execute(ctx context.Context, input string) (string, error) {
+	c.mu.Lock()
+	c.running++
+	c.max = max(c.max, c.running)
+	c.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+	return input, nil
+}
+
+func TestExecuteParallelConcurrencyLimit(t *testing.T) {
+	testCases := []struct {
+		maxConcurrency int
+		expectedMax    int
+	}{
+		{1, 1},
+		{2, 2},
+		{0, 6},
+	}
+	for _, tc := range testCases {
+		c := &concurrencyTool{}
+		e := NewToolExecutor(WithMaxConcurrency(tc.maxConcurrency))
+		e.AddTool(&testTool{name: "slow", fn: c.execute})
+
+		var requests []ToolRequest
+		for _, input := range []string{"a", "b", "c", "d", "e", "f"} {
+			requests = append(requests, ToolRequest{ID: input, ToolName: "slow", Input: input})
+		}
+		responses := e.ExecuteParallel(context.Background(), requests, nil)
+
+		if c.max != tc.expectedMax {
+			t.Errorf("max concurrency %d: %d tools ran at the same time, want %d", tc.maxConcurrency, c.max, tc.expectedMax)
+		}
+		// Responses are returned in request order
+		for i, response := range responses {
+			if response.Error != nil || response.ID != requests[i].ID || response.Result != requests[i].Input {
+				t.Errorf("max concurrency %d: response %d = %+v, want the result of request %s", tc.maxConcurrency, i, response, requests[i].ID)
+			}
+		}
+	}
+}
+
+func TestExecuteParallelTimeout(t *testing.T) {
+	e := NewToolExecutor(WithToolTimeout(20 * time.Millisecond))
+	// The blocking tool ignores its context, the executor still returns on timeout
+	release := make(chan struct{})
+	defer close(release)
+	e.AddTool(&testTool{name: "blocking", fn: func(ctx context.Context, input string) (string, error) {
+		<-release
+		return "too late", nil
+	}})
+	e.AddTool(&testTool{name: "fast", fn: func(ctx context.Context, input string) (string, error) {
+		return "done", nil
+	}})
+
+	start := time.Now()
+	responses := e.ExecuteParallel(context.Background(), []ToolRequest{
+		{ID: "1", ToolName: "blocking"},
+		{ID: "2", ToolName: "fast"},
+		{ID: "3", ToolName: "missing"},
+	}, nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ExecuteParallel took %s, the timeout did not apply", elapsed)
+	}
+
+	if err := responses[0].Error; err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("blocking tool error = %v, want a timeout", err)
+	}
+	if responses[1].Error != nil || responses[1].Result != "done" {
+		t.Errorf("fast tool response = %+v, want done", responses[1])
+	}
+	if err := responses[2].Error; err == nil || !strings.Contains(err.Error(), "tool not found") {
+		t.Errorf("missing tool error = %v, want tool not found", err)
+	}
+}
+
+// timeoutTool is a testTool with its own timeout
+type timeoutTool struct {
+	testTool
+	timeout time.Duration
+}
+
+func (t *timeoutTool) Timeout() time.Duration { return t.timeout }
+
+func TestExecuteParallelToolTimeout(t *testing.T) {
+	e := NewToolExecutor(WithToolTimeout(20 * time.Millisecond))
+	slow := func(ctx context.Context, input string) (string, error) {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return "done", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	// The tool timeout of the executor does not apply to tools with their own timeout
+	e.AddTool(&timeoutTool{testTool: testTool{name: "no-timeout", fn: slow}})
+	e.AddTool(&timeoutTool{testTool: testTool{name: "long-timeout", fn: slow}, timeout: time.Second})
+	e.AddTool(&timeoutTool{testTool: testTool{name: "short-timeout", fn: slow}, timeout: 10 * time.Millisecond})
+
+	responses := e.ExecuteParallel(context.Background(), []ToolRequest{
+		{ID: "1", ToolName: "no-timeout"},
+		{ID: "2", ToolName: "long-timeout"},
+		{ID: "3", ToolName: "short-timeout"},
+	}, nil)
+	for _, response := range responses[:2] {
+		if response.Error != nil || response.Result != "done" {
+			t.Errorf("response %s = %+v, want done", response.ID, response)
+		}
+	}
+	if err := responses[2].Error; err == nil || err.Error() != "tool short-timeout timed out after 10ms" {
+		t.Errorf("short-timeout tool error = %v, want a timeout after 10ms", err)
+	}
+}
+
+func TestExecuteToolApproval(t *testing.T) {
+	queue := NewApprovalQueue()
+	e := NewToolExecutor(
+		WithApprovalPolicy(&ApprovalPolicy{Default: ApprovalRequired}),
+		WithApprover(queue),
+	)
+	e.AddTool(&testTool{name: "write", fn: func(ctx context.Context, input string) (string, error) {
+		return "wrote " + input, nil
+	}})
+
+	requested := make(chan ToolRequest, 1)
+	hooks := &ToolCallHooks{
+		OnApprovalRequested: func(ctx context.Context, req ToolRequest) {
+			requested <- req
+		},
+	}
+	type result struct {
+		value string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := e.ExecuteTool(context.Background(), "write", "a.txt", hooks)
+		done <- result{value, err}
+	}()
+
+	req := <-requested
+	if req.ID == "" || req.ToolName != "write" || req.Input != "a.txt" {
+		t.Fatalf("approval requested for %+v, want a write call with an ID", req)
+	}
+	// The approval hook runs before the request is queued
+	for len(queue.Pending()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := queue.Decide(req.ID, ApprovalDecision{Approved: true}); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-done; r.err != nil || r.value != "wrote a.txt" {
+		t.Errorf("ExecuteTool() = %q, %v, want the tool result", r.value, r.err)
+	}
+}
+
+func TestExecuteParallelApprovalOutsideConcurrencyLimit(t *testing.T) {
+	queue := NewApprovalQueue()
+	e := NewToolExecutor(
+		WithMaxConcurrency(1),
+		WithApprovalPolicy(&ApprovalPolicy{Tools: map[string]ApprovalMode{"write": ApprovalRequired}}),
+		WithApprover(queue),
+	)
+	e.AddTool(&testTool{name: "write", fn: func(ctx context.Context, input string) (string, error) {
+		return "wrote", nil
+	}})
+	// read only returns once write is approved, so the test deadlocks if write holds
+	// the only slot while it waits for approval
+	readDone := make(chan struct{})
+	e.AddTool(&testTool{name: "read", fn: func(ctx context.Context, input string) (string, error) {
+		close(readDone)
+		return "read", nil
+	}})
+
+	go func() {
+		<-readDone
+		for len(queue.Pending()) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		_ = queue.Decide("1", ApprovalDecision{Approved: true})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	responses := e.ExecuteParallel(ctx, []ToolRequest{
+		{ID: "1", ToolName: "write"},
+		{ID: "2", ToolName: "read"},
+	}, nil)
+	if responses[0].Error != nil || responses[0].Result != "wrote" {
+		t.Errorf("write response = %+v, want an approved call", responses[0])
+	}
+	if responses[1].Error != nil || responses[1].Result != "read" {
+		t.Errorf("read response = %+v, want read", responses[1])
+	}
+}
+
+func TestExecuteParallelHooks(t *testing.T) {
+	e := NewToolExecutor(WithApprovalPolicy(&ApprovalPolicy{Tools: map[string]ApprovalMode{"rm": ApprovalDenied}}))
+	for _, name := range []string{"read", "rm"} {
+		e.AddTool(&testTool{name: name, fn: func(ctx context.Context, input string) (string, error) {
+			return "done", nil
+		}})
+	}
+
+	var mu sync.Mutex
+	invoked := map[string]bool{}
+	returned := map[string]error{}
+	hooks := &ToolCallHooks{
+		OnInvoked: func(ctx context.Context, req ToolRequest) {
+			mu.Lock()
+			defer mu.Unlock()
+			invoked[req.ID] = true
+		},
+		OnReturned: func(ctx context.Context, req ToolRequest, response ToolResponse) {
+			mu.Lock()
+			defer mu.Unlock()
+			returned[req.ID] = response.Error
+		},
+	}
+	e.ExecuteParallel(context.Background(), []ToolRequest{
+		{ID: "1", ToolName: "read"},
+		{ID: "2", ToolName: "rm"},
+		{ID: "3", ToolName: "missing"},
+	}, hooks)
+
+	// Calls that never ran are not invoked, but return their error
+	testCases := []struct {
+		id      string
+		invoked bool
+		err     string
+	}{
+		{"1", true, ""},
+		{"2", false, "call to tool rm was not approved: denied by policy"},
+		{"3", false, "tool not found: missing"},
+	}
+	for _, tc := range testCases {
+		err, ok := returned[tc.id]
+		if invoked[tc.id] != tc.invoked || !ok {
+			t.Errorf("call %s: invoked = %v, returned = %v, want invoked %v and returned", tc.id, invoked[tc.id], ok, tc.invoked)
+		}
+		if (err == nil && tc.err != "") || (err != nil && err.Error() != tc.err) {
+			t.Errorf("call %s returned %v, want %q", tc.id, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Exporters of the spans of agent runs
+const (
+	// ExporterNone disables tracing
+	ExporterNone = "none"
+	// ExporterOTLP sends the spans to an OTLP/HTTP endpoint, such as a local
+	// OpenTelemetry collector, Jaeger or Tempo
+	ExporterOTLP = "otlp"
+	// ExporterFile appends the spans to a file as OTLP/JSON lines, the format read by
+	// the otlpjsonfile receiver of the collector
+	ExporterFile = "file"
+)
+
+// Exporters lists the exporters accepted by NewTracerProvider
+var Exporters = []string{ExporterNone, ExporterOTLP, ExporterFile}
+
+// DefaultServiceName is the service name of the spans of agent runs
+const DefaultServiceName = "goagent"
+
+// DefaultOTLPEndpoint is the OTLP/HTTP endpoint of a local collector
+const DefaultOTLPEndpoint = "http://localhost:4318"
+
+type providerConfig struct {
+	exporter    string
+	endpoint    string
+	file        string
+	serviceName string
+}
+
+// ProviderOption configures NewTracerProvider
+type ProviderOption func(*providerConfig)
+
+// WithOTLPEndpoint exports the spans to the OTLP/HTTP endpoint url, such as
+// http://localhost:4318
+func WithOTLPEndpoint(url string) ProviderOption {
+	return func(c *providerConfig) {
+		c.exporter = ExporterOTLP
+		c.endpoint = url
+	}
+}
+
+// WithFile appends the spans to the file at path as OTLP/JSON lines
+func WithFile(path string) ProviderOption {
+	return func(c *providerConfig) {
+		c.exporter = ExporterFile
+		c.file = path
+	}
+}
+
+// WithServiceName sets the service.name resource attribute of the spans
+func WithServiceName(name string) ProviderOption {
+	return func(c *providerConfig) {
+		c.serviceName = name
+	}
+}
+
+// NewTracerProvider creates a TracerProvider batching the spans to the exporter
+// selected by options. It returns nil without an exporter. The provider must be shut
+// down to flush the last spans.
+func NewTracerProvider(ctx context.Context, options ...ProviderOption) (*sdktrace.TracerProvider, error) {
+	c := &providerConfig{
+		exporter:    ExporterNone,
+		serviceName: DefaultServiceName,
+	}
+	for _, o := range options {
+		o(c)
+	}
+
+	var client otlptrace.Client
+	switch c.exporter {
+	case ExporterNone:
+		return nil, nil
+	case ExporterOTLP:
+		client = otlptracehttp.NewClient(otlptracehttp.WithEndpointURL(c.endpoint))
+	case ExporterFile:
+		client = &fileClient{path: c.file}
+	default:
+		return nil, errors.Errorf("unknown trace exporter %s", c.exporter)
+	}
+	exporter, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s trace exporter", c.exporter)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the service name
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(c.serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create trace resource")
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+// fileClient is an otlptrace.Client writing each upload to a file as a line of
+// OTLP/JSON
+type fileClient struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+	w  *bufio.Writer
+}
+
+func (c *fileClient) Start(ctx context.Context) error {
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open trace file %s", c.path)
+	}
+	c.f = f
+	c.w = bufio.NewWriter(f)
+	return nil
+}
+
+func (c *fileClient) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.w.Flush()
+	if closeErr := c.f.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return errors.Wrapf(err, "failed to close trace file %s", c.path)
+}
+
+func (c *fileClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	line, err := marshalOTLPJSON(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.w.Write(append(line, '\n')); err != nil {
+		return errors.Wrapf(err, "failed to write trace file %s", c.path)
+	}
+	return errors.Wrapf(c.w.Flush(), "failed to write trace file %s", c.path)
+}
+
+// otlpIDFields are the fields holding trace and span IDs, which OTLP/JSON encodes as
+// hex strings instead of the base64 of the protobuf JSON mapping
+var otlpIDFields = map[string]bool{"traceId": true, "spanId": true, "parentSpanId": true}
+
+// marshalOTLPJSON encodes request as OTLP/JSON: the protobuf JSON mapping with enums
+// as numbers and IDs as hex strings
+func marshalOTLPJSON(request *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	b, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal spans")
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal spans")
+	}
+	if err := hexIDs(v); err != nil {
+		return nil, err
+	}
+	b, err = json.Marshal(v)
+	return b, errors.Wrap(err, "failed to marshal spans")
+}
+
+func hexIDs(v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if s, ok := field.(string); ok && otlpIDFields[k] {
+				id, err := base64.StdEncoding.DecodeString(s)
+				if err != nil {
+					return errors.Wrapf(err, "invalid %s %s", k, s)
+				}
+				v[k] = hex.EncodeToString(id)
+				continue
+			}
+			if err := hexIDs(field); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := hexIDs(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
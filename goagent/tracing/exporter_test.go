@@ -0,0 +1,166 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	events "github.com/go-go-golems/go-go-agent/proto"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestMarshalOTLPJSON(t *testing.T) {
+	traceID := []byte{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c}
+	request := &coltracepb.ExportTraceServiceRequest{ResourceSpans: []*tracepb.ResourceSpans{{
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Spans: []*tracepb.Span{{
+				TraceId:           traceID,
+				SpanId:            []byte{0xee, 0xe1, 0x9b, 0x7e, 0xc3, 0xc1, 0xb1, 0x74},
+				ParentSpanId:      []byte{0xee, 0xe1, 0x9b, 0x7e, 0xc3, 0xc1, 0xb1, 0x73},
+				Name:              "step 1",
+				Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+				StartTimeUnixNano: 1544712660000000000,
+				Links:             []*tracepb.Span_Link{{TraceId: traceID, SpanId: []byte{1, 2, 3, 4, 5, 6, 7, 8}}},
+			}},
+		}},
+	}}}
+
+	b, err := marshalOTLPJSON(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// IDs are hex strings, also in nested messages, enums are numbers and 64-bit
+	// integers are strings
+	expected := map[string]interface{}{"resourceSpans": []interface{}{map[string]interface{}{
+		"scopeSpans": []interface{}{map[string]interface{}{
+			"spans": []interface{}{map[string]interface{}{
+				"traceId":           "5b8efff798038103d269b633813fc60c",
+				"spanId":            "eee19b7ec3c1b174",
+				"parentSpanId":      "eee19b7ec3c1b173",
+				"name":              "step 1",
+				"kind":              float64(1),
+				"startTimeUnixNano": "1544712660000000000",
+				"links": []interface{}{map[string]interface{}{
+					"traceId": "5b8efff798038103d269b633813fc60c",
+					"spanId":  "0102030405060708",
+				}},
+			}},
+		}},
+	}}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("marshalOTLPJSON() = %s", b)
+	}
+}
+
+func TestHexIDs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+		err      bool
+	}{
+		{
+			name:     "ids",
+			value:    map[string]interface{}{"traceId": "AQI=", "spanId": "/w==", "name": "AQI="},
+			expected: map[string]interface{}{"traceId": "0102", "spanId": "ff", "name": "AQI="},
+		},
+		{
+			name:     "nested",
+			value:    []interface{}{map[string]interface{}{"links": []interface{}{map[string]interface{}{"parentSpanId": "AQI="}}}},
+			expected: []interface{}{map[string]interface{}{"links": []interface{}{map[string]interface{}{"parentSpanId": "0102"}}}},
+		},
+		{
+			name:  "invalid id",
+			value: map[string]interface{}{"spanId": "not base64!"},
+			err:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := hexIDs(tc.value)
+			if (err != nil) != tc.err {
+				t.Fatalf("hexIDs() = %v, want error: %v", err, tc.err)
+			}
+			if err == nil && !reflect.DeepEqual(tc.value, tc.expected) {
+				t.Errorf("hexIDs() = %v, want %v", tc.value, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	// The environment overrides the service name
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "")
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	provider, err := NewTracerProvider(context.Background(), WithFile(path), WithServiceName("weather"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewSpanRecorder(provider)
+	r.Record(newTestEvent(t, "run-1", 0, events.EventType_EVENT_TYPE_RUN_STARTED, runStarted(t, map[string]interface{}{"command": "weather"})))
+	r.Record(newTestEvent(t, "run-1", 1, events.EventType_EVENT_TYPE_RUN_FINISHED, &events.RunFinishedPayload{}))
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 1 {
+		t.Fatalf("trace file has %d lines, want 1", len(lines))
+	}
+
+	var request struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value struct{ StringValue string }
+				}
+			}
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID string `json:"traceId"`
+					Name    string
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &request); err != nil {
+		t.Fatal(err)
+	}
+	if len(request.ResourceSpans) != 1 || len(request.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("trace file line = %s, want the spans of one resource and scope", lines[0])
+	}
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 || spans[0].Name != "invoke_agent weather" || len(spans[0].TraceID) != 32 {
+		t.Errorf("spans = %+v, want the span of the run with a hex trace ID", spans)
+	}
+	serviceName := ""
+	for _, a := range request.ResourceSpans[0].Resource.Attributes {
+		if a.Key == "service.name" {
+			serviceName = a.Value.StringValue
+		}
+	}
+	if serviceName != "weather" {
+		t.Errorf("service name = %q, want weather", serviceName)
+	}
+}
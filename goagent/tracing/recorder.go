@@ -0,0 +1,393 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the OpenTelemetry tracers of goagent
+const InstrumentationName = "github.com/go-go-golems/go-go-agent/goagent"
+
+// GenAI semantic-convention attributes of agents and tools, which are not part of
+// the semconv package yet
+const (
+	genAIAgentNameKey  = attribute.Key("gen_ai.agent.name")
+	genAIToolNameKey   = attribute.Key("gen_ai.tool.name")
+	genAIToolCallIDKey = attribute.Key("gen_ai.tool.call.id")
+)
+
+var (
+	genAIOperationInvokeAgent = semconv.GenAIOperationNameKey.String("invoke_agent")
+	genAIOperationExecuteTool = semconv.GenAIOperationNameKey.String("execute_tool")
+)
+
+// Attributes of the spans of agent runs
+const (
+	runIDKey          = attribute.Key("goagent.run.id")
+	runModeKey        = attribute.Key("goagent.run.mode")
+	stepKey           = attribute.Key("goagent.step")
+	nodeIDKey         = attribute.Key("goagent.node.id")
+	nodeGoalKey       = attribute.Key("goagent.node.goal")
+	actionNameKey     = attribute.Key("goagent.action.name")
+	statusKey         = attribute.Key("goagent.status")
+	agentClassKey     = attribute.Key("goagent.agent.class")
+	llmCallIDKey      = attribute.Key("goagent.llm.call_id")
+	toolAPIKey        = attribute.Key("goagent.tool.api")
+	toolArgsKey       = attribute.Key("goagent.tool.args")
+	totalStepsKey     = attribute.Key("goagent.run.total_steps")
+	totalLlmCallsKey  = attribute.Key("goagent.run.total_llm_calls")
+	totalToolCallsKey = attribute.Key("goagent.run.total_tool_calls")
+)
+
+// SpanRecorder builds the spans of agent runs from their events. Each run is a span,
+// parent of the spans of its steps, which are the parents of the LLM calls and tool
+// calls of the step. Runs delegated by a tool call are children of the span of the
+// tool call. Spans start and end at the timestamps of the events, and the LLM call
+// spans carry the GenAI semantic-convention attributes, model and token usage.
+//
+// SpanRecorder is a watermill Publisher, to be added as a sink of the EventBus of
+// the runs.
+type SpanRecorder struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+
+	mu   sync.Mutex
+	runs map[string]*runSpans
+}
+
+var _ message.Publisher = (*SpanRecorder)(nil)
+
+// runSpans holds the open spans of a run
+type runSpans struct {
+	span  openSpan
+	steps map[int32]openSpan
+	llms  map[string]openSpan
+	tools map[string]openSpan
+}
+
+type openSpan struct {
+	ctx  context.Context
+	span trace.Span
+}
+
+// NewSpanRecorder creates a SpanRecorder exporting its spans with provider. Closing
+// the recorder shuts provider down.
+func NewSpanRecorder(provider *sdktrace.TracerProvider) *SpanRecorder {
+	return &SpanRecorder{
+		provider: provider,
+		tracer:   provider.Tracer(InstrumentationName),
+		runs:     map[string]*runSpans{},
+	}
+}
+
+// Publish records the events of messages. The topic is ignored.
+func (r *SpanRecorder) Publish(topic string, messages ...*message.Message) error {
+	var err error
+	for _, msg := range messages {
+		event, decodeErr := codec.DecodeMessage(msg)
+		if decodeErr != nil {
+			if err == nil {
+				err = errors.Wrapf(decodeErr, "failed to decode event %s", msg.UUID)
+			}
+			continue
+		}
+		r.Record(event)
+	}
+	return err
+}
+
+// Close ends the spans that are still open and shuts down the provider, flushing the
+// spans to its exporter
+func (r *SpanRecorder) Close() error {
+	r.mu.Lock()
+	now := time.Now()
+	for runID, run := range r.runs {
+		run.span.span.SetStatus(codes.Error, "run did not finish")
+		r.endRun(runID, run, now)
+	}
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return errors.Wrap(r.provider.Shutdown(ctx), "failed to shut down trace provider")
+}
+
+// Record updates the spans of the run of event
+func (r *SpanRecorder) Record(event *events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ts := time.Now()
+	if event.Timestamp != nil {
+		ts = event.Timestamp.AsTime()
+	}
+	runID := event.GetRunId()
+
+	switch p := event.Payload.(type) {
+	case *events.Event_RunStarted:
+		r.startRun(runID, p.RunStarted, ts)
+
+	case *events.Event_RunFinished:
+		run := r.run(runID, ts)
+		payload := p.RunFinished
+		run.span.span.SetAttributes(
+			totalStepsKey.Int(int(payload.TotalSteps)),
+			totalLlmCallsKey.Int(int(payload.TotalLlmCalls)),
+			totalToolCallsKey.Int(int(payload.TotalToolCalls)),
+		)
+		if usage := payload.TokenUsageSummary; usage != nil {
+			run.span.span.SetAttributes(
+				semconv.GenAIUsageInputTokens(int(usage.TotalPromptTokens)),
+				semconv.GenAIUsageOutputTokens(int(usage.TotalCompletionTokens)),
+			)
+		}
+		r.endRun(runID, run, ts)
+
+	case *events.Event_RunError:
+		run := r.run(runID, ts)
+		payload := p.RunError
+		run.span.span.SetAttributes(semconv.ErrorTypeKey.String(payload.ErrorType))
+		run.span.span.SetStatus(codes.Error, payload.ErrorMessage)
+		r.endRun(runID, run, ts)
+
+	case *events.Event_StepStarted:
+		run := r.run(runID, ts)
+		payload := p.StepStarted
+		if step, ok := run.steps[payload.Step]; ok {
+			step.span.End(trace.WithTimestamp(ts))
+		}
+		run.steps[payload.Step] = r.start(run.span.ctx, fmt.Sprintf("step %d", payload.Step), ts,
+			stepKey.Int(int(payload.Step)),
+			nodeIDKey.String(payload.NodeId),
+			nodeGoalKey.String(payload.NodeGoal),
+		)
+
+	case *events.Event_StepFinished:
+		run := r.run(runID, ts)
+		payload := p.StepFinished
+		if step, ok := run.steps[payload.Step]; ok {
+			step.span.SetAttributes(
+				actionNameKey.String(payload.ActionName),
+				statusKey.String(payload.StatusAfter),
+			)
+			step.span.End(trace.WithTimestamp(ts))
+			delete(run.steps, payload.Step)
+		}
+
+	case *events.Event_LlmCallStarted:
+		run := r.run(runID, ts)
+		payload := p.LlmCallStarted
+		attributes := []attribute.KeyValue{
+			semconv.GenAIOperationNameChat,
+			semconv.GenAIRequestModel(payload.Model),
+			agentClassKey.String(payload.AgentClass),
+			llmCallIDKey.String(payload.CallId),
+		}
+		attributes = append(attributes, stepAttributes(payload.Step, payload.NodeId, payload.ActionName)...)
+		run.llms[payload.CallId] = r.start(run.parent(payload.Step), "chat "+payload.Model, ts, attributes...)
+
+	case *events.Event_LlmCallCompleted:
+		run := r.run(runID, ts)
+		payload := p.LlmCallCompleted
+		call, ok := run.llms[payload.CallId]
+		if !ok {
+			// The call started before the recorder, its duration is known
+			call = r.start(run.parent(payload.Step), "chat "+payload.Model,
+				ts.Add(-time.Duration(payload.DurationSeconds*float64(time.Second))),
+				semconv.GenAIOperationNameChat,
+				semconv.GenAIRequestModel(payload.Model),
+				agentClassKey.String(payload.AgentClass),
+				llmCallIDKey.String(payload.CallId),
+			)
+		}
+		call.span.SetAttributes(semconv.GenAIResponseModel(payload.Model))
+		if usage := payload.TokenUsage; usage != nil {
+			call.span.SetAttributes(
+				semconv.GenAIUsageInputTokens(int(usage.PromptTokens)),
+				semconv.GenAIUsageOutputTokens(int(usage.CompletionTokens)),
+			)
+		}
+		if payload.Error != nil {
+			call.span.SetAttributes(semconv.ErrorTypeOther)
+			call.span.SetStatus(codes.Error, payload.GetError())
+		}
+		call.span.End(trace.WithTimestamp(ts))
+		delete(run.llms, payload.CallId)
+
+	case *events.Event_ToolInvoked:
+		run := r.run(runID, ts)
+		payload := p.ToolInvoked
+		attributes := []attribute.KeyValue{
+			genAIOperationExecuteTool,
+			genAIToolNameKey.String(payload.ToolName),
+			genAIToolCallIDKey.String(payload.ToolCallId),
+			toolAPIKey.String(payload.ApiName),
+			toolArgsKey.String(payload.ArgsSummary),
+		}
+		attributes = append(attributes, stepAttributes(payload.Step, payload.NodeId, nil)...)
+		run.tools[payload.ToolCallId] = r.start(run.parent(payload.Step), "execute_tool "+payload.ToolName, ts, attributes...)
+
+	case *events.Event_ToolReturned:
+		run := r.run(runID, ts)
+		payload := p.ToolReturned
+		call, ok := run.tools[payload.ToolCallId]
+		if !ok {
+			call = r.start(run.parent(payload.Step), "execute_tool "+payload.ToolName,
+				ts.Add(-time.Duration(payload.DurationSeconds*float64(time.Second))),
+				genAIOperationExecuteTool,
+				genAIToolNameKey.String(payload.ToolName),
+				genAIToolCallIDKey.String(payload.ToolCallId),
+			)
+		}
+		call.span.SetAttributes(statusKey.String(payload.State))
+		if payload.Error != nil {
+			call.span.SetAttributes(semconv.ErrorTypeOther)
+			call.span.SetStatus(codes.Error, payload.GetError())
+		}
+		call.span.End(trace.WithTimestamp(ts))
+		delete(run.tools, payload.ToolCallId)
+
+	case *events.Event_LlmCallDelta:
+		// Streamed output is part of the LLM call span
+
+	default:
+		// Other events are events of the span of their step
+		run := r.run(runID, ts)
+		step, _ := eventStep(event)
+		trace.SpanFromContext(run.parent(step)).AddEvent(codec.EventTypeName(event.EventType), trace.WithTimestamp(ts))
+	}
+}
+
+// startRun starts the span of a run. A run delegated by a tool call of another run is
+// a child of the span of the tool call.
+func (r *SpanRecorder) startRun(runID string, payload *events.RunStartedPayload, ts time.Time) {
+	if run, ok := r.runs[runID]; ok {
+		r.endRun(runID, run, ts)
+	}
+
+	ctx := context.Background()
+	input := payload.GetInputData().GetFields()
+	if parent, ok := r.runs[input["parent_run_id"].GetStringValue()]; ok {
+		if call, ok := parent.tools[input["parent_tool_call_id"].GetStringValue()]; ok {
+			ctx = call.ctx
+		} else {
+			ctx = parent.span.ctx
+		}
+	}
+
+	name := "invoke_agent"
+	attributes := []attribute.KeyValue{
+		genAIOperationInvokeAgent,
+		runIDKey.String(runID),
+		runModeKey.String(payload.RunMode),
+	}
+	if command := input["command"].GetStringValue(); command != "" {
+		name += " " + command
+		attributes = append(attributes, genAIAgentNameKey.String(command))
+	}
+	r.runs[runID] = newRunSpans(r.start(ctx, name, ts, attributes...))
+}
+
+// run returns the spans of the run runID, starting its span if its RunStarted event
+// was not recorded
+func (r *SpanRecorder) run(runID string, ts time.Time) *runSpans {
+	run, ok := r.runs[runID]
+	if !ok {
+		run = newRunSpans(r.start(context.Background(), "invoke_agent", ts,
+			genAIOperationInvokeAgent,
+			runIDKey.String(runID),
+		))
+		r.runs[runID] = run
+	}
+	return run
+}
+
+// endRun ends the spans of a run still open and the span of the run at ts
+func (r *SpanRecorder) endRun(runID string, run *runSpans, ts time.Time) {
+	for _, spans := range []map[string]openSpan{run.tools, run.llms} {
+		for _, s := range spans {
+			s.span.End(trace.WithTimestamp(ts))
+		}
+	}
+	for _, s := range run.steps {
+		s.span.End(trace.WithTimestamp(ts))
+	}
+	run.span.span.End(trace.WithTimestamp(ts))
+	delete(r.runs, runID)
+}
+
+// start starts a span at ts. Empty string attributes are left out.
+func (r *SpanRecorder) start(ctx context.Context, name string, ts time.Time, attributes ...attribute.KeyValue) openSpan {
+	set := attributes[:0]
+	for _, a := range attributes {
+		if a.Value.Type() != attribute.STRING || a.Value.AsString() != "" {
+			set = append(set, a)
+		}
+	}
+	ctx, span := r.tracer.Start(ctx, name,
+		trace.WithTimestamp(ts),
+		trace.WithAttributes(set...),
+	)
+	return openSpan{ctx: ctx, span: span}
+}
+
+func newRunSpans(span openSpan) *runSpans {
+	return &runSpans{
+		span:  span,
+		steps: map[int32]openSpan{},
+		llms:  map[string]openSpan{},
+		tools: map[string]openSpan{},
+	}
+}
+
+// parent returns the context of the span of step, or of the run if the step is unknown
+func (run *runSpans) parent(step *int32) context.Context {
+	if step != nil {
+		if s, ok := run.steps[*step]; ok {
+			return s.ctx
+		}
+	}
+	return run.span.ctx
+}
+
+func stepAttributes(step *int32, nodeID *string, actionName *string) []attribute.KeyValue {
+	var ret []attribute.KeyValue
+	if step != nil {
+		ret = append(ret, stepKey.Int(int(*step)))
+	}
+	if nodeID != nil {
+		ret = append(ret, nodeIDKey.String(*nodeID))
+	}
+	if actionName != nil {
+		ret = append(ret, actionNameKey.String(*actionName))
+	}
+	return ret
+}
+
+// eventStep returns the step field of the payload of event, if it has one
+func eventStep(event *events.Event) (*int32, bool) {
+	m := event.ProtoReflect()
+	oneof := m.WhichOneof(m.Descriptor().Oneofs().ByName("payload"))
+	if oneof == nil {
+		return nil, false
+	}
+	payload := m.Get(oneof).Message()
+	field := payload.Descriptor().Fields().ByName("step")
+	if field == nil || !payload.Has(field) {
+		return nil, false
+	}
+	step := int32(payload.Get(field).Int())
+	return &step, true
+}
@@ -0,0 +1,198 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var testStart = time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestEvent returns an event of run runID emitted seconds after testStart
+func newTestEvent(t *testing.T, runID string, seconds int, eventType events.EventType, payload interface{}) *events.Event {
+	t.Helper()
+	event, err := events.NewEvent(eventType, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.RunId = proto.String(runID)
+	event.Timestamp = timestamppb.New(testStart.Add(time.Duration(seconds) * time.Second))
+	return event
+}
+
+func runStarted(t *testing.T, input map[string]interface{}) *events.RunStartedPayload {
+	t.Helper()
+	inputData, err := structpb.NewStruct(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &events.RunStartedPayload{InputData: inputData, RunMode: "cli"}
+}
+
+func TestSpanRecorder(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	r := NewSpanRecorder(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+
+	// A supervisor run whose first step calls the LLM, then delegates to the research agent
+	for _, event := range []*events.Event{
+		newTestEvent(t, "run-1", 0, events.EventType_EVENT_TYPE_RUN_STARTED, runStarted(t, map[string]interface{}{"command": "supervisor"})),
+		newTestEvent(t, "run-1", 1, events.EventType_EVENT_TYPE_STEP_STARTED, &events.StepStartedPayload{Step: 1, NodeId: "node-1", NodeGoal: "research go"}),
+		newTestEvent(t, "run-1", 2, events.EventType_EVENT_TYPE_LLM_CALL_STARTED, &events.LlmCallStartedPayload{
+			AgentClass: "ReActAgent", Model: "gpt-4o", CallId: "call-1", Step: proto.Int32(1), NodeId: proto.String("node-1"),
+		}),
+		newTestEvent(t, "run-1", 3, events.EventType_EVENT_TYPE_LLM_CALL_COMPLETED, &events.LlmCallCompletedPayload{
+			AgentClass: "ReActAgent", Model: "gpt-4o", CallId: "call-1", Step: proto.Int32(1),
+			TokenUsage: &events.TokenUsage{PromptTokens: 10, CompletionTokens: 5},
+		}),
+		newTestEvent(t, "run-1", 4, events.EventType_EVENT_TYPE_TOOL_INVOKED, &events.ToolInvokedPayload{
+			ToolName: "research", ToolCallId: "tool-call-1", ArgsSummary: "go", Step: proto.Int32(1),
+		}),
+		newTestEvent(t, "run-1.1", 5, events.EventType_EVENT_TYPE_RUN_STARTED, runStarted(t, map[string]interface{}{
+			"command": "research", "parent_run_id": "run-1", "parent_tool_call_id": "tool-call-1",
+		})),
+		newTestEvent(t, "run-1.1", 6, events.EventType_EVENT_TYPE_RUN_ERROR, &events.RunErrorPayload{ErrorType: "llm_error", ErrorMessage: "rate limited"}),
+		newTestEvent(t, "run-1", 7, events.EventType_EVENT_TYPE_TOOL_RETURNED, &events.ToolReturnedPayload{
+			ToolName: "research", ToolCallId: "tool-call-1", State: "error", Error: proto.String("agent research failed"), Step: proto.Int32(1),
+		}),
+		newTestEvent(t, "run-1", 8, events.EventType_EVENT_TYPE_STEP_FINISHED, &events.StepFinishedPayload{Step: 1, ActionName: "research", StatusAfter: "TOOL_ERROR"}),
+		newTestEvent(t, "run-1", 9, events.EventType_EVENT_TYPE_RUN_FINISHED, &events.RunFinishedPayload{
+			TotalSteps: 1, TotalLlmCalls: 1, TotalToolCalls: 1,
+			TokenUsageSummary: &events.RunFinishedPayload_TokenUsageSummary{TotalPromptTokens: 10, TotalCompletionTokens: 5},
+		}),
+	} {
+		r.Record(event)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spans.Ended() {
+		byName[span.Name()] = span
+	}
+	testCases := []struct {
+		name       string
+		parent     string
+		start, end int
+		status     codes.Code
+		attributes []attribute.KeyValue
+	}{
+		{
+			name: "invoke_agent supervisor", start: 0, end: 9,
+			attributes: []attribute.KeyValue{
+				attribute.String("gen_ai.operation.name", "invoke_agent"),
+				attribute.String("gen_ai.agent.name", "supervisor"),
+				attribute.String("goagent.run.id", "run-1"),
+				attribute.Int("goagent.run.total_llm_calls", 1),
+				attribute.Int("gen_ai.usage.input_tokens", 10),
+			},
+		},
+		{
+			name: "step 1", parent: "invoke_agent supervisor", start: 1, end: 8,
+			attributes: []attribute.KeyValue{
+				attribute.Int("goagent.step", 1),
+				attribute.String("goagent.node.id", "node-1"),
+				attribute.String("goagent.status", "TOOL_ERROR"),
+			},
+		},
+		{
+			name: "chat gpt-4o", parent: "step 1", start: 2, end: 3,
+			attributes: []attribute.KeyValue{
+				attribute.String("gen_ai.operation.name", "chat"),
+				attribute.String("gen_ai.request.model", "gpt-4o"),
+				attribute.String("gen_ai.response.model", "gpt-4o"),
+				attribute.Int("gen_ai.usage.input_tokens", 10),
+				attribute.Int("gen_ai.usage.output_tokens", 5),
+				attribute.String("goagent.agent.class", "ReActAgent"),
+			},
+		},
+		{
+			name: "execute_tool research", parent: "step 1", start: 4, end: 7, status: codes.Error,
+			attributes: []attribute.KeyValue{
+				attribute.String("gen_ai.operation.name", "execute_tool"),
+				attribute.String("gen_ai.tool.name", "research"),
+				attribute.String("gen_ai.tool.call.id", "tool-call-1"),
+				attribute.String("goagent.tool.args", "go"),
+			},
+		},
+		{
+			// The delegated run is a child of the tool call that started it
+			name: "invoke_agent research", parent: "execute_tool research", start: 5, end: 6, status: codes.Error,
+			attributes: []attribute.KeyValue{
+				attribute.String("gen_ai.agent.name", "research"),
+				attribute.String("goagent.run.id", "run-1.1"),
+				attribute.String("error.type", "llm_error"),
+			},
+		},
+	}
+	if len(byName) != len(testCases) {
+		t.Errorf("recorded %d spans, want %d", len(byName), len(testCases))
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			span, ok := byName[tc.name]
+			if !ok {
+				t.Fatalf("span %s was not recorded", tc.name)
+			}
+			if tc.parent == "" {
+				if span.Parent().IsValid() {
+					t.Errorf("span has parent %s, want a root span", span.Parent().SpanID())
+				}
+			} else if parent := byName[tc.parent]; parent == nil || span.Parent().SpanID() != parent.SpanContext().SpanID() ||
+				span.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+				t.Errorf("span is not a child of %s", tc.parent)
+			}
+			if !span.StartTime().Equal(testStart.Add(time.Duration(tc.start)*time.Second)) || !span.EndTime().Equal(testStart.Add(time.Duration(tc.end)*time.Second)) {
+				t.Errorf("span runs from %s to %s, want %ds to %ds after %s", span.StartTime(), span.EndTime(), tc.start, tc.end, testStart)
+			}
+			if span.Status().Code != tc.status {
+				t.Errorf("status = %v, want %v", span.Status().Code, tc.status)
+			}
+			attributes := map[attribute.Key]attribute.Value{}
+			for _, a := range span.Attributes() {
+				attributes[a.Key] = a.Value
+			}
+			for _, a := range tc.attributes {
+				if value, ok := attributes[a.Key]; !ok || value != a.Value {
+					t.Errorf("attribute %s = %v, want %v", a.Key, value.Emit(), a.Value.Emit())
+				}
+			}
+		})
+	}
+}
+
+func TestSpanRecorderClose(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	r := NewSpanRecorder(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+
+	// Events of a run whose RunStarted event was not recorded, which never finishes
+	r.Record(newTestEvent(t, "run-1", 1, events.EventType_EVENT_TYPE_STEP_STARTED, &events.StepStartedPayload{Step: 1}))
+	r.Record(newTestEvent(t, "run-1", 2, events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED, &events.NodeStatusChangePayload{NodeId: "node-1", Step: proto.Int32(1)}))
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spans.Ended() {
+		byName[span.Name()] = span
+	}
+	run, step := byName["invoke_agent"], byName["step 1"]
+	if run == nil || step == nil {
+		t.Fatalf("recorded spans %v, want the run and its step", byName)
+	}
+	if run.Status().Code != codes.Error || run.Status().Description != "run did not finish" {
+		t.Errorf("run status = %+v, want an unfinished run error", run.Status())
+	}
+	// Events other than the run, step, LLM and tool events are events of their step span
+	if len(step.Events()) != 1 || !step.Events()[0].Time.Equal(testStart.Add(2*time.Second)) {
+		t.Errorf("step span events = %+v, want the node status change", step.Events())
+	}
+}
@@ -85,6 +85,9 @@ type ParameterSchema struct {
 
 	// Enum is a list of allowed values for the parameter
 	Enum []string `json:"enum,omitempty"`
+
+	// ItemType is the data type of the elements of array parameters
+	ItemType string `json:"item_type,omitempty"`
 }
 
 // Event represents a traceable event in the agent's execution
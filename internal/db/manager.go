@@ -8,6 +8,8 @@ import (
 	"fmt"
 
 	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	"github.com/go-go-golems/go-go-agent/pkg/pricing"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -24,34 +26,37 @@ var Schema embed.FS
 type DatabaseManager struct {
 	db     *sql.DB
 	logger zerolog.Logger
+	prices *pricing.PriceTable
+}
+
+// DatabaseManagerOption configures a DatabaseManager
+type DatabaseManagerOption func(*DatabaseManager)
+
+// WithPriceTable sets the prices used to compute the cost of LLM calls.
+// Defaults to the built-in price table.
+func WithPriceTable(prices *pricing.PriceTable) DatabaseManagerOption {
+	return func(m *DatabaseManager) {
+		m.prices = prices
+	}
 }
 
 // NewDatabaseManager creates a new DatabaseManager with the given database path
-func NewDatabaseManager(dbPath string) (*DatabaseManager, error) {
+func NewDatabaseManager(dbPath string, options ...DatabaseManagerOption) (*DatabaseManager, error) {
 	logger := log.With().Str("component", "database_manager").Logger()
 	logger.Info().Str("db_path", dbPath).Msg("Initializing database manager")
 
-	// Connect to the database
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_foreign_keys=ON")
+	db, err := OpenSQLite(dbPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to open SQLite database")
-	}
-
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		if closeErr := db.Close(); closeErr != nil {
-			logger.Error().Err(closeErr).Msg("Error closing database connection after ping failure")
-		}
-		return nil, errors.Wrap(err, "failed to ping SQLite database")
+		return nil, err
 	}
 
-	// Configure database
-	db.SetMaxOpenConns(1) // SQLite supports only one writer at a time
-	db.SetMaxIdleConns(1)
-
 	manager := &DatabaseManager{
 		db:     db,
 		logger: logger,
+		prices: pricing.DefaultPriceTable(),
+	}
+	for _, option := range options {
+		option(manager)
 	}
 
 	// Ensure schema exists
@@ -65,6 +70,30 @@ func NewDatabaseManager(dbPath string) (*DatabaseManager, error) {
 	return manager, nil
 }
 
+// OpenSQLite opens the SQLite database at dbPath in WAL mode with foreign keys enabled,
+// and limits the pool to a single connection.
+func OpenSQLite(dbPath string) (*sql.DB, error) {
+	// Connect to the database
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_foreign_keys=ON")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open SQLite database")
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			log.Error().Err(closeErr).Msg("Error closing database connection after ping failure")
+		}
+		return nil, errors.Wrap(err, "failed to ping SQLite database")
+	}
+
+	// Configure database
+	db.SetMaxOpenConns(1) // SQLite supports only one writer at a time
+	db.SetMaxIdleConns(1)
+
+	return db, nil
+}
+
 // ensureSchema creates all necessary tables, indexes and views if they don't exist
 func (m *DatabaseManager) ensureSchema() error {
 	m.logger.Info().Msg("Ensuring database schema exists")
@@ -79,10 +108,56 @@ func (m *DatabaseManager) ensureSchema() error {
 		return errors.Wrap(err, "failed to execute schema SQL")
 	}
 
+	// Columns added after a database may have been created with an older schema
+	if err := m.ensureColumn("runs", "cost_usd", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := m.ensureColumn("runs", "command", "TEXT"); err != nil {
+		return err
+	}
+	if err := m.ensureColumn("runs", "result", "TEXT"); err != nil {
+		return err
+	}
+
 	m.logger.Info().Msg("Database schema initialized successfully")
 	return nil
 }
 
+// ensureColumn adds a column to an existing table if it is missing
+func (m *DatabaseManager) ensureColumn(table, column, definition string) error {
+	rows, err := m.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read columns of table %s", table)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return errors.Wrapf(err, "failed to scan columns of table %s", table)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrapf(err, "failed to read columns of table %s", table)
+	}
+
+	m.logger.Info().Str("table", table).Str("column", column).Msg("Adding missing column")
+	_, err = m.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return errors.Wrapf(err, "failed to add column %s to table %s", column, table)
+}
+
 // Close closes the database connection
 func (m *DatabaseManager) Close() error {
 	return m.db.Close()
@@ -99,11 +174,12 @@ type Event struct {
 
 // HandleMessage processes a Watermill message containing an event
 func (m *DatabaseManager) HandleMessage(msg *message.Message) error {
-	// Parse the event from the message payload
-	var event Event
-	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+	// Parse the event from the message payload, in any of the codec formats
+	modelEvent, err := codec.DecodeModelMessage(msg)
+	if err != nil {
 		return errors.Wrap(err, "failed to unmarshal event")
 	}
+	event := Event(*modelEvent)
 
 	// Store the event in the database
 	if err := m.StoreEvent(&event); err != nil {
@@ -181,6 +257,8 @@ func (m *DatabaseManager) processEventByType(tx *sql.Tx, event *Event) error {
 		return m.handleEdgeAdded(tx, event)
 	case "plan_received":
 		return m.handlePlanReceived(tx, event)
+	case "llm_call_completed":
+		return m.handleLlmCallCompleted(tx, event)
 	default:
 		// No specific handling needed for other event types
 		return nil
@@ -196,9 +274,15 @@ func (m *DatabaseManager) handleRunStarted(tx *sql.Tx, event *Event) error {
 		return errors.Wrap(err, "failed to unmarshal run_started payload")
 	}
 
+	// Runs started through the API already have a row. Their status only moves
+	// forward from starting, a run paused or cancelled in the meantime keeps its status.
 	_, err := tx.Exec(
 		`INSERT INTO runs (run_id, start_time, status, created_at, updated_at)
-        VALUES (?, ?, 'running', datetime('now'), datetime('now'))`,
+        VALUES (?, ?, 'running', datetime('now'), datetime('now'))
+        ON CONFLICT(run_id) DO UPDATE SET
+            start_time = excluded.start_time,
+            status = CASE WHEN status = 'starting' THEN 'running' ELSE status END,
+            updated_at = datetime('now')`,
 		event.RunID, payload.TimestampUTC,
 	)
 	return err
@@ -358,6 +442,47 @@ func (m *DatabaseManager) handlePlanReceived(tx *sql.Tx, event *Event) error {
 	return err
 }
 
+// handleLlmCallCompleted adds the cost of an llm_call_completed event to its run
+func (m *DatabaseManager) handleLlmCallCompleted(tx *sql.Tx, event *Event) error {
+	var payload struct {
+		Model      string `json:"model"`
+		TokenUsage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"token_usage"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return errors.Wrap(err, "failed to unmarshal llm_call_completed payload")
+	}
+	if payload.TokenUsage == nil || m.prices == nil {
+		return nil
+	}
+
+	cost, ok := m.prices.Cost(payload.Model, payload.TokenUsage.PromptTokens, payload.TokenUsage.CompletionTokens)
+	if !ok {
+		m.logger.Debug().Str("model", payload.Model).Msg("No price configured for model, not adding cost")
+		return nil
+	}
+
+	_, err := tx.Exec(
+		`UPDATE runs 
+        SET cost_usd = cost_usd + ?, updated_at = datetime('now')
+        WHERE run_id = ?`,
+		cost, event.RunID,
+	)
+	return err
+}
+
+// GetRunCost returns the total cost in USD of the LLM calls of a run
+func (m *DatabaseManager) GetRunCost(ctx context.Context, runID string) (float64, error) {
+	var cost float64
+	err := m.db.QueryRowContext(ctx, `SELECT cost_usd FROM runs WHERE run_id = ?`, runID).Scan(&cost)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get cost of run %s", runID)
+	}
+	return cost, nil
+}
+
 // GraphData holds the complete set of nodes and edges for a run
 type GraphData struct {
 	Nodes map[string]json.RawMessage `json:"nodes"`
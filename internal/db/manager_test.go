@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-go-golems/go-go-agent/pkg/pricing"
+)
+
+func newTestDatabaseManager(t *testing.T, options ...DatabaseManagerOption) *DatabaseManager {
+	t.Helper()
+	m, err := NewDatabaseManager(filepath.Join(t.TempDir(), "events.db"), options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = m.Close()
+	})
+	return m
+}
+
+// storeTestEvent stores an event of run runID with the given type and payload
+func storeTestEvent(t *testing.T, m *DatabaseManager, runID, eventType string, payload interface{}) {
+	t.Helper()
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := &Event{
+		EventID:   eventType + "-" + runID,
+		Timestamp: "2025-05-01T12:00:00Z",
+		EventType: eventType,
+		Payload:   b,
+		RunID:     runID,
+	}
+	if err := m.StoreEvent(event); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnsureSchemaAddsColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	// A database created with the runs table of the first schema, with a finished run
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE runs (
+        run_id TEXT PRIMARY KEY,
+        start_time TEXT NOT NULL,
+        end_time TEXT,
+        status TEXT NOT NULL,
+        total_steps INTEGER,
+        total_nodes INTEGER,
+        error_message TEXT,
+        root_node_id TEXT,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+    INSERT INTO runs (run_id, start_time, status) VALUES ('run-1', '2025-05-01T12:00:00Z', 'completed');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening the database twice adds the columns once
+	for i := 0; i < 2; i++ {
+		m, err := NewDatabaseManager(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := m.db.Query(`SELECT name FROM pragma_table_info('runs')`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var columns []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			columns = append(columns, name)
+		}
+		_ = rows.Close()
+		expected := []string{
+			"run_id", "start_time", "end_time", "status", "total_steps", "total_nodes", "error_message",
+			"root_node_id", "created_at", "updated_at", "cost_usd", "command", "result",
+		}
+		if !reflect.DeepEqual(columns, expected) {
+			t.Errorf("columns of runs = %v, want %v", columns, expected)
+		}
+
+		// Existing runs get the default values of the new columns
+		run, err := m.GetRun(context.Background(), "run-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run == nil || run.Status != RunStatusCompleted || run.CostUSD != 0 || run.Command != "" || run.Result != "" {
+			t.Errorf("GetRun() = %+v, want the completed run without cost, command and result", run)
+		}
+		if err := m.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunCost(t *testing.T) {
+	prices, err := pricing.ParsePriceTable([]byte(`
+models:
+  openai/gpt-4o:
+    prompt: 2.50
+    completion: 10.00
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTestDatabaseManager(t, WithPriceTable(prices))
+	ctx := context.Background()
+
+	for _, runID := range []string{"run-1", "run-2"} {
+		storeTestEvent(t, m, runID, "run_started", map[string]interface{}{"timestamp_utc": "2025-05-01T12:00:00Z"})
+	}
+	usage := func(prompt, completion int) map[string]interface{} {
+		return map[string]interface{}{"prompt_tokens": prompt, "completion_tokens": completion}
+	}
+	for _, call := range []struct {
+		runID   string
+		payload map[string]interface{}
+	}{
+		{"run-1", map[string]interface{}{"model": "openai/gpt-4o", "token_usage": usage(1000, 100)}},
+		{"run-1", map[string]interface{}{"model": "openai/gpt-4o", "token_usage": usage(2000, 200)}},
+		// Calls of models without a price and calls without token usage cost nothing
+		{"run-1", map[string]interface{}{"model": "ollama/llama3", "token_usage": usage(1000, 100)}},
+		{"run-1", map[string]interface{}{"model": "openai/gpt-4o"}},
+		{"run-2", map[string]interface{}{"model": "openai/gpt-4o", "token_usage": usage(4000, 0)}},
+	} {
+		storeTestEvent(t, m, call.runID, "llm_call_completed", call.payload)
+	}
+
+	for runID, expected := range map[string]float64{"run-1": 0.0105, "run-2": 0.01} {
+		cost, err := m.GetRunCost(ctx, runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(cost-expected) > 1e-9 {
+			t.Errorf("GetRunCost(%s) = %f, want %f", runID, cost, expected)
+		}
+	}
+	if _, err := m.GetRunCost(ctx, "run-3"); err == nil {
+		t.Error("GetRunCost() of an unknown run succeeded")
+	}
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Run statuses stored in the runs table
+const (
+	RunStatusStarting  = "starting"
+	RunStatusRunning   = "running"
+	RunStatusPaused    = "paused"
+	RunStatusCompleted = "completed"
+	RunStatusError     = "error"
+	RunStatusCancelled = "cancelled"
+)
+
+// RunRecord is a row of the runs table
+type RunRecord struct {
+	RunID        string  `json:"run_id"`
+	Command      string  `json:"command,omitempty"`
+	Status       string  `json:"status"`
+	StartTime    string  `json:"start_time"`
+	EndTime      string  `json:"end_time,omitempty"`
+	TotalSteps   int     `json:"total_steps"`
+	TotalNodes   int     `json:"total_nodes"`
+	ErrorMessage string  `json:"error_message,omitempty"`
+	Result       string  `json:"result,omitempty"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// CreateRun records a run started through the API, before it emits its first event
+func (m *DatabaseManager) CreateRun(ctx context.Context, runID, command string) error {
+	_, err := m.db.ExecContext(ctx,
+		`INSERT INTO runs (run_id, start_time, status, command, created_at, updated_at)
+        VALUES (?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		runID, time.Now().UTC().Format(time.RFC3339Nano), RunStatusStarting, command,
+	)
+	return errors.Wrapf(err, "failed to create run %s", runID)
+}
+
+// SetRunStatus updates the status of a run
+func (m *DatabaseManager) SetRunStatus(ctx context.Context, runID, status string) error {
+	_, err := m.db.ExecContext(ctx,
+		`UPDATE runs SET status = ?, updated_at = datetime('now') WHERE run_id = ?`,
+		status, runID,
+	)
+	return errors.Wrapf(err, "failed to set status of run %s", runID)
+}
+
+// FinishRun records the final status, output and error of a run started through the API.
+// The end time set by the run's last event is kept.
+func (m *DatabaseManager) FinishRun(ctx context.Context, runID, status, result, errorMessage string) error {
+	_, err := m.db.ExecContext(ctx,
+		`UPDATE runs
+        SET status = ?, result = ?, error_message = COALESCE(NULLIF(?, ''), error_message),
+            end_time = COALESCE(end_time, ?), updated_at = datetime('now')
+        WHERE run_id = ?`,
+		status, result, errorMessage, time.Now().UTC().Format(time.RFC3339Nano), runID,
+	)
+	return errors.Wrapf(err, "failed to finish run %s", runID)
+}
+
+const runColumns = `run_id, COALESCE(command, ''), status, start_time, COALESCE(end_time, ''),
+    COALESCE(total_steps, 0), COALESCE(total_nodes, 0), COALESCE(error_message, ''),
+    COALESCE(result, ''), cost_usd`
+
+func scanRun(row interface{ Scan(...interface{}) error }) (*RunRecord, error) {
+	r := &RunRecord{}
+	err := row.Scan(&r.RunID, &r.Command, &r.Status, &r.StartTime, &r.EndTime,
+		&r.TotalSteps, &r.TotalNodes, &r.ErrorMessage, &r.Result, &r.CostUSD)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetRun returns the run with the given ID, or nil if there is none
+func (m *DatabaseManager) GetRun(ctx context.Context, runID string) (*RunRecord, error) {
+	r, err := scanRun(m.db.QueryRowContext(ctx, `SELECT `+runColumns+` FROM runs WHERE run_id = ?`, runID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get run %s", runID)
+	}
+	return r, nil
+}
+
+// ListRuns returns all runs, most recent first
+func (m *DatabaseManager) ListRuns(ctx context.Context) ([]*RunRecord, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT `+runColumns+` FROM runs ORDER BY start_time DESC`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query runs")
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			m.logger.Error().Err(err).Msg("Error closing rows")
+		}
+	}()
+
+	runs := []*RunRecord{}
+	for rows.Next() {
+		r, err := scanRun(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan run row")
+		}
+		runs = append(runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error iterating run rows")
+	}
+	return runs, nil
+}
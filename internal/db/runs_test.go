@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRunLifecycle(t *testing.T) {
+	m := newTestDatabaseManager(t)
+	ctx := context.Background()
+	getRun := func(runID string) *RunRecord {
+		t.Helper()
+		run, err := m.GetRun(ctx, runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run == nil {
+			t.Fatalf("run %s not found", runID)
+		}
+		return run
+	}
+	runStarted := func(runID, timestamp string) {
+		t.Helper()
+		storeTestEvent(t, m, runID, "run_started", map[string]interface{}{"timestamp_utc": timestamp})
+	}
+
+	// A run started through the API is recorded before its first event
+	if err := m.CreateRun(ctx, "run-1", "research"); err != nil {
+		t.Fatal(err)
+	}
+	if run := getRun("run-1"); run.Status != RunStatusStarting || run.Command != "research" || run.StartTime == "" {
+		t.Errorf("created run = %+v, want a starting research run", run)
+	}
+	if err := m.CreateRun(ctx, "run-1", "research"); err == nil {
+		t.Error("CreateRun() of an existing run succeeded")
+	}
+
+	// Its run_started event updates the row
+	runStarted("run-1", "2025-05-01T12:00:00Z")
+	if run := getRun("run-1"); run.Status != RunStatusRunning || run.StartTime != "2025-05-01T12:00:00Z" || run.Command != "research" {
+		t.Errorf("started run = %+v, want the running research run", run)
+	}
+
+	// The event finishing the run sets the end time, the API its result
+	storeTestEvent(t, m, "run-1", "run_finished", map[string]interface{}{"total_steps": 3, "total_nodes": 2})
+	if err := m.FinishRun(ctx, "run-1", RunStatusCompleted, "sunny", ""); err != nil {
+		t.Fatal(err)
+	}
+	expected := &RunRecord{
+		RunID: "run-1", Command: "research", Status: RunStatusCompleted, StartTime: "2025-05-01T12:00:00Z",
+		EndTime: "2025-05-01T12:00:00Z", TotalSteps: 3, TotalNodes: 2, Result: "sunny",
+	}
+	if run := getRun("run-1"); !reflect.DeepEqual(run, expected) {
+		t.Errorf("finished run = %+v, want %+v", run, expected)
+	}
+
+	// A run cancelled before its run_started event stays cancelled
+	if err := m.CreateRun(ctx, "run-2", "research"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetRunStatus(ctx, "run-2", RunStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	runStarted("run-2", "2025-05-01T13:00:00Z")
+	if run := getRun("run-2"); run.Status != RunStatusCancelled {
+		t.Errorf("status of run-2 = %s, want %s", run.Status, RunStatusCancelled)
+	}
+
+	// A failed run keeps the error of its run_error event if the API has none
+	if err := m.CreateRun(ctx, "run-3", "research"); err != nil {
+		t.Fatal(err)
+	}
+	runStarted("run-3", "2025-05-01T11:00:00Z")
+	storeTestEvent(t, m, "run-3", "run_error", map[string]interface{}{"error_message": "rate limited"})
+	if err := m.FinishRun(ctx, "run-3", RunStatusError, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if run := getRun("run-3"); run.Status != RunStatusError || run.ErrorMessage != "rate limited" || run.EndTime != "2025-05-01T12:00:00Z" {
+		t.Errorf("failed run = %+v, want the error of its event", run)
+	}
+
+	// Runs started outside of the API are recorded by their run_started event
+	runStarted("run-4", "2025-05-01T10:00:00Z")
+	if run := getRun("run-4"); run.Status != RunStatusRunning || run.Command != "" {
+		t.Errorf("run started by an event = %+v, want a running run without command", run)
+	}
+
+	if run, err := m.GetRun(ctx, "run-5"); run != nil || err != nil {
+		t.Errorf("GetRun() of an unknown run = %+v, %v, want nil", run, err)
+	}
+
+	runs, err := m.ListRuns(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var runIDs []string
+	for _, run := range runs {
+		runIDs = append(runIDs, run.RunID)
+	}
+	if !reflect.DeepEqual(runIDs, []string{"run-2", "run-1", "run-3", "run-4"}) {
+		t.Errorf("ListRuns() = %v, want the runs from the most recent one", runIDs)
+	}
+}
@@ -15,11 +15,14 @@ CREATE TABLE IF NOT EXISTS runs (
     run_id TEXT PRIMARY KEY,
     start_time TEXT NOT NULL,        -- From run_started event
     end_time TEXT,                   -- From run_finished event
-    status TEXT NOT NULL,            -- 'running', 'completed', 'error'
+    status TEXT NOT NULL,            -- 'starting', 'running', 'paused', 'completed', 'error', 'cancelled'
     total_steps INTEGER,
     total_nodes INTEGER,
     error_message TEXT,              -- If status is 'error'
     root_node_id TEXT,               -- Link to root node of the run
+    cost_usd REAL NOT NULL DEFAULT 0, -- Sum of the cost of the run's LLM calls
+    command TEXT,                    -- Agent command of runs started through the API
+    result TEXT,                     -- Final output of runs started through the API
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -0,0 +1,197 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/model"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/rs/zerolog"
+)
+
+func newTestConfig(t *testing.T, transportType TransportType) (*miniredis.Miniredis, RouterConfig) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	config := DefaultRouterConfig()
+	config.RedisURL = mr.Addr()
+	config.TransportType = transportType
+	return mr, config
+}
+
+// subscribePubSub subscribes to pattern with the subscriber used by the server and
+// waits until Redis has registered the subscription
+func subscribePubSub(t *testing.T, ctx context.Context, mr *miniredis.Miniredis, config RouterConfig, pattern string) <-chan *message.Message {
+	t.Helper()
+	client, err := NewClient(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to connect to Redis: %v", err)
+	}
+	config.redisClient = client
+	subscriber, err := NewPubSubTransport().CreateSubscriber(config, watermill.NopLogger{})
+	if err != nil {
+		t.Fatalf("failed to create subscriber: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = subscriber.Close()
+		_ = client.Close()
+	})
+
+	messages, err := subscriber.Subscribe(ctx, pattern)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for mr.PubSubNumPat() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the Pub/Sub subscription")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return messages
+}
+
+func receive(t *testing.T, messages <-chan *message.Message) *message.Message {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		msg.Ack()
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+		return nil
+	}
+}
+
+func TestStreamPublisher(t *testing.T) {
+	ctx := context.Background()
+	mr, config := newTestConfig(t, TransportStream)
+
+	publisher, err := NewPublisher(ctx, config, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to create publisher: %v", err)
+	}
+	defer func() {
+		_ = publisher.Close()
+	}()
+
+	payload := `{"event_type":"run_started"}`
+	if err := publisher.Publish(config.StreamName, message.NewMessage(watermill.NewUUID(), []byte(payload))); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	entries, err := mr.Stream(config.StreamName)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 stream entry, got %d", len(entries))
+	}
+	values := map[string]interface{}{}
+	for i := 0; i+1 < len(entries[0].Values); i += 2 {
+		values[entries[0].Values[i]] = entries[0].Values[i+1]
+	}
+	msg, err := CustomEventUnmarshaller{}.Unmarshal(values)
+	if err != nil {
+		t.Fatalf("failed to unmarshal stream entry %v: %v", values, err)
+	}
+	if string(msg.Payload) != payload {
+		t.Errorf("expected payload %s, got %s", payload, msg.Payload)
+	}
+}
+
+func TestPubSubPublisher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mr, config := newTestConfig(t, TransportPubSub)
+	messages := subscribePubSub(t, ctx, mr, config, config.TopicPattern)
+
+	publisher, err := NewPublisher(ctx, config, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to create publisher: %v", err)
+	}
+	defer func() {
+		_ = publisher.Close()
+	}()
+
+	channel := PubSubChannel(config.TopicPattern, "run-1")
+	if channel != "agent_events:run-1" {
+		t.Errorf("expected channel agent_events:run-1, got %s", channel)
+	}
+	payload := `{"event_type":"run_started"}`
+	if err := publisher.Publish(channel, message.NewMessage(watermill.NewUUID(), []byte(payload))); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	msg := receive(t, messages)
+	if string(msg.Payload) != payload {
+		t.Errorf("expected payload %s, got %s", payload, msg.Payload)
+	}
+	if got := msg.Metadata.Get("redis_channel"); got != channel {
+		t.Errorf("expected message on channel %s, got %s", channel, got)
+	}
+}
+
+// TestEventBusRedisSinks publishes events of a run to a stream and a Pub/Sub channel
+// at once, and checks that both carry the model.Event JSON consumed by the server
+func TestEventBusRedisSinks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mr, streamConfig := newTestConfig(t, TransportStream)
+	pubSubConfig := streamConfig
+	pubSubConfig.TransportType = TransportPubSub
+	runID := "run-1"
+	messages := subscribePubSub(t, ctx, mr, pubSubConfig, pubSubConfig.TopicPattern)
+
+	streamPublisher, err := NewPublisher(ctx, streamConfig, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to create stream publisher: %v", err)
+	}
+	pubSubPublisher, err := NewPublisher(ctx, pubSubConfig, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to create Pub/Sub publisher: %v", err)
+	}
+	eb, err := eventbus.NewEventBus(
+		eventbus.WithPublisher(streamPublisher),
+		eventbus.WithTopic(streamConfig.StreamName),
+		eventbus.WithFormat(codec.FormatModelJSON),
+		eventbus.WithSink(pubSubPublisher, PubSubChannel(pubSubConfig.TopicPattern, runID), codec.FormatModelJSON),
+	)
+	if err != nil {
+		t.Fatalf("failed to create event bus: %v", err)
+	}
+	defer func() {
+		_ = eb.Close()
+	}()
+
+	if err := eb.EmitRunStarted(ctx, &events.RunStartedPayload{RunMode: "cli"}, &runID); err != nil {
+		t.Fatalf("failed to emit event: %v", err)
+	}
+
+	checkEvent := func(source string, payload []byte) {
+		t.Helper()
+		var event model.Event
+		if err := json.Unmarshal(payload, &event); err != nil {
+			t.Fatalf("%s: invalid event JSON %s: %v", source, payload, err)
+		}
+		if event.EventType != model.EventTypeRunStarted || event.RunID != runID {
+			t.Errorf("%s: expected run_started event of run %s, got %s event of run %s", source, runID, event.EventType, event.RunID)
+		}
+	}
+
+	entries, err := mr.Stream(streamConfig.StreamName)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+	if len(entries) != 1 || len(entries[0].Values) != 2 || entries[0].Values[0] != "json_payload" {
+		t.Fatalf("expected 1 stream entry with a json_payload, got %v", entries)
+	}
+	checkEvent("stream", []byte(entries[0].Values[1]))
+	checkEvent("pubsub", receive(t, messages).Payload)
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,8 +35,12 @@ func (t *PubSubTransport) CreateSubscriber(config RouterConfig, logger watermill
 
 // CreatePublisher creates a new Redis Pub/Sub publisher
 func (t *PubSubTransport) CreatePublisher(config RouterConfig, logger watermill.LoggerAdapter) (message.Publisher, error) {
-	// For now, we don't need a publisher
-	return nil, nil
+	return NewPubSubPublisher(
+		PubSubPublisherConfig{
+			Client: config.redisClient,
+		},
+		logger,
+	)
 }
 
 // GetTopicName returns the topic pattern for Pub/Sub
@@ -43,6 +48,80 @@ func (t *PubSubTransport) GetTopicName(config RouterConfig) string {
 	return config.TopicPattern
 }
 
+// PubSubChannel returns the channel matching topicPattern that the events of runID
+// are published to: the "*" of the pattern is replaced with the run ID, so that
+// agent_events:* gives agent_events:<runID>.
+func PubSubChannel(topicPattern string, runID string) string {
+	if !strings.Contains(topicPattern, "*") {
+		return topicPattern
+	}
+	return strings.Replace(topicPattern, "*", runID, 1)
+}
+
+// PubSubPublisher implements message.Publisher for Redis Pub/Sub. The payload of each
+// message is published as is on the channel of its topic, the format read by
+// PubSubSubscriber. Redis drops messages published while no one is subscribed.
+type PubSubPublisher struct {
+	config     PubSubPublisherConfig
+	logger     watermill.LoggerAdapter
+	closed     bool
+	closeMutex sync.Mutex
+}
+
+// PubSubPublisherConfig holds configuration for the Redis Pub/Sub publisher
+type PubSubPublisherConfig struct {
+	Client redis.UniversalClient
+}
+
+// NewPubSubPublisher creates a new PubSubPublisher. Closing the publisher closes its
+// client.
+func NewPubSubPublisher(config PubSubPublisherConfig, logger watermill.LoggerAdapter) (*PubSubPublisher, error) {
+	if config.Client == nil {
+		return nil, errors.New("redis client is required")
+	}
+	if logger == nil {
+		logger = watermill.NopLogger{}
+	}
+	return &PubSubPublisher{
+		config: config,
+		logger: logger,
+	}, nil
+}
+
+// Publish publishes the messages on the topic channel
+func (p *PubSubPublisher) Publish(topic string, msgs ...*message.Message) error {
+	p.closeMutex.Lock()
+	closed := p.closed
+	p.closeMutex.Unlock()
+	if closed {
+		return errors.New("publisher closed")
+	}
+
+	for _, msg := range msgs {
+		ctx := msg.Context()
+		if err := p.config.Client.Publish(ctx, topic, string(msg.Payload)).Err(); err != nil {
+			return errors.Wrapf(err, "failed to publish message %s to channel %s", msg.UUID, topic)
+		}
+		p.logger.Trace("Message published to Redis Pub/Sub", watermill.LogFields{
+			"message_uuid": msg.UUID,
+			"topic":        topic,
+		})
+	}
+	return nil
+}
+
+// Close closes the publisher and its client
+func (p *PubSubPublisher) Close() error {
+	p.closeMutex.Lock()
+	defer p.closeMutex.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	return p.config.Client.Close()
+}
+
 // PubSubSubscriber implements message.Subscriber for Redis Pub/Sub
 type PubSubSubscriber struct {
 	config        PubSubSubscriberConfig
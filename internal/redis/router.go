@@ -124,6 +124,56 @@ func (l *WatermillLogger) With(fields watermill.LogFields) watermill.LoggerAdapt
 	return &WatermillLogger{logger: loggerContext.Logger()}
 }
 
+// NewClient creates a Redis client with the connection options of config and checks
+// that the server is reachable
+func NewClient(ctx context.Context, config RouterConfig) (redis.UniversalClient, error) {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:        config.RedisURL,
+		Password:    config.RedisPassword,
+		DB:          config.RedisDB,
+		MaxRetries:  config.RedisMaxRetries,
+		DialTimeout: config.RedisDialTimeout,
+	})
+
+	// Test Redis connection
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		_ = redisClient.Close()
+		return nil, errors.Wrap(err, "failed to connect to Redis")
+	}
+	return redisClient, nil
+}
+
+// NewPublisher creates a publisher connected to Redis for the transport of config.
+// Events published to the stream named by the StreamName of config, or to a
+// PubSubChannel of its TopicPattern, are received by the routers subscribed with
+// the same config. Closing the publisher closes its Redis client.
+func NewPublisher(ctx context.Context, config RouterConfig, logger zerolog.Logger) (message.Publisher, error) {
+	var transport Transport
+	switch config.TransportType {
+	case TransportStream:
+		transport = NewStreamTransport()
+	case TransportPubSub:
+		transport = NewPubSubTransport()
+	default:
+		return nil, errors.Errorf("unsupported transport type: %s", config.TransportType)
+	}
+
+	redisClient, err := NewClient(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	config.redisClient = redisClient
+
+	publisher, err := transport.CreatePublisher(config, NewWatermillLogger(logger))
+	if err != nil {
+		if closeErr := redisClient.Close(); closeErr != nil {
+			logger.Error().Err(closeErr).Msg("Error closing Redis client after publisher creation failure")
+		}
+		return nil, errors.Wrapf(err, "failed to create %s publisher", config.TransportType)
+	}
+	return publisher, nil
+}
+
 // MessageHandler is a function that processes a message and returns an error if processing fails
 type MessageHandler func(msg *message.Message) error
 
@@ -137,18 +187,9 @@ func NewRouter(
 	// Watermill's router still needs its adapter
 	watermillLogger := NewWatermillLogger(logger)
 
-	// Create Redis client
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:        config.RedisURL,
-		Password:    config.RedisPassword,
-		DB:          config.RedisDB,
-		MaxRetries:  config.RedisMaxRetries,
-		DialTimeout: config.RedisDialTimeout,
-	})
-
-	// Test Redis connection
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		return nil, errors.Wrap(err, "failed to connect to Redis")
+	redisClient, err := NewClient(ctx, config)
+	if err != nil {
+		return nil, err
 	}
 
 	// Store Redis client in config for use by transport
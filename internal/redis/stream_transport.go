@@ -35,10 +35,21 @@ func (t *StreamTransport) CreateSubscriber(config RouterConfig, logger watermill
 	return subscriber, nil
 }
 
-// CreatePublisher creates a new Redis Stream publisher
+// CreatePublisher creates a new Redis Stream publisher. Messages are added to the
+// stream of their topic with their payload under "json_payload", the format read by
+// CreateSubscriber.
 func (t *StreamTransport) CreatePublisher(config RouterConfig, logger watermill.LoggerAdapter) (message.Publisher, error) {
-	// For now, we don't need a publisher
-	return nil, nil
+	publisher, err := redisstream.NewPublisher(
+		redisstream.PublisherConfig{
+			Client:     config.redisClient,
+			Marshaller: CustomEventUnmarshaller{},
+		},
+		logger,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Redis Stream publisher")
+	}
+	return publisher, nil
 }
 
 // GetTopicName returns the stream name as the topic
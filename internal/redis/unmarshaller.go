@@ -6,7 +6,6 @@ import (
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
-	"github.com/redis/go-redis/v9"
 )
 
 // CustomEventUnmarshaller handles the specific format of messages published
@@ -47,15 +46,10 @@ func (u CustomEventUnmarshaller) Unmarshal(values map[string]interface{}) (*mess
 	return msg, nil
 }
 
-// Marshal implements the RedisStreamMarshaller interface, but is not used
-// when the component is a subscriber only
-func (u CustomEventUnmarshaller) Marshal(topic string, msg *message.Message) (*redis.XAddArgs, error) {
-	// This method is not needed for a subscriber-only implementation
-	// However, we provide a basic implementation in case it's used for publishing
-	return &redis.XAddArgs{
-		Stream: topic,
-		Values: map[string]interface{}{
-			"json_payload": string(msg.Payload),
-		},
+// Marshal implements redisstream.Marshaller. Like the Python EventBus, it stores the
+// payload as a JSON string under the "json_payload" key, so that Unmarshal reads it back.
+func (u CustomEventUnmarshaller) Marshal(_ string, msg *message.Message) (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"json_payload": string(msg.Payload),
 	}, nil
 }
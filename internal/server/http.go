@@ -2,17 +2,26 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"strings"
 	"time"
 
+	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/internal/db"
 	"github.com/go-go-golems/go-go-agent/internal/state"
 )
 
@@ -60,6 +69,9 @@ type HTTPServerConfig struct {
 	ListenAddr     string
 	StaticFilesDir string
 	ReloadSession  bool
+	// APIToken, if set, must be sent as bearer token with the requests of the run
+	// control API
+	APIToken string
 }
 
 // DefaultHTTPServerConfig returns a config with sensible defaults
@@ -80,6 +92,30 @@ type HTTPServer struct {
 	config       HTTPServerConfig
 	eventManager *state.EventManager
 	graphManager *state.GraphManager
+	dbManager    *db.DatabaseManager
+	runManager   *RunManager
+	ingest       func(*message.Message) error
+}
+
+// HTTPServerOption configures optional features of the HTTPServer
+type HTTPServerOption func(*HTTPServer)
+
+// WithRunControl enables the /api/runs endpoints, which start runs with runManager and
+// read them from dbManager.
+func WithRunControl(runManager *RunManager, dbManager *db.DatabaseManager) HTTPServerOption {
+	return func(s *HTTPServer) {
+		s.runManager = runManager
+		s.dbManager = dbManager
+	}
+}
+
+// WithEventIngestion enables POST /api/events, which passes the events in the request
+// bodies to handler, the handler of the events consumed from Redis. It lets tools such
+// as the replay of event logs feed the server without Redis.
+func WithEventIngestion(handler func(*message.Message) error) HTTPServerOption {
+	return func(s *HTTPServer) {
+		s.ingest = handler
+	}
 }
 
 // NewHTTPServer creates a new HTTP server with the given config
@@ -88,6 +124,7 @@ func NewHTTPServer(
 	logger zerolog.Logger,
 	eventManager *state.EventManager,
 	graphManager *state.GraphManager,
+	options ...HTTPServerOption,
 ) *HTTPServer {
 	router := mux.NewRouter()
 
@@ -111,6 +148,9 @@ func NewHTTPServer(
 		eventManager: eventManager,
 		graphManager: graphManager,
 	}
+	for _, option := range options {
+		option(httpServer)
+	}
 
 	// Set up all routes
 	httpServer.setupRoutes()
@@ -126,6 +166,11 @@ func (s *HTTPServer) setupRoutes() {
 	// GET /api/events
 	api.HandleFunc("/events", s.handleGetEvents).Methods("GET")
 
+	if s.ingest != nil {
+		// POST /api/events, which writes to the database like the run control API
+		api.HandleFunc("/events", s.runControl(s.handleIngestEvent, true)).Methods("POST")
+	}
+
 	// GET /api/graph
 	api.HandleFunc("/graph", s.handleGetGraph).Methods("GET")
 
@@ -141,6 +186,27 @@ func (s *HTTPServer) setupRoutes() {
 	// GET /api/graph/edges/{id}
 	api.HandleFunc("/graph/edges/{id}", s.handleGetEdge).Methods("GET")
 
+	if s.runManager != nil {
+		// GET /api/commands
+		api.HandleFunc("/commands", s.runControl(s.handleGetCommands, false)).Methods("GET")
+
+		// GET /api/runs, POST /api/runs
+		api.HandleFunc("/runs", s.runControl(s.handleGetRuns, false)).Methods("GET")
+		api.HandleFunc("/runs", s.runControl(s.handleStartRun, true)).Methods("POST")
+
+		// GET /api/runs/{id}, DELETE /api/runs/{id}
+		api.HandleFunc("/runs/{id}", s.runControl(s.handleGetRun, false)).Methods("GET")
+		api.HandleFunc("/runs/{id}", s.runControl(s.handleCancelRun, false)).Methods("DELETE")
+
+		// POST /api/runs/{id}/pause, POST /api/runs/{id}/resume
+		api.HandleFunc("/runs/{id}/pause", s.runControl(s.handlePauseRun, false)).Methods("POST")
+		api.HandleFunc("/runs/{id}/resume", s.runControl(s.handleResumeRun, false)).Methods("POST")
+
+		// GET /api/runs/{id}/approvals, POST /api/runs/{id}/approvals/{tool_call_id}
+		api.HandleFunc("/runs/{id}/approvals", s.runControl(s.handleGetApprovals, false)).Methods("GET")
+		api.HandleFunc("/runs/{id}/approvals/{tool_call_id}", s.runControl(s.handleDecideApproval, true)).Methods("POST")
+	}
+
 	// WebSocket endpoint
 	s.router.HandleFunc("/ws/events", s.handleWebSocket)
 
@@ -283,6 +349,244 @@ func (s *HTTPServer) handleGetEdge(w http.ResponseWriter, r *http.Request) {
 	writeJSONResponse(w, edge)
 }
 
+// Run control handlers
+
+// runControl wraps a handler of the run control API, which starts agent runs and
+// approves their tool calls. Requests must carry the API token if one is configured.
+// Requests sent by browsers from other origins are rejected, and jsonBody requests must
+// be JSON, which web pages can only send cross-site after a CORS preflight the server
+// does not answer.
+func (s *HTTPServer) runControl(handler http.HandlerFunc, jsonBody bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.APIToken != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.APIToken)) != 1 {
+				http.Error(w, "Missing or invalid API token", http.StatusUnauthorized)
+				return
+			}
+		}
+		if !isSameOrigin(r) {
+			http.Error(w, "Cross-origin requests are not allowed", http.StatusForbidden)
+			return
+		}
+		if jsonBody {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		handler(w, r)
+	}
+}
+
+// isSameOrigin returns false for requests that browsers send on behalf of pages of
+// another origin. Requests of other clients carry no Origin header. Local origins are
+// accepted on a local server, for the UI development server proxying /api.
+func isSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return r.Header.Get("Sec-Fetch-Site") != "cross-site"
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == r.Host || (isLoopbackHost(u.Hostname()) && isLoopbackHost(requestHostname(r)))
+}
+
+// requestHostname returns the host of the request without port
+func requestHostname(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		return r.Host
+	}
+	return host
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// LocalListenAddr returns addr restricted to the loopback interface. An address
+// without host, which listens on all interfaces, listens on 127.0.0.1 instead. Other
+// non-loopback addresses are an error.
+func LocalListenAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid listen address %s", addr)
+	}
+	if host == "" {
+		return net.JoinHostPort("127.0.0.1", port), nil
+	}
+	if isLoopbackHost(host) {
+		return addr, nil
+	}
+	return "", errors.Errorf("listen address %s is not a loopback address", addr)
+}
+
+// handleGetCommands returns the agent commands that can be started
+func (s *HTTPServer) handleGetCommands(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, map[string]interface{}{
+		"commands": s.runManager.Commands(),
+	})
+}
+
+// handleGetRuns returns all runs stored in the database
+func (s *HTTPServer) handleGetRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.dbManager.ListRuns(r.Context())
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to list runs")
+		http.Error(w, "Failed to list runs", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONResponse(w, map[string]interface{}{
+		"runs": runs,
+	})
+}
+
+// handleStartRun starts a run of an agent command
+func (s *HTTPServer) handleStartRun(w http.ResponseWriter, r *http.Request) {
+	var req StartRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	runID, err := s.runManager.Start(&req)
+	if err != nil {
+		s.writeRunError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSONResponse(w, map[string]interface{}{
+		"run_id": runID,
+		"status": db.RunStatusStarting,
+	})
+}
+
+// handleGetRun returns a specific run by ID
+func (s *HTTPServer) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	runID := mux.Vars(r)["id"]
+
+	run, err := s.dbManager.GetRun(r.Context(), runID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("run_id", runID).Msg("Failed to get run")
+		http.Error(w, "Failed to get run", http.StatusInternalServerError)
+		return
+	}
+	if run == nil {
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSONResponse(w, run)
+}
+
+// handleCancelRun cancels a running or paused run
+func (s *HTTPServer) handleCancelRun(w http.ResponseWriter, r *http.Request) {
+	runID := mux.Vars(r)["id"]
+	if err := s.runManager.Cancel(runID); err != nil {
+		s.writeRunError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	writeJSONResponse(w, map[string]interface{}{
+		"run_id": runID,
+		"status": "cancelling",
+	})
+}
+
+// handlePauseRun pauses a run before its next LLM call
+func (s *HTTPServer) handlePauseRun(w http.ResponseWriter, r *http.Request) {
+	runID := mux.Vars(r)["id"]
+	if err := s.runManager.Pause(runID); err != nil {
+		s.writeRunError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, map[string]interface{}{
+		"run_id": runID,
+		"status": db.RunStatusPaused,
+	})
+}
+
+// handleResumeRun resumes a paused run
+func (s *HTTPServer) handleResumeRun(w http.ResponseWriter, r *http.Request) {
+	runID := mux.Vars(r)["id"]
+	if err := s.runManager.Resume(runID); err != nil {
+		s.writeRunError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, map[string]interface{}{
+		"run_id": runID,
+		"status": db.RunStatusRunning,
+	})
+}
+
+// handleGetApprovals returns the tool calls of a run waiting for approval
+func (s *HTTPServer) handleGetApprovals(w http.ResponseWriter, r *http.Request) {
+	runID := mux.Vars(r)["id"]
+	pending, err := s.runManager.PendingApprovals(runID)
+	if err != nil {
+		s.writeRunError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, map[string]interface{}{
+		"run_id":    runID,
+		"approvals": pending,
+	})
+}
+
+// handleDecideApproval approves or rejects a tool call waiting for approval. The body
+// is {"approved": true|false, "reason": "..."}.
+func (s *HTTPServer) handleDecideApproval(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	runID, toolCallID := vars["id"], vars["tool_call_id"]
+
+	var decision tools.ApprovalDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.runManager.DecideApproval(runID, toolCallID, decision); err != nil {
+		s.writeRunError(w, err)
+		return
+	}
+
+	writeJSONResponse(w, map[string]interface{}{
+		"run_id":       runID,
+		"tool_call_id": toolCallID,
+		"approved":     decision.Approved,
+	})
+}
+
+// writeRunError maps the errors of the RunManager to HTTP status codes
+func (s *HTTPServer) writeRunError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownCommand), errors.Is(err, ErrRunNotActive), errors.Is(err, tools.ErrNoPendingApproval):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrInvalidParameters):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrRunStateUnchanged):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		s.logger.Error().Err(err).Msg("Run control request failed")
+		http.Error(w, "Run control request failed", http.StatusInternalServerError)
+	}
+}
+
 // WebSocket configuration
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRunControl(t *testing.T) {
+	testCases := []struct {
+		name     string
+		token    string
+		jsonBody bool
+		headers  map[string]string
+		expected int
+	}{
+		{
+			name:     "json body",
+			jsonBody: true,
+			headers:  map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "missing content type",
+			jsonBody: true,
+			expected: http.StatusUnsupportedMediaType,
+		},
+		{
+			// Browsers send text/plain cross-site without a CORS preflight
+			name:     "text body",
+			jsonBody: true,
+			headers:  map[string]string{"Content-Type": "text/plain"},
+			expected: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:     "request without body",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "same origin",
+			headers:  map[string]string{"Origin": "http://localhost:9999"},
+			expected: http.StatusOK,
+		},
+		{
+			// The UI development server proxies /api with the host of the server
+			name:     "local development server",
+			headers:  map[string]string{"Origin": "http://localhost:5173"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "cross origin",
+			headers:  map[string]string{"Origin": "http://evil.example"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "cross site without origin",
+			headers:  map[string]string{"Sec-Fetch-Site": "cross-site"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "missing token",
+			token:    "secret",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "wrong token",
+			token:    "secret",
+			headers:  map[string]string{"Authorization": "Bearer other"},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "token",
+			token:    "secret",
+			headers:  map[string]string{"Authorization": "Bearer secret"},
+			expected: http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &HTTPServer{config: HTTPServerConfig{APIToken: tc.token}}
+			handler := s.runControl(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}, tc.jsonBody)
+
+			r := httptest.NewRequest("POST", "http://localhost:9999/api/runs", strings.NewReader(`{}`))
+			for key, value := range tc.headers {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tc.expected {
+				t.Errorf("status = %d, want %d", w.Code, tc.expected)
+			}
+		})
+	}
+}
+
+func TestIngestEvent(t *testing.T) {
+	event, err := events.NewEvent(events.EventType_EVENT_TYPE_RUN_STARTED, &events.RunStartedPayload{RunMode: "cli"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.RunId = proto.String("run-1")
+	payload, err := codec.Encode(event, codec.FormatModelJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		token    string
+		body     []byte
+		headers  map[string]string
+		expected int
+	}{
+		{
+			name:     "event",
+			headers:  map[string]string{"Content-Type": "application/json"},
+			expected: http.StatusAccepted,
+		},
+		{
+			name:     "event with token",
+			token:    "secret",
+			headers:  map[string]string{"Content-Type": "application/json", "Authorization": "Bearer secret"},
+			expected: http.StatusAccepted,
+		},
+		{
+			name:     "missing token",
+			token:    "secret",
+			headers:  map[string]string{"Content-Type": "application/json"},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "cross origin",
+			headers:  map[string]string{"Content-Type": "application/json", "Origin": "http://evil.example"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "text body",
+			headers:  map[string]string{"Content-Type": "text/plain"},
+			expected: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:     "invalid event",
+			body:     []byte(`{"event_id": `),
+			headers:  map[string]string{"Content-Type": "application/json"},
+			expected: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ingested []*message.Message
+			s := NewHTTPServer(HTTPServerConfig{ListenAddr: "localhost:9999", APIToken: tc.token}, zerolog.Nop(), nil, nil,
+				WithEventIngestion(func(msg *message.Message) error {
+					ingested = append(ingested, msg)
+					return nil
+				}),
+			)
+
+			body := payload
+			if tc.body != nil {
+				body = tc.body
+			}
+			r := httptest.NewRequest("POST", "http://localhost:9999/api/events", bytes.NewReader(body))
+			for key, value := range tc.headers {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, r)
+			if w.Code != tc.expected {
+				t.Errorf("status = %d, want %d: %s", w.Code, tc.expected, w.Body.String())
+			}
+			// Only accepted events reach the ingestion handler
+			expected := 0
+			if tc.expected == http.StatusAccepted {
+				expected = 1
+			}
+			if len(ingested) != expected {
+				t.Errorf("%d events ingested, want %d", len(ingested), expected)
+			}
+		})
+	}
+}
+
+func TestLocalListenAddr(t *testing.T) {
+	testCases := []struct {
+		addr     string
+		expected string
+		err      bool
+	}{
+		{addr: ":9999", expected: "127.0.0.1:9999"},
+		{addr: "localhost:9999", expected: "localhost:9999"},
+		{addr: "127.0.0.1:8080", expected: "127.0.0.1:8080"},
+		{addr: "[::1]:9999", expected: "[::1]:9999"},
+		{addr: "0.0.0.0:9999", err: true},
+		{addr: "192.168.1.10:9999", err: true},
+		{addr: "9999", err: true},
+	}
+	for _, tc := range testCases {
+		addr, err := LocalListenAddr(tc.addr)
+		if (err != nil) != tc.err || addr != tc.expected {
+			t.Errorf("LocalListenAddr(%s) = %q, %v, want %q, error %v", tc.addr, addr, err, tc.expected, tc.err)
+		}
+	}
+}
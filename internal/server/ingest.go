@@ -0,0 +1,48 @@
+package server
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+)
+
+// EventFormatHeader is the header of the requests to POST /api/events holding the
+// codec.Format of the event in the body. The format is detected if it is missing.
+const EventFormatHeader = "X-Event-Format"
+
+// maxIngestedEventSize bounds the size of the events posted to /api/events
+const maxIngestedEventSize = 16 << 20
+
+// handleIngestEvent passes the event in the request body to the ingestion handler, as
+// if it was consumed from Redis
+func (s *HTTPServer) handleIngestEvent(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestedEventSize))
+	if err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msg := message.NewMessage(watermill.NewUUID(), payload)
+	format := codec.Format(r.Header.Get(EventFormatHeader))
+	if format == "" {
+		format = codec.DetectFormat(payload)
+	}
+	msg.Metadata.Set(codec.FormatMetadataKey, string(format))
+
+	// Decode first to tell invalid events from failures of the handler
+	if _, err := codec.DecodeMessage(msg); err != nil {
+		http.Error(w, "Invalid event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.ingest(msg); err != nil {
+		s.logger.Error().Err(err).Str("message_id", msg.UUID).Msg("Failed to ingest event")
+		http.Error(w, "Failed to ingest event", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,307 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/clay/pkg/repositories"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/layers"
+	"github.com/go-go-golems/glazed/pkg/help"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	goagentcmds "github.com/go-go-golems/go-go-agent/goagent/cmds"
+	"github.com/go-go-golems/go-go-agent/goagent/runs"
+	"github.com/go-go-golems/go-go-agent/goagent/tools"
+	"github.com/go-go-golems/go-go-agent/internal/db"
+	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+)
+
+var (
+	// ErrUnknownCommand is returned when starting a run of a command that is not loaded
+	ErrUnknownCommand = errors.New("unknown agent command")
+	// ErrRunNotActive is returned when controlling a run that is not running
+	ErrRunNotActive = errors.New("run is not active")
+	// ErrRunStateUnchanged is returned when pausing a paused run or resuming a running one
+	ErrRunStateUnchanged = errors.New("run is already in the requested state")
+	// ErrInvalidParameters is returned when the parameters of a run cannot be parsed
+	ErrInvalidParameters = errors.New("invalid run parameters")
+)
+
+// StartRunRequest is the body of POST /api/runs
+type StartRunRequest struct {
+	// Command is the name of the agent command to run
+	Command string `json:"command"`
+	// Parameters are the flags and arguments of the command
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// Layers sets parameters of other layers by layer slug, e.g. "ai-chat"
+	Layers map[string]map[string]interface{} `json:"layers,omitempty"`
+}
+
+// CommandInfo describes an agent command that can be run through the API
+type CommandInfo struct {
+	Name      string `json:"name"`
+	Short     string `json:"short"`
+	AgentType string `json:"agent_type"`
+}
+
+// activeRun is a run started by the RunManager that has not finished yet
+type activeRun struct {
+	id        string
+	cancel    context.CancelFunc
+	control   *runs.Control
+	approvals *tools.ApprovalQueue
+}
+
+// RunManager starts agent commands in the server process and lets clients cancel,
+// pause and resume them. The events of the runs are passed to the same handler as the
+// events received over Redis, so they are stored and broadcast like any other run.
+type RunManager struct {
+	ctx      context.Context
+	commands map[string]*goagentcmds.AgentCommand
+	db       *db.DatabaseManager
+	handler  func(msg *message.Message) error
+	logger   zerolog.Logger
+
+	mu   sync.Mutex
+	runs map[string]*activeRun
+	wg   sync.WaitGroup
+}
+
+// NewRunManager creates a RunManager for commands. Runs are cancelled when ctx is done.
+func NewRunManager(
+	ctx context.Context,
+	commands []cmds.Command,
+	dbManager *db.DatabaseManager,
+	handler func(msg *message.Message) error,
+	logger zerolog.Logger,
+) *RunManager {
+	rm := &RunManager{
+		ctx:      ctx,
+		commands: map[string]*goagentcmds.AgentCommand{},
+		db:       dbManager,
+		handler:  handler,
+		logger:   logger.With().Str("component", "run_manager").Logger(),
+		runs:     map[string]*activeRun{},
+	}
+	for _, c := range commands {
+		switch ac := c.(type) {
+		case *goagentcmds.WriterAgentCommand:
+			rm.commands[ac.Name] = ac.AgentCommand
+		case *goagentcmds.GlazedAgentCommand:
+			rm.commands[ac.Name] = ac.AgentCommand
+		default:
+			rm.logger.Warn().Str("command", c.Description().Name).Msg("Skipping command that is not an agent command")
+		}
+	}
+	return rm
+}
+
+// LoadAgentCommands loads the agent commands found in the YAML files under dir
+func LoadAgentCommands(dir string) ([]cmds.Command, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read commands directory %s", dir)
+	}
+	if !fi.IsDir() {
+		return nil, errors.Errorf("%s is not a directory", dir)
+	}
+
+	repo := repositories.NewRepository(
+		repositories.WithDirectories(repositories.Directory{
+			FS:            os.DirFS(dir),
+			RootDirectory: ".",
+			Name:          "server-agents",
+			SourcePrefix:  "file",
+		}),
+		repositories.WithCommandLoader(&goagentcmds.AgentCommandLoader{}),
+	)
+	if err := repo.LoadCommands(help.NewHelpSystem()); err != nil {
+		return nil, errors.Wrapf(err, "failed to load commands from %s", dir)
+	}
+	commands := repo.CollectCommands([]string{}, true)
+	// Agents can delegate to the loaded commands by listing them as tools
+	goagentcmds.RegisterAgentTools(commands)
+	return commands, nil
+}
+
+// Commands returns the commands that can be run, sorted by name
+func (rm *RunManager) Commands() []CommandInfo {
+	ret := make([]CommandInfo, 0, len(rm.commands))
+	for name, c := range rm.commands {
+		ret = append(ret, CommandInfo{Name: name, Short: c.Short, AgentType: c.AgentType})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+// Start parses the parameters of the request and starts the run in the background.
+// It returns the ID of the run.
+func (rm *RunManager) Start(req *StartRunRequest) (string, error) {
+	command, ok := rm.commands[req.Command]
+	if !ok {
+		return "", errors.Wrapf(ErrUnknownCommand, "command %q", req.Command)
+	}
+	parsedLayers, err := parseRunParameters(command, req)
+	if err != nil {
+		return "", err
+	}
+
+	runID := uuid.New().String()
+	if err := rm.db.CreateRun(rm.ctx, runID, req.Command); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(rm.ctx)
+	run := &activeRun{
+		id:        runID,
+		cancel:    cancel,
+		control:   runs.NewControl(),
+		approvals: tools.NewApprovalQueue(),
+	}
+	rm.mu.Lock()
+	rm.runs[runID] = run
+	rm.mu.Unlock()
+
+	rm.wg.Add(1)
+	go func() {
+		defer rm.wg.Done()
+		defer cancel()
+
+		logger := rm.logger.With().Str("run_id", runID).Str("command", req.Command).Logger()
+		logger.Info().Msg("Starting run")
+
+		var output bytes.Buffer
+		runErr := command.RunIntoWriter(ctx, parsedLayers, &output,
+			goagentcmds.WithRunID(runID),
+			goagentcmds.WithRunMode("server"),
+			goagentcmds.WithEventPublisher(&handlerPublisher{handler: rm.handler}, "run-events-"+runID, eventbus.ModelJSONEncoder),
+			goagentcmds.WithRunControl(run.control),
+			goagentcmds.WithToolApprover(run.approvals),
+		)
+
+		rm.mu.Lock()
+		delete(rm.runs, runID)
+		rm.mu.Unlock()
+
+		status, errorMessage := db.RunStatusCompleted, ""
+		switch {
+		case ctx.Err() != nil:
+			status = db.RunStatusCancelled
+		case runErr != nil:
+			status, errorMessage = db.RunStatusError, runErr.Error()
+		}
+		logger.Info().Err(runErr).Str("status", status).Msg("Run finished")
+
+		if err := rm.db.FinishRun(context.Background(), runID, status, output.String(), errorMessage); err != nil {
+			logger.Error().Err(err).Msg("Failed to record the end of the run")
+		}
+	}()
+
+	return runID, nil
+}
+
+// Cancel cancels a run. The run stops at its next cancellation point, usually the
+// LLM or tool call in progress.
+func (rm *RunManager) Cancel(runID string) error {
+	run, err := rm.activeRun(runID)
+	if err != nil {
+		return err
+	}
+	run.cancel()
+	return nil
+}
+
+// Pause pauses a run before its next LLM call
+func (rm *RunManager) Pause(runID string) error {
+	run, err := rm.activeRun(runID)
+	if err != nil {
+		return err
+	}
+	if !run.control.Pause() {
+		return ErrRunStateUnchanged
+	}
+	return rm.db.SetRunStatus(rm.ctx, runID, db.RunStatusPaused)
+}
+
+// Resume resumes a paused run
+func (rm *RunManager) Resume(runID string) error {
+	run, err := rm.activeRun(runID)
+	if err != nil {
+		return err
+	}
+	if !run.control.Resume() {
+		return ErrRunStateUnchanged
+	}
+	return rm.db.SetRunStatus(rm.ctx, runID, db.RunStatusRunning)
+}
+
+// PendingApprovals returns the tool calls of a run waiting for approval
+func (rm *RunManager) PendingApprovals(runID string) ([]tools.ApprovalRequest, error) {
+	run, err := rm.activeRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	return run.approvals.Pending(), nil
+}
+
+// DecideApproval approves or rejects a tool call of a run waiting for approval
+func (rm *RunManager) DecideApproval(runID, toolCallID string, decision tools.ApprovalDecision) error {
+	run, err := rm.activeRun(runID)
+	if err != nil {
+		return err
+	}
+	return run.approvals.Decide(toolCallID, decision)
+}
+
+// Wait blocks until all runs have finished
+func (rm *RunManager) Wait() {
+	rm.wg.Wait()
+}
+
+func (rm *RunManager) activeRun(runID string) (*activeRun, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	run, ok := rm.runs[runID]
+	if !ok {
+		return nil, errors.Wrapf(ErrRunNotActive, "run %s", runID)
+	}
+	return run, nil
+}
+
+// parseRunParameters parses the parameters of req for command. Parameters missing from
+// the request are read from PINOCCHIO_* environment variables, then set to their defaults.
+func parseRunParameters(command *goagentcmds.AgentCommand, req *StartRunRequest) (*layers.ParsedLayers, error) {
+	parsedLayers, err := command.ParseParameters(req.Parameters, req.Layers)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidParameters, err.Error())
+	}
+	return parsedLayers, nil
+}
+
+// handlerPublisher is a watermill Publisher that passes messages directly to a handler
+type handlerPublisher struct {
+	handler func(msg *message.Message) error
+}
+
+var _ message.Publisher = (*handlerPublisher)(nil)
+
+func (p *handlerPublisher) Publish(topic string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		if err := p.handler(msg); err != nil {
+			return errors.Wrapf(err, "failed to handle message %s on topic %s", msg.UUID, topic)
+		}
+	}
+	return nil
+}
+
+func (p *handlerPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,288 @@
+// Package codec converts agent events between the protobuf events.Event published by
+// the EventBus and the model.Event stored and served by the server, and encodes and
+// decodes them in the formats used on the wire.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/go-go-agent/pkg/model"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Format is the encoding of an event in a message payload
+type Format string
+
+const (
+	// FormatModelJSON is the JSON of model.Event: a snake_case event type and the
+	// payload as a flat object. This is the format stored and served by the server.
+	FormatModelJSON Format = "model-json"
+	// FormatProtoJSON is the protojson of events.Event, with the payload under its
+	// oneof field and event types like EVENT_TYPE_STEP_STARTED
+	FormatProtoJSON Format = "protojson"
+	// FormatProto is the protobuf binary encoding of events.Event
+	FormatProto Format = "proto"
+)
+
+// FormatMetadataKey is the key of the message metadata holding the Format of the
+// payload. Messages without it are decoded with the format returned by DetectFormat.
+const FormatMetadataKey = "event_format"
+
+// eventTypePrefix is the prefix of the names of the EventType values
+const eventTypePrefix = "EVENT_TYPE_"
+
+// unknownPayloadField is the oneof field holding the payloads of event types that
+// are not part of the proto definitions
+const unknownPayloadField = "unknown_payload"
+
+// ToModel converts event to a model.Event. The payload is the protojson of the
+// payload message, with the proto field names.
+func ToModel(event *events.Event) (*model.Event, error) {
+	m := event.ProtoReflect()
+	payloadField := m.WhichOneof(m.Descriptor().Oneofs().ByName("payload"))
+	if payloadField == nil {
+		return nil, errors.Errorf("event %s has no payload", event.EventId)
+	}
+	payload, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m.Get(payloadField).Message().Interface())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal event payload to JSON")
+	}
+
+	ret := &model.Event{
+		EventID:   event.EventId,
+		EventType: EventTypeName(event.EventType),
+		Payload:   payload,
+		RunID:     event.GetRunId(),
+	}
+	if event.Timestamp != nil {
+		ret.Timestamp = event.Timestamp.AsTime().Format(time.RFC3339Nano)
+	}
+	return ret, nil
+}
+
+// FromModel converts event to an events.Event. Event types that are not part of the
+// proto definitions are converted to events of type EVENT_TYPE_UNSPECIFIED with their
+// payload as unknown_payload.
+func FromModel(event *model.Event) (*events.Event, error) {
+	ret := &events.Event{
+		EventId:   event.EventID,
+		EventType: EventTypeFromName(event.EventType),
+	}
+	if event.RunID != "" {
+		runID := event.RunID
+		ret.RunId = &runID
+	}
+	if event.Timestamp != "" {
+		t, err := parseTimestamp(event.Timestamp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid timestamp of event %s", event.EventID)
+		}
+		ret.Timestamp = timestamppb.New(t)
+	}
+
+	payload := []byte(event.Payload)
+	if len(bytes.TrimSpace(payload)) == 0 || bytes.Equal(bytes.TrimSpace(payload), []byte("null")) {
+		payload = []byte("{}")
+	}
+
+	m := ret.ProtoReflect()
+	fields := m.Descriptor().Oneofs().ByName("payload").Fields()
+	payloadField := fields.ByName(protoreflect.Name(event.EventType))
+	if payloadField == nil || ret.EventType == events.EventType_EVENT_TYPE_UNSPECIFIED {
+		payloadField = fields.ByName(unknownPayloadField)
+	}
+	value := m.NewField(payloadField)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(payload, value.Message().Interface()); err != nil {
+		return nil, errors.Wrapf(err, "invalid payload of %s event %s", event.EventType, event.EventID)
+	}
+	m.Set(payloadField, value)
+	return ret, nil
+}
+
+// EventTypeName returns the model name of eventType, such as step_started for
+// EVENT_TYPE_STEP_STARTED
+func EventTypeName(eventType events.EventType) string {
+	return strings.ToLower(strings.TrimPrefix(eventType.String(), eventTypePrefix))
+}
+
+// EventTypeFromName returns the EventType of a model event type name, and
+// EVENT_TYPE_UNSPECIFIED for unknown names
+func EventTypeFromName(name string) events.EventType {
+	if v, ok := events.EventType_value[eventTypePrefix+strings.ToUpper(name)]; ok {
+		return events.EventType(v)
+	}
+	return events.EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+// timestampLayouts are the accepted layouts of model event timestamps. The Python
+// EventBus emits ISO timestamps without time zone, which are read as UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	var err error
+	for _, layout := range timestampLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// Encode encodes event in format
+func Encode(event *events.Event, format Format) ([]byte, error) {
+	switch format {
+	case FormatModelJSON:
+		m, err := ToModel(event)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal event to JSON")
+		}
+		return b, nil
+	case FormatProtoJSON:
+		opts := protojson.MarshalOptions{
+			EmitUnpopulated: true,
+			UseProtoNames:   true,
+		}
+		b, err := opts.Marshal(event)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal event to JSON")
+		}
+		return b, nil
+	case FormatProto:
+		b, err := proto.Marshal(event)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal event to proto binary")
+		}
+		return b, nil
+	default:
+		return nil, errors.Errorf("unknown event format %q", format)
+	}
+}
+
+// Encoder returns a function encoding events in format, as used by the EventBus
+func Encoder(format Format) func(event *events.Event) ([]byte, error) {
+	return func(event *events.Event) ([]byte, error) {
+		return Encode(event, format)
+	}
+}
+
+// Decode decodes an event encoded in format
+func Decode(payload []byte, format Format) (*events.Event, error) {
+	switch format {
+	case FormatModelJSON:
+		var m model.Event
+		if err := json.Unmarshal(payload, &m); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal event JSON")
+		}
+		return FromModel(&m)
+	case FormatProtoJSON:
+		event := &events.Event{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(payload, event); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal event protojson")
+		}
+		return event, nil
+	case FormatProto:
+		event := &events.Event{}
+		if err := proto.Unmarshal(payload, event); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal event proto binary")
+		}
+		return event, nil
+	default:
+		return nil, errors.Errorf("unknown event format %q", format)
+	}
+}
+
+// DecodeModel decodes an event encoded in format as a model.Event. Model JSON is
+// decoded as is, so that event types unknown to the proto definitions are kept.
+func DecodeModel(payload []byte, format Format) (*model.Event, error) {
+	if format == FormatModelJSON {
+		var m model.Event
+		if err := json.Unmarshal(payload, &m); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal event JSON")
+		}
+		if m.EventType == "" {
+			return nil, errors.New("event JSON has no event_type")
+		}
+		return &m, nil
+	}
+	event, err := Decode(payload, format)
+	if err != nil {
+		return nil, err
+	}
+	return ToModel(event)
+}
+
+// DetectFormat returns the format of an encoded event: binary proto unless the
+// payload is a JSON object, and protojson if its event type is an EventType name.
+func DetectFormat(payload []byte) Format {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return FormatProto
+	}
+	var header struct {
+		EventType json.RawMessage `json:"event_type"`
+	}
+	if err := json.Unmarshal(trimmed, &header); err != nil {
+		// A binary event can't start with '{', this is broken JSON
+		return FormatModelJSON
+	}
+	if len(header.EventType) == 0 {
+		return FormatModelJSON
+	}
+	var name string
+	if err := json.Unmarshal(header.EventType, &name); err != nil {
+		// protojson can encode enums as numbers
+		return FormatProtoJSON
+	}
+	if strings.HasPrefix(name, eventTypePrefix) {
+		return FormatProtoJSON
+	}
+	return FormatModelJSON
+}
+
+// MessageFormat returns the format of the payload of msg, from its metadata or
+// detected from the payload
+func MessageFormat(msg *message.Message) Format {
+	if format := msg.Metadata.Get(FormatMetadataKey); format != "" {
+		return Format(format)
+	}
+	return DetectFormat(msg.Payload)
+}
+
+// NewMessage returns a message with event encoded in format, and the format in its
+// metadata
+func NewMessage(uuid string, event *events.Event, format Format) (*message.Message, error) {
+	payload, err := Encode(event, format)
+	if err != nil {
+		return nil, err
+	}
+	msg := message.NewMessage(uuid, payload)
+	msg.Metadata.Set(FormatMetadataKey, string(format))
+	return msg, nil
+}
+
+// DecodeMessage decodes the event of msg, in any format
+func DecodeMessage(msg *message.Message) (*events.Event, error) {
+	return Decode(msg.Payload, MessageFormat(msg))
+}
+
+// DecodeModelMessage decodes the event of msg, in any format, as a model.Event
+func DecodeModelMessage(msg *message.Message) (*model.Event, error) {
+	return DecodeModel(msg.Payload, MessageFormat(msg))
+}
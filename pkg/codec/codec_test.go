@@ -0,0 +1,263 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/go-go-agent/pkg/model"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var formats = []Format{FormatModelJSON, FormatProtoJSON, FormatProto}
+
+func mustStruct(t *testing.T, m map[string]interface{}) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(m)
+	if err != nil {
+		t.Fatalf("failed to create struct: %v", err)
+	}
+	return s
+}
+
+// samplePayloads returns a payload for every event type
+func samplePayloads(t *testing.T) map[events.EventType]interface{} {
+	step := int32(3)
+	nodeID := "node-1"
+	agentClass := "SearchAgent"
+	errMsg := "rate limited"
+	taskType := "SEARCH"
+
+	return map[events.EventType]interface{}{
+		events.EventType_EVENT_TYPE_STEP_STARTED: &events.StepStartedPayload{
+			Step: step, NodeId: nodeID, NodeGoal: "find the answer", RootId: "root",
+		},
+		events.EventType_EVENT_TYPE_STEP_FINISHED: &events.StepFinishedPayload{
+			Step: step, NodeId: nodeID, ActionName: "execute", StatusAfter: "DONE", DurationSeconds: 1.5,
+		},
+		events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED: &events.NodeStatusChangePayload{
+			NodeId: nodeID, NodeGoal: "find the answer", OldStatus: "READY", NewStatus: "DOING", Step: &step,
+		},
+		events.EventType_EVENT_TYPE_LLM_CALL_STARTED: &events.LlmCallStartedPayload{
+			AgentClass: agentClass,
+			Model:      "gpt-4o",
+			Prompt: []*events.LlmMessage{
+				{Role: "system", Content: "You are a planner"},
+				{Role: "user", Content: "Plan the task"},
+			},
+			PromptPreview: "Plan the task",
+			Step:          &step,
+			NodeId:        &nodeID,
+			CallId:        "call-1",
+		},
+		events.EventType_EVENT_TYPE_LLM_CALL_COMPLETED: &events.LlmCallCompletedPayload{
+			AgentClass:      agentClass,
+			Model:           "gpt-4o",
+			DurationSeconds: 2.25,
+			Response:        "the plan",
+			ResultSummary:   "the plan",
+			Error:           &errMsg,
+			Step:            &step,
+			NodeId:          &nodeID,
+			TokenUsage:      &events.TokenUsage{PromptTokens: 120, CompletionTokens: 30},
+			CallId:          "call-1",
+		},
+		events.EventType_EVENT_TYPE_LLM_CALL_DELTA: &events.LlmCallDeltaPayload{
+			CallId: "call-1", AgentClass: agentClass, Model: "gpt-4o", Delta: "the", Sequence: 2, AccumulatedLength: 8,
+		},
+		events.EventType_EVENT_TYPE_TOOL_INVOKED: &events.ToolInvokedPayload{
+			ToolName: "search", ApiName: "web", ArgsSummary: `{"query":"go"}`, NodeId: &nodeID, ToolCallId: "tool-1",
+		},
+		events.EventType_EVENT_TYPE_TOOL_APPROVAL_REQUESTED: &events.ToolApprovalRequestedPayload{
+			ToolName: "shell", ToolCallId: "tool-2", ArgsSummary: "rm -rf build", AgentClass: &agentClass,
+		},
+		events.EventType_EVENT_TYPE_TOOL_RETURNED: &events.ToolReturnedPayload{
+			ToolName: "search", ApiName: "web", State: "success", DurationSeconds: 0.5, ResultSummary: "3 results", ToolCallId: "tool-1",
+		},
+		events.EventType_EVENT_TYPE_NODE_CREATED: &events.NodeCreatedPayload{
+			NodeId: nodeID, NodeNid: "1", NodeType: "PLAN_NODE", TaskType: taskType, TaskGoal: "search",
+			Layer: 1, RootNodeId: "root", InitialParentNids: []string{"0", "0.1"},
+		},
+		events.EventType_EVENT_TYPE_PLAN_RECEIVED: &events.PlanReceivedPayload{
+			NodeId: nodeID,
+			RawPlan: mustStruct(t, map[string]interface{}{
+				"steps": []interface{}{map[string]interface{}{"id": 1.0, "goal": "search"}},
+			}),
+			TaskType: &taskType,
+		},
+		events.EventType_EVENT_TYPE_NODE_ADDED: &events.NodeAddedPayload{
+			GraphOwnerNodeId: "root", AddedNodeId: nodeID, AddedNodeNid: "1",
+		},
+		events.EventType_EVENT_TYPE_EDGE_ADDED: &events.EdgeAddedPayload{
+			GraphOwnerNodeId: "root", ParentNodeId: "node-0", ChildNodeId: nodeID, ParentNodeNid: "0", ChildNodeNid: "1",
+		},
+		events.EventType_EVENT_TYPE_INNER_GRAPH_BUILT: &events.InnerGraphBuiltPayload{
+			NodeId: "root", NodeCount: 2, EdgeCount: 1, NodeIds: []string{"node-0", nodeID},
+		},
+		events.EventType_EVENT_TYPE_NODE_RESULT_AVAILABLE: &events.NodeResultAvailablePayload{
+			NodeId: nodeID, ActionName: "execute", ResultSummary: "42", TaskType: &taskType,
+		},
+		events.EventType_EVENT_TYPE_RUN_STARTED: &events.RunStartedPayload{
+			InputData:    mustStruct(t, map[string]interface{}{"question": "why?"}),
+			Config:       mustStruct(t, map[string]interface{}{"max_steps": 10.0}),
+			RunMode:      "cli",
+			TimestampUtc: timestamppb.New(time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)),
+		},
+		events.EventType_EVENT_TYPE_RUN_FINISHED: &events.RunFinishedPayload{
+			TotalSteps:        4,
+			DurationSeconds:   12.5,
+			TotalNodes:        3,
+			TotalLlmCalls:     5,
+			TotalToolCalls:    2,
+			TokenUsageSummary: &events.RunFinishedPayload_TokenUsageSummary{TotalPromptTokens: 500, TotalCompletionTokens: 100},
+			NodeStatistics: &events.RunFinishedPayload_NodeStatistics{
+				TotalCreated: 3, TotalCompleted: 3, ByType: map[string]int32{"PLAN_NODE": 1, "EXECUTE_NODE": 2},
+			},
+		},
+		events.EventType_EVENT_TYPE_RUN_ERROR: &events.RunErrorPayload{
+			ErrorType: "Timeout", ErrorMessage: "step timed out", StackTrace: "main.go:1", NodeId: &nodeID, Step: &step,
+		},
+	}
+}
+
+// sampleEvents returns an event of every event type, failing if one is missing
+func sampleEvents(t *testing.T) []*events.Event {
+	t.Helper()
+	payloads := samplePayloads(t)
+	runID := "run-1"
+
+	var ret []*events.Event
+	for value := range events.EventType_name {
+		eventType := events.EventType(value)
+		if eventType == events.EventType_EVENT_TYPE_UNSPECIFIED {
+			continue
+		}
+		payload, ok := payloads[eventType]
+		if !ok {
+			t.Fatalf("no sample payload for %s", eventType)
+		}
+		event, err := events.NewEvent(eventType, payload)
+		if err != nil {
+			t.Fatalf("failed to create %s event: %v", eventType, err)
+		}
+		event.RunId = &runID
+		ret = append(ret, event)
+	}
+	return ret
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, event := range sampleEvents(t) {
+		for _, format := range formats {
+			t.Run(EventTypeName(event.EventType)+"/"+string(format), func(t *testing.T) {
+				payload, err := Encode(event, format)
+				if err != nil {
+					t.Fatalf("failed to encode: %v", err)
+				}
+				if detected := DetectFormat(payload); detected != format {
+					t.Errorf("expected detected format %s, got %s", format, detected)
+				}
+				decoded, err := Decode(payload, format)
+				if err != nil {
+					t.Fatalf("failed to decode: %v", err)
+				}
+				if !proto.Equal(event, decoded) {
+					t.Errorf("decoded event differs:\nexpected %v\ngot      %v", event, decoded)
+				}
+
+				// Without metadata, the format is detected from the payload
+				decoded, err = DecodeMessage(message.NewMessage(watermill.NewUUID(), payload))
+				if err != nil {
+					t.Fatalf("failed to decode message: %v", err)
+				}
+				if !proto.Equal(event, decoded) {
+					t.Errorf("decoded message differs:\nexpected %v\ngot      %v", event, decoded)
+				}
+
+				msg, err := NewMessage(watermill.NewUUID(), event, format)
+				if err != nil {
+					t.Fatalf("failed to create message: %v", err)
+				}
+				if got := msg.Metadata.Get(FormatMetadataKey); got != string(format) {
+					t.Errorf("expected format %s in metadata, got %s", format, got)
+				}
+				modelEvent, err := DecodeModelMessage(msg)
+				if err != nil {
+					t.Fatalf("failed to decode model message: %v", err)
+				}
+				if modelEvent.EventType != EventTypeName(event.EventType) || modelEvent.EventID != event.EventId || modelEvent.RunID != "run-1" {
+					t.Errorf("unexpected model event %+v", modelEvent)
+				}
+			})
+		}
+	}
+}
+
+func TestModelRoundTrip(t *testing.T) {
+	for _, event := range sampleEvents(t) {
+		t.Run(EventTypeName(event.EventType), func(t *testing.T) {
+			m, err := ToModel(event)
+			if err != nil {
+				t.Fatalf("failed to convert to model: %v", err)
+			}
+			back, err := FromModel(m)
+			if err != nil {
+				t.Fatalf("failed to convert from model: %v", err)
+			}
+			if !proto.Equal(event, back) {
+				t.Errorf("converted event differs:\nexpected %v\ngot      %v", event, back)
+			}
+		})
+	}
+}
+
+func TestModelEventTypes(t *testing.T) {
+	names := map[events.EventType]string{
+		events.EventType_EVENT_TYPE_STEP_STARTED:        model.EventTypeStepStarted,
+		events.EventType_EVENT_TYPE_LLM_CALL_DELTA:      model.EventTypeLLMCallDelta,
+		events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED: model.EventTypeNodeStatusChanged,
+		events.EventType_EVENT_TYPE_RUN_FINISHED:        model.EventTypeRunFinished,
+	}
+	for eventType, name := range names {
+		if got := EventTypeName(eventType); got != name {
+			t.Errorf("expected name %s for %s, got %s", name, eventType, got)
+		}
+		if got := EventTypeFromName(name); got != eventType {
+			t.Errorf("expected event type %s for %s, got %s", eventType, name, got)
+		}
+	}
+}
+
+func TestUnknownModelEventType(t *testing.T) {
+	payload := []byte(`{"event_id":"e-1","timestamp":"2025-04-01T12:00:00.123456","event_type":"search_completed","payload":{"pages":3},"run_id":"run-1"}`)
+	if format := DetectFormat(payload); format != FormatModelJSON {
+		t.Fatalf("expected format %s, got %s", FormatModelJSON, format)
+	}
+
+	m, err := DecodeModel(payload, FormatModelJSON)
+	if err != nil {
+		t.Fatalf("failed to decode model event: %v", err)
+	}
+	if m.EventType != "search_completed" {
+		t.Errorf("expected event type search_completed, got %s", m.EventType)
+	}
+
+	event, err := Decode(payload, FormatModelJSON)
+	if err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	if event.EventType != events.EventType_EVENT_TYPE_UNSPECIFIED {
+		t.Errorf("expected unspecified event type, got %s", event.EventType)
+	}
+	if pages := event.GetUnknownPayload().GetFields()["pages"].GetNumberValue(); pages != 3 {
+		t.Errorf("expected unknown payload with 3 pages, got %v", event.GetUnknownPayload())
+	}
+	expected := time.Date(2025, 4, 1, 12, 0, 0, 123456000, time.UTC)
+	if !event.Timestamp.AsTime().Equal(expected) {
+		t.Errorf("expected timestamp %s, got %s", expected, event.Timestamp.AsTime())
+	}
+}
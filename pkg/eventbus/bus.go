@@ -2,13 +2,16 @@ package eventbus
 
 import (
 	"context"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
 	events "github.com/go-go-golems/go-go-agent/proto"
 	"github.com/pkg/errors"
-	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // EventBus provides methods for publishing agent events.
@@ -16,6 +19,18 @@ type EventBus struct {
 	publisher message.Publisher
 	topic     string
 	encoder   func(event *events.Event) ([]byte, error)
+	// format is the codec format of the encoder, set in the metadata of the messages.
+	// It is empty for custom encoders.
+	format codec.Format
+	sinks  []sink
+	stats  *RunStats
+}
+
+// sink is an additional destination of the events of an EventBus
+type sink struct {
+	publisher message.Publisher
+	topic     string
+	format    codec.Format
 }
 
 // EventBusOption defines options for configuring the EventBus.
@@ -36,39 +51,55 @@ func WithTopic(topic string) EventBusOption {
 }
 
 // WithEncoder sets the function used to encode events before publishing.
-// Defaults to protojson encoding.
+// Defaults to protojson encoding. Prefer WithFormat for the formats of the codec
+// package, which are recorded in the message metadata.
 func WithEncoder(encoder func(event *events.Event) ([]byte, error)) EventBusOption {
 	return func(eb *EventBus) {
 		eb.encoder = encoder
+		eb.format = ""
 	}
 }
 
-// DefaultJSONEncoder encodes events using protojson.
-func DefaultJSONEncoder(event *events.Event) ([]byte, error) {
-	opts := protojson.MarshalOptions{
-		EmitUnpopulated: true,
-		UseProtoNames:   true,
+// WithFormat encodes the events in format, and sets the format in the metadata of
+// the messages so that consumers can decode them with codec.DecodeMessage.
+func WithFormat(format codec.Format) EventBusOption {
+	return func(eb *EventBus) {
+		eb.encoder = codec.Encoder(format)
+		eb.format = format
 	}
-	b, err := opts.Marshal(event)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to marshal event to JSON")
+}
+
+// WithSink also publishes the events to topic with publisher, encoded in format.
+// Sinks let a single bus feed consumers expecting different formats, such as the
+// stdout printer and the server.
+func WithSink(publisher message.Publisher, topic string, format codec.Format) EventBusOption {
+	return func(eb *EventBus) {
+		eb.sinks = append(eb.sinks, sink{publisher: publisher, topic: topic, format: format})
 	}
-	return b, nil
+}
+
+// DefaultJSONEncoder encodes events using protojson.
+func DefaultJSONEncoder(event *events.Event) ([]byte, error) {
+	return codec.Encode(event, codec.FormatProtoJSON)
+}
+
+// ModelJSONEncoder encodes events as the JSON of model.Event, the format stored and
+// served by the server: a snake_case event type and the payload as a flat object.
+func ModelJSONEncoder(event *events.Event) ([]byte, error) {
+	return codec.Encode(event, codec.FormatModelJSON)
 }
 
 // DefaultProtoEncoder encodes events using protobuf binary format.
 func DefaultProtoEncoder(event *events.Event) ([]byte, error) {
-	b, err := proto.Marshal(event)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to marshal event to proto binary")
-	}
-	return b, nil
+	return codec.Encode(event, codec.FormatProto)
 }
 
 // NewEventBus creates a new EventBus.
 func NewEventBus(options ...EventBusOption) (*EventBus, error) {
 	eb := &EventBus{
 		encoder: DefaultJSONEncoder, // Default to JSON encoding
+		format:  codec.FormatProtoJSON,
+		stats:   NewRunStats(),
 	}
 	for _, option := range options {
 		option(eb)
@@ -80,11 +111,18 @@ func NewEventBus(options ...EventBusOption) (*EventBus, error) {
 	if eb.topic == "" {
 		return nil, errors.New("topic is required")
 	}
+	for _, s := range eb.sinks {
+		if s.publisher == nil || s.topic == "" {
+			return nil, errors.New("sinks require a publisher and a topic")
+		}
+	}
 
 	return eb, nil
 }
 
-// Publish encodes and sends an event to the configured topic.
+// Publish encodes and sends an event to the configured topic, and to the topics of
+// the sinks. An event that can't be published to a sink is still published to the
+// other sinks.
 func (eb *EventBus) Publish(ctx context.Context, event *events.Event) error {
 	if eb.publisher == nil {
 		return errors.New("cannot publish event, publisher is not configured")
@@ -93,30 +131,75 @@ func (eb *EventBus) Publish(ctx context.Context, event *events.Event) error {
 		return errors.New("cannot publish nil event")
 	}
 
-	payloadBytes, err := eb.encoder(event)
+	err := publish(eb.publisher, eb.topic, eb.encoder, eb.format, event)
+	for _, s := range eb.sinks {
+		if sinkErr := publish(s.publisher, s.topic, codec.Encoder(s.format), s.format, event); sinkErr != nil && err == nil {
+			err = sinkErr
+		}
+	}
+	return err
+}
+
+func publish(
+	publisher message.Publisher,
+	topic string,
+	encoder func(event *events.Event) ([]byte, error),
+	format codec.Format,
+	event *events.Event,
+) error {
+	payloadBytes, err := encoder(event)
 	if err != nil {
 		return errors.Wrap(err, "failed to encode event")
 	}
 
 	msg := message.NewMessage(watermill.NewUUID(), payloadBytes)
+	if format != "" {
+		msg.Metadata.Set(codec.FormatMetadataKey, string(format))
+	}
 	// TODO(manuel): Add metadata? Maybe event_type?
 	// msg.Metadata.Set("event_type", event.EventType.String())
 
-	err = eb.publisher.Publish(eb.topic, msg)
+	err = publisher.Publish(topic, msg)
 	if err != nil {
 		return errors.Wrapf(err, "failed to publish event (id: %s, type: %s) to topic %s",
-			event.EventId, event.EventType.String(), eb.topic)
+			event.EventId, event.EventType.String(), topic)
 	}
 
 	return nil
 }
 
-// Close closes the underlying publisher.
+// Close closes the underlying publisher and the publishers of the sinks.
 func (eb *EventBus) Close() error {
+	var err error
 	if eb.publisher != nil {
-		return eb.publisher.Close()
+		err = eb.publisher.Close()
 	}
-	return nil
+	for _, s := range eb.sinks {
+		if closeErr := s.publisher.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// fillFromExecutionContext sets the step, node_id, action_name and agent_class fields
+// of payload that are not set to the values of ec
+func fillFromExecutionContext(payload protoreflect.Message, ec execctx.ExecutionContext) {
+	fields := payload.Descriptor().Fields()
+	setString := func(name protoreflect.Name, value string) {
+		fd := fields.ByName(name)
+		if fd == nil || fd.Kind() != protoreflect.StringKind || value == "" || payload.Has(fd) {
+			return
+		}
+		payload.Set(fd, protoreflect.ValueOfString(value))
+	}
+
+	if fd := fields.ByName("step"); fd != nil && fd.Kind() == protoreflect.Int32Kind && ec.Step > 0 && !payload.Has(fd) {
+		payload.Set(fd, protoreflect.ValueOfInt32(int32(ec.Step)))
+	}
+	setString("node_id", ec.NodeID)
+	setString("action_name", ec.ActionName)
+	setString("agent_class", ec.AgentClass)
 }
 
 // --- Helper methods for specific event types --- //
@@ -126,10 +209,33 @@ func (eb *EventBus) Emit(ctx context.Context, event *events.Event, runID *string
 	if runID != nil {
 		event.RunId = runID
 	}
+	if eb.stats != nil {
+		eb.stats.Record(event)
+	}
 	return eb.Publish(ctx, event)
 }
 
+// RunFinishedPayload returns a RunFinishedPayload summarizing the events emitted so far
+// for runID: steps, LLM and tool calls, token usage and nodes.
+func (eb *EventBus) RunFinishedPayload(runID string, duration time.Duration) *events.RunFinishedPayload {
+	if eb.stats == nil {
+		return NewRunStats().FinishedPayload(runID, duration)
+	}
+	return eb.stats.FinishedPayload(runID, duration)
+}
+
+// emitEvent emits an event of eventType. The run ID, if nil, and the step, node_id,
+// action_name and agent_class fields of payload that are not set are filled in from
+// the execution context of ctx.
 func (eb *EventBus) emitEvent(ctx context.Context, eventType events.EventType, payload interface{}, runID *string) error {
+	ec := execctx.FromContext(ctx)
+	if runID == nil {
+		runID = ec.RunIDPtr()
+	}
+	if m, ok := payload.(proto.Message); ok {
+		fillFromExecutionContext(m.ProtoReflect(), ec)
+	}
+
 	e, err := events.NewEvent(eventType, payload)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create event %s", eventType.String())
@@ -157,10 +263,18 @@ func (eb *EventBus) EmitLlmCallCompleted(ctx context.Context, payload *events.Ll
 	return eb.emitEvent(ctx, events.EventType_EVENT_TYPE_LLM_CALL_COMPLETED, payload, runID)
 }
 
+func (eb *EventBus) EmitLlmCallDelta(ctx context.Context, payload *events.LlmCallDeltaPayload, runID *string) error {
+	return eb.emitEvent(ctx, events.EventType_EVENT_TYPE_LLM_CALL_DELTA, payload, runID)
+}
+
 func (eb *EventBus) EmitToolInvoked(ctx context.Context, payload *events.ToolInvokedPayload, runID *string) error {
 	return eb.emitEvent(ctx, events.EventType_EVENT_TYPE_TOOL_INVOKED, payload, runID)
 }
 
+func (eb *EventBus) EmitToolApprovalRequested(ctx context.Context, payload *events.ToolApprovalRequestedPayload, runID *string) error {
+	return eb.emitEvent(ctx, events.EventType_EVENT_TYPE_TOOL_APPROVAL_REQUESTED, payload, runID)
+}
+
 func (eb *EventBus) EmitToolReturned(ctx context.Context, payload *events.ToolReturnedPayload, runID *string) error {
 	return eb.emitEvent(ctx, events.EventType_EVENT_TYPE_TOOL_RETURNED, payload, runID)
 }
@@ -0,0 +1,146 @@
+package eventbus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	"github.com/go-go-golems/go-go-agent/pkg/execctx"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordingPublisher decodes and keeps the events published to it
+type recordingPublisher struct {
+	events []*events.Event
+}
+
+func (p *recordingPublisher) Publish(topic string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		event, err := codec.DecodeMessage(msg)
+		if err != nil {
+			return err
+		}
+		p.events = append(p.events, event)
+	}
+	return nil
+}
+
+func (p *recordingPublisher) Close() error {
+	return nil
+}
+
+func TestEmitFromExecutionContext(t *testing.T) {
+	inStep := execctx.WithAction(
+		execctx.WithStep(
+			execctx.WithAgentClass(execctx.WithRunID(context.Background(), "run-1"), "ReActAgent"),
+			2, "node-2"),
+		"web_search")
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		payload  *events.ToolInvokedPayload
+		runID    *string
+		expected *events.ToolInvokedPayload
+		runIDSet string
+	}{
+		{
+			name:     "outside of a run",
+			ctx:      context.Background(),
+			payload:  &events.ToolInvokedPayload{ToolName: "web_search"},
+			expected: &events.ToolInvokedPayload{ToolName: "web_search"},
+		},
+		{
+			name:    "in a step",
+			ctx:     inStep,
+			payload: &events.ToolInvokedPayload{ToolName: "web_search"},
+			expected: &events.ToolInvokedPayload{
+				ToolName:   "web_search",
+				NodeId:     proto.String("node-2"),
+				Step:       proto.Int32(2),
+				AgentClass: proto.String("ReActAgent"),
+			},
+			runIDSet: "run-1",
+		},
+		{
+			// Fields and run IDs set by the caller are kept
+			name: "explicit values",
+			ctx:  inStep,
+			payload: &events.ToolInvokedPayload{
+				ToolName: "web_search",
+				NodeId:   proto.String("node-7"),
+				Step:     proto.Int32(7),
+			},
+			runID: proto.String("run-2"),
+			expected: &events.ToolInvokedPayload{
+				ToolName:   "web_search",
+				NodeId:     proto.String("node-7"),
+				Step:       proto.Int32(7),
+				AgentClass: proto.String("ReActAgent"),
+			},
+			runIDSet: "run-2",
+		},
+		{
+			// A run started from a step of another run is not attributed to that step
+			name:     "nested run",
+			ctx:      execctx.WithRunID(inStep, "run-1.1"),
+			payload:  &events.ToolInvokedPayload{ToolName: "web_search"},
+			expected: &events.ToolInvokedPayload{ToolName: "web_search"},
+			runIDSet: "run-1.1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			eb, err := NewEventBus(WithPublisher(publisher), WithTopic("test"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := eb.EmitToolInvoked(tc.ctx, tc.payload, tc.runID); err != nil {
+				t.Fatal(err)
+			}
+			if len(publisher.events) != 1 {
+				t.Fatalf("published %d events, want 1", len(publisher.events))
+			}
+			event := publisher.events[0]
+			if event.GetRunId() != tc.runIDSet {
+				t.Errorf("run ID = %q, want %q", event.GetRunId(), tc.runIDSet)
+			}
+			if payload := event.GetToolInvoked(); !proto.Equal(payload, tc.expected) {
+				t.Errorf("payload = %v, want %v", payload, tc.expected)
+			}
+		})
+	}
+}
+
+func TestEmitActionName(t *testing.T) {
+	publisher := &recordingPublisher{}
+	eb, err := NewEventBus(WithPublisher(publisher), WithTopic("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := execctx.WithAction(execctx.WithStep(context.Background(), 1, "node-1"), "Replan")
+	if err := eb.EmitLlmCallStarted(ctx, &events.LlmCallStartedPayload{CallId: "call-1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// The next step clears the action of the previous one
+	ctx = execctx.WithStep(ctx, 2, "node-2")
+	if err := eb.EmitLlmCallStarted(ctx, &events.LlmCallStartedPayload{CallId: "call-2"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*events.LlmCallStartedPayload{
+		{CallId: "call-1", Step: proto.Int32(1), NodeId: proto.String("node-1"), ActionName: proto.String("Replan")},
+		{CallId: "call-2", Step: proto.Int32(2), NodeId: proto.String("node-2")},
+	}
+	if len(publisher.events) != len(expected) {
+		t.Fatalf("published %d events, want %d", len(publisher.events), len(expected))
+	}
+	for i, event := range publisher.events {
+		if payload := event.GetLlmCallStarted(); !proto.Equal(payload, expected[i]) {
+			t.Errorf("event %d payload = %v, want %v", i, payload, expected[i])
+		}
+	}
+}
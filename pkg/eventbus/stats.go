@@ -0,0 +1,111 @@
+package eventbus
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	events "github.com/go-go-golems/go-go-agent/proto"
+)
+
+// RunStats accumulates the statistics reported in RunFinishedPayload from the events
+// emitted for each run.
+type RunStats struct {
+	mu   sync.Mutex
+	runs map[string]*runCounters
+}
+
+type runCounters struct {
+	steps            int32
+	llmCalls         int32
+	toolCalls        int32
+	promptTokens     int32
+	completionTokens int32
+	nodesCreated     int32
+	nodesCompleted   int32
+	nodesByType      map[string]int32
+}
+
+// NewRunStats creates an empty RunStats
+func NewRunStats() *RunStats {
+	return &RunStats{
+		runs: make(map[string]*runCounters),
+	}
+}
+
+// Record updates the counters of the event's run. Events without a run ID are ignored.
+func (s *RunStats) Record(event *events.Event) {
+	runID := event.GetRunId()
+	if runID == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.runs[runID]
+	if !ok {
+		c = &runCounters{nodesByType: map[string]int32{}}
+		s.runs[runID] = c
+	}
+
+	switch event.GetEventType() {
+	case events.EventType_EVENT_TYPE_STEP_STARTED:
+		c.steps++
+	case events.EventType_EVENT_TYPE_LLM_CALL_COMPLETED:
+		c.llmCalls++
+		if usage := event.GetLlmCallCompleted().GetTokenUsage(); usage != nil {
+			c.promptTokens += usage.GetPromptTokens()
+			c.completionTokens += usage.GetCompletionTokens()
+		}
+	case events.EventType_EVENT_TYPE_TOOL_INVOKED:
+		c.toolCalls++
+	case events.EventType_EVENT_TYPE_NODE_CREATED:
+		c.nodesCreated++
+		c.nodesByType[event.GetNodeCreated().GetNodeType()]++
+	case events.EventType_EVENT_TYPE_NODE_STATUS_CHANGED:
+		if isCompletedStatus(event.GetNodeStatusChanged().GetNewStatus()) {
+			c.nodesCompleted++
+		}
+	}
+}
+
+// isCompletedStatus reports whether a node status marks the node as done
+func isCompletedStatus(status string) bool {
+	switch strings.ToUpper(status) {
+	case "FINISH", "FINISHED", "DONE", "COMPLETED", "SUCCEEDED":
+		return true
+	default:
+		return false
+	}
+}
+
+// FinishedPayload returns a RunFinishedPayload with the statistics recorded for runID
+// and forgets the run.
+func (s *RunStats) FinishedPayload(runID string, duration time.Duration) *events.RunFinishedPayload {
+	s.mu.Lock()
+	c, ok := s.runs[runID]
+	delete(s.runs, runID)
+	s.mu.Unlock()
+
+	if !ok {
+		c = &runCounters{nodesByType: map[string]int32{}}
+	}
+
+	return &events.RunFinishedPayload{
+		TotalSteps:      c.steps,
+		DurationSeconds: duration.Seconds(),
+		TotalNodes:      c.nodesCreated,
+		TotalLlmCalls:   c.llmCalls,
+		TotalToolCalls:  c.toolCalls,
+		TokenUsageSummary: &events.RunFinishedPayload_TokenUsageSummary{
+			TotalPromptTokens:     c.promptTokens,
+			TotalCompletionTokens: c.completionTokens,
+		},
+		NodeStatistics: &events.RunFinishedPayload_NodeStatistics{
+			TotalCreated:   c.nodesCreated,
+			TotalCompleted: c.nodesCompleted,
+			ByType:         c.nodesByType,
+		},
+	}
+}
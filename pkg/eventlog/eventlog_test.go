@@ -0,0 +1,191 @@
+package eventlog
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	"github.com/go-go-golems/go-go-agent/pkg/eventbus"
+	"github.com/go-go-golems/go-go-agent/pkg/model"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newEventBus(t *testing.T, p *Publisher) *eventbus.EventBus {
+	t.Helper()
+	eb, err := eventbus.NewEventBus(
+		eventbus.WithPublisher(p),
+		eventbus.WithTopic("events"),
+		eventbus.WithFormat(codec.FormatProto),
+	)
+	if err != nil {
+		t.Fatalf("failed to create event bus: %v", err)
+	}
+	return eb
+}
+
+func newPublisher(t *testing.T, options ...PublisherOption) *Publisher {
+	t.Helper()
+	p, err := NewPublisher(t.TempDir(), options...)
+	if err != nil {
+		t.Fatalf("failed to create publisher: %v", err)
+	}
+	return p
+}
+
+func readAll(t *testing.T, path string, filter Filter) []*events.Event {
+	t.Helper()
+	var ret []*events.Event
+	if err := Read(path, filter, func(event *events.Event) error {
+		ret = append(ret, event)
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return ret
+}
+
+// TestRotation writes more than the maximum size of a log, and reads the events back
+// from the rotated files and the log
+func TestRotation(t *testing.T) {
+	ctx := context.Background()
+	p := newPublisher(t, WithMaxSize(1), WithMaxBackups(0))
+	eb := newEventBus(t, p)
+	runID := "run-1"
+	otherRunID := "run-10"
+
+	// 10KB events, about 1.5MB
+	response := strings.Repeat("x", 10*1024)
+	const count = 150
+	for i := 0; i < count; i++ {
+		if err := eb.EmitLlmCallCompleted(ctx, &events.LlmCallCompletedPayload{Response: response, Model: "gpt-4o", CallId: string(rune('a' + i%26))}, &runID); err != nil {
+			t.Fatalf("failed to emit event: %v", err)
+		}
+		if err := eb.EmitStepStarted(ctx, &events.StepStartedPayload{Step: int32(i)}, &otherRunID); err != nil {
+			t.Fatalf("failed to emit event: %v", err)
+		}
+	}
+	if err := eb.Close(); err != nil {
+		t.Fatalf("failed to close event bus: %v", err)
+	}
+
+	path := p.Path(runID)
+	if filepath.Base(path) != "run-1.jsonl" {
+		t.Errorf("unexpected log path %s", path)
+	}
+	files, err := Files(path)
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+	if len(files) != 2 || files[1] != path {
+		t.Fatalf("expected a rotated file and the log, got %v", files)
+	}
+
+	evs := readAll(t, path, Filter{})
+	if len(evs) != count {
+		t.Fatalf("expected %d events, got %d", count, len(evs))
+	}
+	for i, event := range evs {
+		if event.GetRunId() != runID || event.GetLlmCallCompleted().GetCallId() != string(rune('a'+i%26)) {
+			t.Fatalf("unexpected event %d: run %s call %s", i, event.GetRunId(), event.GetLlmCallCompleted().GetCallId())
+		}
+	}
+
+	if evs := readAll(t, p.Path(otherRunID), Filter{EventTypes: []string{model.EventTypeStepStarted}}); len(evs) != count {
+		t.Errorf("expected %d step_started events, got %d", count, len(evs))
+	}
+	if evs := readAll(t, p.Path(otherRunID), Filter{RunIDs: []string{runID}}); len(evs) != 0 {
+		t.Errorf("expected no events of run %s in the log of run %s, got %d", runID, otherRunID, len(evs))
+	}
+}
+
+func TestTailFollow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := newPublisher(t)
+	eb := newEventBus(t, p)
+	defer func() {
+		_ = eb.Close()
+	}()
+	runID := "run-1"
+	emit := func(step int32) {
+		t.Helper()
+		if err := eb.EmitStepStarted(ctx, &events.StepStartedPayload{Step: step}, &runID); err != nil {
+			t.Fatalf("failed to emit event: %v", err)
+		}
+	}
+	for step := int32(1); step <= 5; step++ {
+		emit(step)
+	}
+
+	steps := make(chan int32, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Tail(ctx, p.Path(runID), TailOptions{Last: 2, Follow: true, PollInterval: 10 * time.Millisecond}, func(event *events.Event) error {
+			steps <- event.GetStepStarted().GetStep()
+			return nil
+		})
+	}()
+
+	receive := func(expected int32) {
+		t.Helper()
+		select {
+		case step := <-steps:
+			if step != expected {
+				t.Fatalf("expected step %d, got %d", expected, step)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for step %d", expected)
+		}
+	}
+	receive(4)
+	receive(5)
+	emit(6)
+	receive(6)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("tail failed: %v", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	var evs []*events.Event
+	for i := 0; i < 3; i++ {
+		event, err := events.NewEvent(events.EventType_EVENT_TYPE_STEP_STARTED, &events.StepStartedPayload{Step: int32(i)})
+		if err != nil {
+			t.Fatalf("failed to create event: %v", err)
+		}
+		event.Timestamp = timestamppb.New(start.Add(time.Duration(i) * time.Second))
+		evs = append(evs, event)
+	}
+
+	var published []int32
+	replayStart := time.Now()
+	err := Replay(context.Background(), evs, 10, func(event *events.Event) error {
+		published = append(published, event.GetStepStarted().GetStep())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if elapsed := time.Since(replayStart); elapsed < 200*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("expected the 2s run to be replayed in about 200ms at speed 10, took %s", elapsed)
+	}
+	if len(published) != 3 || published[0] != 0 || published[2] != 2 {
+		t.Errorf("unexpected replayed steps %v", published)
+	}
+
+	runID := "run-1"
+	for _, event := range evs {
+		event.RunId = &runID
+	}
+	ids := RenewIDs(evs)
+	if len(ids) != 1 || ids[runID] == runID || evs[0].GetRunId() != ids[runID] || evs[2].GetRunId() != ids[runID] {
+		t.Errorf("unexpected new run IDs %v", ids)
+	}
+}
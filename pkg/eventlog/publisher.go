@@ -0,0 +1,136 @@
+// Package eventlog stores the events of agent runs in JSONL files, one protojson event
+// per line and one file per run, and reads them back to tail or replay runs without
+// Redis or the server.
+package eventlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	"github.com/pkg/errors"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Extension is the extension of event log files
+const Extension = ".jsonl"
+
+// DefaultMaxSize is the size in megabytes at which the log of a run is rotated
+const DefaultMaxSize = 100
+
+// DefaultMaxBackups is the number of rotated files kept per run
+const DefaultMaxBackups = 5
+
+// noRunID names the log of the events published without a run ID
+const noRunID = "no-run"
+
+// Publisher is a watermill Publisher appending the events it receives to the log of
+// their run, <dir>/<run ID>.jsonl. A log growing beyond its maximum size is renamed
+// to <run ID>-<timestamp>.jsonl and a new log is started. The messages can hold events
+// in any format of the codec package.
+type Publisher struct {
+	dir        string
+	maxSize    int
+	maxBackups int
+
+	mu    sync.Mutex
+	files map[string]*lumberjack.Logger
+}
+
+var _ message.Publisher = (*Publisher)(nil)
+
+// PublisherOption configures a Publisher
+type PublisherOption func(*Publisher)
+
+// WithMaxSize sets the size in megabytes at which logs are rotated
+func WithMaxSize(megabytes int) PublisherOption {
+	return func(p *Publisher) {
+		p.maxSize = megabytes
+	}
+}
+
+// WithMaxBackups sets the number of rotated files kept per run, 0 keeps them all
+func WithMaxBackups(n int) PublisherOption {
+	return func(p *Publisher) {
+		p.maxBackups = n
+	}
+}
+
+// NewPublisher creates a Publisher writing the logs of runs to dir, which is created
+// if needed
+func NewPublisher(dir string, options ...PublisherOption) (*Publisher, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create event log directory %s", dir)
+	}
+	p := &Publisher{
+		dir:        dir,
+		maxSize:    DefaultMaxSize,
+		maxBackups: DefaultMaxBackups,
+		files:      map[string]*lumberjack.Logger{},
+	}
+	for _, o := range options {
+		o(p)
+	}
+	return p, nil
+}
+
+// Path returns the path of the log of the run runID
+func (p *Publisher) Path(runID string) string {
+	if runID == "" {
+		runID = noRunID
+	}
+	// Run IDs are UUIDs, but must not escape dir
+	name := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(runID)
+	return filepath.Join(p.dir, name+Extension)
+}
+
+// Publish appends the events of messages to the logs of their runs. The topic is
+// ignored.
+func (p *Publisher) Publish(topic string, messages ...*message.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, msg := range messages {
+		event, err := codec.DecodeMessage(msg)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode event %s", msg.UUID)
+		}
+		line, err := codec.Encode(event, codec.FormatProtoJSON)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode event %s", event.EventId)
+		}
+
+		path := p.Path(event.GetRunId())
+		f, ok := p.files[path]
+		if !ok {
+			f = &lumberjack.Logger{
+				Filename:   path,
+				MaxSize:    p.maxSize,
+				MaxBackups: p.maxBackups,
+			}
+			p.files[path] = f
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return errors.Wrapf(err, "failed to write event log %s", path)
+		}
+	}
+	return nil
+}
+
+// Close closes the logs
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for path, f := range p.files {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = errors.Wrapf(closeErr, "failed to close event log %s", path)
+		}
+		delete(p.files, path)
+	}
+	return err
+}
@@ -0,0 +1,297 @@
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/go-go-agent/pkg/codec"
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/pkg/errors"
+)
+
+// backupTimeFormat is the format of the timestamps lumberjack adds to rotated files
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// DefaultPollInterval is how often a followed log is checked for new events
+const DefaultPollInterval = 250 * time.Millisecond
+
+// Files returns the rotated files of the log at path, oldest first, followed by path
+// if it exists
+func Files(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(path, ext) + "-"
+	matches, err := filepath.Glob(globEscape(prefix) + "*" + globEscape(ext))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list rotated files of %s", path)
+	}
+
+	type backup struct {
+		path string
+		t    time.Time
+	}
+	var backups []backup
+	for _, m := range matches {
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(m, prefix), ext))
+		if err != nil {
+			// The log of another run, whose ID starts with the ID of this run
+			continue
+		}
+		backups = append(backups, backup{path: m, t: t})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].t.Before(backups[j].t) })
+
+	var ret []string
+	for _, b := range backups {
+		ret = append(ret, b.path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		ret = append(ret, path)
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "failed to open event log %s", path)
+	}
+	return ret, nil
+}
+
+func globEscape(s string) string {
+	return strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`).Replace(s)
+}
+
+// Filter selects events by type and run. Empty lists select all events.
+type Filter struct {
+	// EventTypes are the types of the selected events, as named in model.Event, such
+	// as llm_call_completed
+	EventTypes []string
+	// RunIDs are the runs of the selected events
+	RunIDs []string
+}
+
+// Match returns true if event is selected by f
+func (f Filter) Match(event *events.Event) bool {
+	if len(f.EventTypes) > 0 && !slices.Contains(f.EventTypes, codec.EventTypeName(event.EventType)) {
+		return false
+	}
+	if len(f.RunIDs) > 0 && !slices.Contains(f.RunIDs, event.GetRunId()) {
+		return false
+	}
+	return true
+}
+
+// Read calls fn with the events of the log at path matching filter, oldest first,
+// starting with its rotated files
+func Read(path string, filter Filter, fn func(*events.Event) error) error {
+	files, err := Files(path)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.Errorf("event log %s does not exist", path)
+	}
+	for _, file := range files {
+		if err := readFile(file, filter, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile(path string, filter Filter, fn func(*events.Event) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open event log %s", path)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	l := &lineReader{path: path, r: bufio.NewReader(f)}
+	if err := l.readLines(filter, fn); err != nil {
+		return err
+	}
+	return l.finish(filter, fn)
+}
+
+// TailOptions configures Tail
+type TailOptions struct {
+	// Filter selects the events
+	Filter Filter
+	// Last only passes the last Last events of the log, 0 passes them all
+	Last int
+	// Follow waits for the events appended to the log, until the context is done
+	Follow bool
+	// PollInterval is how often a followed log is checked, DefaultPollInterval if 0
+	PollInterval time.Duration
+}
+
+// Tail calls fn with the events of the log at path, as selected by options. A followed
+// log that is rotated is followed in its new file, and a log that does not exist yet
+// is waited for.
+func Tail(ctx context.Context, path string, options TailOptions, fn func(*events.Event) error) error {
+	files, err := Files(path)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 && !options.Follow {
+		return errors.Errorf("event log %s does not exist", path)
+	}
+
+	// Keep the last events of the existing files, and pass them once they are read
+	emit := fn
+	var last []*events.Event
+	if options.Last > 0 {
+		emit = func(event *events.Event) error {
+			last = append(last, event)
+			if len(last) > options.Last {
+				last = last[1:]
+			}
+			return nil
+		}
+	}
+	flush := func() error {
+		for _, event := range last {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		last = nil
+		emit = fn
+		return nil
+	}
+
+	// The rotated files are complete, the log itself is followed
+	if len(files) > 0 && files[len(files)-1] == path {
+		files = files[:len(files)-1]
+	}
+	for _, file := range files {
+		if err := readFile(file, options.Filter, emit); err != nil {
+			return err
+		}
+	}
+
+	if !options.Follow {
+		if _, err := os.Stat(path); err == nil {
+			if err := readFile(path, options.Filter, emit); err != nil {
+				return err
+			}
+		}
+		return flush()
+	}
+
+	interval := options.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var f *os.File
+	var info os.FileInfo
+	var l *lineReader
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+		}
+	}()
+	for first := true; ; first = false {
+		if !first {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+
+		current, err := os.Stat(path)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to open event log %s", path)
+		}
+		if f != nil && (current == nil || !os.SameFile(info, current)) {
+			// The log was rotated, finish the rotated file before switching to the new log
+			if err := l.readLines(options.Filter, emit); err != nil {
+				return err
+			}
+			if err := l.finish(options.Filter, emit); err != nil {
+				return err
+			}
+			_ = f.Close()
+			f = nil
+		}
+		if f == nil && current != nil {
+			f, err = os.Open(path)
+			if err != nil {
+				return errors.Wrapf(err, "failed to open event log %s", path)
+			}
+			info = current
+			l = &lineReader{path: path, r: bufio.NewReader(f)}
+		}
+		if f != nil {
+			if err := l.readLines(options.Filter, emit); err != nil {
+				return err
+			}
+		}
+		if first {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// lineReader decodes the events of a log line by line, keeping the incomplete last
+// line of a log being written until it is complete
+type lineReader struct {
+	path    string
+	r       *bufio.Reader
+	line    int
+	partial []byte
+}
+
+// readLines calls fn with the events of the complete lines read until the end of the
+// log
+func (l *lineReader) readLines(filter Filter, fn func(*events.Event) error) error {
+	for {
+		b, err := l.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return errors.Wrapf(err, "failed to read event log %s", l.path)
+		}
+		l.partial = append(l.partial, b...)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err := l.decodeLine(filter, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// finish decodes the last line of a complete log, if it has no line break
+func (l *lineReader) finish(filter Filter, fn func(*events.Event) error) error {
+	if len(l.partial) == 0 {
+		return nil
+	}
+	return l.decodeLine(filter, fn)
+}
+
+func (l *lineReader) decodeLine(filter Filter, fn func(*events.Event) error) error {
+	line := bytes.TrimSpace(l.partial)
+	l.partial = nil
+	l.line++
+	if len(line) == 0 {
+		return nil
+	}
+	event, err := codec.Decode(line, codec.DetectFormat(line))
+	if err != nil {
+		return errors.Wrapf(err, "invalid event at %s:%d", l.path, l.line)
+	}
+	if !filter.Match(event) {
+		return nil
+	}
+	return fn(event)
+}
@@ -0,0 +1,62 @@
+package eventlog
+
+import (
+	"context"
+	"time"
+
+	events "github.com/go-go-golems/go-go-agent/proto"
+	"github.com/google/uuid"
+)
+
+// Replay calls publish with evs, at the pace they were emitted divided by speed: with
+// a speed of 2, a run that took a minute is replayed in 30 seconds. A speed of 0
+// publishes the events without waiting. Replay stops when ctx is done.
+func Replay(ctx context.Context, evs []*events.Event, speed float64, publish func(*events.Event) error) error {
+	start := time.Now()
+	var first time.Time
+	for _, event := range evs {
+		if speed > 0 && event.Timestamp != nil {
+			ts := event.Timestamp.AsTime()
+			if first.IsZero() {
+				first = ts
+			}
+			// Events are scheduled from the start of the replay, so that the time spent
+			// publishing does not add up
+			if wait := time.Until(start.Add(time.Duration(float64(ts.Sub(first)) / speed))); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := publish(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenewIDs gives evs new event IDs, and the events of each run a new run ID, so that a
+// run can be replayed next to the original one. It returns the new ID of each run.
+func RenewIDs(evs []*events.Event) map[string]string {
+	runIDs := map[string]string{}
+	for _, event := range evs {
+		event.EventId = uuid.New().String()
+		if event.RunId == nil {
+			continue
+		}
+		runID, ok := runIDs[*event.RunId]
+		if !ok {
+			runID = uuid.New().String()
+			runIDs[*event.RunId] = runID
+		}
+		event.RunId = &runID
+	}
+	return runIDs
+}
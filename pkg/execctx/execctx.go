@@ -0,0 +1,98 @@
+// Package execctx stores what an agent is executing, the run, step, node, action and
+// agent class, in a context.Context. Agents set it once per step, and the events
+// emitted by the LLM calls and tool calls of the step are attributed from it.
+package execctx
+
+import (
+	"context"
+)
+
+// ExecutionContext describes what an agent is executing. Empty fields are unknown.
+type ExecutionContext struct {
+	// RunID is the ID of the run
+	RunID string
+	// Step is the step of the agent, counted from 1. 0 outside of steps.
+	Step int
+	// NodeID is the node of the graph of the run executed by the step
+	NodeID string
+	// ActionName is the action of the step, such as a tool name
+	ActionName string
+	// AgentClass is the type of the agent, such as ReActAgent
+	AgentClass string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying ec
+func NewContext(ctx context.Context, ec ExecutionContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, ec)
+}
+
+// FromContext returns the ExecutionContext of ctx, empty if none is set
+func FromContext(ctx context.Context) ExecutionContext {
+	ec, _ := ctx.Value(contextKey{}).(ExecutionContext)
+	return ec
+}
+
+// WithRunID starts the execution context of run runID. The step, node, action and
+// agent class of the caller are cleared, so that a run started from within another
+// run is not attributed to the step of its parent.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return NewContext(ctx, ExecutionContext{RunID: runID})
+}
+
+// WithAgentClass returns a copy of ctx executed by an agent of type agentClass
+func WithAgentClass(ctx context.Context, agentClass string) context.Context {
+	ec := FromContext(ctx)
+	ec.AgentClass = agentClass
+	return NewContext(ctx, ec)
+}
+
+// WithStep returns a copy of ctx executing step on node nodeID. The action of the
+// previous step is cleared.
+func WithStep(ctx context.Context, step int, nodeID string) context.Context {
+	ec := FromContext(ctx)
+	ec.Step = step
+	ec.NodeID = nodeID
+	ec.ActionName = ""
+	return NewContext(ctx, ec)
+}
+
+// WithAction returns a copy of ctx executing actionName in the current step
+func WithAction(ctx context.Context, actionName string) context.Context {
+	ec := FromContext(ctx)
+	ec.ActionName = actionName
+	return NewContext(ctx, ec)
+}
+
+// StepPtr returns the step for the optional step fields of events, nil outside of steps
+func (ec ExecutionContext) StepPtr() *int32 {
+	if ec.Step <= 0 {
+		return nil
+	}
+	step := int32(ec.Step)
+	return &step
+}
+
+// NodeIDPtr returns the node ID for the optional node_id fields of events, nil if unknown
+func (ec ExecutionContext) NodeIDPtr() *string {
+	return stringPtr(ec.NodeID)
+}
+
+// ActionNamePtr returns the action for the optional action_name fields of events, nil
+// if unknown
+func (ec ExecutionContext) ActionNamePtr() *string {
+	return stringPtr(ec.ActionName)
+}
+
+// RunIDPtr returns the run ID as passed to the Emit methods of the EventBus, nil if unknown
+func (ec ExecutionContext) RunIDPtr() *string {
+	return stringPtr(ec.RunID)
+}
+
+func stringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
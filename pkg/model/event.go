@@ -15,22 +15,24 @@ type Event struct {
 
 // EventType constants
 const (
-	EventTypeRunStarted          = "run_started"
-	EventTypeRunFinished         = "run_finished"
-	EventTypeRunError            = "run_error"
-	EventTypeStepStarted         = "step_started"
-	EventTypeStepFinished        = "step_finished"
-	EventTypeNodeStatusChanged   = "node_status_changed"
-	EventTypeLLMCallStarted      = "llm_call_started"
-	EventTypeLLMCallCompleted    = "llm_call_completed"
-	EventTypeToolInvoked         = "tool_invoked"
-	EventTypeToolReturned        = "tool_returned"
-	EventTypeNodeCreated         = "node_created"
-	EventTypePlanReceived        = "plan_received"
-	EventTypeNodeAdded           = "node_added"
-	EventTypeEdgeAdded           = "edge_added"
-	EventTypeInnerGraphBuilt     = "inner_graph_built"
-	EventTypeNodeResultAvailable = "node_result_available"
+	EventTypeRunStarted            = "run_started"
+	EventTypeRunFinished           = "run_finished"
+	EventTypeRunError              = "run_error"
+	EventTypeStepStarted           = "step_started"
+	EventTypeStepFinished          = "step_finished"
+	EventTypeNodeStatusChanged     = "node_status_changed"
+	EventTypeLLMCallStarted        = "llm_call_started"
+	EventTypeLLMCallCompleted      = "llm_call_completed"
+	EventTypeLLMCallDelta          = "llm_call_delta"
+	EventTypeToolInvoked           = "tool_invoked"
+	EventTypeToolReturned          = "tool_returned"
+	EventTypeToolApprovalRequested = "tool_approval_requested"
+	EventTypeNodeCreated           = "node_created"
+	EventTypePlanReceived          = "plan_received"
+	EventTypeNodeAdded             = "node_added"
+	EventTypeEdgeAdded             = "edge_added"
+	EventTypeInnerGraphBuilt       = "inner_graph_built"
+	EventTypeNodeResultAvailable   = "node_result_available"
 )
 
 // EventPayload is an interface for event-specific payload types
@@ -157,6 +159,23 @@ func (p LLMCallCompletedPayload) GetType() string {
 	return EventTypeLLMCallCompleted
 }
 
+// LLMCallDeltaPayload represents the payload of a llm_call_delta event, carrying
+// partial output of a streaming LLM call
+type LLMCallDeltaPayload struct {
+	CallID            string `json:"call_id"`
+	AgentClass        string `json:"agent_class"`
+	Model             string `json:"model"`
+	Delta             string `json:"delta"`
+	Sequence          int    `json:"sequence"`
+	AccumulatedLength int    `json:"accumulated_length"`
+	Step              int    `json:"step,omitempty"`
+	NodeID            string `json:"node_id,omitempty"`
+}
+
+func (p LLMCallDeltaPayload) GetType() string {
+	return EventTypeLLMCallDelta
+}
+
 // ToolInvokedPayload represents the payload of a tool_invoked event
 type ToolInvokedPayload struct {
 	ToolName    string `json:"tool_name"`
@@ -172,6 +191,21 @@ func (p ToolInvokedPayload) GetType() string {
 	return EventTypeToolInvoked
 }
 
+// ToolApprovalRequestedPayload represents the payload of a tool_approval_requested event,
+// sent when a tool call waits for approval before it runs
+type ToolApprovalRequestedPayload struct {
+	ToolName    string `json:"tool_name"`
+	ToolCallID  string `json:"tool_call_id"`
+	ArgsSummary string `json:"args_summary"`
+	NodeID      string `json:"node_id,omitempty"`
+	Step        int    `json:"step,omitempty"`
+	AgentClass  string `json:"agent_class,omitempty"`
+}
+
+func (p ToolApprovalRequestedPayload) GetType() string {
+	return EventTypeToolApprovalRequested
+}
+
 // ToolReturnedPayload represents the payload of a tool_returned event
 type ToolReturnedPayload struct {
 	ToolName        string  `json:"tool_name"`
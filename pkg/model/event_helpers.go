@@ -114,6 +114,16 @@ func ToLLMCallCompletedPayload(data json.RawMessage) (LLMCallCompletedPayload, b
 	return payload, true
 }
 
+// ToLLMCallDeltaPayload converts raw JSON payload to LLMCallDeltaPayload
+func ToLLMCallDeltaPayload(data json.RawMessage) (LLMCallDeltaPayload, bool) {
+	var payload LLMCallDeltaPayload
+	err := json.Unmarshal(data, &payload)
+	if err != nil {
+		return LLMCallDeltaPayload{}, false
+	}
+	return payload, true
+}
+
 // ToToolInvokedPayload converts raw JSON payload to ToolInvokedPayload
 func ToToolInvokedPayload(data json.RawMessage) (ToolInvokedPayload, bool) {
 	var payload ToolInvokedPayload
@@ -124,6 +134,16 @@ func ToToolInvokedPayload(data json.RawMessage) (ToolInvokedPayload, bool) {
 	return payload, true
 }
 
+// ToToolApprovalRequestedPayload converts raw JSON payload to ToolApprovalRequestedPayload
+func ToToolApprovalRequestedPayload(data json.RawMessage) (ToolApprovalRequestedPayload, bool) {
+	var payload ToolApprovalRequestedPayload
+	err := json.Unmarshal(data, &payload)
+	if err != nil {
+		return ToolApprovalRequestedPayload{}, false
+	}
+	return payload, true
+}
+
 // ToToolReturnedPayload converts raw JSON payload to ToolReturnedPayload
 func ToToolReturnedPayload(data json.RawMessage) (ToolReturnedPayload, bool) {
 	var payload ToolReturnedPayload
@@ -0,0 +1,66 @@
+package pricing
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BudgetExceededError is returned by Budget.Charge once the cost of a run exceeds its budget
+type BudgetExceededError struct {
+	Spent   float64
+	MaxCost float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("cost budget exceeded: spent $%.4f of $%.4f", e.Spent, e.MaxCost)
+}
+
+// Budget tracks the cost of the LLM calls of a run against a maximum cost.
+// It is safe for concurrent use.
+type Budget struct {
+	mu       sync.Mutex
+	prices   *PriceTable
+	maxCost  float64
+	spent    float64
+	unpriced map[string]bool
+}
+
+// NewBudget creates a Budget. A maxCost of 0 only tracks spending.
+func NewBudget(prices *PriceTable, maxCost float64) *Budget {
+	return &Budget{
+		prices:   prices,
+		maxCost:  maxCost,
+		unpriced: map[string]bool{},
+	}
+}
+
+// Charge adds the cost of a call to model and returns it. Calls to models missing from
+// the price table cost nothing and are logged once per model.
+func (b *Budget) Charge(model string, promptTokens, completionTokens int) (float64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cost, ok := b.prices.Cost(model, promptTokens, completionTokens)
+	if !ok {
+		if !b.unpriced[model] {
+			log.Warn().Str("model", model).Msg("No price configured for model, its calls are not counted against the budget")
+			b.unpriced[model] = true
+		}
+		return 0, nil
+	}
+
+	b.spent += cost
+	if b.maxCost > 0 && b.spent > b.maxCost {
+		return cost, &BudgetExceededError{Spent: b.spent, MaxCost: b.maxCost}
+	}
+	return cost, nil
+}
+
+// Spent returns the total cost charged so far
+func (b *Budget) Spent() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
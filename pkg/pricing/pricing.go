@@ -0,0 +1,108 @@
+// Package pricing computes the cost of LLM calls from their token usage.
+package pricing
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed prices.yaml
+var defaultPricesYAML []byte
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Prompt     float64 `yaml:"prompt"`
+	Completion float64 `yaml:"completion"`
+}
+
+// PriceTable maps model strings ("apiType/engine", as reported in llm_call_completed
+// events) to prices. Keys are prefixes: a key matches every model string starting with
+// it, and keys without an api type match the engine of any provider. The longest
+// matching key wins.
+type PriceTable struct {
+	Models map[string]ModelPrice `yaml:"models"`
+}
+
+// ParsePriceTable parses a price table from YAML
+func ParsePriceTable(b []byte) (*PriceTable, error) {
+	t := &PriceTable{}
+	if err := yaml.Unmarshal(b, t); err != nil {
+		return nil, errors.Wrap(err, "failed to parse price table")
+	}
+	if t.Models == nil {
+		t.Models = map[string]ModelPrice{}
+	}
+	return t, nil
+}
+
+// LoadPriceTable loads a price table from a YAML file
+func LoadPriceTable(path string) (*PriceTable, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read price file %s", path)
+	}
+	return ParsePriceTable(b)
+}
+
+var (
+	defaultTable     *PriceTable
+	defaultTableOnce sync.Once
+)
+
+// DefaultPriceTable returns the built-in price table
+func DefaultPriceTable() *PriceTable {
+	defaultTableOnce.Do(func() {
+		t, err := ParsePriceTable(defaultPricesYAML)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in price table: %v", err))
+		}
+		defaultTable = t
+	})
+	return defaultTable
+}
+
+// LoadPriceTableOrDefault loads the price table at path, or returns the built-in
+// table if path is empty.
+func LoadPriceTableOrDefault(path string) (*PriceTable, error) {
+	if path == "" {
+		return DefaultPriceTable(), nil
+	}
+	return LoadPriceTable(path)
+}
+
+// Lookup returns the price of model
+func (t *PriceTable) Lookup(model string) (ModelPrice, bool) {
+	engine := model
+	if i := strings.Index(model, "/"); i >= 0 {
+		engine = model[i+1:]
+	}
+
+	var best ModelPrice
+	bestLen := -1
+	for key, price := range t.Models {
+		target := engine
+		if strings.Contains(key, "/") {
+			target = model
+		}
+		if strings.HasPrefix(target, key) && len(key) > bestLen {
+			best = price
+			bestLen = len(key)
+		}
+	}
+	return best, bestLen >= 0
+}
+
+// Cost returns the cost in USD of a call to model, and false if the model has no price.
+func (t *PriceTable) Cost(model string, promptTokens, completionTokens int) (float64, bool) {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1_000_000, true
+}
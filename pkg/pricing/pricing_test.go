@@ -0,0 +1,103 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+const testPrices = `
+models:
+  openai/gpt-4o:
+    prompt: 2.50
+    completion: 10.00
+  openai/gpt-4o-mini:
+    prompt: 0.15
+    completion: 0.60
+  llama3:
+    prompt: 0.10
+    completion: 0.20
+`
+
+func TestPriceTableLookup(t *testing.T) {
+	table, err := ParsePriceTable([]byte(testPrices))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		model    string
+		found    bool
+		expected ModelPrice
+	}{
+		{"openai/gpt-4o", true, ModelPrice{Prompt: 2.50, Completion: 10.00}},
+		// Keys are prefixes of dated model versions
+		{"openai/gpt-4o-2024-08-06", true, ModelPrice{Prompt: 2.50, Completion: 10.00}},
+		// The longest matching key wins
+		{"openai/gpt-4o-mini-2024-07-18", true, ModelPrice{Prompt: 0.15, Completion: 0.60}},
+		// Keys without api type match the engine of any provider
+		{"ollama/llama3:8b", true, ModelPrice{Prompt: 0.10, Completion: 0.20}},
+		{"llama3", true, ModelPrice{Prompt: 0.10, Completion: 0.20}},
+		// Keys with api type only match that provider
+		{"azure/gpt-4o", false, ModelPrice{}},
+		{"claude/claude-3-opus", false, ModelPrice{}},
+	}
+	for _, tc := range testCases {
+		price, found := table.Lookup(tc.model)
+		if found != tc.found || price != tc.expected {
+			t.Errorf("Lookup(%s) = %+v, %v, want %+v, %v", tc.model, price, found, tc.expected, tc.found)
+		}
+	}
+
+	cost, ok := table.Cost("openai/gpt-4o", 1000, 500)
+	if !ok || math.Abs(cost-0.0075) > 1e-9 {
+		t.Errorf("Cost() = %f, %v, want 0.0075", cost, ok)
+	}
+}
+
+func TestDefaultPriceTable(t *testing.T) {
+	for _, model := range []string{"openai/gpt-4o-mini", "claude/claude-3-5-sonnet-20241022"} {
+		if _, ok := DefaultPriceTable().Lookup(model); !ok {
+			t.Errorf("built-in price table has no price for %s", model)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	table, err := ParsePriceTable([]byte(testPrices))
+	if err != nil {
+		t.Fatal(err)
+	}
+	budget := NewBudget(table, 0.02)
+
+	// 1000 prompt and 500 completion tokens of gpt-4o cost $0.0075
+	for i := 0; i < 2; i++ {
+		if _, err := budget.Charge("openai/gpt-4o", 1000, 500); err != nil {
+			t.Fatalf("call %d exceeded the budget: %v", i+1, err)
+		}
+	}
+	// Models without a price are not counted
+	if cost, err := budget.Charge("local/unknown", 1_000_000, 1_000_000); cost != 0 || err != nil {
+		t.Errorf("unpriced call cost %f, %v, want 0", cost, err)
+	}
+
+	_, err = budget.Charge("openai/gpt-4o", 1000, 500)
+	var exceeded *BudgetExceededError
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("third call error = %v, want a BudgetExceededError", err)
+	}
+	if math.Abs(exceeded.Spent-0.0225) > 1e-9 || exceeded.MaxCost != 0.02 {
+		t.Errorf("exceeded error = %+v, want $0.0225 spent of $0.02", exceeded)
+	}
+
+	// Without a maximum cost, spending is only tracked
+	unlimited := NewBudget(table, 0)
+	for i := 0; i < 10; i++ {
+		if _, err := unlimited.Charge("openai/gpt-4o", 1000, 500); err != nil {
+			t.Fatalf("budget without maximum cost failed: %v", err)
+		}
+	}
+	if math.Abs(unlimited.Spent()-0.075) > 1e-9 {
+		t.Errorf("Spent() = %f, want 0.075", unlimited.Spent())
+	}
+}
@@ -27,23 +27,25 @@ const (
 type EventType int32
 
 const (
-	EventType_EVENT_TYPE_UNSPECIFIED           EventType = 0
-	EventType_EVENT_TYPE_STEP_STARTED          EventType = 1
-	EventType_EVENT_TYPE_STEP_FINISHED         EventType = 2
-	EventType_EVENT_TYPE_NODE_STATUS_CHANGED   EventType = 3
-	EventType_EVENT_TYPE_LLM_CALL_STARTED      EventType = 4
-	EventType_EVENT_TYPE_LLM_CALL_COMPLETED    EventType = 5
-	EventType_EVENT_TYPE_TOOL_INVOKED          EventType = 6
-	EventType_EVENT_TYPE_TOOL_RETURNED         EventType = 7
-	EventType_EVENT_TYPE_NODE_CREATED          EventType = 8
-	EventType_EVENT_TYPE_PLAN_RECEIVED         EventType = 9
-	EventType_EVENT_TYPE_NODE_ADDED            EventType = 10
-	EventType_EVENT_TYPE_EDGE_ADDED            EventType = 11
-	EventType_EVENT_TYPE_INNER_GRAPH_BUILT     EventType = 12
-	EventType_EVENT_TYPE_NODE_RESULT_AVAILABLE EventType = 13
-	EventType_EVENT_TYPE_RUN_STARTED           EventType = 14
-	EventType_EVENT_TYPE_RUN_FINISHED          EventType = 15
-	EventType_EVENT_TYPE_RUN_ERROR             EventType = 16
+	EventType_EVENT_TYPE_UNSPECIFIED             EventType = 0
+	EventType_EVENT_TYPE_STEP_STARTED            EventType = 1
+	EventType_EVENT_TYPE_STEP_FINISHED           EventType = 2
+	EventType_EVENT_TYPE_NODE_STATUS_CHANGED     EventType = 3
+	EventType_EVENT_TYPE_LLM_CALL_STARTED        EventType = 4
+	EventType_EVENT_TYPE_LLM_CALL_COMPLETED      EventType = 5
+	EventType_EVENT_TYPE_TOOL_INVOKED            EventType = 6
+	EventType_EVENT_TYPE_TOOL_RETURNED           EventType = 7
+	EventType_EVENT_TYPE_NODE_CREATED            EventType = 8
+	EventType_EVENT_TYPE_PLAN_RECEIVED           EventType = 9
+	EventType_EVENT_TYPE_NODE_ADDED              EventType = 10
+	EventType_EVENT_TYPE_EDGE_ADDED              EventType = 11
+	EventType_EVENT_TYPE_INNER_GRAPH_BUILT       EventType = 12
+	EventType_EVENT_TYPE_NODE_RESULT_AVAILABLE   EventType = 13
+	EventType_EVENT_TYPE_RUN_STARTED             EventType = 14
+	EventType_EVENT_TYPE_RUN_FINISHED            EventType = 15
+	EventType_EVENT_TYPE_RUN_ERROR               EventType = 16
+	EventType_EVENT_TYPE_LLM_CALL_DELTA          EventType = 17
+	EventType_EVENT_TYPE_TOOL_APPROVAL_REQUESTED EventType = 18
 )
 
 // Enum value maps for EventType.
@@ -66,25 +68,29 @@ var (
 		14: "EVENT_TYPE_RUN_STARTED",
 		15: "EVENT_TYPE_RUN_FINISHED",
 		16: "EVENT_TYPE_RUN_ERROR",
+		17: "EVENT_TYPE_LLM_CALL_DELTA",
+		18: "EVENT_TYPE_TOOL_APPROVAL_REQUESTED",
 	}
 	EventType_value = map[string]int32{
-		"EVENT_TYPE_UNSPECIFIED":           0,
-		"EVENT_TYPE_STEP_STARTED":          1,
-		"EVENT_TYPE_STEP_FINISHED":         2,
-		"EVENT_TYPE_NODE_STATUS_CHANGED":   3,
-		"EVENT_TYPE_LLM_CALL_STARTED":      4,
-		"EVENT_TYPE_LLM_CALL_COMPLETED":    5,
-		"EVENT_TYPE_TOOL_INVOKED":          6,
-		"EVENT_TYPE_TOOL_RETURNED":         7,
-		"EVENT_TYPE_NODE_CREATED":          8,
-		"EVENT_TYPE_PLAN_RECEIVED":         9,
-		"EVENT_TYPE_NODE_ADDED":            10,
-		"EVENT_TYPE_EDGE_ADDED":            11,
-		"EVENT_TYPE_INNER_GRAPH_BUILT":     12,
-		"EVENT_TYPE_NODE_RESULT_AVAILABLE": 13,
-		"EVENT_TYPE_RUN_STARTED":           14,
-		"EVENT_TYPE_RUN_FINISHED":          15,
-		"EVENT_TYPE_RUN_ERROR":             16,
+		"EVENT_TYPE_UNSPECIFIED":             0,
+		"EVENT_TYPE_STEP_STARTED":            1,
+		"EVENT_TYPE_STEP_FINISHED":           2,
+		"EVENT_TYPE_NODE_STATUS_CHANGED":     3,
+		"EVENT_TYPE_LLM_CALL_STARTED":        4,
+		"EVENT_TYPE_LLM_CALL_COMPLETED":      5,
+		"EVENT_TYPE_TOOL_INVOKED":            6,
+		"EVENT_TYPE_TOOL_RETURNED":           7,
+		"EVENT_TYPE_NODE_CREATED":            8,
+		"EVENT_TYPE_PLAN_RECEIVED":           9,
+		"EVENT_TYPE_NODE_ADDED":              10,
+		"EVENT_TYPE_EDGE_ADDED":              11,
+		"EVENT_TYPE_INNER_GRAPH_BUILT":       12,
+		"EVENT_TYPE_NODE_RESULT_AVAILABLE":   13,
+		"EVENT_TYPE_RUN_STARTED":             14,
+		"EVENT_TYPE_RUN_FINISHED":            15,
+		"EVENT_TYPE_RUN_ERROR":               16,
+		"EVENT_TYPE_LLM_CALL_DELTA":          17,
+		"EVENT_TYPE_TOOL_APPROVAL_REQUESTED": 18,
 	}
 )
 
@@ -739,6 +745,107 @@ func (x *LlmCallCompletedPayload) GetCallId() string {
 	return ""
 }
 
+// LlmCallDeltaPayload - Streamed partial output of an LLM call
+type LlmCallDeltaPayload struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	CallId            string                 `protobuf:"bytes,1,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	AgentClass        string                 `protobuf:"bytes,2,opt,name=agent_class,json=agentClass,proto3" json:"agent_class,omitempty"`
+	Model             string                 `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	Delta             string                 `protobuf:"bytes,4,opt,name=delta,proto3" json:"delta,omitempty"`
+	Sequence          int32                  `protobuf:"varint,5,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	AccumulatedLength int32                  `protobuf:"varint,6,opt,name=accumulated_length,json=accumulatedLength,proto3" json:"accumulated_length,omitempty"`
+	Step              *int32                 `protobuf:"varint,7,opt,name=step,proto3,oneof" json:"step,omitempty"`
+	NodeId            *string                `protobuf:"bytes,8,opt,name=node_id,json=nodeId,proto3,oneof" json:"node_id,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *LlmCallDeltaPayload) Reset() {
+	*x = LlmCallDeltaPayload{}
+	mi := &file_proto_events_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LlmCallDeltaPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LlmCallDeltaPayload) ProtoMessage() {}
+
+func (x *LlmCallDeltaPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_events_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LlmCallDeltaPayload.ProtoReflect.Descriptor instead.
+func (*LlmCallDeltaPayload) Descriptor() ([]byte, []int) {
+	return file_proto_events_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LlmCallDeltaPayload) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *LlmCallDeltaPayload) GetAgentClass() string {
+	if x != nil {
+		return x.AgentClass
+	}
+	return ""
+}
+
+func (x *LlmCallDeltaPayload) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *LlmCallDeltaPayload) GetDelta() string {
+	if x != nil {
+		return x.Delta
+	}
+	return ""
+}
+
+func (x *LlmCallDeltaPayload) GetSequence() int32 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *LlmCallDeltaPayload) GetAccumulatedLength() int32 {
+	if x != nil {
+		return x.AccumulatedLength
+	}
+	return 0
+}
+
+func (x *LlmCallDeltaPayload) GetStep() int32 {
+	if x != nil && x.Step != nil {
+		return *x.Step
+	}
+	return 0
+}
+
+func (x *LlmCallDeltaPayload) GetNodeId() string {
+	if x != nil && x.NodeId != nil {
+		return *x.NodeId
+	}
+	return ""
+}
+
 // ToolInvokedPayload - Tool is invoked
 type ToolInvokedPayload struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -755,7 +862,7 @@ type ToolInvokedPayload struct {
 
 func (x *ToolInvokedPayload) Reset() {
 	*x = ToolInvokedPayload{}
-	mi := &file_proto_events_proto_msgTypes[7]
+	mi := &file_proto_events_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -767,7 +874,7 @@ func (x *ToolInvokedPayload) String() string {
 func (*ToolInvokedPayload) ProtoMessage() {}
 
 func (x *ToolInvokedPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[7]
+	mi := &file_proto_events_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -780,7 +887,7 @@ func (x *ToolInvokedPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolInvokedPayload.ProtoReflect.Descriptor instead.
 func (*ToolInvokedPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{7}
+	return file_proto_events_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ToolInvokedPayload) GetToolName() string {
@@ -832,6 +939,91 @@ func (x *ToolInvokedPayload) GetToolCallId() string {
 	return ""
 }
 
+// ToolApprovalRequestedPayload - A tool call waits for approval before it runs
+type ToolApprovalRequestedPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ToolName      string                 `protobuf:"bytes,1,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	ToolCallId    string                 `protobuf:"bytes,2,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
+	ArgsSummary   string                 `protobuf:"bytes,3,opt,name=args_summary,json=argsSummary,proto3" json:"args_summary,omitempty"`
+	NodeId        *string                `protobuf:"bytes,4,opt,name=node_id,json=nodeId,proto3,oneof" json:"node_id,omitempty"`
+	Step          *int32                 `protobuf:"varint,5,opt,name=step,proto3,oneof" json:"step,omitempty"`
+	AgentClass    *string                `protobuf:"bytes,6,opt,name=agent_class,json=agentClass,proto3,oneof" json:"agent_class,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolApprovalRequestedPayload) Reset() {
+	*x = ToolApprovalRequestedPayload{}
+	mi := &file_proto_events_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolApprovalRequestedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolApprovalRequestedPayload) ProtoMessage() {}
+
+func (x *ToolApprovalRequestedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_events_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolApprovalRequestedPayload.ProtoReflect.Descriptor instead.
+func (*ToolApprovalRequestedPayload) Descriptor() ([]byte, []int) {
+	return file_proto_events_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ToolApprovalRequestedPayload) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *ToolApprovalRequestedPayload) GetToolCallId() string {
+	if x != nil {
+		return x.ToolCallId
+	}
+	return ""
+}
+
+func (x *ToolApprovalRequestedPayload) GetArgsSummary() string {
+	if x != nil {
+		return x.ArgsSummary
+	}
+	return ""
+}
+
+func (x *ToolApprovalRequestedPayload) GetNodeId() string {
+	if x != nil && x.NodeId != nil {
+		return *x.NodeId
+	}
+	return ""
+}
+
+func (x *ToolApprovalRequestedPayload) GetStep() int32 {
+	if x != nil && x.Step != nil {
+		return *x.Step
+	}
+	return 0
+}
+
+func (x *ToolApprovalRequestedPayload) GetAgentClass() string {
+	if x != nil && x.AgentClass != nil {
+		return *x.AgentClass
+	}
+	return ""
+}
+
 // ToolReturnedPayload - Tool returns
 type ToolReturnedPayload struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
@@ -851,7 +1043,7 @@ type ToolReturnedPayload struct {
 
 func (x *ToolReturnedPayload) Reset() {
 	*x = ToolReturnedPayload{}
-	mi := &file_proto_events_proto_msgTypes[8]
+	mi := &file_proto_events_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -863,7 +1055,7 @@ func (x *ToolReturnedPayload) String() string {
 func (*ToolReturnedPayload) ProtoMessage() {}
 
 func (x *ToolReturnedPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[8]
+	mi := &file_proto_events_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -876,7 +1068,7 @@ func (x *ToolReturnedPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolReturnedPayload.ProtoReflect.Descriptor instead.
 func (*ToolReturnedPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{8}
+	return file_proto_events_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ToolReturnedPayload) GetToolName() string {
@@ -968,7 +1160,7 @@ type NodeCreatedPayload struct {
 
 func (x *NodeCreatedPayload) Reset() {
 	*x = NodeCreatedPayload{}
-	mi := &file_proto_events_proto_msgTypes[9]
+	mi := &file_proto_events_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -980,7 +1172,7 @@ func (x *NodeCreatedPayload) String() string {
 func (*NodeCreatedPayload) ProtoMessage() {}
 
 func (x *NodeCreatedPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[9]
+	mi := &file_proto_events_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -993,7 +1185,7 @@ func (x *NodeCreatedPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NodeCreatedPayload.ProtoReflect.Descriptor instead.
 func (*NodeCreatedPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{9}
+	return file_proto_events_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *NodeCreatedPayload) GetNodeId() string {
@@ -1080,7 +1272,7 @@ type PlanReceivedPayload struct {
 
 func (x *PlanReceivedPayload) Reset() {
 	*x = PlanReceivedPayload{}
-	mi := &file_proto_events_proto_msgTypes[10]
+	mi := &file_proto_events_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1092,7 +1284,7 @@ func (x *PlanReceivedPayload) String() string {
 func (*PlanReceivedPayload) ProtoMessage() {}
 
 func (x *PlanReceivedPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[10]
+	mi := &file_proto_events_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1105,7 +1297,7 @@ func (x *PlanReceivedPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlanReceivedPayload.ProtoReflect.Descriptor instead.
 func (*PlanReceivedPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{10}
+	return file_proto_events_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *PlanReceivedPayload) GetNodeId() string {
@@ -1158,7 +1350,7 @@ type NodeAddedPayload struct {
 
 func (x *NodeAddedPayload) Reset() {
 	*x = NodeAddedPayload{}
-	mi := &file_proto_events_proto_msgTypes[11]
+	mi := &file_proto_events_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1170,7 +1362,7 @@ func (x *NodeAddedPayload) String() string {
 func (*NodeAddedPayload) ProtoMessage() {}
 
 func (x *NodeAddedPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[11]
+	mi := &file_proto_events_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1183,7 +1375,7 @@ func (x *NodeAddedPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NodeAddedPayload.ProtoReflect.Descriptor instead.
 func (*NodeAddedPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{11}
+	return file_proto_events_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *NodeAddedPayload) GetGraphOwnerNodeId() string {
@@ -1245,7 +1437,7 @@ type EdgeAddedPayload struct {
 
 func (x *EdgeAddedPayload) Reset() {
 	*x = EdgeAddedPayload{}
-	mi := &file_proto_events_proto_msgTypes[12]
+	mi := &file_proto_events_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1257,7 +1449,7 @@ func (x *EdgeAddedPayload) String() string {
 func (*EdgeAddedPayload) ProtoMessage() {}
 
 func (x *EdgeAddedPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[12]
+	mi := &file_proto_events_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1270,7 +1462,7 @@ func (x *EdgeAddedPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EdgeAddedPayload.ProtoReflect.Descriptor instead.
 func (*EdgeAddedPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{12}
+	return file_proto_events_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *EdgeAddedPayload) GetGraphOwnerNodeId() string {
@@ -1345,7 +1537,7 @@ type InnerGraphBuiltPayload struct {
 
 func (x *InnerGraphBuiltPayload) Reset() {
 	*x = InnerGraphBuiltPayload{}
-	mi := &file_proto_events_proto_msgTypes[13]
+	mi := &file_proto_events_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1357,7 +1549,7 @@ func (x *InnerGraphBuiltPayload) String() string {
 func (*InnerGraphBuiltPayload) ProtoMessage() {}
 
 func (x *InnerGraphBuiltPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[13]
+	mi := &file_proto_events_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1370,7 +1562,7 @@ func (x *InnerGraphBuiltPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InnerGraphBuiltPayload.ProtoReflect.Descriptor instead.
 func (*InnerGraphBuiltPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{13}
+	return file_proto_events_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *InnerGraphBuiltPayload) GetNodeId() string {
@@ -1437,7 +1629,7 @@ type NodeResultAvailablePayload struct {
 
 func (x *NodeResultAvailablePayload) Reset() {
 	*x = NodeResultAvailablePayload{}
-	mi := &file_proto_events_proto_msgTypes[14]
+	mi := &file_proto_events_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1449,7 +1641,7 @@ func (x *NodeResultAvailablePayload) String() string {
 func (*NodeResultAvailablePayload) ProtoMessage() {}
 
 func (x *NodeResultAvailablePayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[14]
+	mi := &file_proto_events_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1462,7 +1654,7 @@ func (x *NodeResultAvailablePayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NodeResultAvailablePayload.ProtoReflect.Descriptor instead.
 func (*NodeResultAvailablePayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{14}
+	return file_proto_events_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *NodeResultAvailablePayload) GetNodeId() string {
@@ -1520,7 +1712,7 @@ type RunStartedPayload struct {
 
 func (x *RunStartedPayload) Reset() {
 	*x = RunStartedPayload{}
-	mi := &file_proto_events_proto_msgTypes[15]
+	mi := &file_proto_events_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1532,7 +1724,7 @@ func (x *RunStartedPayload) String() string {
 func (*RunStartedPayload) ProtoMessage() {}
 
 func (x *RunStartedPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[15]
+	mi := &file_proto_events_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1545,7 +1737,7 @@ func (x *RunStartedPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RunStartedPayload.ProtoReflect.Descriptor instead.
 func (*RunStartedPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{15}
+	return file_proto_events_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *RunStartedPayload) GetInputData() *structpb.Struct {
@@ -1593,7 +1785,7 @@ type RunFinishedPayload struct {
 
 func (x *RunFinishedPayload) Reset() {
 	*x = RunFinishedPayload{}
-	mi := &file_proto_events_proto_msgTypes[16]
+	mi := &file_proto_events_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1605,7 +1797,7 @@ func (x *RunFinishedPayload) String() string {
 func (*RunFinishedPayload) ProtoMessage() {}
 
 func (x *RunFinishedPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[16]
+	mi := &file_proto_events_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1618,7 +1810,7 @@ func (x *RunFinishedPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RunFinishedPayload.ProtoReflect.Descriptor instead.
 func (*RunFinishedPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{16}
+	return file_proto_events_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *RunFinishedPayload) GetTotalSteps() int32 {
@@ -1692,7 +1884,7 @@ type RunErrorPayload struct {
 
 func (x *RunErrorPayload) Reset() {
 	*x = RunErrorPayload{}
-	mi := &file_proto_events_proto_msgTypes[17]
+	mi := &file_proto_events_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1704,7 +1896,7 @@ func (x *RunErrorPayload) String() string {
 func (*RunErrorPayload) ProtoMessage() {}
 
 func (x *RunErrorPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[17]
+	mi := &file_proto_events_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1717,7 +1909,7 @@ func (x *RunErrorPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RunErrorPayload.ProtoReflect.Descriptor instead.
 func (*RunErrorPayload) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{17}
+	return file_proto_events_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *RunErrorPayload) GetErrorType() string {
@@ -1789,6 +1981,8 @@ type Event struct {
 	//	*Event_RunStarted
 	//	*Event_RunFinished
 	//	*Event_RunError
+	//	*Event_LlmCallDelta
+	//	*Event_ToolApprovalRequested
 	//	*Event_UnknownPayload
 	Payload       isEvent_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
@@ -1797,7 +1991,7 @@ type Event struct {
 
 func (x *Event) Reset() {
 	*x = Event{}
-	mi := &file_proto_events_proto_msgTypes[18]
+	mi := &file_proto_events_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1809,7 +2003,7 @@ func (x *Event) String() string {
 func (*Event) ProtoMessage() {}
 
 func (x *Event) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[18]
+	mi := &file_proto_events_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1822,7 +2016,7 @@ func (x *Event) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Event.ProtoReflect.Descriptor instead.
 func (*Event) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{18}
+	return file_proto_events_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *Event) GetEventId() string {
@@ -2004,6 +2198,24 @@ func (x *Event) GetRunError() *RunErrorPayload {
 	return nil
 }
 
+func (x *Event) GetLlmCallDelta() *LlmCallDeltaPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Event_LlmCallDelta); ok {
+			return x.LlmCallDelta
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetToolApprovalRequested() *ToolApprovalRequestedPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Event_ToolApprovalRequested); ok {
+			return x.ToolApprovalRequested
+		}
+	}
+	return nil
+}
+
 func (x *Event) GetUnknownPayload() *structpb.Struct {
 	if x != nil {
 		if x, ok := x.Payload.(*Event_UnknownPayload); ok {
@@ -2081,6 +2293,14 @@ type Event_RunError struct {
 	RunError *RunErrorPayload `protobuf:"bytes,20,opt,name=run_error,json=runError,proto3,oneof"`
 }
 
+type Event_LlmCallDelta struct {
+	LlmCallDelta *LlmCallDeltaPayload `protobuf:"bytes,21,opt,name=llm_call_delta,json=llmCallDelta,proto3,oneof"`
+}
+
+type Event_ToolApprovalRequested struct {
+	ToolApprovalRequested *ToolApprovalRequestedPayload `protobuf:"bytes,22,opt,name=tool_approval_requested,json=toolApprovalRequested,proto3,oneof"`
+}
+
 type Event_UnknownPayload struct {
 	// For unknown event types
 	UnknownPayload *structpb.Struct `protobuf:"bytes,100,opt,name=unknown_payload,json=unknownPayload,proto3,oneof"`
@@ -2118,6 +2338,10 @@ func (*Event_RunFinished) isEvent_Payload() {}
 
 func (*Event_RunError) isEvent_Payload() {}
 
+func (*Event_LlmCallDelta) isEvent_Payload() {}
+
+func (*Event_ToolApprovalRequested) isEvent_Payload() {}
+
 func (*Event_UnknownPayload) isEvent_Payload() {}
 
 // EventsResponse - Response from the events API
@@ -2131,7 +2355,7 @@ type EventsResponse struct {
 
 func (x *EventsResponse) Reset() {
 	*x = EventsResponse{}
-	mi := &file_proto_events_proto_msgTypes[19]
+	mi := &file_proto_events_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2143,7 +2367,7 @@ func (x *EventsResponse) String() string {
 func (*EventsResponse) ProtoMessage() {}
 
 func (x *EventsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[19]
+	mi := &file_proto_events_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2156,7 +2380,7 @@ func (x *EventsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EventsResponse.ProtoReflect.Descriptor instead.
 func (*EventsResponse) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{19}
+	return file_proto_events_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *EventsResponse) GetStatus() ConnectionStatus {
@@ -2183,7 +2407,7 @@ type RunFinishedPayload_TokenUsageSummary struct {
 
 func (x *RunFinishedPayload_TokenUsageSummary) Reset() {
 	*x = RunFinishedPayload_TokenUsageSummary{}
-	mi := &file_proto_events_proto_msgTypes[20]
+	mi := &file_proto_events_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2195,7 +2419,7 @@ func (x *RunFinishedPayload_TokenUsageSummary) String() string {
 func (*RunFinishedPayload_TokenUsageSummary) ProtoMessage() {}
 
 func (x *RunFinishedPayload_TokenUsageSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[20]
+	mi := &file_proto_events_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2208,7 +2432,7 @@ func (x *RunFinishedPayload_TokenUsageSummary) ProtoReflect() protoreflect.Messa
 
 // Deprecated: Use RunFinishedPayload_TokenUsageSummary.ProtoReflect.Descriptor instead.
 func (*RunFinishedPayload_TokenUsageSummary) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{16, 0}
+	return file_proto_events_proto_rawDescGZIP(), []int{18, 0}
 }
 
 func (x *RunFinishedPayload_TokenUsageSummary) GetTotalPromptTokens() int32 {
@@ -2236,7 +2460,7 @@ type RunFinishedPayload_NodeStatistics struct {
 
 func (x *RunFinishedPayload_NodeStatistics) Reset() {
 	*x = RunFinishedPayload_NodeStatistics{}
-	mi := &file_proto_events_proto_msgTypes[21]
+	mi := &file_proto_events_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2248,7 +2472,7 @@ func (x *RunFinishedPayload_NodeStatistics) String() string {
 func (*RunFinishedPayload_NodeStatistics) ProtoMessage() {}
 
 func (x *RunFinishedPayload_NodeStatistics) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[21]
+	mi := &file_proto_events_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2261,7 +2485,7 @@ func (x *RunFinishedPayload_NodeStatistics) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use RunFinishedPayload_NodeStatistics.ProtoReflect.Descriptor instead.
 func (*RunFinishedPayload_NodeStatistics) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{16, 1}
+	return file_proto_events_proto_rawDescGZIP(), []int{18, 1}
 }
 
 func (x *RunFinishedPayload_NodeStatistics) GetTotalCreated() int32 {
@@ -2296,7 +2520,7 @@ type RunFinishedPayload_SearchStatistics struct {
 
 func (x *RunFinishedPayload_SearchStatistics) Reset() {
 	*x = RunFinishedPayload_SearchStatistics{}
-	mi := &file_proto_events_proto_msgTypes[22]
+	mi := &file_proto_events_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2308,7 +2532,7 @@ func (x *RunFinishedPayload_SearchStatistics) String() string {
 func (*RunFinishedPayload_SearchStatistics) ProtoMessage() {}
 
 func (x *RunFinishedPayload_SearchStatistics) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[22]
+	mi := &file_proto_events_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2321,7 +2545,7 @@ func (x *RunFinishedPayload_SearchStatistics) ProtoReflect() protoreflect.Messag
 
 // Deprecated: Use RunFinishedPayload_SearchStatistics.ProtoReflect.Descriptor instead.
 func (*RunFinishedPayload_SearchStatistics) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{16, 2}
+	return file_proto_events_proto_rawDescGZIP(), []int{18, 2}
 }
 
 func (x *RunFinishedPayload_SearchStatistics) GetTotalSearches() int32 {
@@ -2357,7 +2581,7 @@ type RunErrorPayload_Context struct {
 
 func (x *RunErrorPayload_Context) Reset() {
 	*x = RunErrorPayload_Context{}
-	mi := &file_proto_events_proto_msgTypes[24]
+	mi := &file_proto_events_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2369,7 +2593,7 @@ func (x *RunErrorPayload_Context) String() string {
 func (*RunErrorPayload_Context) ProtoMessage() {}
 
 func (x *RunErrorPayload_Context) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_events_proto_msgTypes[24]
+	mi := &file_proto_events_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2382,7 +2606,7 @@ func (x *RunErrorPayload_Context) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RunErrorPayload_Context.ProtoReflect.Descriptor instead.
 func (*RunErrorPayload_Context) Descriptor() ([]byte, []int) {
-	return file_proto_events_proto_rawDescGZIP(), []int{17, 0}
+	return file_proto_events_proto_rawDescGZIP(), []int{19, 0}
 }
 
 func (x *RunErrorPayload_Context) GetLastSuccessfulStep() int32 {
@@ -2488,7 +2712,20 @@ const file_proto_events_proto_rawDesc = "" +
 	"\n" +
 	"\b_node_idB\x0e\n" +
 	"\f_token_usageB\x0e\n" +
-	"\f_action_name\"\x93\x02\n" +
+	"\f_action_name\"\x92\x02\n" +
+	"\x13LlmCallDeltaPayload\x12\x17\n" +
+	"\acall_id\x18\x01 \x01(\tR\x06callId\x12\x1f\n" +
+	"\vagent_class\x18\x02 \x01(\tR\n" +
+	"agentClass\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05model\x12\x14\n" +
+	"\x05delta\x18\x04 \x01(\tR\x05delta\x12\x1a\n" +
+	"\bsequence\x18\x05 \x01(\x05R\bsequence\x12-\n" +
+	"\x12accumulated_length\x18\x06 \x01(\x05R\x11accumulatedLength\x12\x17\n" +
+	"\x04step\x18\a \x01(\x05H\x00R\x04step\x88\x01\x01\x12\x1c\n" +
+	"\anode_id\x18\b \x01(\tH\x01R\x06nodeId\x88\x01\x01B\a\n" +
+	"\x05_stepB\n" +
+	"\n" +
+	"\b_node_id\"\x93\x02\n" +
 	"\x12ToolInvokedPayload\x12\x1b\n" +
 	"\ttool_name\x18\x01 \x01(\tR\btoolName\x12\x19\n" +
 	"\bapi_name\x18\x02 \x01(\tR\aapiName\x12!\n" +
@@ -2502,6 +2739,19 @@ const file_proto_events_proto_rawDesc = "" +
 	"\n" +
 	"\b_node_idB\a\n" +
 	"\x05_stepB\x0e\n" +
+	"\f_agent_class\"\x82\x02\n" +
+	"\x1cToolApprovalRequestedPayload\x12\x1b\n" +
+	"\ttool_name\x18\x01 \x01(\tR\btoolName\x12 \n" +
+	"\ftool_call_id\x18\x02 \x01(\tR\n" +
+	"toolCallId\x12!\n" +
+	"\fargs_summary\x18\x03 \x01(\tR\vargsSummary\x12\x1c\n" +
+	"\anode_id\x18\x04 \x01(\tH\x00R\x06nodeId\x88\x01\x01\x12\x17\n" +
+	"\x04step\x18\x05 \x01(\x05H\x01R\x04step\x88\x01\x01\x12$\n" +
+	"\vagent_class\x18\x06 \x01(\tH\x02R\n" +
+	"agentClass\x88\x01\x01B\n" +
+	"\n" +
+	"\b_node_idB\a\n" +
+	"\x05_stepB\x0e\n" +
 	"\f_agent_class\"\xfe\x02\n" +
 	"\x13ToolReturnedPayload\x12\x1b\n" +
 	"\ttool_name\x18\x01 \x01(\tR\btoolName\x12\x19\n" +
@@ -2652,8 +2902,7 @@ const file_proto_events_proto_rawDesc = "" +
 	"\x0f_engine_backendB\n" +
 	"\n" +
 	"\b_node_idB\a\n" +
-	"\x05_step\"\xd7\n" +
-	"\n" +
+	"\x05_step\"\xfc\v\n" +
 	"\x05Event\x12\x19\n" +
 	"\bevent_id\x18\x01 \x01(\tR\aeventId\x128\n" +
 	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x120\n" +
@@ -2679,13 +2928,15 @@ const file_proto_events_proto_rawDesc = "" +
 	"\vrun_started\x18\x12 \x01(\v2\x19.events.RunStartedPayloadH\x00R\n" +
 	"runStarted\x12?\n" +
 	"\frun_finished\x18\x13 \x01(\v2\x1a.events.RunFinishedPayloadH\x00R\vrunFinished\x126\n" +
-	"\trun_error\x18\x14 \x01(\v2\x17.events.RunErrorPayloadH\x00R\brunError\x12B\n" +
+	"\trun_error\x18\x14 \x01(\v2\x17.events.RunErrorPayloadH\x00R\brunError\x12C\n" +
+	"\x0ellm_call_delta\x18\x15 \x01(\v2\x1b.events.LlmCallDeltaPayloadH\x00R\fllmCallDelta\x12^\n" +
+	"\x17tool_approval_requested\x18\x16 \x01(\v2$.events.ToolApprovalRequestedPayloadH\x00R\x15toolApprovalRequested\x12B\n" +
 	"\x0funknown_payload\x18d \x01(\v2\x17.google.protobuf.StructH\x00R\x0eunknownPayloadB\t\n" +
 	"\apayloadB\t\n" +
 	"\a_run_id\"i\n" +
 	"\x0eEventsResponse\x120\n" +
 	"\x06status\x18\x01 \x01(\x0e2\x18.events.ConnectionStatusR\x06status\x12%\n" +
-	"\x06events\x18\x02 \x03(\v2\r.events.EventR\x06events*\x91\x04\n" +
+	"\x06events\x18\x02 \x03(\v2\r.events.EventR\x06events*\xd8\x04\n" +
 	"\tEventType\x12\x1a\n" +
 	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1b\n" +
 	"\x17EVENT_TYPE_STEP_STARTED\x10\x01\x12\x1c\n" +
@@ -2704,7 +2955,9 @@ const file_proto_events_proto_rawDesc = "" +
 	" EVENT_TYPE_NODE_RESULT_AVAILABLE\x10\r\x12\x1a\n" +
 	"\x16EVENT_TYPE_RUN_STARTED\x10\x0e\x12\x1b\n" +
 	"\x17EVENT_TYPE_RUN_FINISHED\x10\x0f\x12\x18\n" +
-	"\x14EVENT_TYPE_RUN_ERROR\x10\x10*\x9c\x01\n" +
+	"\x14EVENT_TYPE_RUN_ERROR\x10\x10\x12\x1d\n" +
+	"\x19EVENT_TYPE_LLM_CALL_DELTA\x10\x11\x12&\n" +
+	"\"EVENT_TYPE_TOOL_APPROVAL_REQUESTED\x10\x12*\x9c\x01\n" +
 	"\x10ConnectionStatus\x12!\n" +
 	"\x1dCONNECTION_STATUS_UNSPECIFIED\x10\x00\x12 \n" +
 	"\x1cCONNECTION_STATUS_CONNECTING\x10\x01\x12\x1f\n" +
@@ -2724,7 +2977,7 @@ func file_proto_events_proto_rawDescGZIP() []byte {
 }
 
 var file_proto_events_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_proto_events_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_proto_events_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_proto_events_proto_goTypes = []any{
 	(EventType)(0),                               // 0: events.EventType
 	(ConnectionStatus)(0),                        // 1: events.ConnectionStatus
@@ -2735,65 +2988,69 @@ var file_proto_events_proto_goTypes = []any{
 	(*TokenUsage)(nil),                           // 6: events.TokenUsage
 	(*LlmCallStartedPayload)(nil),                // 7: events.LlmCallStartedPayload
 	(*LlmCallCompletedPayload)(nil),              // 8: events.LlmCallCompletedPayload
-	(*ToolInvokedPayload)(nil),                   // 9: events.ToolInvokedPayload
-	(*ToolReturnedPayload)(nil),                  // 10: events.ToolReturnedPayload
-	(*NodeCreatedPayload)(nil),                   // 11: events.NodeCreatedPayload
-	(*PlanReceivedPayload)(nil),                  // 12: events.PlanReceivedPayload
-	(*NodeAddedPayload)(nil),                     // 13: events.NodeAddedPayload
-	(*EdgeAddedPayload)(nil),                     // 14: events.EdgeAddedPayload
-	(*InnerGraphBuiltPayload)(nil),               // 15: events.InnerGraphBuiltPayload
-	(*NodeResultAvailablePayload)(nil),           // 16: events.NodeResultAvailablePayload
-	(*RunStartedPayload)(nil),                    // 17: events.RunStartedPayload
-	(*RunFinishedPayload)(nil),                   // 18: events.RunFinishedPayload
-	(*RunErrorPayload)(nil),                      // 19: events.RunErrorPayload
-	(*Event)(nil),                                // 20: events.Event
-	(*EventsResponse)(nil),                       // 21: events.EventsResponse
-	(*RunFinishedPayload_TokenUsageSummary)(nil), // 22: events.RunFinishedPayload.TokenUsageSummary
-	(*RunFinishedPayload_NodeStatistics)(nil),    // 23: events.RunFinishedPayload.NodeStatistics
-	(*RunFinishedPayload_SearchStatistics)(nil),  // 24: events.RunFinishedPayload.SearchStatistics
-	nil,                             // 25: events.RunFinishedPayload.NodeStatistics.ByTypeEntry
-	(*RunErrorPayload_Context)(nil), // 26: events.RunErrorPayload.Context
-	(*structpb.Struct)(nil),         // 27: google.protobuf.Struct
-	(*timestamppb.Timestamp)(nil),   // 28: google.protobuf.Timestamp
+	(*LlmCallDeltaPayload)(nil),                  // 9: events.LlmCallDeltaPayload
+	(*ToolInvokedPayload)(nil),                   // 10: events.ToolInvokedPayload
+	(*ToolApprovalRequestedPayload)(nil),         // 11: events.ToolApprovalRequestedPayload
+	(*ToolReturnedPayload)(nil),                  // 12: events.ToolReturnedPayload
+	(*NodeCreatedPayload)(nil),                   // 13: events.NodeCreatedPayload
+	(*PlanReceivedPayload)(nil),                  // 14: events.PlanReceivedPayload
+	(*NodeAddedPayload)(nil),                     // 15: events.NodeAddedPayload
+	(*EdgeAddedPayload)(nil),                     // 16: events.EdgeAddedPayload
+	(*InnerGraphBuiltPayload)(nil),               // 17: events.InnerGraphBuiltPayload
+	(*NodeResultAvailablePayload)(nil),           // 18: events.NodeResultAvailablePayload
+	(*RunStartedPayload)(nil),                    // 19: events.RunStartedPayload
+	(*RunFinishedPayload)(nil),                   // 20: events.RunFinishedPayload
+	(*RunErrorPayload)(nil),                      // 21: events.RunErrorPayload
+	(*Event)(nil),                                // 22: events.Event
+	(*EventsResponse)(nil),                       // 23: events.EventsResponse
+	(*RunFinishedPayload_TokenUsageSummary)(nil), // 24: events.RunFinishedPayload.TokenUsageSummary
+	(*RunFinishedPayload_NodeStatistics)(nil),    // 25: events.RunFinishedPayload.NodeStatistics
+	(*RunFinishedPayload_SearchStatistics)(nil),  // 26: events.RunFinishedPayload.SearchStatistics
+	nil,                             // 27: events.RunFinishedPayload.NodeStatistics.ByTypeEntry
+	(*RunErrorPayload_Context)(nil), // 28: events.RunErrorPayload.Context
+	(*structpb.Struct)(nil),         // 29: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),   // 30: google.protobuf.Timestamp
 }
 var file_proto_events_proto_depIdxs = []int32{
 	2,  // 0: events.LlmCallStartedPayload.prompt:type_name -> events.LlmMessage
 	6,  // 1: events.LlmCallCompletedPayload.token_usage:type_name -> events.TokenUsage
-	27, // 2: events.PlanReceivedPayload.raw_plan:type_name -> google.protobuf.Struct
-	27, // 3: events.RunStartedPayload.input_data:type_name -> google.protobuf.Struct
-	27, // 4: events.RunStartedPayload.config:type_name -> google.protobuf.Struct
-	28, // 5: events.RunStartedPayload.timestamp_utc:type_name -> google.protobuf.Timestamp
-	22, // 6: events.RunFinishedPayload.token_usage_summary:type_name -> events.RunFinishedPayload.TokenUsageSummary
-	23, // 7: events.RunFinishedPayload.node_statistics:type_name -> events.RunFinishedPayload.NodeStatistics
-	24, // 8: events.RunFinishedPayload.search_statistics:type_name -> events.RunFinishedPayload.SearchStatistics
-	26, // 9: events.RunErrorPayload.context:type_name -> events.RunErrorPayload.Context
-	28, // 10: events.Event.timestamp:type_name -> google.protobuf.Timestamp
+	29, // 2: events.PlanReceivedPayload.raw_plan:type_name -> google.protobuf.Struct
+	29, // 3: events.RunStartedPayload.input_data:type_name -> google.protobuf.Struct
+	29, // 4: events.RunStartedPayload.config:type_name -> google.protobuf.Struct
+	30, // 5: events.RunStartedPayload.timestamp_utc:type_name -> google.protobuf.Timestamp
+	24, // 6: events.RunFinishedPayload.token_usage_summary:type_name -> events.RunFinishedPayload.TokenUsageSummary
+	25, // 7: events.RunFinishedPayload.node_statistics:type_name -> events.RunFinishedPayload.NodeStatistics
+	26, // 8: events.RunFinishedPayload.search_statistics:type_name -> events.RunFinishedPayload.SearchStatistics
+	28, // 9: events.RunErrorPayload.context:type_name -> events.RunErrorPayload.Context
+	30, // 10: events.Event.timestamp:type_name -> google.protobuf.Timestamp
 	0,  // 11: events.Event.event_type:type_name -> events.EventType
 	3,  // 12: events.Event.step_started:type_name -> events.StepStartedPayload
 	4,  // 13: events.Event.step_finished:type_name -> events.StepFinishedPayload
 	5,  // 14: events.Event.node_status_changed:type_name -> events.NodeStatusChangePayload
 	7,  // 15: events.Event.llm_call_started:type_name -> events.LlmCallStartedPayload
 	8,  // 16: events.Event.llm_call_completed:type_name -> events.LlmCallCompletedPayload
-	9,  // 17: events.Event.tool_invoked:type_name -> events.ToolInvokedPayload
-	10, // 18: events.Event.tool_returned:type_name -> events.ToolReturnedPayload
-	11, // 19: events.Event.node_created:type_name -> events.NodeCreatedPayload
-	12, // 20: events.Event.plan_received:type_name -> events.PlanReceivedPayload
-	13, // 21: events.Event.node_added:type_name -> events.NodeAddedPayload
-	14, // 22: events.Event.edge_added:type_name -> events.EdgeAddedPayload
-	15, // 23: events.Event.inner_graph_built:type_name -> events.InnerGraphBuiltPayload
-	16, // 24: events.Event.node_result_available:type_name -> events.NodeResultAvailablePayload
-	17, // 25: events.Event.run_started:type_name -> events.RunStartedPayload
-	18, // 26: events.Event.run_finished:type_name -> events.RunFinishedPayload
-	19, // 27: events.Event.run_error:type_name -> events.RunErrorPayload
-	27, // 28: events.Event.unknown_payload:type_name -> google.protobuf.Struct
-	1,  // 29: events.EventsResponse.status:type_name -> events.ConnectionStatus
-	20, // 30: events.EventsResponse.events:type_name -> events.Event
-	25, // 31: events.RunFinishedPayload.NodeStatistics.by_type:type_name -> events.RunFinishedPayload.NodeStatistics.ByTypeEntry
-	32, // [32:32] is the sub-list for method output_type
-	32, // [32:32] is the sub-list for method input_type
-	32, // [32:32] is the sub-list for extension type_name
-	32, // [32:32] is the sub-list for extension extendee
-	0,  // [0:32] is the sub-list for field type_name
+	10, // 17: events.Event.tool_invoked:type_name -> events.ToolInvokedPayload
+	12, // 18: events.Event.tool_returned:type_name -> events.ToolReturnedPayload
+	13, // 19: events.Event.node_created:type_name -> events.NodeCreatedPayload
+	14, // 20: events.Event.plan_received:type_name -> events.PlanReceivedPayload
+	15, // 21: events.Event.node_added:type_name -> events.NodeAddedPayload
+	16, // 22: events.Event.edge_added:type_name -> events.EdgeAddedPayload
+	17, // 23: events.Event.inner_graph_built:type_name -> events.InnerGraphBuiltPayload
+	18, // 24: events.Event.node_result_available:type_name -> events.NodeResultAvailablePayload
+	19, // 25: events.Event.run_started:type_name -> events.RunStartedPayload
+	20, // 26: events.Event.run_finished:type_name -> events.RunFinishedPayload
+	21, // 27: events.Event.run_error:type_name -> events.RunErrorPayload
+	9,  // 28: events.Event.llm_call_delta:type_name -> events.LlmCallDeltaPayload
+	11, // 29: events.Event.tool_approval_requested:type_name -> events.ToolApprovalRequestedPayload
+	29, // 30: events.Event.unknown_payload:type_name -> google.protobuf.Struct
+	1,  // 31: events.EventsResponse.status:type_name -> events.ConnectionStatus
+	22, // 32: events.EventsResponse.events:type_name -> events.Event
+	27, // 33: events.RunFinishedPayload.NodeStatistics.by_type:type_name -> events.RunFinishedPayload.NodeStatistics.ByTypeEntry
+	34, // [34:34] is the sub-list for method output_type
+	34, // [34:34] is the sub-list for method input_type
+	34, // [34:34] is the sub-list for extension type_name
+	34, // [34:34] is the sub-list for extension extendee
+	0,  // [0:34] is the sub-list for field type_name
 }
 
 func init() { file_proto_events_proto_init() }
@@ -2813,9 +3070,11 @@ func file_proto_events_proto_init() {
 	file_proto_events_proto_msgTypes[12].OneofWrappers = []any{}
 	file_proto_events_proto_msgTypes[13].OneofWrappers = []any{}
 	file_proto_events_proto_msgTypes[14].OneofWrappers = []any{}
+	file_proto_events_proto_msgTypes[15].OneofWrappers = []any{}
 	file_proto_events_proto_msgTypes[16].OneofWrappers = []any{}
-	file_proto_events_proto_msgTypes[17].OneofWrappers = []any{}
-	file_proto_events_proto_msgTypes[18].OneofWrappers = []any{
+	file_proto_events_proto_msgTypes[18].OneofWrappers = []any{}
+	file_proto_events_proto_msgTypes[19].OneofWrappers = []any{}
+	file_proto_events_proto_msgTypes[20].OneofWrappers = []any{
 		(*Event_StepStarted)(nil),
 		(*Event_StepFinished)(nil),
 		(*Event_NodeStatusChanged)(nil),
@@ -2832,16 +3091,18 @@ func file_proto_events_proto_init() {
 		(*Event_RunStarted)(nil),
 		(*Event_RunFinished)(nil),
 		(*Event_RunError)(nil),
+		(*Event_LlmCallDelta)(nil),
+		(*Event_ToolApprovalRequested)(nil),
 		(*Event_UnknownPayload)(nil),
 	}
-	file_proto_events_proto_msgTypes[24].OneofWrappers = []any{}
+	file_proto_events_proto_msgTypes[26].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_events_proto_rawDesc), len(file_proto_events_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   25,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
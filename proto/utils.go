@@ -119,6 +119,18 @@ func NewEvent(eventType EventType, payload interface{}) (*Event, error) {
 		} else {
 			return nil, fmt.Errorf("payload must be *RunErrorPayload for EVENT_TYPE_RUN_ERROR")
 		}
+	case EventType_EVENT_TYPE_LLM_CALL_DELTA:
+		if p, ok := payload.(*LlmCallDeltaPayload); ok {
+			event.Payload = &Event_LlmCallDelta{LlmCallDelta: p}
+		} else {
+			return nil, fmt.Errorf("payload must be *LlmCallDeltaPayload for EVENT_TYPE_LLM_CALL_DELTA")
+		}
+	case EventType_EVENT_TYPE_TOOL_APPROVAL_REQUESTED:
+		if p, ok := payload.(*ToolApprovalRequestedPayload); ok {
+			event.Payload = &Event_ToolApprovalRequested{ToolApprovalRequested: p}
+		} else {
+			return nil, fmt.Errorf("payload must be *ToolApprovalRequestedPayload for EVENT_TYPE_TOOL_APPROVAL_REQUESTED")
+		}
 	default:
 		// For unknown event types, try to convert the payload to a structpb.Struct
 		s, err := ToStruct(payload)
@@ -204,6 +216,14 @@ func (e *Event) GetStep() (int32, bool) {
 		if p.LlmCallCompleted.Step != nil {
 			return *p.LlmCallCompleted.Step, true
 		}
+	case *Event_LlmCallDelta:
+		if p.LlmCallDelta.Step != nil {
+			return *p.LlmCallDelta.Step, true
+		}
+	case *Event_ToolApprovalRequested:
+		if p.ToolApprovalRequested.Step != nil {
+			return *p.ToolApprovalRequested.Step, true
+		}
 	case *Event_ToolInvoked:
 		if p.ToolInvoked.Step != nil {
 			return *p.ToolInvoked.Step, true
@@ -261,6 +281,14 @@ func (e *Event) GetNodeID() (string, bool) {
 		if p.LlmCallCompleted.NodeId != nil {
 			return *p.LlmCallCompleted.NodeId, true
 		}
+	case *Event_LlmCallDelta:
+		if p.LlmCallDelta.NodeId != nil {
+			return *p.LlmCallDelta.NodeId, true
+		}
+	case *Event_ToolApprovalRequested:
+		if p.ToolApprovalRequested.NodeId != nil {
+			return *p.ToolApprovalRequested.NodeId, true
+		}
 	case *Event_ToolInvoked:
 		if p.ToolInvoked.NodeId != nil {
 			return *p.ToolInvoked.NodeId, true
@@ -1,6 +1,6 @@
 import React from 'react';
 import { Badge } from 'react-bootstrap';
-import { Play, CheckCircle, ArrowRight, Clock, Zap, Database, Search, AlertCircle, GitCommit, FileCode, Network, PlusCircle, GitFork, FileText, Icon } from 'lucide-react';
+import { Play, CheckCircle, ArrowRight, Clock, Zap, Database, Search, AlertCircle, GitCommit, FileCode, Network, PlusCircle, GitFork, FileText, ShieldQuestion, Icon } from 'lucide-react';
 import { eventTypeBadgeVariant } from '../helpers/eventConstants'; // Import shared constant
 
 // Map event types to icons and colors (consolidated from EventTable)
@@ -11,6 +11,7 @@ const eventTypeConfig: { [key: string]: { icon: Icon, bsTextColor: string } } =
     llm_call_started: { icon: Clock, bsTextColor: 'warning' },
     llm_call_completed: { icon: Zap, bsTextColor: 'warning' }, 
     tool_invoked: { icon: Database, bsTextColor: 'secondary' }, 
+    tool_approval_requested: { icon: ShieldQuestion, bsTextColor: 'danger' },
     tool_returned: { icon: Database, bsTextColor: 'secondary' }, 
     search_completed: { icon: Search, bsTextColor: 'primary' }, 
     node_created: { icon: GitCommit, bsTextColor: 'info' },
@@ -0,0 +1,94 @@
+import React from 'react';
+import { EventSummaryWidgetProps, EventTableWidgetProps } from './types';
+import CodeHighlighter from '../SyntaxHighlighter';
+import ErrorBoundary from '../ErrorBoundary';
+import SimpleCodeFallback from '../SimpleCodeFallback';
+import { isEventType } from '../../helpers/eventType';
+import { RenderClickableNodeId } from '../../helpers/formatters';
+
+/**
+ * Summary widget for llm_call_delta events, showing the output streamed so far
+ */
+export const LlmCallDeltaSummary: React.FC<EventSummaryWidgetProps> = ({
+  event,
+  onNodeClick
+}) => {
+  if (!isEventType('llm_call_delta')(event)) {
+    return <div className="alert alert-warning">Invalid event type for LlmCallDeltaSummary</div>;
+  }
+
+  const { agent_class, model, call_id, node_id, accumulated_length, text, delta } = event.payload;
+  const streamed = text ?? delta;
+
+  return (
+    <>
+      <div className="card mb-3">
+        <div className="card-header bg-light py-2">
+          <strong>Streaming LLM Call</strong>
+        </div>
+        <div className="card-body">
+          <div className="row g-2">
+            <div className="col-md-6">
+              <p className="mb-1"><strong>Agent Class:</strong> {agent_class}</p>
+              <p className="mb-1"><strong>Model:</strong> {model}</p>
+            </div>
+            <div className="col-md-6">
+              <p className="mb-1"><strong>Call ID:</strong> {call_id}</p>
+              <p className="mb-1"><strong>Received:</strong> {accumulated_length} chars</p>
+              {node_id && (
+                <p className="mb-1">
+                  <strong>Node ID:</strong>
+                  <RenderClickableNodeId nodeId={node_id} onNodeClick={onNodeClick} />
+                </p>
+              )}
+            </div>
+          </div>
+        </div>
+      </div>
+      <div className="card">
+        <div className="card-header bg-light py-2">
+          <strong>Output So Far</strong>
+        </div>
+        <div className="card-body">
+          <ErrorBoundary
+            fallback={SimpleCodeFallback}
+            contentForFallback={streamed}
+          >
+            <CodeHighlighter
+              code={streamed}
+              language="markdown"
+              maxHeight="400px"
+            />
+          </ErrorBoundary>
+        </div>
+      </div>
+    </>
+  );
+};
+
+/**
+ * Table widget for llm_call_delta events (for the event table row)
+ */
+export const LlmCallDeltaTable: React.FC<EventTableWidgetProps> = ({
+  event,
+  className = '',
+  showCallIds = false
+}) => {
+  if (!isEventType('llm_call_delta')(event)) {
+    return <span className="text-warning">Invalid event</span>;
+  }
+
+  const { model, accumulated_length, text, delta, call_id } = event.payload;
+  const streamed = text ?? delta;
+  const tail = streamed.length > 80 ? '...' + streamed.substring(streamed.length - 80) : streamed;
+
+  return (
+    <small className={className}>
+      <strong>Streaming:</strong> {model}, {accumulated_length} chars{' '}
+      <span className="text-muted">{tail}</span>
+      {showCallIds && call_id && (
+        <span>, <strong>Call ID:</strong> {call_id.substring(0, 8)}...</span>
+      )}
+    </small>
+  );
+};
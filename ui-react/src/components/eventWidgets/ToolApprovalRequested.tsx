@@ -0,0 +1,110 @@
+import React, { useState } from 'react';
+import { Badge, Button } from 'react-bootstrap';
+import { EventSummaryWidgetProps, EventTableWidgetProps } from './types';
+import { isEventType } from '../../helpers/eventType';
+import { RenderClickableNodeId } from '../../helpers/formatters';
+
+/**
+ * Summary widget for tool_approval_requested events. Runs started through the server
+ * can be approved or rejected from here.
+ */
+export const ToolApprovalRequestedSummary: React.FC<EventSummaryWidgetProps> = ({
+  event,
+  onNodeClick
+}) => {
+  const [decision, setDecision] = useState<string | null>(null);
+  const [error, setError] = useState<string | null>(null);
+
+  if (!isEventType('tool_approval_requested')(event)) {
+    return <div className="alert alert-warning">Invalid event type for ToolApprovalRequestedSummary</div>;
+  }
+
+  const { node_id, tool_name, args_summary, agent_class, tool_call_id } = event.payload;
+  const runId = event.run_id;
+
+  const decide = async (approved: boolean) => {
+    setError(null);
+    try {
+      const response = await fetch(`/api/runs/${runId}/approvals/${tool_call_id}`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ approved, reason: approved ? '' : 'rejected in the UI' }),
+      });
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+      setDecision(approved ? 'Approved' : 'Rejected');
+    } catch (err) {
+      setError(String(err));
+    }
+  };
+
+  return (
+    <div className="card">
+      <div className="card-header bg-light py-2">
+        <strong>Tool Approval Requested</strong>
+      </div>
+      <div className="card-body">
+        <div className="row g-2 mb-3">
+          <div className="col-md-6">
+            <p className="mb-1">
+              <strong>Node ID:</strong>
+              <RenderClickableNodeId nodeId={node_id} onNodeClick={onNodeClick} />
+            </p>
+            {agent_class && (
+              <p className="mb-1">
+                <strong>Agent Class:</strong> {agent_class}
+              </p>
+            )}
+            <p className="mb-1">
+              <strong>Tool Call ID:</strong> <span className="font-monospace small">{tool_call_id}</span>
+            </p>
+          </div>
+          <div className="col-md-6">
+            <p className="mb-1">
+              <strong>Tool:</strong>
+              <Badge bg="danger" className="ms-1">{tool_name}</Badge>
+            </p>
+          </div>
+        </div>
+
+        {args_summary && (
+          <div className="mb-3">
+            <strong>Arguments:</strong>
+            <p className="mb-0 mt-1 p-2 bg-light rounded font-monospace small">{args_summary}</p>
+          </div>
+        )}
+
+        {runId && !decision && (
+          <div className="d-flex gap-2">
+            <Button variant="success" size="sm" onClick={() => decide(true)}>Approve</Button>
+            <Button variant="outline-danger" size="sm" onClick={() => decide(false)}>Reject</Button>
+          </div>
+        )}
+        {decision && <p className="mb-0 text-muted">{decision}</p>}
+        {error && <p className="mb-0 mt-2 text-danger small">{error}</p>}
+      </div>
+    </div>
+  );
+};
+
+/**
+ * Table widget for tool_approval_requested events (for the event table row)
+ */
+export const ToolApprovalRequestedTable: React.FC<EventTableWidgetProps> = ({
+  event,
+  className = ''
+}) => {
+  if (!isEventType('tool_approval_requested')(event)) {
+    return <span className="text-warning">Invalid event</span>;
+  }
+
+  const { tool_name, args_summary } = event.payload;
+
+  return (
+    <div className={`d-flex align-items-center ${className}`}>
+      <Badge bg="danger" className="me-2">{tool_name}</Badge>
+      <span className="text-truncate">{args_summary || 'Waiting for approval'}</span>
+    </div>
+  );
+};
@@ -17,6 +17,10 @@ import {
   LlmCallCompletedTable, 
   LlmCallCompletedResponseTab 
 } from './LlmCallCompleted';
+import {
+  LlmCallDeltaSummary,
+  LlmCallDeltaTable
+} from './LlmCallDelta';
 import {
   StepStartedSummary,
   StepStartedTable
@@ -57,6 +61,10 @@ import {
   ToolInvokedSummary,
   ToolInvokedTable
 } from './ToolInvoked';
+import {
+  ToolApprovalRequestedSummary,
+  ToolApprovalRequestedTable
+} from './ToolApprovalRequested';
 import {
   ToolReturnedSummary,
   ToolReturnedTable
@@ -178,6 +186,12 @@ export function registerAllWidgets() {
     tableWidget: ToolInvokedTable
   });
   
+  registerEventWidget({
+    eventType: 'tool_approval_requested',
+    summaryWidget: ToolApprovalRequestedSummary,
+    tableWidget: ToolApprovalRequestedTable
+  });
+  
   registerEventWidget({
     eventType: 'tool_returned',
     summaryWidget: ToolReturnedSummary,
@@ -212,5 +226,12 @@ export function registerAllWidgets() {
     ]
   });
   
+  // Register LLM call delta widgets (streamed output of a running call)
+  registerEventWidget({
+    eventType: 'llm_call_delta',
+    summaryWidget: LlmCallDeltaSummary,
+    tableWidget: LlmCallDeltaTable
+  });
+  
   // Add more widget registrations here as they are implemented
 } 
\ No newline at end of file
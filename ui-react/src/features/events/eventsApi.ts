@@ -69,6 +69,18 @@ export interface LlmCallCompletedPayload {
   call_id: string; // Added for pairing
 }
 
+export interface LlmCallDeltaPayload {
+  call_id: string;
+  agent_class: string;
+  model: string;
+  delta: string;
+  sequence: number;
+  accumulated_length: number;
+  step?: number | null;
+  node_id?: string | null;
+  text?: string; // Output streamed so far, accumulated client-side
+}
+
 export interface ToolInvokedPayload {
   tool_name: string;
   api_name: string;
@@ -79,6 +91,15 @@ export interface ToolInvokedPayload {
   tool_call_id: string; // Added for pairing
 }
 
+export interface ToolApprovalRequestedPayload {
+  tool_name: string;
+  tool_call_id: string;
+  args_summary: string;
+  node_id?: string | null;
+  step?: number | null;
+  agent_class?: string | null;
+}
+
 export interface ToolReturnedPayload {
   tool_name: string;
   api_name: string;
@@ -162,7 +183,9 @@ export type KnownEventType =
   | "node_status_changed"
   | "llm_call_started"
   | "llm_call_completed"
+  | "llm_call_delta"
   | "tool_invoked"
+  | "tool_approval_requested"
   | "tool_returned"
   | "node_created"
   | "plan_received"
@@ -208,6 +231,13 @@ export type AgentEvent =
       run_id?: string | null;
       payload: LlmCallCompletedPayload;
     }
+  | {
+      event_id: string;
+      timestamp: string;
+      event_type: "llm_call_delta";
+      run_id?: string | null;
+      payload: LlmCallDeltaPayload;
+    }
   | {
       event_id: string;
       timestamp: string;
@@ -215,6 +245,13 @@ export type AgentEvent =
       run_id?: string | null;
       payload: ToolInvokedPayload;
     }
+  | {
+      event_id: string;
+      timestamp: string;
+      event_type: "tool_approval_requested";
+      run_id?: string | null;
+      payload: ToolApprovalRequestedPayload;
+    }
   | {
       event_id: string;
       timestamp: string;
@@ -477,6 +514,42 @@ export const eventsApi = createApi({
                 );
                 break;
               }
+              case "llm_call_delta": {
+                // Keep a single live entry per call, showing the output streamed so far
+                const p = msg.payload as LlmCallDeltaPayload;
+                updateCachedData((draft) => {
+                  const live = draft.events.find(
+                    (e) =>
+                      e.event_type === "llm_call_delta" &&
+                      (e.payload as LlmCallDeltaPayload).call_id === p.call_id
+                  );
+                  if (live) {
+                    const lp = live.payload as LlmCallDeltaPayload;
+                    Object.assign(lp, p, { text: (lp.text ?? "") + p.delta });
+                    live.timestamp = msg.timestamp;
+                  } else {
+                    draft.events.unshift({
+                      ...msg,
+                      payload: { ...p, text: p.delta },
+                    } as AgentEvent);
+                  }
+                });
+                return;
+              }
+              case "llm_call_completed": {
+                // The completed event replaces the live streaming entry of the call
+                const p = msg.payload as LlmCallCompletedPayload;
+                updateCachedData((draft) => {
+                  draft.events = draft.events.filter(
+                    (e) =>
+                      !(
+                        e.event_type === "llm_call_delta" &&
+                        (e.payload as LlmCallDeltaPayload).call_id === p.call_id
+                      )
+                  );
+                });
+                break;
+              }
               // Handle other event types if they should affect the graph
             }
           } catch (e) {
@@ -5,7 +5,9 @@ export const eventTypeBadgeVariant: Record<string, string> = {
   node_status_changed: "info",
   llm_call_started: "warning",
   llm_call_completed: "warning",
+  llm_call_delta: "light",
   tool_invoked: "secondary",
+  tool_approval_requested: "danger",
   tool_returned: "secondary",
   node_created: "info", // Changed from purple-subtle for consistency
   plan_received: "info",